@@ -13,7 +13,11 @@
 
 package ast
 
-import "fmt"
+import (
+	"fmt"
+
+	"github.com/pingcap/tidb/model"
+)
 
 // Cloner is an ast visitor that clones a node.
 type Cloner struct {
@@ -161,6 +165,16 @@ func copyStruct(in Node) (out Node) {
 	case *UnionStmt:
 		nv := *v
 		out = &nv
+	case *CommonTableExpression:
+		nv := *v
+		nv.ColNames = make([]model.CIStr, len(v.ColNames))
+		copy(nv.ColNames, v.ColNames)
+		out = &nv
+	case *WithClause:
+		nv := *v
+		nv.CTEs = make([]*CommonTableExpression, len(v.CTEs))
+		copy(nv.CTEs, v.CTEs)
+		out = &nv
 	default:
 		// We currently only handle expression and select statement.
 		// Will add more when we need to.
@@ -27,6 +27,7 @@ var (
 
 	_ Node = &Assignment{}
 	_ Node = &ByItem{}
+	_ Node = &CommonTableExpression{}
 	_ Node = &FieldList{}
 	_ Node = &GroupByClause{}
 	_ Node = &HavingClause{}
@@ -40,6 +41,7 @@ var (
 	_ Node = &TableSource{}
 	_ Node = &UnionSelectList{}
 	_ Node = &WildCardField{}
+	_ Node = &WithClause{}
 )
 
 // JoinType is join type, including cross/left/right/full.
@@ -110,6 +112,9 @@ type TableName struct {
 	TableInfo *model.TableInfo
 
 	IndexHints []*IndexHint
+
+	// CTE is the common table expression the name refers to, it is set by the name resolver.
+	CTE *CommonTableExpression
 }
 
 // IndexHintType is the type for index hint use, ignore or force.
@@ -436,12 +441,72 @@ func (n *OrderByClause) Accept(v Visitor) (Node, bool) {
 	return v.Leave(n)
 }
 
+// CommonTableExpression represents a named subquery in the with clause.
+// See: https://dev.mysql.com/doc/refman/8.0/en/with.html
+type CommonTableExpression struct {
+	node
+
+	Name model.CIStr
+	// ColNames are the names of the columns, the names of the result fields of Query are used if it is empty.
+	ColNames []model.CIStr
+	// Query is a SelectStmt or a UnionStmt.
+	Query ResultSetNode
+
+	// IsRecursive is set by the name resolver if Query refers to the common table expression itself.
+	IsRecursive bool
+	// TableInfo describes the columns of the common table expression, it is set by the name resolver.
+	TableInfo *model.TableInfo
+}
+
+// Accept implements Node Accept interface.
+func (n *CommonTableExpression) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*CommonTableExpression)
+	node, ok := n.Query.Accept(v)
+	if !ok {
+		return n, false
+	}
+	n.Query = node.(ResultSetNode)
+	return v.Leave(n)
+}
+
+// WithClause represents the with clause of a select or union statement.
+// It is only supported by the new planner.
+type WithClause struct {
+	node
+
+	IsRecursive bool
+	CTEs        []*CommonTableExpression
+}
+
+// Accept implements Node Accept interface.
+func (n *WithClause) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*WithClause)
+	for i, cte := range n.CTEs {
+		node, ok := cte.Accept(v)
+		if !ok {
+			return n, false
+		}
+		n.CTEs[i] = node.(*CommonTableExpression)
+	}
+	return v.Leave(n)
+}
+
 // SelectStmt represents the select query node.
 // See: https://dev.mysql.com/doc/refman/5.7/en/select.html
 type SelectStmt struct {
 	dmlNode
 	resultSetNode
 
+	// With is the with clause of the query.
+	With *WithClause
 	// Distinct represents if the select has distinct option.
 	Distinct bool
 	// From is the from clause of the query.
@@ -470,6 +535,14 @@ func (n *SelectStmt) Accept(v Visitor) (Node, bool) {
 	}
 
 	n = newNode.(*SelectStmt)
+	if n.With != nil {
+		node, ok := n.With.Accept(v)
+		if !ok {
+			return n, false
+		}
+		n.With = node.(*WithClause)
+	}
+
 	if n.From != nil {
 		node, ok := n.From.Accept(v)
 		if !ok {
@@ -559,6 +632,8 @@ type UnionStmt struct {
 	dmlNode
 	resultSetNode
 
+	// With is the with clause of the query.
+	With       *WithClause
 	Distinct   bool
 	SelectList *UnionSelectList
 	OrderBy    *OrderByClause
@@ -572,6 +647,13 @@ func (n *UnionStmt) Accept(v Visitor) (Node, bool) {
 		return v.Leave(newNode)
 	}
 	n = newNode.(*UnionStmt)
+	if n.With != nil {
+		node, ok := n.With.Accept(v)
+		if !ok {
+			return n, false
+		}
+		n.With = node.(*WithClause)
+	}
 	if n.SelectList != nil {
 		node, ok := n.SelectList.Accept(v)
 		if !ok {
@@ -864,6 +946,8 @@ const (
 	ShowTriggers
 	ShowProcedureStatus
 	ShowIndex
+	ShowStatsMeta
+	ShowStatsHistograms
 )
 
 // ShowStmt is a statement to provide information about databases, tables, columns and so on.
@@ -82,6 +82,14 @@ func (ts *testFlagSuite) TestFlag(c *C) {
 			"sum(a)",
 			ast.FlagHasAggregateFunc | ast.FlagHasReference,
 		},
+		{
+			"row_number() over ()",
+			ast.FlagHasFunc | ast.FlagHasReference,
+		},
+		{
+			"rank() over (partition by a order by sum(b))",
+			ast.FlagHasFunc | ast.FlagHasReference | ast.FlagHasAggregateFunc,
+		},
 		{
 			"(select 1) as a",
 			ast.FlagHasSubquery,
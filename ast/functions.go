@@ -28,6 +28,7 @@ var (
 	_ FuncNode = &AggregateFuncExpr{}
 	_ FuncNode = &FuncCallExpr{}
 	_ FuncNode = &FuncCastExpr{}
+	_ FuncNode = &WindowFuncExpr{}
 )
 
 // List scalar function names.
@@ -438,3 +439,122 @@ type AggEvaluateContext struct {
 	Buffer          *bytes.Buffer // Buffer is used for group_concat.
 	Evaluated       bool
 }
+
+const (
+	// WindowFuncRowNumber is the name of row_number function.
+	WindowFuncRowNumber = "row_number"
+	// WindowFuncRank is the name of rank function.
+	WindowFuncRank = "rank"
+	// WindowFuncDenseRank is the name of dense_rank function.
+	WindowFuncDenseRank = "dense_rank"
+	// WindowFuncLead is the name of lead function.
+	WindowFuncLead = "lead"
+	// WindowFuncLag is the name of lag function.
+	WindowFuncLag = "lag"
+	// WindowFuncFirstValue is the name of first_value function.
+	WindowFuncFirstValue = "first_value"
+)
+
+// WindowFuncExpr represents window function expression, it is a window function
+// or an aggregate function evaluated over the window defined by its OVER clause.
+// See: https://dev.mysql.com/doc/refman/8.0/en/window-functions.html
+type WindowFuncExpr struct {
+	funcNode
+	// F is the function name.
+	F string
+	// Args is the function args.
+	Args []ExprNode
+	// Spec is the window specification of the OVER clause.
+	Spec WindowSpec
+}
+
+// WindowSpec is the window specification of a window function.
+type WindowSpec struct {
+	// PartitionBy divides the rows into partitions, the function is evaluated in each partition.
+	PartitionBy []ExprNode
+	// OrderBy is the order of the rows in a partition.
+	OrderBy *OrderByClause
+	// Frame is the frame of the rows in a partition, it is nil if not specified.
+	Frame *FrameClause
+}
+
+// FrameBoundType is the type of a frame bound.
+type FrameBoundType int
+
+// Frame bound types.
+const (
+	UnboundedPreceding FrameBoundType = iota
+	Preceding
+	CurrentRow
+	Following
+	UnboundedFollowing
+)
+
+// FrameBound is the start or the end of a frame.
+type FrameBound struct {
+	Type FrameBoundType
+	// Offset is the number of rows of a Preceding or Following bound.
+	Offset uint64
+}
+
+// FrameClause represents a ROWS frame of the rows from Start to End in a partition.
+type FrameClause struct {
+	Start FrameBound
+	End   FrameBound
+}
+
+// Accept implements Node Accept interface.
+func (n *WindowFuncExpr) Accept(v Visitor) (Node, bool) {
+	newNode, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNode)
+	}
+	n = newNode.(*WindowFuncExpr)
+	for i, val := range n.Args {
+		node, ok := val.Accept(v)
+		if !ok {
+			return n, false
+		}
+		n.Args[i] = node.(ExprNode)
+	}
+	for i, val := range n.Spec.PartitionBy {
+		node, ok := val.Accept(v)
+		if !ok {
+			return n, false
+		}
+		n.Spec.PartitionBy[i] = node.(ExprNode)
+	}
+	if n.Spec.OrderBy != nil {
+		node, ok := n.Spec.OrderBy.Accept(v)
+		if !ok {
+			return n, false
+		}
+		n.Spec.OrderBy = node.(*OrderByClause)
+	}
+	return v.Leave(n)
+}
+
+// WindowFuncExtractor visits Expr tree.
+// It collects WindowFuncExprs out of the subqueries.
+type WindowFuncExtractor struct {
+	// WindowFuncs is the collected WindowFuncExprs.
+	WindowFuncs []*WindowFuncExpr
+}
+
+// Enter implements Visitor interface.
+func (a *WindowFuncExtractor) Enter(n Node) (node Node, skipChildren bool) {
+	switch n.(type) {
+	case *SelectStmt, *UnionStmt:
+		// The window functions in subqueries belong to their own select statements.
+		return n, true
+	}
+	return n, false
+}
+
+// Leave implements Visitor interface.
+func (a *WindowFuncExtractor) Leave(n Node) (node Node, ok bool) {
+	if v, ok := n.(*WindowFuncExpr); ok {
+		a.WindowFuncs = append(a.WindowFuncs, v)
+	}
+	return n, true
+}
@@ -150,3 +150,17 @@ func (ts *testFunctionsSuite) TestAggFuncSum(c *C) {
 	c.Assert(ok, IsTrue)
 	c.Assert(v.Equals(expect), IsTrue)
 }
+
+func (ts *testFunctionsSuite) TestWindowFuncExtractor(c *C) {
+	extractor := &WindowFuncExtractor{}
+	w := &WindowFuncExpr{F: WindowFuncRank}
+	expr := &BinaryOperationExpr{
+		L: w,
+		R: &SubqueryExpr{Query: &SelectStmt{
+			Fields: &FieldList{Fields: []*SelectField{{Expr: &WindowFuncExpr{F: WindowFuncRowNumber}}}},
+		}},
+	}
+	expr.Accept(extractor)
+	c.Assert(extractor.WindowFuncs, HasLen, 1)
+	c.Assert(extractor.WindowFuncs[0], Equals, w)
+}
@@ -73,6 +73,8 @@ type ExplainStmt struct {
 	stmtNode
 
 	Stmt StmtNode
+	// Analyze is true for EXPLAIN ANALYZE, which executes the statement and shows the runtime information.
+	Analyze bool
 }
 
 // Accept implements Node Accept interface.
@@ -370,6 +372,8 @@ type AdminStmtType int
 const (
 	AdminShowDDL = iota + 1
 	AdminCheckTable
+	AdminShowDDLJobs
+	AdminCancelDDLJobs
 )
 
 // AdminStmt is the struct for Admin statement.
@@ -378,6 +382,7 @@ type AdminStmt struct {
 
 	Tp     AdminStmtType
 	Tables []*TableName
+	JobIDs []int64
 }
 
 // Accept implements Node Accpet interface.
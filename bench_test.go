@@ -6,6 +6,7 @@ import (
 
 	"github.com/ngaut/log"
 	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/executor"
 	"github.com/pingcap/tidb/plan"
 )
 
@@ -263,3 +264,51 @@ func BenchmarkNewJoinLimit(b *testing.B) {
 	}
 	plan.UseNewPlanner = false
 }
+
+// batchBenchCount is the number of rows for the benchmarks of the batch execution, so each executor
+// returns several chunks.
+var batchBenchCount = 4096
+
+func benchmarkBatchExecution(b *testing.B, sql string, count int, batch bool) {
+	b.StopTimer()
+	se := prepareBenchSession()
+	prepareJoinBenchData(se, "int", "%v", batchBenchCount)
+	plan.UseNewPlanner = true
+	executor.UseBatchExecution = batch
+	defer func() {
+		plan.UseNewPlanner = false
+		executor.UseBatchExecution = true
+	}()
+	b.StartTimer()
+	for i := 0; i < b.N; i++ {
+		rs, err := se.Execute(sql)
+		if err != nil {
+			b.Fatal(err)
+		}
+		readResult(rs[0], count)
+	}
+}
+
+func BenchmarkRowSelection(b *testing.B) {
+	benchmarkBatchExecution(b, "select col + 1, pk from t where col >= 96", batchBenchCount-96, false)
+}
+
+func BenchmarkBatchSelection(b *testing.B) {
+	benchmarkBatchExecution(b, "select col + 1, pk from t where col >= 96", batchBenchCount-96, true)
+}
+
+func BenchmarkRowAggregation(b *testing.B) {
+	benchmarkBatchExecution(b, "select col % 10, count(*), sum(col) from t group by col % 10", 10, false)
+}
+
+func BenchmarkBatchAggregation(b *testing.B) {
+	benchmarkBatchExecution(b, "select col % 10, count(*), sum(col) from t group by col % 10", 10, true)
+}
+
+func BenchmarkRowHashJoin(b *testing.B) {
+	benchmarkBatchExecution(b, "select a.pk, b.col from t a join t b on a.col = b.col", batchBenchCount, false)
+}
+
+func BenchmarkBatchHashJoin(b *testing.B) {
+	benchmarkBatchExecution(b, "select a.pk, b.col from t a join t b on a.col = b.col", batchBenchCount, true)
+}
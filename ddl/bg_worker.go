@@ -18,6 +18,7 @@ import (
 
 	"github.com/juju/errors"
 	"github.com/ngaut/log"
+	"github.com/pingcap/tidb/infoschema"
 	"github.com/pingcap/tidb/kv"
 	"github.com/pingcap/tidb/meta"
 	"github.com/pingcap/tidb/model"
@@ -31,7 +32,10 @@ func (d *ddl) handleBgJobQueue() error {
 	}
 
 	job := &model.Job{}
+	// analyzeJob is the claimed analyze job, it runs outside the owner transaction.
+	var analyzeJob *model.Job
 	err := kv.RunInNewTxn(d.store, false, func(txn kv.Transaction) error {
+		analyzeJob = nil
 		t := meta.NewMeta(txn)
 		owner, err := d.checkOwner(t, bgJobFlag)
 		if terror.ErrorEqual(err, errNotOwner) {
@@ -50,11 +54,18 @@ func (d *ddl) handleBgJobQueue() error {
 			return nil
 		}
 
-		d.runBgJob(t, job)
-		if job.IsFinished() {
-			err = d.finishBgJob(t, job)
-		} else {
+		if job.Type == model.ActionAnalyzeTable {
+			// The analyze job is only claimed here.
+			job.State = model.JobRunning
 			err = d.updateBgJob(t, job)
+			analyzeJob = job
+		} else {
+			d.runBgJob(t, job)
+			if job.IsFinished() {
+				err = d.finishBgJob(t, job)
+			} else {
+				err = d.updateBgJob(t, job)
+			}
 		}
 		if err != nil {
 			return errors.Trace(err)
@@ -69,7 +80,76 @@ func (d *ddl) handleBgJobQueue() error {
 	if err != nil {
 		return errors.Trace(err)
 	}
+	if analyzeJob != nil {
+		return errors.Trace(d.runAnalyzeJob(analyzeJob))
+	}
+
+	return nil
+}
+
+// runAnalyzeJob runs the claimed analyze job in a goroutine, so that the long analyze neither conflicts with
+// the updates of the owner and the job queue, nor stops the owner from being refreshed. It returns at once if
+// the analyze is still running, the job is checked again in the next round, and it is finished in a new
+// transaction after the analyze is done.
+func (d *ddl) runAnalyzeJob(job *model.Job) error {
+	if d.analyzeDoneCh == nil {
+		d.wait.Add(1)
+		d.analyzeJobID = job.ID
+		d.analyzeDoneCh = make(chan error, 1)
+		go func(schemaID, tableID int64) {
+			defer d.wait.Done()
+			d.analyzeDoneCh <- d.analyzeTable(schemaID, tableID)
+			asyncNotify(d.bgJobCh)
+		}(job.SchemaID, job.TableID)
+	}
+
+	var analyzeErr error
+	select {
+	case analyzeErr = <-d.analyzeDoneCh:
+		d.analyzeDoneCh = nil
+	default:
+		return nil
+	}
+	if d.analyzeJobID != job.ID {
+		// The job analyzed before losing the owner has been finished by another server, run the current one.
+		asyncNotify(d.bgJobCh)
+		return nil
+	}
+
+	err := kv.RunInNewTxn(d.store, false, func(txn kv.Transaction) error {
+		t := meta.NewMeta(txn)
+		owner, err := d.checkOwner(t, bgJobFlag)
+		if terror.ErrorEqual(err, errNotOwner) {
+			return nil
+		}
+		if err != nil {
+			return errors.Trace(err)
+		}
+		job, err = d.getFirstBgJob(t)
+		if err != nil || job == nil || job.ID != d.analyzeJobID {
+			return errors.Trace(err)
+		}
 
+		if analyzeErr != nil {
+			// The table may have been dropped, we don't retry the job and wait for the next one.
+			job.State = model.JobCancelled
+			job.Error = analyzeErr.Error()
+			job.ErrorCount++
+		} else {
+			job.State = model.JobDone
+		}
+		if err = d.finishBgJob(t, job); err != nil {
+			return errors.Trace(err)
+		}
+		owner.LastUpdateTS = time.Now().UnixNano()
+		err = t.SetBgJobOwner(owner)
+		return errors.Trace(err)
+	})
+	if err != nil {
+		return errors.Trace(err)
+	}
+	// The notifications during the analyze have been consumed, run the next job in the queue.
+	asyncNotify(d.bgJobCh)
 	return nil
 }
 
@@ -83,6 +163,8 @@ func (d *ddl) runBgJob(t *meta.Meta, job *model.Job) {
 		err = d.delReorgSchema(t, job)
 	case model.ActionDropTable:
 		err = d.delReorgTable(t, job)
+	case model.ActionTruncateTable:
+		err = d.delReorgTruncatedTable(job)
 	default:
 		job.State = model.JobCancelled
 		err = errInvalidBgJob
@@ -121,11 +203,73 @@ func (d *ddl) prepareBgJob(ddlJob *model.Job) error {
 // startBgJob starts a background job.
 func (d *ddl) startBgJob(tp model.ActionType) {
 	switch tp {
-	case model.ActionDropSchema, model.ActionDropTable:
+	case model.ActionDropSchema, model.ActionDropTable, model.ActionTruncateTable, model.ActionAnalyzeTable:
 		asyncNotify(d.bgJobCh)
 	}
 }
 
+// AnalyzeTableFunc builds and saves the statistics of a table.
+// Building statistics needs a session to run statements, so the function is registered by the
+// upper layer which is able to create sessions.
+type AnalyzeTableFunc func(store kv.Storage, schemaID, tableID int64) error
+
+var analyzeTableFunc AnalyzeTableFunc
+
+// RegisterAnalyzeTableFunc registers the function used by the background analyze table jobs.
+func RegisterAnalyzeTableFunc(fn AnalyzeTableFunc) {
+	analyzeTableFunc = fn
+}
+
+// AnalyzeTable adds a background job to rebuild the statistics of the table.
+func (d *ddl) AnalyzeTable(tableID int64) error {
+	job := &model.Job{
+		TableID: tableID,
+		Type:    model.ActionAnalyzeTable,
+	}
+	var found bool
+	for _, di := range d.infoHandle.Get().AllSchemas() {
+		for _, tbl := range di.Tables {
+			if tbl.ID == tableID {
+				job.SchemaID = di.ID
+				found = true
+				break
+			}
+		}
+	}
+	if !found {
+		return errors.Trace(infoschema.ErrTableNotExists)
+	}
+
+	err := kv.RunInNewTxn(d.store, true, func(txn kv.Transaction) error {
+		t := meta.NewMeta(txn)
+		var err1 error
+		job.ID, err1 = t.GenGlobalID()
+		if err1 != nil {
+			return errors.Trace(err1)
+		}
+		err1 = t.EnQueueBgJob(job)
+		return errors.Trace(err1)
+	})
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	d.startBgJob(job.Type)
+	return nil
+}
+
+func (d *ddl) analyzeTable(schemaID, tableID int64) error {
+	if analyzeTableFunc == nil {
+		return errors.Trace(errInvalidBgJob)
+	}
+
+	err := analyzeTableFunc(d.store, schemaID, tableID)
+	if err != nil {
+		log.Warnf("[ddl] analyze table %d err %v", tableID, errors.ErrorStack(err))
+	}
+	return errors.Trace(err)
+}
+
 // getFirstBgJob gets the first background job.
 func (d *ddl) getFirstBgJob(t *meta.Meta) (*model.Job, error) {
 	job, err := t.GetBgJob(0)
@@ -106,3 +106,53 @@ func (s *testDDLSuite) TestInvalidBgJobType(c *C) {
 	time.Sleep(lease)
 	verifyBgJobState(c, d, job, model.JobCancelled)
 }
+
+func (s *testDDLSuite) TestAnalyzeTableJob(c *C) {
+	defer testleak.AfterTest(c)()
+	store := testCreateStore(c, "test_analyze_table_job")
+	defer store.Close()
+
+	lease := 50 * time.Millisecond
+	d := newDDL(store, nil, nil, lease)
+	defer d.close()
+
+	startCh := make(chan struct{}, 1)
+	doneCh := make(chan error)
+	RegisterAnalyzeTableFunc(func(store kv.Storage, schemaID, tableID int64) error {
+		startCh <- struct{}{}
+		return <-doneCh
+	})
+	defer RegisterAnalyzeTableFunc(nil)
+
+	job := &model.Job{
+		ID:       1,
+		SchemaID: 1,
+		TableID:  1,
+		Type:     model.ActionAnalyzeTable,
+	}
+	d.prepareBgJob(job)
+	d.startBgJob(job.Type)
+	<-startCh
+
+	// The job is claimed while the analyze is running, and the queue and the owner can be updated.
+	err := d.handleBgJobQueue()
+	c.Assert(err, IsNil)
+	kv.RunInNewTxn(d.store, false, func(txn kv.Transaction) error {
+		t := meta.NewMeta(txn)
+		bgJob, err1 := t.GetBgJob(0)
+		c.Assert(err1, IsNil)
+		c.Assert(bgJob, NotNil)
+		c.Assert(bgJob.State, Equals, model.JobRunning)
+		err1 = t.EnQueueBgJob(&model.Job{ID: 2, SchemaID: 1, TableID: 1, Type: model.ActionAnalyzeTable})
+		c.Assert(err1, IsNil)
+		return nil
+	})
+
+	doneCh <- nil
+	<-startCh
+	verifyBgJobState(c, d, job, model.JobDone)
+
+	doneCh <- errInvalidBgJob
+	time.Sleep(lease)
+	verifyBgJobState(c, d, &model.Job{ID: 2}, model.JobCancelled)
+}
@@ -14,6 +14,8 @@
 package ddl
 
 import (
+	"strings"
+
 	"github.com/juju/errors"
 	"github.com/ngaut/log"
 	"github.com/pingcap/tidb/ast"
@@ -21,9 +23,12 @@ import (
 	"github.com/pingcap/tidb/kv"
 	"github.com/pingcap/tidb/meta"
 	"github.com/pingcap/tidb/model"
+	"github.com/pingcap/tidb/mysql"
 	"github.com/pingcap/tidb/table"
 	"github.com/pingcap/tidb/table/tables"
+	"github.com/pingcap/tidb/tablecodec"
 	"github.com/pingcap/tidb/terror"
+	"github.com/pingcap/tidb/util/types"
 )
 
 func (d *ddl) adjustColumnOffset(columns []*model.ColumnInfo, indices []*model.IndexInfo, offset int, added bool) {
@@ -430,3 +435,495 @@ func (d *ddl) dropTableColumn(t table.Table, colInfo *model.ColumnInfo, reorgInf
 		}
 	}
 }
+
+func (d *ddl) onModifyColumn(t *meta.Meta, job *model.Job) error {
+	schemaID := job.SchemaID
+	tblInfo, err := d.getTableInfo(t, job)
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	newCol := &model.ColumnInfo{}
+	var oldColName model.CIStr
+	pos := &ast.ColumnPosition{}
+	err = job.DecodeArgs(newCol, &oldColName, pos)
+	if err != nil {
+		job.State = model.JobCancelled
+		return errors.Trace(err)
+	}
+
+	_, err = t.GenSchemaVersion()
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	if job.SchemaState == model.StateNone {
+		return errors.Trace(d.startModifyColumn(t, job, tblInfo, newCol, oldColName, pos))
+	}
+
+	// changingCol is the column of the new type before it replaces the modified column,
+	// and the column of the origin type after that.
+	changingCol := findChangingCol(tblInfo.Columns)
+	if changingCol == nil {
+		job.State = model.JobCancelled
+		return infoschema.ErrColumnNotExists.Gen("the changing column of %s doesn't exist", oldColName)
+	}
+
+	switch job.SchemaState {
+	case model.StateDeleteOnly:
+		// delete only -> write only
+		job.SchemaState = model.StateWriteOnly
+		changingCol.State = model.StateWriteOnly
+	case model.StateWriteOnly:
+		// write only -> reorganization
+		job.SchemaState = model.StateWriteReorganization
+		changingCol.State = model.StateWriteReorganization
+		// initialize SnapshotVer to 0 for later reorganization check.
+		job.SnapshotVer = 0
+	case model.StateWriteReorganization:
+		colInfo := findCol(tblInfo.Columns, oldColName.L)
+		if colInfo == nil {
+			job.State = model.JobCancelled
+			return infoschema.ErrColumnNotExists.Gen("column %s doesn't exist", oldColName)
+		}
+		reorgInfo, err := d.getReorgInfo(t, job)
+		if err != nil || reorgInfo.first {
+			// if we run reorg firstly, we should update the job snapshot version
+			// and then run the reorg next time.
+			return errors.Trace(err)
+		}
+
+		tbl, err := d.getTable(schemaID, tblInfo)
+		if err != nil {
+			return errors.Trace(err)
+		}
+
+		err = d.runReorgJob(func() error {
+			return d.convertColumnData(tbl, colInfo, changingCol, reorgInfo)
+		})
+
+		if terror.ErrorEqual(err, errWaitReorgTimeout) {
+			// if timeout, we should return, check for the owner and re-wait job done.
+			return nil
+		}
+		if terror.ErrorEqual(err, errInvalidColumnData) {
+			// The data can't be converted, roll back the job by removing the changing column.
+			// reorganization -> delete only
+			job.SchemaState = model.StateDeleteOnly
+			job.State = model.JobRollback
+			changingCol.State = model.StateDeleteOnly
+			if err1 := t.UpdateTable(schemaID, tblInfo); err1 != nil {
+				return errors.Trace(err1)
+			}
+			return errors.Trace(err)
+		}
+		if err != nil {
+			return errors.Trace(err)
+		}
+
+		// reorganization -> public
+		if err = d.replaceModifiedColumn(t, schemaID, tblInfo, colInfo, changingCol, newCol, pos); err != nil {
+			return errors.Trace(err)
+		}
+		job.SchemaState = model.StatePublic
+	case model.StatePublic:
+		// The modified column is public, the changing column of the origin type is dropped.
+		switch changingCol.State {
+		case model.StateWriteOnly:
+			// write only -> delete only
+			changingCol.State = model.StateDeleteOnly
+		case model.StateDeleteOnly:
+			// delete only -> reorganization
+			changingCol.State = model.StateDeleteReorganization
+			// initialize SnapshotVer to 0 and remove the handle of the conversion for later reorganization check.
+			job.SnapshotVer = 0
+			if err = t.RemoveDDLReorgHandle(job); err != nil {
+				return errors.Trace(err)
+			}
+		case model.StateDeleteReorganization:
+			// reorganization -> absent
+			reorgInfo, err := d.getReorgInfo(t, job)
+			if err != nil || reorgInfo.first {
+				// if we run reorg firstly, we should update the job snapshot version
+				// and then run the reorg next time.
+				return errors.Trace(err)
+			}
+
+			tbl, err := d.getTable(schemaID, tblInfo)
+			if err != nil {
+				return errors.Trace(err)
+			}
+
+			err = d.runReorgJob(func() error {
+				return d.dropTableColumn(tbl, changingCol, reorgInfo)
+			})
+
+			if terror.ErrorEqual(err, errWaitReorgTimeout) {
+				// if timeout, we should return, check for the owner and re-wait job done.
+				return nil
+			}
+			if err != nil {
+				return errors.Trace(err)
+			}
+
+			// the changing column is the last column, so the other offsets are unchanged.
+			tblInfo.Columns = tblInfo.Columns[:len(tblInfo.Columns)-1]
+			if err = t.UpdateTable(schemaID, tblInfo); err != nil {
+				return errors.Trace(err)
+			}
+
+			// finish this job
+			job.State = model.JobDone
+			return nil
+		default:
+			return ErrInvalidColumnState.Gen("invalid column state %v", changingCol.State)
+		}
+	default:
+		return ErrInvalidColumnState.Gen("invalid column state %v", job.SchemaState)
+	}
+
+	err = t.UpdateTable(schemaID, tblInfo)
+	return errors.Trace(err)
+}
+
+// changingColumnPrefix is the name prefix of the changing column, which isn't public so its name is never used
+// by the statements, and it is different from the names of the public columns.
+const changingColumnPrefix = "_Col$_"
+
+// startModifyColumn checks whether the column can be modified, and updates the column meta if the existing data
+// is valid for the new type. Otherwise the changing column of the new type is added, it is written with the
+// converted values of the modified column, and it replaces the modified column after the existing data is
+// converted, so the data of the origin type is never read as the new type.
+func (d *ddl) startModifyColumn(t *meta.Meta, job *model.Job, tblInfo *model.TableInfo, newCol *model.ColumnInfo,
+	oldColName model.CIStr, pos *ast.ColumnPosition) error {
+	colInfo := findCol(tblInfo.Columns, oldColName.L)
+	if colInfo == nil {
+		job.State = model.JobCancelled
+		return infoschema.ErrColumnNotExists.Gen("column %s doesn't exist", oldColName)
+	}
+	if newCol.Name.L != oldColName.L && findCol(tblInfo.Columns, newCol.Name.L) != nil {
+		job.State = model.JobCancelled
+		return infoschema.ErrColumnExists.Gen("MODIFY COLUMN: column already exist %s", newCol.Name)
+	}
+	needReorg, err := checkModifyColumn(colInfo, newCol)
+	if err != nil {
+		job.State = model.JobCancelled
+		return errors.Trace(err)
+	}
+	if needReorg {
+		// The index values and the handles would have to be converted together, we don't support it now.
+		if tblInfo.PKIsHandle && mysql.HasPriKeyFlag(colInfo.Flag) {
+			job.State = model.JobCancelled
+			return errUnsupportedModifyColumn.Gen("can't convert the data of the primary key column %s", oldColName)
+		}
+		for _, indexInfo := range tblInfo.Indices {
+			for _, col := range indexInfo.Columns {
+				if col.Name.L == oldColName.L {
+					job.State = model.JobCancelled
+					return errUnsupportedModifyColumn.Gen("can't convert the data of column %s with index %s covered now",
+						oldColName, indexInfo.Name)
+				}
+			}
+		}
+	}
+
+	position, err := getModifiedColumnPosition(tblInfo, colInfo, pos)
+	if err != nil {
+		job.State = model.JobCancelled
+		return errors.Trace(err)
+	}
+	if !needReorg {
+		// none -> public, the lossless changes only update the column meta.
+		if err = d.modifyColumnInfo(t, job.SchemaID, tblInfo, colInfo, newCol, position); err != nil {
+			return errors.Trace(err)
+		}
+		if err = t.UpdateTable(job.SchemaID, tblInfo); err != nil {
+			return errors.Trace(err)
+		}
+		job.SchemaState = model.StatePublic
+		job.State = model.JobDone
+		return nil
+	}
+
+	// none -> delete only
+	changingCol := newCol.Clone()
+	changingCol.ID, err = t.GenGlobalID()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	changingCol.Name = model.NewCIStr(changingColumnPrefix + colInfo.Name.O)
+	changingCol.Offset = len(tblInfo.Columns)
+	changingCol.State = model.StateDeleteOnly
+	changingCol.ChangeFrom = colInfo.ID
+	tblInfo.Columns = append(tblInfo.Columns, changingCol)
+	job.SchemaState = model.StateDeleteOnly
+	return errors.Trace(t.UpdateTable(job.SchemaID, tblInfo))
+}
+
+// replaceModifiedColumn replaces the modified column with the changing column, whose data has been converted.
+// The modified column takes the ID and the type of the changing column, and the changing column takes the
+// origin ID and type. It becomes write only and is written with the values converted back, so the servers
+// which still read the column of the origin type read the latest values.
+func (d *ddl) replaceModifiedColumn(t *meta.Meta, schemaID int64, tblInfo *model.TableInfo, colInfo *model.ColumnInfo,
+	changingCol *model.ColumnInfo, newCol *model.ColumnInfo, pos *ast.ColumnPosition) error {
+	// the changing column is the last column, take it out to move the modified column among the others.
+	tblInfo.Columns = tblInfo.Columns[:len(tblInfo.Columns)-1]
+	position, err := getModifiedColumnPosition(tblInfo, colInfo, pos)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	originCol := colInfo.Clone()
+	if err = d.modifyColumnInfo(t, schemaID, tblInfo, colInfo, newCol, position); err != nil {
+		return errors.Trace(err)
+	}
+	colInfo.ID = changingCol.ID
+
+	originCol.Name = changingCol.Name
+	originCol.Offset = len(tblInfo.Columns)
+	originCol.State = model.StateWriteOnly
+	originCol.ChangeFrom = colInfo.ID
+	tblInfo.Columns = append(tblInfo.Columns, originCol)
+	return nil
+}
+
+// findChangingCol finds the column of MODIFY COLUMN which isn't public.
+func findChangingCol(cols []*model.ColumnInfo) *model.ColumnInfo {
+	for _, col := range cols {
+		if col.ChangeFrom != 0 {
+			return col
+		}
+	}
+	return nil
+}
+
+// checkModifyColumn checks whether the column can be modified from origin to to.
+// It returns true if the existing data must be checked and converted in reorganization,
+// otherwise only the column meta is updated.
+func checkModifyColumn(origin *model.ColumnInfo, to *model.ColumnInfo) (bool, error) {
+	if mysql.HasAutoIncrementFlag(origin.Flag) != mysql.HasAutoIncrementFlag(to.Flag) {
+		return false, errUnsupportedModifyColumn.Gen("can't change the AUTO_INCREMENT attribute of column %s", origin.Name)
+	}
+	needReorg := !mysql.HasNotNullFlag(origin.Flag) && mysql.HasNotNullFlag(to.Flag)
+	switch {
+	case isIntegerType(origin.Tp) && isIntegerType(to.Tp):
+		if mysql.HasUnsignedFlag(origin.Flag) != mysql.HasUnsignedFlag(to.Flag) ||
+			integerStorageSize[to.Tp] < integerStorageSize[origin.Tp] {
+			needReorg = true
+		}
+	case isStringType(origin.Tp) && isStringType(to.Tp):
+		if origin.Charset != to.Charset {
+			return false, errUnsupportedModifyColumn.Gen("can't change the charset of column %s from %s to %s",
+				origin.Name, origin.Charset, to.Charset)
+		}
+		if stringMaxLength(&to.FieldType) < stringMaxLength(&origin.FieldType) {
+			needReorg = true
+		}
+	default:
+		if origin.Tp != to.Tp || origin.Flen != to.Flen || origin.Decimal != to.Decimal ||
+			mysql.HasUnsignedFlag(origin.Flag) != mysql.HasUnsignedFlag(to.Flag) ||
+			strings.Join(origin.Elems, ",") != strings.Join(to.Elems, ",") {
+			return false, errUnsupportedModifyColumn.Gen("can't change column %s from %s to %s",
+				origin.Name, origin.FieldType.String(), to.FieldType.String())
+		}
+	}
+	return needReorg, nil
+}
+
+var integerStorageSize = map[byte]int{
+	mysql.TypeTiny:     1,
+	mysql.TypeShort:    2,
+	mysql.TypeInt24:    3,
+	mysql.TypeLong:     4,
+	mysql.TypeLonglong: 8,
+}
+
+func isIntegerType(tp byte) bool {
+	_, ok := integerStorageSize[tp]
+	return ok
+}
+
+func isStringType(tp byte) bool {
+	return types.IsTypeChar(tp) || types.IsTypeBlob(tp)
+}
+
+// stringMaxLength returns the max length of the values of a string type.
+func stringMaxLength(ft *types.FieldType) int {
+	if ft.Flen != types.UnspecifiedLength {
+		return ft.Flen
+	}
+	switch ft.Tp {
+	case mysql.TypeTinyBlob:
+		return 1<<8 - 1
+	case mysql.TypeBlob:
+		return 1<<16 - 1
+	case mysql.TypeMediumBlob:
+		return 1<<24 - 1
+	default:
+		return 1<<32 - 1
+	}
+}
+
+// getModifiedColumnPosition gets the position of the modified column in the column list.
+func getModifiedColumnPosition(tblInfo *model.TableInfo, colInfo *model.ColumnInfo, pos *ast.ColumnPosition) (int, error) {
+	switch pos.Tp {
+	case ast.ColumnPositionFirst:
+		return 0, nil
+	case ast.ColumnPositionAfter:
+		c := findCol(tblInfo.Columns, pos.RelativeColumn.Name.L)
+		if c == nil || c == colInfo {
+			return 0, infoschema.ErrColumnNotExists.Gen("no such column: %v", pos.RelativeColumn)
+		}
+		if c.Offset < colInfo.Offset {
+			return c.Offset + 1, nil
+		}
+		// The modified column is removed from the front of c first.
+		return c.Offset, nil
+	default:
+		return colInfo.Offset, nil
+	}
+}
+
+// modifyColumnInfo updates colInfo with the definition of newCol and moves it to the position,
+// the names and offsets of the columns in the indices and foreign keys are updated as well,
+// including the foreign keys of the other tables referencing the column.
+func (d *ddl) modifyColumnInfo(t *meta.Meta, schemaID int64, tblInfo *model.TableInfo, colInfo *model.ColumnInfo,
+	newCol *model.ColumnInfo, position int) error {
+	oldName := colInfo.Name
+	colInfo.Name = newCol.Name
+	colInfo.FieldType = newCol.FieldType
+	colInfo.DefaultValue = newCol.DefaultValue
+	colInfo.Comment = newCol.Comment
+
+	cols := make([]*model.ColumnInfo, 0, len(tblInfo.Columns))
+	for _, col := range tblInfo.Columns {
+		if col != colInfo {
+			cols = append(cols, col)
+		}
+	}
+	cols = append(cols[:position], append([]*model.ColumnInfo{colInfo}, cols[position:]...)...)
+	for i, col := range cols {
+		col.Offset = i
+	}
+	tblInfo.Columns = cols
+
+	for _, indexInfo := range tblInfo.Indices {
+		for _, col := range indexInfo.Columns {
+			if col.Name.L == oldName.L {
+				col.Name = colInfo.Name
+			}
+			col.Offset = findCol(cols, col.Name.L).Offset
+		}
+	}
+	for _, fkInfo := range tblInfo.ForeignKeys {
+		for i, col := range fkInfo.Cols {
+			if col.L == oldName.L {
+				fkInfo.Cols[i] = colInfo.Name
+			}
+		}
+	}
+	if oldName.L == colInfo.Name.L {
+		return nil
+	}
+
+	dbInfo, err := t.GetDatabase(schemaID)
+	if err != nil {
+		return errors.Trace(err)
+	} else if dbInfo == nil {
+		return errors.Trace(infoschema.ErrDatabaseNotExists)
+	}
+	err = updateFKRefs(t, map[int64]*model.TableInfo{tblInfo.ID: tblInfo},
+		func(db *model.DBInfo, _ *model.TableInfo, fk *model.FKInfo) bool {
+			if getFKRefSchema(db, fk).L != dbInfo.Name.L || fk.RefTable.L != tblInfo.Name.L {
+				return false
+			}
+			changed := false
+			for i, col := range fk.RefCols {
+				if col.L == oldName.L {
+					fk.RefCols[i] = colInfo.Name
+					changed = true
+				}
+			}
+			return changed
+		})
+	return errors.Trace(err)
+}
+
+// How to convert column data in reorganization state?
+//  1. Generate a snapshot with special version.
+//  2. Traverse the snapshot, get every row in the table.
+//  3. For one row, if the row has been already deleted, skip to next row.
+//  4. Convert the value of the modified column to the changing column type, and write it to the changing column
+//     if it is converted losslessly, otherwise return errInvalidColumnData to roll back the job.
+func (d *ddl) convertColumnData(t table.Table, colInfo *model.ColumnInfo, changingCol *model.ColumnInfo, reorgInfo *reorgInfo) error {
+	version := reorgInfo.SnapshotVer
+	seekHandle := reorgInfo.Handle
+
+	col := &table.Column{ColumnInfo: *colInfo}
+	changing := &table.Column{ColumnInfo: *changingCol}
+	for {
+		handles, err := d.getSnapshotRows(t, version, seekHandle)
+		if err != nil {
+			return errors.Trace(err)
+		} else if len(handles) == 0 {
+			return nil
+		}
+
+		seekHandle = handles[len(handles)-1] + 1
+
+		err = kv.RunInNewTxn(d.store, true, func(txn kv.Transaction) error {
+			if err1 := d.isReorgRunnable(txn); err1 != nil {
+				return errors.Trace(err1)
+			}
+
+			for _, h := range handles {
+				if err1 := d.convertColumnValue(txn, t, col, changing, h); err1 != nil {
+					return errors.Trace(err1)
+				}
+			}
+			return errors.Trace(reorgInfo.UpdateHandle(txn, handles[len(handles)-1]))
+		})
+		if err != nil {
+			return errors.Trace(err)
+		}
+	}
+}
+
+func (d *ddl) convertColumnValue(txn kv.Transaction, t table.Table, col *table.Column, changing *table.Column, h int64) error {
+	exist, err := checkRowExist(txn, t, h)
+	if err != nil {
+		return errors.Trace(err)
+	} else if !exist {
+		// If row doesn't exist, skip it.
+		return nil
+	}
+
+	var value types.Datum
+	data, err := txn.Get(t.RecordKey(h, col))
+	if terror.ErrorEqual(err, kv.ErrNotExist) {
+		// The null value isn't stored if the column has no default value,
+		// otherwise the column is filled with the default value when the row is read.
+		if col.DefaultValue != nil {
+			value, _, err = table.GetColDefaultValue(nil, &col.ColumnInfo)
+			if err != nil {
+				return errors.Trace(err)
+			}
+		}
+	} else if err != nil {
+		return errors.Trace(err)
+	} else {
+		value, err = tablecodec.DecodeColumnValue(data, &col.FieldType)
+		if err != nil {
+			return errors.Trace(err)
+		}
+	}
+	v, err := table.ConvertChangingValue(value, &changing.ColumnInfo)
+	if err != nil {
+		return errInvalidColumnData.Gen("column %s of row %d: %v", col.Name, h, err)
+	}
+
+	err = lockRow(txn, t, h)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Trace(tables.SetColValue(txn, t.RecordKey(h, changing), v))
+}
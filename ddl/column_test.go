@@ -16,6 +16,7 @@ package ddl
 import (
 	"time"
 
+	"github.com/juju/errors"
 	. "github.com/pingcap/check"
 	"github.com/pingcap/tidb/ast"
 	"github.com/pingcap/tidb/context"
@@ -25,6 +26,7 @@ import (
 	"github.com/pingcap/tidb/table"
 	"github.com/pingcap/tidb/table/tables"
 	"github.com/pingcap/tidb/tablecodec"
+	"github.com/pingcap/tidb/terror"
 	"github.com/pingcap/tidb/util/mock"
 	"github.com/pingcap/tidb/util/testleak"
 	"github.com/pingcap/tidb/util/types"
@@ -685,3 +687,260 @@ func (s *testColumnSuite) TestDropColumn(c *C) {
 	d.close()
 	s.d.start()
 }
+
+func (s *testColumnSuite) TestModifyColumnSignChanged(c *C) {
+	defer testleak.AfterTest(c)()
+	d := newDDL(s.store, nil, nil, 100*time.Millisecond)
+	tblInfo := testTableInfo(c, d, "t", 3)
+	ctx := testNewContext(c, d)
+
+	_, err := ctx.GetTxn(true)
+	c.Assert(err, IsNil)
+
+	testCreateTable(c, ctx, d, s.dbInfo, tblInfo)
+
+	t := testGetTable(c, d, s.dbInfo.ID, tblInfo.ID)
+
+	// -1 can't be converted to the unsigned column, so the job is rolled back.
+	handle, err := t.AddRecord(ctx, types.MakeDatums(int64(1), int64(-1), int64(3)))
+	c.Assert(err, IsNil)
+
+	err = ctx.CommitTxn()
+	c.Assert(err, IsNil)
+
+	var (
+		newHandle int64
+		writeErr  error
+	)
+	tc := &testDDLCallback{}
+	tc.onJobUpdated = func(job *model.Job) {
+		if job.Type != model.ActionModifyColumn || newHandle != 0 || job.SchemaState != model.StateWriteReorganization {
+			return
+		}
+
+		// The row is written in reorganization, the value is also converted to the changing column.
+		t := testGetTable(c, d, s.dbInfo.ID, tblInfo.ID)
+		writeCtx := testNewContext(c, d)
+		if _, writeErr = writeCtx.GetTxn(true); writeErr != nil {
+			return
+		}
+		newHandle, writeErr = t.AddRecord(writeCtx, types.MakeDatums(int64(2), int64(5), int64(3)))
+		if writeErr != nil {
+			return
+		}
+		writeErr = writeCtx.CommitTxn()
+	}
+
+	d.hook = tc
+
+	// Use local ddl for callback test.
+	s.d.close()
+
+	d.close()
+	d.start()
+
+	newCol := tblInfo.Columns[1].Clone()
+	newCol.Flag |= mysql.UnsignedFlag
+	job := &model.Job{
+		SchemaID: s.dbInfo.ID,
+		TableID:  tblInfo.ID,
+		Type:     model.ActionModifyColumn,
+		Args:     []interface{}{newCol, newCol.Name, &ast.ColumnPosition{}},
+	}
+	err = d.doDDLJob(ctx, job)
+	// The error of the conversion is kept after the job is rolled back.
+	c.Assert(err, ErrorMatches, ".*column c2 of row 1.*")
+	c.Assert(writeErr, IsNil)
+	c.Assert(newHandle, Not(Equals), int64(0))
+	testCheckJobCancelled(c, d, job)
+
+	// The changing column is removed, and the column is unchanged.
+	t = testGetTable(c, d, s.dbInfo.ID, tblInfo.ID)
+	c.Assert(mysql.HasUnsignedFlag(table.FindCol(t.Cols(), "c2").Flag), IsFalse)
+	c.Assert(t.Meta().Columns, HasLen, 3)
+	c.Assert(findChangingCol(t.Meta().Columns), IsNil)
+	_, err = ctx.GetTxn(true)
+	c.Assert(err, IsNil)
+	row, err := t.Row(ctx, handle)
+	c.Assert(err, IsNil)
+	c.Assert(row[1], DeepEquals, types.NewIntDatum(-1))
+	row, err = t.Row(ctx, newHandle)
+	c.Assert(err, IsNil)
+	c.Assert(row[1], DeepEquals, types.NewIntDatum(5))
+
+	job = testDropTable(c, ctx, d, s.dbInfo, tblInfo)
+	testCheckJobDone(c, d, job, false)
+
+	err = ctx.CommitTxn()
+	c.Assert(err, IsNil)
+
+	d.close()
+	s.d.start()
+}
+
+// TestModifyColumnSchemaVersions checks the rows written and read by the tables of two adjacent schema versions,
+// like the servers which are loading the latest schema, in every state of MODIFY COLUMN.
+func (s *testColumnSuite) TestModifyColumnSchemaVersions(c *C) {
+	defer testleak.AfterTest(c)()
+	d := newDDL(s.store, nil, nil, 100*time.Millisecond)
+	tblInfo := testTableInfo(c, d, "t", 3)
+	ctx := testNewContext(c, d)
+
+	_, err := ctx.GetTxn(true)
+	c.Assert(err, IsNil)
+
+	testCreateTable(c, ctx, d, s.dbInfo, tblInfo)
+
+	t := testGetTable(c, d, s.dbInfo.ID, tblInfo.ID)
+	handle, err := t.AddRecord(ctx, types.MakeDatums(int64(1), int64(2), int64(3)))
+	c.Assert(err, IsNil)
+
+	err = ctx.CommitTxn()
+	c.Assert(err, IsNil)
+
+	var (
+		prevTbl  = t
+		states   []model.SchemaState
+		checkErr error
+	)
+	tc := &testDDLCallback{}
+	tc.onJobUpdated = func(job *model.Job) {
+		if job.Type != model.ActionModifyColumn || checkErr != nil {
+			return
+		}
+
+		curTbl := testGetTable(c, d, s.dbInfo.ID, tblInfo.ID)
+		defer func() { prevTbl = curTbl }()
+		if len(states) == 0 || states[len(states)-1] != job.SchemaState {
+			states = append(states, job.SchemaState)
+		}
+
+		// The type of the column is changed only when the converted data can be read.
+		unsigned := mysql.HasUnsignedFlag(table.FindCol(curTbl.Cols(), "c2").Flag)
+		if unsigned != (job.SchemaState == model.StatePublic) {
+			checkErr = errors.Errorf("column c2 is unsigned %v in state %v", unsigned, job.SchemaState)
+			return
+		}
+
+		// The row written by a version is read by the other version.
+		for i, tbls := range [][]table.Table{{prevTbl, curTbl}, {curTbl, prevTbl}} {
+			v := int64(len(states)*10 + i)
+			got, err := testAddAndReadC2(c, d, tbls[0], tbls[1], v)
+			if err != nil {
+				checkErr = errors.Trace(err)
+				return
+			}
+			if got.IsNull() || got.GetInt64() != v {
+				checkErr = errors.Errorf("column c2 is %v, expect %d in state %v", got.GetValue(), v, job.SchemaState)
+				return
+			}
+		}
+	}
+
+	d.hook = tc
+
+	// Use local ddl for callback test.
+	s.d.close()
+
+	d.close()
+	d.start()
+
+	newCol := tblInfo.Columns[1].Clone()
+	newCol.Flag |= mysql.UnsignedFlag
+	job := &model.Job{
+		SchemaID: s.dbInfo.ID,
+		TableID:  tblInfo.ID,
+		Type:     model.ActionModifyColumn,
+		Args:     []interface{}{newCol, newCol.Name, &ast.ColumnPosition{}},
+	}
+	err = d.doDDLJob(ctx, job)
+	c.Assert(err, IsNil)
+	c.Assert(checkErr, IsNil)
+	c.Assert(states, DeepEquals, []model.SchemaState{model.StateDeleteOnly, model.StateWriteOnly,
+		model.StateWriteReorganization, model.StatePublic})
+	testCheckJobDone(c, d, job, true)
+
+	// The changing column of the origin type is dropped, and the existing row is converted.
+	t = testGetTable(c, d, s.dbInfo.ID, tblInfo.ID)
+	c.Assert(t.Meta().Columns, HasLen, 3)
+	c.Assert(findChangingCol(t.Meta().Columns), IsNil)
+	c.Assert(mysql.HasUnsignedFlag(table.FindCol(t.Cols(), "c2").Flag), IsTrue)
+	_, err = ctx.GetTxn(true)
+	c.Assert(err, IsNil)
+	row, err := t.Row(ctx, handle)
+	c.Assert(err, IsNil)
+	c.Assert(row[1], DeepEquals, types.NewUintDatum(2))
+
+	job = testDropTable(c, ctx, d, s.dbInfo, tblInfo)
+	testCheckJobDone(c, d, job, false)
+
+	err = ctx.CommitTxn()
+	c.Assert(err, IsNil)
+
+	d.close()
+	s.d.start()
+}
+
+// testAddAndReadC2 adds a row of the value v in column c2 by table w, and returns column c2 of the row read by table r.
+func testAddAndReadC2(c *C, d *ddl, w table.Table, r table.Table, v int64) (types.Datum, error) {
+	ctx := testNewContext(c, d)
+	defer ctx.RollbackTxn()
+	if _, err := ctx.GetTxn(true); err != nil {
+		return types.Datum{}, errors.Trace(err)
+	}
+	c2 := types.NewIntDatum(v)
+	if mysql.HasUnsignedFlag(table.FindCol(w.Cols(), "c2").Flag) {
+		c2 = types.NewUintDatum(uint64(v))
+	}
+	h, err := w.AddRecord(ctx, []types.Datum{types.NewIntDatum(v), c2, types.NewIntDatum(v)})
+	if err != nil {
+		return types.Datum{}, errors.Trace(err)
+	}
+	row, err := r.Row(ctx, h)
+	if err != nil {
+		return types.Datum{}, errors.Trace(err)
+	}
+	return row[1], nil
+}
+
+func (s *testColumnSuite) TestCheckModifyColumn(c *C) {
+	defer testleak.AfterTest(c)()
+	newCol := func(tp byte, flen int, flag uint, cs string) *model.ColumnInfo {
+		col := &model.ColumnInfo{Name: model.NewCIStr("c")}
+		col.Tp = tp
+		col.Flen = flen
+		col.Decimal = types.UnspecifiedLength
+		col.Flag = flag
+		col.Charset = cs
+		return col
+	}
+	tbl := []struct {
+		origin    *model.ColumnInfo
+		to        *model.ColumnInfo
+		needReorg bool
+		err       bool
+	}{
+		{newCol(mysql.TypeLong, 11, 0, "binary"), newCol(mysql.TypeLonglong, 21, 0, "binary"), false, false},
+		{newCol(mysql.TypeLonglong, 21, 0, "binary"), newCol(mysql.TypeShort, 6, 0, "binary"), true, false},
+		{newCol(mysql.TypeLong, 11, 0, "binary"), newCol(mysql.TypeLong, 11, mysql.UnsignedFlag, "binary"), true, false},
+		{newCol(mysql.TypeLong, 11, 0, "binary"), newCol(mysql.TypeLong, 11, mysql.NotNullFlag, "binary"), true, false},
+		{newCol(mysql.TypeLong, 11, mysql.NotNullFlag, "binary"), newCol(mysql.TypeLong, 11, 0, "binary"), false, false},
+		{newCol(mysql.TypeVarchar, 10, 0, "utf8"), newCol(mysql.TypeVarchar, 20, 0, "utf8"), false, false},
+		{newCol(mysql.TypeVarchar, 10, 0, "utf8"), newCol(mysql.TypeBlob, types.UnspecifiedLength, 0, "utf8"), false, false},
+		{newCol(mysql.TypeBlob, types.UnspecifiedLength, 0, "utf8"), newCol(mysql.TypeVarchar, 255, 0, "utf8"), true, false},
+		{newCol(mysql.TypeVarchar, 10, 0, "utf8"), newCol(mysql.TypeVarchar, 10, 0, "binary"), false, true},
+		{newCol(mysql.TypeVarchar, 10, 0, "utf8"), newCol(mysql.TypeLong, 11, 0, "binary"), false, true},
+		{newCol(mysql.TypeLong, 11, mysql.AutoIncrementFlag, "binary"), newCol(mysql.TypeLong, 11, 0, "binary"), false, true},
+		{newCol(mysql.TypeDouble, 22, 0, "binary"), newCol(mysql.TypeFloat, 12, 0, "binary"), false, true},
+		{newCol(mysql.TypeDatetime, 19, 0, "binary"), newCol(mysql.TypeDatetime, 19, mysql.NotNullFlag, "binary"), true, false},
+	}
+	for _, t := range tbl {
+		needReorg, err := checkModifyColumn(t.origin, t.to)
+		if t.err {
+			c.Assert(terror.ErrorEqual(err, errUnsupportedModifyColumn), IsTrue, Commentf("%v -> %v", t.origin, t.to))
+			continue
+		}
+		c.Assert(err, IsNil)
+		c.Assert(needReorg, Equals, t.needReorg, Commentf("%v -> %v", t.origin, t.to))
+	}
+}
@@ -53,10 +53,16 @@ var (
 	errRunMultiSchemaChanges = terror.ClassDDL.New(codeRunMultiSchemaChanges, "can't run multi schema change")
 	errWaitReorgTimeout      = terror.ClassDDL.New(codeWaitReorgTimeout, "wait for reorganization timeout")
 	errInvalidStoreVer       = terror.ClassDDL.New(codeInvalidStoreVer, "invalid storage current version")
+	// errCancelledDDLJob means the DDL job is cancelled by the user.
+	errCancelledDDLJob = terror.ClassDDL.New(codeCancelledDDLJob, "cancelled DDL job")
 
 	// we don't support drop column with index covered now.
 	errCantDropColWithIndex = terror.ClassDDL.New(codeCantDropColWithIndex, "can't drop column with index")
 	errUnsupportedAddColumn = terror.ClassDDL.New(codeUnsupportedAddColumn, "unsupported add column")
+	// we only support the column type changes which keep the data of the same kind.
+	errUnsupportedModifyColumn = terror.ClassDDL.New(codeUnsupportedModifyColumn, "unsupported modify column")
+	// errInvalidColumnData means the existing data can't be converted to the modified column type.
+	errInvalidColumnData = terror.ClassDDL.New(codeInvalidColumnData, "invalid data for the modified column")
 
 	// ErrInvalidDBState returns for invalid database state.
 	ErrInvalidDBState = terror.ClassDDL.New(codeInvalidDBState, "invalid database state")
@@ -77,6 +83,10 @@ var (
 	ErrCantDropFieldOrKey = terror.ClassDDL.New(codeCantDropFieldOrKey, "can't drop field; check that column/key exists")
 	// ErrInvalidOnUpdate returns for invalid ON UPDATE clause.
 	ErrInvalidOnUpdate = terror.ClassDDL.New(codeInvalidOnUpdate, "invalid ON UPDATE clause for the column")
+	// ErrFKNoIndexChild returns for adding a foreign key whose columns have no index.
+	ErrFKNoIndexChild = terror.ClassDDL.New(codeFKNoIndexChild, "missing index for the foreign key in the foreign table")
+	// ErrFKNoIndexParent returns for adding a foreign key whose referenced columns have no index.
+	ErrFKNoIndexParent = terror.ClassDDL.New(codeFKNoIndexParent, "missing index for the foreign key in the referenced table")
 )
 
 // DDL is responsible for updating schema in data store and maintaining in-memory InfoSchema cache.
@@ -86,11 +96,17 @@ type DDL interface {
 	CreateTable(ctx context.Context, ident ast.Ident, cols []*ast.ColumnDef,
 		constrs []*ast.Constraint, options []*ast.TableOption) error
 	DropTable(ctx context.Context, tableIdent ast.Ident) (err error)
+	// RenameTable renames the old tables to the new tables in one job, the new tables may be in other databases.
+	RenameTable(ctx context.Context, oldIdents, newIdents []ast.Ident) error
+	// TruncateTable replaces the table with an empty one of a new table ID, the old data is deleted in the background.
+	TruncateTable(ctx context.Context, tableIdent ast.Ident) error
 	CreateIndex(ctx context.Context, tableIdent ast.Ident, unique bool, indexName model.CIStr,
 		columnNames []*ast.IndexColName) error
 	DropIndex(ctx context.Context, tableIdent ast.Ident, indexName model.CIStr) error
 	GetInformationSchema() infoschema.InfoSchema
 	AlterTable(ctx context.Context, tableIdent ast.Ident, spec []*ast.AlterTableSpec) error
+	// AnalyzeTable rebuilds the statistics of the table in a background job.
+	AnalyzeTable(tableID int64) error
 	// SetLease will reset the lease time for online DDL change,
 	// it's a very dangerous function and you must guarantee that all servers have the same lease time.
 	SetLease(lease time.Duration)
@@ -124,6 +140,10 @@ type ddl struct {
 	// TODO: now we use goroutine to simulate reorganization jobs, later we may
 	// use a persistent job list.
 	reorgDoneCh chan error
+	// analyzeDoneCh is for the analyze job of analyzeJobID running outside the owner transaction,
+	// if the analyze is done, we will use this channel to notify the background worker.
+	analyzeDoneCh chan error
+	analyzeJobID  int64
 
 	quitCh chan struct{}
 	wait   sync.WaitGroup
@@ -410,17 +430,7 @@ func (d *ddl) buildColumnsAndConstraints(ctx context.Context, colDefs []*ast.Col
 
 func (d *ddl) buildColumnAndConstraint(ctx context.Context, offset int,
 	colDef *ast.ColumnDef) (*table.Column, []*ast.Constraint, error) {
-	// Set charset.
-	if len(colDef.Tp.Charset) == 0 {
-		switch colDef.Tp.Tp {
-		case mysql.TypeString, mysql.TypeVarchar, mysql.TypeVarString, mysql.TypeBlob, mysql.TypeTinyBlob, mysql.TypeMediumBlob, mysql.TypeLongBlob:
-			colDef.Tp.Charset, colDef.Tp.Collate = getDefaultCharsetAndCollate()
-		default:
-			colDef.Tp.Charset = charset.CharsetBin
-			colDef.Tp.Collate = charset.CharsetBin
-		}
-	}
-
+	setColumnCharset(colDef)
 	col, cts, err := columnDefToCol(ctx, offset, colDef)
 	if err != nil {
 		return nil, nil, errors.Trace(err)
@@ -434,6 +444,20 @@ func (d *ddl) buildColumnAndConstraint(ctx context.Context, offset int,
 	return col, cts, nil
 }
 
+// setColumnCharset sets the default charset and collation if the column definition doesn't specify them.
+func setColumnCharset(colDef *ast.ColumnDef) {
+	if len(colDef.Tp.Charset) != 0 {
+		return
+	}
+	switch colDef.Tp.Tp {
+	case mysql.TypeString, mysql.TypeVarchar, mysql.TypeVarString, mysql.TypeBlob, mysql.TypeTinyBlob, mysql.TypeMediumBlob, mysql.TypeLongBlob:
+		colDef.Tp.Charset, colDef.Tp.Collate = getDefaultCharsetAndCollate()
+	default:
+		colDef.Tp.Charset = charset.CharsetBin
+		colDef.Tp.Collate = charset.CharsetBin
+	}
+}
+
 // columnDefToCol converts ColumnDef to Col and TableConstraints.
 func columnDefToCol(ctx context.Context, offset int, colDef *ast.ColumnDef) (*table.Column, []*ast.Constraint, error) {
 	constraints := []*ast.Constraint{}
@@ -725,6 +749,7 @@ func (d *ddl) buildTableInfo(tableName model.CIStr, cols []*table.Column, constr
 			}
 			var fk model.FKInfo
 			fk.Name = model.NewCIStr(constr.Name)
+			fk.RefSchema = constr.Refer.Table.Schema
 			fk.RefTable = constr.Refer.Table.Name
 			fk.State = model.StatePublic
 			for _, key := range constr.Keys {
@@ -801,6 +826,35 @@ func (d *ddl) buildTableInfo(tableName model.CIStr, cols []*table.Column, constr
 		}
 		tbInfo.Indices = append(tbInfo.Indices, idxInfo)
 	}
+
+	// The foreign keys are checked by the indices on their columns, so the index is created like MySQL
+	// for the foreign key whose columns are not the leading columns of any index.
+	for _, fk := range tbInfo.ForeignKeys {
+		if hasIndexOnCols(tbInfo, fk.Cols) {
+			continue
+		}
+		idxInfo := &model.IndexInfo{
+			Name:  getFKIndexName(tbInfo, fk),
+			State: model.StatePublic,
+			Tp:    model.IndexTypeBtree,
+		}
+		for _, name := range fk.Cols {
+			col := table.FindCol(cols, name.O)
+			if col == nil {
+				return nil, infoschema.ErrColumnNotExists.Gen("no such column: %v", name)
+			}
+			idxInfo.Columns = append(idxInfo.Columns, &model.IndexColumn{
+				Name:   col.Name,
+				Offset: col.Offset,
+				Length: types.UnspecifiedLength,
+			})
+		}
+		idxInfo.ID, err = d.genGlobalID()
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		tbInfo.Indices = append(tbInfo.Indices, idxInfo)
+	}
 	return
 }
 
@@ -832,6 +886,11 @@ func (d *ddl) CreateTable(ctx context.Context, ident ast.Ident, colDefs []*ast.C
 	if err != nil {
 		return errors.Trace(err)
 	}
+	for _, fk := range tbInfo.ForeignKeys {
+		if err = checkFKParentIndex(is, ident.Schema, tbInfo, fk); err != nil {
+			return errors.Trace(err)
+		}
+	}
 
 	job := &model.Job{
 		SchemaID: schema.ID,
@@ -899,6 +958,8 @@ func (d *ddl) AlterTable(ctx context.Context, ident ast.Ident, specs []*ast.Alte
 			err = d.AddColumn(ctx, ident, spec)
 		case ast.AlterTableDropColumn:
 			err = d.DropColumn(ctx, ident, spec.DropColumn.Name)
+		case ast.AlterTableModifyColumn, ast.AlterTableChangeColumn:
+			err = d.ModifyColumn(ctx, ident, spec)
 		case ast.AlterTableDropIndex:
 			err = d.DropIndex(ctx, ident, model.NewCIStr(spec.Name))
 		case ast.AlterTableAddConstraint:
@@ -915,6 +976,9 @@ func (d *ddl) AlterTable(ctx context.Context, ident ast.Ident, specs []*ast.Alte
 			}
 		case ast.AlterTableDropForeignKey:
 			err = d.DropForeignKey(ctx, ident, model.NewCIStr(spec.Name))
+		case ast.AlterTableRenameTable:
+			newIdent := ast.Ident{Schema: spec.NewTable.Schema, Name: spec.NewTable.Name}
+			err = d.RenameTable(ctx, []ast.Ident{ident}, []ast.Ident{newIdent})
 		default:
 			// nothing to do now.
 		}
@@ -1015,6 +1079,69 @@ func (d *ddl) DropColumn(ctx context.Context, ti ast.Ident, colName model.CIStr)
 	return errors.Trace(err)
 }
 
+// ModifyColumn changes the definition of a column, CHANGE COLUMN renames the column as well.
+// The lossless changes only update the column meta, the others convert the existing data in reorganization.
+func (d *ddl) ModifyColumn(ctx context.Context, ti ast.Ident, spec *ast.AlterTableSpec) error {
+	for _, option := range spec.Column.Options {
+		switch option.Tp {
+		case ast.ColumnOptionPrimaryKey, ast.ColumnOptionUniq, ast.ColumnOptionUniqKey, ast.ColumnOptionUniqIndex,
+			ast.ColumnOptionIndex, ast.ColumnOptionKey:
+			return errUnsupportedModifyColumn.Gen("unsupported modify column constraint - %v", option.Tp)
+		}
+	}
+
+	is := d.infoHandle.Get()
+	schema, ok := is.SchemaByName(ti.Schema)
+	if !ok {
+		return errors.Trace(infoschema.ErrDatabaseNotExists)
+	}
+
+	t, err := is.TableByName(ti.Schema, ti.Name)
+	if err != nil {
+		return errors.Trace(infoschema.ErrTableNotExists)
+	}
+
+	colName := spec.Column.Name.Name
+	if spec.Tp == ast.AlterTableChangeColumn {
+		colName = spec.OldColumnName.Name
+	}
+	col := table.FindCol(t.Cols(), colName.L)
+	if col == nil {
+		return infoschema.ErrColumnNotExists.Gen("column %s doesn’t exist", colName.L)
+	}
+	newName := spec.Column.Name.Name
+	if newName.L != colName.L && table.FindCol(t.Cols(), newName.L) != nil {
+		return infoschema.ErrColumnExists.Gen("column %s already exists", newName.O)
+	}
+
+	setColumnCharset(spec.Column)
+	newCol, _, err := columnDefToCol(ctx, col.Offset, spec.Column)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	newCol.ID = col.ID
+	newCol.State = col.State
+	// The key flags come from the indices, which are not changed.
+	newCol.Flag |= col.Flag & (mysql.PriKeyFlag | mysql.UniqueKeyFlag | mysql.MultipleKeyFlag)
+	if mysql.HasPriKeyFlag(newCol.Flag) {
+		newCol.Flag |= mysql.NotNullFlag
+	}
+	if _, err = checkModifyColumn(&col.ColumnInfo, &newCol.ColumnInfo); err != nil {
+		return errors.Trace(err)
+	}
+
+	job := &model.Job{
+		SchemaID: schema.ID,
+		TableID:  t.Meta().ID,
+		Type:     model.ActionModifyColumn,
+		Args:     []interface{}{&newCol.ColumnInfo, colName, spec.Position},
+	}
+
+	err = d.doDDLJob(ctx, job)
+	err = d.hook.OnChanged(err)
+	return errors.Trace(err)
+}
+
 // DropTable will proceed even if some table in the list does not exists.
 func (d *ddl) DropTable(ctx context.Context, ti ast.Ident) (err error) {
 	is := d.GetInformationSchema()
@@ -1039,6 +1166,102 @@ func (d *ddl) DropTable(ctx context.Context, ti ast.Ident) (err error) {
 	return errors.Trace(err)
 }
 
+// TruncateTable gives the table a new table ID, so the truncate doesn't need to delete
+// the old data in the transaction.
+func (d *ddl) TruncateTable(ctx context.Context, ti ast.Ident) error {
+	is := d.GetInformationSchema()
+	schema, ok := is.SchemaByName(ti.Schema)
+	if !ok {
+		return infoschema.ErrDatabaseNotExists.Gen("database %s not exists", ti.Schema)
+	}
+
+	tb, err := is.TableByName(ti.Schema, ti.Name)
+	if err != nil {
+		return errors.Trace(infoschema.ErrTableNotExists)
+	}
+	newTableID, err := d.genGlobalID()
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	job := &model.Job{
+		SchemaID: schema.ID,
+		TableID:  tb.Meta().ID,
+		Type:     model.ActionTruncateTable,
+		Args:     []interface{}{newTableID},
+	}
+
+	err = d.doDDLJob(ctx, job)
+	err = d.hook.OnChanged(err)
+	return errors.Trace(err)
+}
+
+// RenameTable checks the renames in order, so a table renamed by a previous pair
+// can be renamed again by a following pair in the same statement.
+func (d *ddl) RenameTable(ctx context.Context, oldIdents, newIdents []ast.Ident) error {
+	if len(oldIdents) != len(newIdents) || len(oldIdents) == 0 {
+		return errors.Errorf("invalid rename table arguments")
+	}
+
+	is := d.GetInformationSchema()
+	// renamed records the table ID of a table name after the previous renames,
+	// 0 means the table name has been renamed away.
+	renamed := make(map[string]int64)
+	renamedKey := func(ident ast.Ident) string {
+		return ident.Schema.L + "." + ident.Name.L
+	}
+	getTableID := func(ident ast.Ident) (int64, bool) {
+		if id, ok := renamed[renamedKey(ident)]; ok {
+			return id, id != 0
+		}
+		tb, err := is.TableByName(ident.Schema, ident.Name)
+		if err != nil {
+			return 0, false
+		}
+		return tb.Meta().ID, true
+	}
+
+	args := make([]*renameTableArg, 0, len(oldIdents))
+	for i, oldIdent := range oldIdents {
+		newIdent := newIdents[i]
+		oldSchema, ok := is.SchemaByName(oldIdent.Schema)
+		if !ok {
+			return infoschema.ErrDatabaseNotExists.Gen("database %s not exists", oldIdent.Schema)
+		}
+		newSchema, ok := is.SchemaByName(newIdent.Schema)
+		if !ok {
+			return infoschema.ErrDatabaseNotExists.Gen("database %s not exists", newIdent.Schema)
+		}
+		tableID, ok := getTableID(oldIdent)
+		if !ok {
+			return infoschema.ErrTableNotExists.Gen("table %s not exists", oldIdent)
+		}
+		if _, ok = getTableID(newIdent); ok {
+			return infoschema.ErrTableExists.Gen("table %s exists", newIdent)
+		}
+
+		renamed[renamedKey(oldIdent)] = 0
+		renamed[renamedKey(newIdent)] = tableID
+		args = append(args, &renameTableArg{
+			OldSchemaID: oldSchema.ID,
+			TableID:     tableID,
+			NewSchemaID: newSchema.ID,
+			NewName:     newIdent.Name,
+		})
+	}
+
+	job := &model.Job{
+		SchemaID: args[0].OldSchemaID,
+		TableID:  args[0].TableID,
+		Type:     model.ActionRenameTable,
+		Args:     []interface{}{args},
+	}
+
+	err := d.doDDLJob(ctx, job)
+	err = d.hook.OnChanged(err)
+	return errors.Trace(err)
+}
+
 func (d *ddl) CreateIndex(ctx context.Context, ti ast.Ident, unique bool, indexName model.CIStr, idxColNames []*ast.IndexColName) error {
 	is := d.infoHandle.Get()
 	schema, ok := is.SchemaByName(ti.Schema)
@@ -1076,6 +1299,7 @@ func (d *ddl) buildFKInfo(fkName model.CIStr, keys []*ast.IndexColName, refer *a
 	var fkInfo model.FKInfo
 	fkInfo.ID = fkID
 	fkInfo.Name = fkName
+	fkInfo.RefSchema = refer.Table.Schema
 	fkInfo.RefTable = refer.Table.Name
 
 	fkInfo.Cols = make([]model.CIStr, len(keys))
@@ -1111,6 +1335,13 @@ func (d *ddl) CreateForeignKey(ctx context.Context, ti ast.Ident, fkName model.C
 	if err != nil {
 		return errors.Trace(err)
 	}
+	if !hasIndexOnCols(t.Meta(), fkInfo.Cols) {
+		return ErrFKNoIndexChild.Gen("Failed to add the foreign key constraint. Missing index for constraint '%s' in the foreign table '%s'",
+			fkInfo.Name, ti.Name)
+	}
+	if err = checkFKParentIndex(is, ti.Schema, t.Meta(), fkInfo); err != nil {
+		return errors.Trace(err)
+	}
 
 	job := &model.Job{
 		SchemaID: schema.ID,
@@ -1195,6 +1426,7 @@ const (
 	codeRunMultiSchemaChanges                = 6
 	codeWaitReorgTimeout                     = 7
 	codeInvalidStoreVer                      = 8
+	codeCancelledDDLJob                      = 9
 
 	codeInvalidDBState         = 100
 	codeInvalidTableState      = 101
@@ -1202,13 +1434,17 @@ const (
 	codeInvalidIndexState      = 103
 	codeInvalidForeignKeyState = 104
 
-	codeCantDropColWithIndex = 201
-	codeUnsupportedAddColumn = 202
+	codeCantDropColWithIndex    = 201
+	codeUnsupportedAddColumn    = 202
+	codeUnsupportedModifyColumn = 203
+	codeInvalidColumnData       = 204
 
 	codeBadNull             = 1048
 	codeCantRemoveAllFields = 1090
 	codeCantDropFieldOrKey  = 1091
 	codeInvalidOnUpdate     = 1294
+	codeFKNoIndexChild      = 1821
+	codeFKNoIndexParent     = 1822
 )
 
 func init() {
@@ -1217,6 +1453,8 @@ func init() {
 		codeCantRemoveAllFields: mysql.ErrCantRemoveAllFields,
 		codeCantDropFieldOrKey:  mysql.ErrCantDropFieldOrKey,
 		codeInvalidOnUpdate:     mysql.ErrInvalidOnUpdate,
+		codeFKNoIndexChild:      mysql.ErrFkNoIndexChild,
+		codeFKNoIndexParent:     mysql.ErrFkNoIndexParent,
 	}
 	terror.ErrClassToMySQLCodes[terror.ClassDDL] = ddlMySQLERrCodes
 }
@@ -28,6 +28,7 @@ import (
 	"github.com/pingcap/tidb/context"
 	"github.com/pingcap/tidb/kv"
 	"github.com/pingcap/tidb/model"
+	"github.com/pingcap/tidb/mysql"
 	"github.com/pingcap/tidb/sessionctx"
 	"github.com/pingcap/tidb/table"
 	"github.com/pingcap/tidb/table/tables"
@@ -473,6 +474,55 @@ LOOP:
 	c.Assert(i, LessEqual, num+step)
 }
 
+func (s *testDBSuite) TestModifyColumn(c *C) {
+	defer testleak.AfterTest(c)()
+	s.mustExec(c, "create table t_modify (c1 int primary key, c2 varchar(10), c3 bigint, c4 int, c5 int)")
+	s.mustExec(c, "create index c4_index on t_modify (c4)")
+	s.mustExec(c, "insert into t_modify values (1, 'a', 1, 1, 1), (2, 'bb', 2, 2, null)")
+
+	// The lossless changes only update the column meta.
+	s.mustExec(c, "alter table t_modify modify column c2 varchar(20)")
+	s.mustExec(c, "insert into t_modify values (3, ?, 3, 3, 3)", strings.Repeat("c", 20))
+	s.mustExec(c, "alter table t_modify modify c4 bigint")
+	s.mustExec(c, "alter table t_modify change c3 c6 bigint not null default 0 first")
+	values := s.showColumns(c, "t_modify")
+	c.Assert(values, HasLen, 5)
+	match(c, values[0][:2], "c6", "bigint(21)")
+	rows := s.mustQuery(c, "select c6, c1 from t_modify where c4 = 2")
+	matchRows(c, rows, [][]interface{}{{2, 2}})
+
+	// The lossy changes convert the data in reorganization.
+	s.mustExec(c, "alter table t_modify modify c6 tinyint unsigned not null")
+	t := s.testGetTable(c, "t_modify")
+	col := table.FindCol(t.Cols(), "c6")
+	c.Assert(col.Tp, Equals, mysql.TypeTiny)
+	c.Assert(mysql.HasUnsignedFlag(col.Flag), IsTrue)
+	rows = s.mustQuery(c, "select sum(c6) from t_modify")
+	matchRows(c, rows, [][]interface{}{{6}})
+
+	// The job is cancelled and the column is restored if the data can't be converted.
+	_, err := s.db.Exec("alter table t_modify modify c2 varchar(5)")
+	c.Assert(err, NotNil)
+	_, err = s.db.Exec("alter table t_modify modify c5 int not null")
+	c.Assert(err, NotNil)
+	t = s.testGetTable(c, "t_modify")
+	col = table.FindCol(t.Cols(), "c2")
+	c.Assert(col.Flen, Equals, 20)
+	c.Assert(mysql.HasNotNullFlag(table.FindCol(t.Cols(), "c5").Flag), IsFalse)
+	s.mustExec(c, "update t_modify set c5 = 0 where c5 is null")
+	s.mustExec(c, "alter table t_modify modify c5 int not null")
+
+	// The type changes across kinds and the conversions of the indexed columns are not supported.
+	_, err = s.db.Exec("alter table t_modify modify c2 int")
+	c.Assert(err, NotNil)
+	_, err = s.db.Exec("alter table t_modify modify c4 tinyint")
+	c.Assert(err, NotNil)
+	_, err = s.db.Exec("alter table t_modify change c2 c1 varchar(20)")
+	c.Assert(err, NotNil)
+	_, err = s.db.Exec("alter table t_modify modify c7 int")
+	c.Assert(err, NotNil)
+}
+
 func (s *testDBSuite) mustExec(c *C, query string, args ...interface{}) sql.Result {
 	r, err := s.db.Exec(query, args...)
 	c.Assert(err, IsNil, Commentf("query %s, args %v", query, args))
@@ -175,7 +175,7 @@ func (d *ddl) finishDDLJob(t *meta.Meta, job *model.Job) error {
 		return errors.Trace(err)
 	}
 	switch job.Type {
-	case model.ActionDropSchema, model.ActionDropTable:
+	case model.ActionDropSchema, model.ActionDropTable, model.ActionTruncateTable:
 		if err = d.prepareBgJob(job); err != nil {
 			return errors.Trace(err)
 		}
@@ -212,7 +212,10 @@ func (d *ddl) handleDDLJobQueue() error {
 
 		waitTime := 2 * d.lease
 
-		var job *model.Job
+		var (
+			job      *model.Job
+			rollback bool
+		)
 		err := kv.RunInNewTxn(d.store, false, func(txn kv.Transaction) error {
 			t := meta.NewMeta(txn)
 			owner, err := d.checkOwner(t, ddlJobFlag)
@@ -230,7 +233,7 @@ func (d *ddl) handleDDLJobQueue() error {
 				return errors.Trace(err)
 			}
 
-			if job.IsRunning() {
+			if job.IsRunning() || job.IsRollingback() {
 				// if we enter a new state, crash when waiting 2 * lease time, and restart quickly,
 				// we may run the job immediately again, but we don't wait enough 2 * lease time to
 				// let other servers update the schema.
@@ -250,7 +253,8 @@ func (d *ddl) handleDDLJobQueue() error {
 
 			// if run job meets error, we will save this error in job Error
 			// and retry later if the job is not cancelled.
-			d.runDDLJob(t, job)
+			rollback = job.IsCancelling() || job.IsRollingback()
+			d.runDDLJob(txn, t, job)
 
 			if job.IsFinished() {
 				err = d.finishDDLJob(t, job)
@@ -278,9 +282,9 @@ func (d *ddl) handleDDLJobQueue() error {
 		d.hook.OnJobUpdated(job)
 
 		// here means the job enters another state (delete only, write only, public, etc...) or is cancelled.
-		// if the job is done or still running, we will wait 2 * lease time to guarantee other servers to update
-		// the newest schema.
-		if job.State == model.JobRunning || job.State == model.JobDone {
+		// if the job is done, still running or rolling back, we will wait 2 * lease time to guarantee other
+		// servers to update the newest schema.
+		if job.State == model.JobRunning || job.State == model.JobDone || rollback {
 			d.waitSchemaChanged(waitTime)
 		}
 
@@ -327,39 +331,49 @@ func (d *ddl) onDDLWorker() {
 	}
 }
 
-func (d *ddl) runDDLJob(t *meta.Meta, job *model.Job) {
+func (d *ddl) runDDLJob(txn kv.Transaction, t *meta.Meta, job *model.Job) {
 	if job.IsFinished() {
 		return
 	}
 
-	job.State = model.JobRunning
-
 	var err error
-	switch job.Type {
-	case model.ActionCreateSchema:
-		err = d.onCreateSchema(t, job)
-	case model.ActionDropSchema:
-		err = d.onDropSchema(t, job)
-	case model.ActionCreateTable:
-		err = d.onCreateTable(t, job)
-	case model.ActionDropTable:
-		err = d.onDropTable(t, job)
-	case model.ActionAddColumn:
-		err = d.onAddColumn(t, job)
-	case model.ActionDropColumn:
-		err = d.onDropColumn(t, job)
-	case model.ActionAddIndex:
-		err = d.onCreateIndex(t, job)
-	case model.ActionDropIndex:
-		err = d.onDropIndex(t, job)
-	case model.ActionAddForeignKey:
-		err = d.onCreateForeignKey(t, job)
-	case model.ActionDropForeignKey:
-		err = d.onDropForeignKey(t, job)
-	default:
-		// invalid job, cancel it.
-		job.State = model.JobCancelled
-		err = errInvalidDDLJob.Gen("invalid ddl job %v", job)
+	if job.IsCancelling() || job.IsRollingback() {
+		// the job is cancelled by the user, roll back the schema changes instead of running it.
+		err = d.rollbackDDLJob(t, job)
+	} else {
+		job.State = model.JobRunning
+		switch job.Type {
+		case model.ActionCreateSchema:
+			err = d.onCreateSchema(t, job)
+		case model.ActionDropSchema:
+			err = d.onDropSchema(t, job)
+		case model.ActionCreateTable:
+			err = d.onCreateTable(t, job)
+		case model.ActionDropTable:
+			err = d.onDropTable(t, job)
+		case model.ActionRenameTable:
+			err = d.onRenameTable(txn, t, job)
+		case model.ActionTruncateTable:
+			err = d.onTruncateTable(t, job)
+		case model.ActionAddColumn:
+			err = d.onAddColumn(t, job)
+		case model.ActionDropColumn:
+			err = d.onDropColumn(t, job)
+		case model.ActionModifyColumn:
+			err = d.onModifyColumn(t, job)
+		case model.ActionAddIndex:
+			err = d.onCreateIndex(t, job)
+		case model.ActionDropIndex:
+			err = d.onDropIndex(t, job)
+		case model.ActionAddForeignKey:
+			err = d.onCreateForeignKey(t, job)
+		case model.ActionDropForeignKey:
+			err = d.onDropForeignKey(t, job)
+		default:
+			// invalid job, cancel it.
+			job.State = model.JobCancelled
+			err = errInvalidDDLJob.Gen("invalid ddl job %v", job)
+		}
 	}
 
 	// saves error in job, so that others can know error happens.
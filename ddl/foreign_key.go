@@ -14,10 +14,13 @@
 package ddl
 
 import (
+	"fmt"
+
 	"github.com/juju/errors"
 	"github.com/pingcap/tidb/infoschema"
 	"github.com/pingcap/tidb/meta"
 	"github.com/pingcap/tidb/model"
+	"github.com/pingcap/tidb/mysql"
 )
 
 func (d *ddl) onCreateForeignKey(t *meta.Meta, job *model.Job) error {
@@ -118,3 +121,109 @@ func (d *ddl) onDropForeignKey(t *meta.Meta, job *model.Job) error {
 	}
 
 }
+
+// updateFKRefs updates the foreign keys of all the tables by update, which returns whether fk is changed.
+// The foreign keys reference the tables by name, so they are updated when the referenced tables or columns are renamed.
+// db is the database of the table tblInfo before the job. The tables in saved are updated in place and saved
+// by the caller, the other tables are saved here.
+func updateFKRefs(t *meta.Meta, saved map[int64]*model.TableInfo,
+	update func(db *model.DBInfo, tblInfo *model.TableInfo, fk *model.FKInfo) bool) error {
+	dbs, err := t.ListDatabases()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	for _, db := range dbs {
+		tables, err := t.ListTables(db.ID)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		for _, tblInfo := range tables {
+			savedInfo, ok := saved[tblInfo.ID]
+			if ok {
+				tblInfo = savedInfo
+			}
+			changed := false
+			for _, fk := range tblInfo.ForeignKeys {
+				if update(db, tblInfo, fk) {
+					changed = true
+				}
+			}
+			if changed && !ok {
+				if err = t.UpdateTable(db.ID, tblInfo); err != nil {
+					return errors.Trace(err)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// getFKRefSchema returns the database of the table referenced by fk, db is the database of the table of fk.
+func getFKRefSchema(db *model.DBInfo, fk *model.FKInfo) model.CIStr {
+	if fk.RefSchema.L != "" {
+		return fk.RefSchema
+	}
+	return db.Name
+}
+
+// hasIndexOnCols returns whether cols are the handle or the leading columns of an index of tblInfo.
+func hasIndexOnCols(tblInfo *model.TableInfo, cols []model.CIStr) bool {
+	if len(cols) == 1 && tblInfo.PKIsHandle {
+		for _, col := range tblInfo.Columns {
+			if col.Name.L == cols[0].L && mysql.HasPriKeyFlag(col.Flag) {
+				return true
+			}
+		}
+	}
+	for _, idxInfo := range tblInfo.Indices {
+		if idxInfo.State != model.StatePublic || len(idxInfo.Columns) < len(cols) {
+			continue
+		}
+		match := true
+		for i, col := range cols {
+			if idxInfo.Columns[i].Name.L != col.L {
+				match = false
+				break
+			}
+		}
+		if match {
+			return true
+		}
+	}
+	return false
+}
+
+// getFKIndexName returns the name of the index created for fk, it is the name of fk if the name isn't used by other indices.
+func getFKIndexName(tblInfo *model.TableInfo, fk *model.FKInfo) model.CIStr {
+	names := make(map[string]bool, len(tblInfo.Indices))
+	for _, idxInfo := range tblInfo.Indices {
+		names[idxInfo.Name.L] = true
+	}
+	name := fk.Name
+	for i := 2; names[name.L]; i++ {
+		name = model.NewCIStr(fmt.Sprintf("%s_%d", fk.Name.O, i))
+	}
+	return name
+}
+
+// checkFKParentIndex checks that the columns referenced by fk of tblInfo in the database schema have an index,
+// the referenced table which doesn't exist yet is not checked.
+func checkFKParentIndex(is infoschema.InfoSchema, schema model.CIStr, tblInfo *model.TableInfo, fk *model.FKInfo) error {
+	refSchema := fk.RefSchema
+	if refSchema.L == "" {
+		refSchema = schema
+	}
+	parentInfo := tblInfo
+	if refSchema.L != schema.L || fk.RefTable.L != tblInfo.Name.L {
+		parent, err := is.TableByName(refSchema, fk.RefTable)
+		if err != nil {
+			return nil
+		}
+		parentInfo = parent.Meta()
+	}
+	if !hasIndexOnCols(parentInfo, fk.RefCols) {
+		return ErrFKNoIndexParent.Gen("Failed to add the foreign key constraint. Missing index for constraint '%s' in the referenced table '%s'",
+			fk.Name, fk.RefTable)
+	}
+	return nil
+}
@@ -17,9 +17,11 @@ import (
 	"strings"
 	"time"
 
+	"github.com/juju/errors"
 	. "github.com/pingcap/check"
 	"github.com/pingcap/tidb/ast"
 	"github.com/pingcap/tidb/context"
+	"github.com/pingcap/tidb/inspectkv"
 	"github.com/pingcap/tidb/kv"
 	"github.com/pingcap/tidb/model"
 	"github.com/pingcap/tidb/table"
@@ -630,6 +632,93 @@ func (s *testIndexSuite) TestAddIndex(c *C) {
 	s.d.start()
 }
 
+func (s *testIndexSuite) TestCancelAddIndex(c *C) {
+	defer testleak.AfterTest(c)()
+	d := newDDL(s.store, nil, nil, 100*time.Millisecond)
+	tblInfo := testTableInfo(c, d, "t", 3)
+	ctx := testNewContext(c, d)
+
+	_, err := ctx.GetTxn(true)
+	c.Assert(err, IsNil)
+
+	testCreateTable(c, ctx, d, s.dbInfo, tblInfo)
+
+	t := testGetTable(c, d, s.dbInfo.ID, tblInfo.ID)
+
+	row := types.MakeDatums(int64(1), int64(2), int64(3))
+	handle, err := t.AddRecord(ctx, row)
+	c.Assert(err, IsNil)
+
+	err = ctx.CommitTxn()
+	c.Assert(err, IsNil)
+
+	var (
+		cancelled bool
+		cancelErr error
+		lastIndex table.Index
+	)
+	tc := &testDDLCallback{}
+	tc.onJobUpdated = func(job *model.Job) {
+		if job.Type != model.ActionAddIndex {
+			return
+		}
+
+		t := testGetTable(c, d, s.dbInfo.ID, tblInfo.ID)
+		if index := getIndex(t, "c1"); index != nil {
+			lastIndex = index
+		}
+		if cancelled || job.SchemaState != model.StateWriteReorganization {
+			return
+		}
+
+		cancelled = true
+		cancelErr = kv.RunInNewTxn(d.store, false, func(txn kv.Transaction) error {
+			errs, err := inspectkv.CancelJobs(txn, []int64{job.ID})
+			if err != nil {
+				return errors.Trace(err)
+			}
+			return errors.Trace(errs[0])
+		})
+	}
+
+	d.hook = tc
+
+	// Use local ddl for callback test.
+	s.d.close()
+
+	d.close()
+	d.start()
+
+	id, err := d.genGlobalID()
+	c.Assert(err, IsNil)
+	job := &model.Job{
+		SchemaID: s.dbInfo.ID,
+		TableID:  tblInfo.ID,
+		Type:     model.ActionAddIndex,
+		Args:     []interface{}{true, model.NewCIStr("c1"), id, []*ast.IndexColName{{Column: &ast.ColumnName{Name: model.NewCIStr("c1")}, Length: 256}}},
+	}
+	err = d.doDDLJob(ctx, job)
+	c.Assert(err, NotNil)
+	c.Assert(cancelled, IsTrue)
+	c.Assert(cancelErr, IsNil)
+	testCheckJobCancelled(c, d, job)
+
+	c.Assert(lastIndex, NotNil)
+	s.checkNoneIndex(c, ctx, d, tblInfo, handle, lastIndex, row)
+
+	_, err = ctx.GetTxn(true)
+	c.Assert(err, IsNil)
+
+	job = testDropTable(c, ctx, d, s.dbInfo, tblInfo)
+	testCheckJobDone(c, d, job, false)
+
+	err = ctx.CommitTxn()
+	c.Assert(err, IsNil)
+
+	d.close()
+	s.d.start()
+}
+
 func (s *testIndexSuite) TestDropIndex(c *C) {
 	defer testleak.AfterTest(c)()
 	d := newDDL(s.store, nil, nil, 100*time.Millisecond)
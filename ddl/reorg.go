@@ -156,6 +156,15 @@ func (d *ddl) isReorgRunnable(txn kv.Transaction) error {
 		return errors.Trace(errNotOwner)
 	}
 
+	job, err := t.GetDDLJob(0)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if job != nil && job.IsCancelling() {
+		// the job is cancelled by the user, stop the reorganization so that the job can be rolled back.
+		return errors.Trace(errCancelledDDLJob)
+	}
+
 	return nil
 }
 
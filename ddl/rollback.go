@@ -0,0 +1,297 @@
+// Copyright 2016 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ddl
+
+import (
+	"github.com/juju/errors"
+	"github.com/ngaut/log"
+	"github.com/pingcap/tidb/infoschema"
+	"github.com/pingcap/tidb/meta"
+	"github.com/pingcap/tidb/model"
+	"github.com/pingcap/tidb/terror"
+)
+
+// rollbackDDLJob rolls back a job cancelled by the user.
+// A job which hasn't changed the schema is cancelled directly, otherwise the schema changes are
+// reverted step by step like the reversed job, and the job becomes cancelled at last.
+func (d *ddl) rollbackDDLJob(t *meta.Meta, job *model.Job) error {
+	if job.SchemaState == model.StateNone {
+		job.State = model.JobCancelled
+		return errors.Trace(errCancelledDDLJob)
+	}
+
+	switch job.Type {
+	case model.ActionAddColumn:
+		return d.rollbackAddColumn(t, job)
+	case model.ActionModifyColumn:
+		return d.rollbackModifyColumn(t, job)
+	case model.ActionAddIndex:
+		return d.rollbackCreateIndex(t, job)
+	default:
+		// the job can't be rolled back after it changes the schema, go on running it.
+		log.Warnf("[ddl] job %v can't be rolled back, continue running it", job)
+		job.State = model.JobRunning
+		return nil
+	}
+}
+
+// waitReorgStopped waits for the running reorganization to stop, the reorganization stops itself
+// when it finds the job is cancelling. It returns errWaitReorgTimeout if the reorganization is still running.
+func (d *ddl) waitReorgStopped() error {
+	if d.reorgDoneCh == nil {
+		return nil
+	}
+
+	err := d.runReorgJob(nil)
+	if terror.ErrorEqual(err, errWaitReorgTimeout) {
+		return errors.Trace(err)
+	}
+	if err != nil {
+		log.Warnf("[ddl] the reorganization of the cancelled job stops with err %v", err)
+	}
+	return nil
+}
+
+func (d *ddl) rollbackAddColumn(t *meta.Meta, job *model.Job) error {
+	tblInfo, err := d.getTableInfo(t, job)
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	col := &model.ColumnInfo{}
+	if err = job.DecodeArgs(col); err != nil {
+		job.State = model.JobCancelled
+		return errors.Trace(err)
+	}
+
+	columnInfo := findCol(tblInfo.Columns, col.Name.L)
+	if columnInfo == nil || columnInfo.State == model.StatePublic {
+		job.State = model.JobCancelled
+		return infoschema.ErrColumnNotExists.Gen("column %s doesn't exist", col.Name)
+	}
+
+	removed, err := d.removeNonPublicColumn(t, job, tblInfo, columnInfo)
+	if err != nil || !removed {
+		return errors.Trace(err)
+	}
+
+	// finish the rollback
+	job.SchemaState = model.StateNone
+	job.State = model.JobCancelled
+	return errors.Trace(errCancelledDDLJob)
+}
+
+// removeNonPublicColumn removes the column which isn't public step by step, it returns true after the column
+// and its data are removed.
+func (d *ddl) removeNonPublicColumn(t *meta.Meta, job *model.Job, tblInfo *model.TableInfo, columnInfo *model.ColumnInfo) (bool, error) {
+	schemaID := job.SchemaID
+	if columnInfo.State == model.StateWriteReorganization {
+		if err := d.waitReorgStopped(); err != nil {
+			// if timeout, we should return, check for the owner and re-wait the reorganization stopped.
+			return false, nil
+		}
+	}
+
+	_, err := t.GenSchemaVersion()
+	if err != nil {
+		return false, errors.Trace(err)
+	}
+
+	switch columnInfo.State {
+	case model.StateWriteReorganization, model.StateWriteOnly:
+		// reorganization or write only -> delete only
+		job.SchemaState = model.StateDeleteOnly
+		columnInfo.State = model.StateDeleteOnly
+	case model.StateDeleteOnly:
+		// delete only -> reorganization
+		job.SchemaState = model.StateDeleteReorganization
+		columnInfo.State = model.StateDeleteReorganization
+		// initialize SnapshotVer to 0 and remove the handle of the backfill for later reorganization check.
+		job.SnapshotVer = 0
+		if err = t.RemoveDDLReorgHandle(job); err != nil {
+			return false, errors.Trace(err)
+		}
+	case model.StateDeleteReorganization:
+		// reorganization -> absent
+		reorgInfo, err := d.getReorgInfo(t, job)
+		if err != nil || reorgInfo.first {
+			// if we run reorg firstly, we should update the job snapshot version
+			// and then run the reorg next time.
+			return false, errors.Trace(err)
+		}
+
+		tbl, err := d.getTable(schemaID, tblInfo)
+		if err != nil {
+			return false, errors.Trace(err)
+		}
+
+		err = d.runReorgJob(func() error {
+			return d.dropTableColumn(tbl, columnInfo, reorgInfo)
+		})
+
+		if terror.ErrorEqual(err, errWaitReorgTimeout) {
+			// if timeout, we should return, check for the owner and re-wait job done.
+			return false, nil
+		}
+		if err != nil {
+			return false, errors.Trace(err)
+		}
+
+		// the column is not public, so it is the last column and the other offsets are unchanged.
+		newColumns := make([]*model.ColumnInfo, 0, len(tblInfo.Columns))
+		for _, c := range tblInfo.Columns {
+			if c.Name.L != columnInfo.Name.L {
+				newColumns = append(newColumns, c)
+			}
+		}
+		tblInfo.Columns = newColumns
+		err = t.UpdateTable(schemaID, tblInfo)
+		return err == nil, errors.Trace(err)
+	default:
+		return false, ErrInvalidColumnState.Gen("invalid column state %v", columnInfo.State)
+	}
+
+	job.State = model.JobRollback
+	err = t.UpdateTable(schemaID, tblInfo)
+	return false, errors.Trace(err)
+}
+
+func (d *ddl) rollbackModifyColumn(t *meta.Meta, job *model.Job) error {
+	if job.SchemaState == model.StatePublic {
+		// the modified column has been replaced, the rest steps only drop the data of the origin type.
+		log.Warnf("[ddl] job %v can't be rolled back, continue running it", job)
+		job.State = model.JobRunning
+		return nil
+	}
+
+	tblInfo, err := d.getTableInfo(t, job)
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	changingCol := findChangingCol(tblInfo.Columns)
+	if changingCol == nil {
+		job.State = model.JobCancelled
+		return infoschema.ErrColumnNotExists.Gen("the changing column of table %s doesn't exist", tblInfo.Name)
+	}
+
+	// The job is rolled back by the user, or by the DDL worker if the data can't be converted.
+	cancelled := job.IsCancelling()
+	removed, err := d.removeNonPublicColumn(t, job, tblInfo, changingCol)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if removed {
+		// finish the rollback, the error of the job is kept since it tells why the job is rolled back.
+		job.SchemaState = model.StateNone
+		job.State = model.JobCancelled
+		return nil
+	}
+	if cancelled && job.IsRollingback() {
+		return errors.Trace(errCancelledDDLJob)
+	}
+	return nil
+}
+
+func (d *ddl) rollbackCreateIndex(t *meta.Meta, job *model.Job) error {
+	schemaID := job.SchemaID
+	tblInfo, err := d.getTableInfo(t, job)
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	var (
+		unique    bool
+		indexName model.CIStr
+	)
+	if err = job.DecodeArgs(&unique, &indexName); err != nil {
+		job.State = model.JobCancelled
+		return errors.Trace(err)
+	}
+
+	var indexInfo *model.IndexInfo
+	for _, idx := range tblInfo.Indices {
+		if idx.Name.L == indexName.L {
+			indexInfo = idx
+		}
+	}
+	if indexInfo == nil || indexInfo.State == model.StatePublic {
+		job.State = model.JobCancelled
+		return ErrCantDropFieldOrKey.Gen("index %s doesn't exist", indexName)
+	}
+
+	if indexInfo.State == model.StateWriteReorganization {
+		if err = d.waitReorgStopped(); err != nil {
+			// if timeout, we should return, check for the owner and re-wait the reorganization stopped.
+			return nil
+		}
+	}
+
+	_, err = t.GenSchemaVersion()
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	switch indexInfo.State {
+	case model.StateWriteReorganization, model.StateWriteOnly:
+		// reorganization or write only -> delete only
+		job.SchemaState = model.StateDeleteOnly
+		indexInfo.State = model.StateDeleteOnly
+	case model.StateDeleteOnly:
+		// delete only -> reorganization
+		job.SchemaState = model.StateDeleteReorganization
+		indexInfo.State = model.StateDeleteReorganization
+	case model.StateDeleteReorganization:
+		// reorganization -> absent
+		tbl, err := d.getTable(schemaID, tblInfo)
+		if err != nil {
+			return errors.Trace(err)
+		}
+
+		err = d.runReorgJob(func() error {
+			return d.dropTableIndex(tbl, indexInfo)
+		})
+
+		if terror.ErrorEqual(err, errWaitReorgTimeout) {
+			// if timeout, we should return, check for the owner and re-wait job done.
+			return nil
+		}
+		if err != nil {
+			return errors.Trace(err)
+		}
+
+		// the index is not public, so the column index flags are not set.
+		newIndices := make([]*model.IndexInfo, 0, len(tblInfo.Indices))
+		for _, idx := range tblInfo.Indices {
+			if idx.Name.L != indexName.L {
+				newIndices = append(newIndices, idx)
+			}
+		}
+		tblInfo.Indices = newIndices
+		if err = t.UpdateTable(schemaID, tblInfo); err != nil {
+			return errors.Trace(err)
+		}
+
+		// finish the rollback
+		job.SchemaState = model.StateNone
+		job.State = model.JobCancelled
+		return errors.Trace(errCancelledDDLJob)
+	default:
+		return ErrInvalidIndexState.Gen("invalid index state %v", indexInfo.State)
+	}
+
+	job.State = model.JobRollback
+	err = t.UpdateTable(schemaID, tblInfo)
+	return errors.Trace(err)
+}
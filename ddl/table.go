@@ -14,14 +14,20 @@
 package ddl
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/juju/errors"
 	"github.com/pingcap/tidb/infoschema"
+	"github.com/pingcap/tidb/kv"
 	"github.com/pingcap/tidb/meta"
 	"github.com/pingcap/tidb/meta/autoid"
 	"github.com/pingcap/tidb/model"
+	"github.com/pingcap/tidb/mysql"
 	"github.com/pingcap/tidb/table"
 	"github.com/pingcap/tidb/tablecodec"
 	"github.com/pingcap/tidb/terror"
+	"github.com/pingcap/tidb/util/types"
 )
 
 func (d *ddl) onCreateTable(t *meta.Meta, job *model.Job) error {
@@ -153,6 +159,360 @@ func (d *ddl) onDropTable(t *meta.Meta, job *model.Job) error {
 	return errors.Trace(err)
 }
 
+func (d *ddl) onTruncateTable(t *meta.Meta, job *model.Job) error {
+	if job.SchemaState == model.StatePublic {
+		// The servers which haven't loaded the new table may write the old table ID until 2 * lease
+		// after the table is replaced, the job is finished after that, so that the background job
+		// deletes the data of the old table ID after all the writes.
+		job.State = model.JobDone
+		return nil
+	}
+
+	schemaID := job.SchemaID
+	tableID := job.TableID
+	var newTableID int64
+	if err := job.DecodeArgs(&newTableID); err != nil {
+		// arg error, cancel this job.
+		job.State = model.JobCancelled
+		return errors.Trace(err)
+	}
+
+	tblInfo, err := d.getTableInfo(t, job)
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	_, err = t.GenSchemaVersion()
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	// none -> public, the old table is replaced by an empty table with the new table ID,
+	// the auto ID starts from the AUTO_INCREMENT table option again.
+	if err = t.DropTable(schemaID, tableID); err != nil {
+		return errors.Trace(err)
+	}
+	tblInfo.ID = newTableID
+	if err = t.CreateTable(schemaID, tblInfo); err != nil {
+		return errors.Trace(err)
+	}
+	if tblInfo.AutoIncID > 1 {
+		if _, err = t.GenAutoTableID(schemaID, newTableID, tblInfo.AutoIncID-1); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	job.SchemaState = model.StatePublic
+	return nil
+}
+
+func (d *ddl) delReorgTruncatedTable(job *model.Job) error {
+	err := d.delKeysWithPrefix(tablecodec.EncodeTablePrefix(job.TableID))
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	// finish this background job
+	job.SchemaState = model.StateNone
+	job.State = model.JobDone
+
+	return nil
+}
+
+// renameTableArg is the argument of a rename table job for one table.
+type renameTableArg struct {
+	OldSchemaID int64       `json:"old_schema_id"`
+	TableID     int64       `json:"table_id"`
+	NewSchemaID int64       `json:"new_schema_id"`
+	NewName     model.CIStr `json:"new_name"`
+}
+
+func (d *ddl) onRenameTable(txn kv.Transaction, t *meta.Meta, job *model.Job) error {
+	var args []*renameTableArg
+	if err := job.DecodeArgs(&args); err != nil {
+		// arg error, cancel this job.
+		job.State = model.JobCancelled
+		return errors.Trace(err)
+	}
+
+	// All the renames are checked before any table is updated,
+	// so that the job either renames all the tables or none of them.
+	tblInfos := make(map[int64]*model.TableInfo)
+	// originSchemaIDs and schemaIDs record the databases of the tables before and after the renames.
+	originSchemaIDs := make(map[int64]int64)
+	schemaIDs := make(map[int64]int64)
+	// tableNames maps a database ID to the table names in it after the previous renames.
+	tableNames := make(map[int64]map[string]int64)
+	dbNames := make(map[int64]model.CIStr)
+	// originNames records the names of the renamed tables before the renames.
+	originNames := make(map[int64]model.CIStr)
+	var renamedIDs []int64
+	// privRenames are the renames of the privileges, which use the names of the tables before every rename.
+	privRenames := make([]privilegeRename, 0, len(args))
+	loadTables := func(schemaID int64) error {
+		if _, ok := tableNames[schemaID]; ok {
+			return nil
+		}
+		tables, err := t.ListTables(schemaID)
+		if terror.ErrorEqual(err, meta.ErrDBNotExists) {
+			job.State = model.JobCancelled
+			return errors.Trace(infoschema.ErrDatabaseNotExists)
+		} else if err != nil {
+			return errors.Trace(err)
+		}
+		dbInfo, err := t.GetDatabase(schemaID)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		dbNames[schemaID] = dbInfo.Name
+		names := make(map[string]int64, len(tables))
+		for _, tbl := range tables {
+			names[tbl.Name.L] = tbl.ID
+			tblInfos[tbl.ID] = tbl
+			originSchemaIDs[tbl.ID] = schemaID
+			schemaIDs[tbl.ID] = schemaID
+		}
+		tableNames[schemaID] = names
+		return nil
+	}
+
+	for _, arg := range args {
+		if err := loadTables(arg.OldSchemaID); err != nil {
+			return errors.Trace(err)
+		}
+		if err := loadTables(arg.NewSchemaID); err != nil {
+			return errors.Trace(err)
+		}
+
+		tblInfo, ok := tblInfos[arg.TableID]
+		if !ok || schemaIDs[arg.TableID] != arg.OldSchemaID {
+			job.State = model.JobCancelled
+			return errors.Trace(infoschema.ErrTableNotExists)
+		}
+		if tblInfo.State != model.StatePublic {
+			job.State = model.JobCancelled
+			return ErrInvalidTableState.Gen("table %s is not in public, but %s", tblInfo.Name.L, tblInfo.State)
+		}
+		if _, ok = tableNames[arg.NewSchemaID][arg.NewName.L]; ok {
+			job.State = model.JobCancelled
+			return errors.Trace(infoschema.ErrTableExists)
+		}
+
+		delete(tableNames[arg.OldSchemaID], tblInfo.Name.L)
+		tableNames[arg.NewSchemaID][arg.NewName.L] = tblInfo.ID
+		schemaIDs[tblInfo.ID] = arg.NewSchemaID
+		if _, ok = originNames[tblInfo.ID]; !ok {
+			originNames[tblInfo.ID] = tblInfo.Name
+		}
+		privRenames = append(privRenames, privilegeRename{
+			oldDB:    dbNames[arg.OldSchemaID].O,
+			oldTable: tblInfo.Name.O,
+			newDB:    dbNames[arg.NewSchemaID].O,
+			newTable: arg.NewName.O,
+		})
+		tblInfo.Name = arg.NewName
+		renamedIDs = append(renamedIDs, tblInfo.ID)
+	}
+
+	// The privileges are moved in the transaction which renames the tables, they are moved before
+	// the tables are updated, so that the job is cancelled without any change if they can't be moved.
+	if err := d.moveTablesPrivileges(txn, t, privRenames); err != nil {
+		job.State = model.JobCancelled
+		return errors.Trace(err)
+	}
+
+	_, err := t.GenSchemaVersion()
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	// The foreign keys must be updated before the renamed tables are moved, so that every table is found
+	// in its database before the renames.
+	renamed := make(map[int64]*model.TableInfo, len(originNames))
+	// renamedRefs maps the names of the renamed tables before the renames to the table IDs.
+	renamedRefs := make(map[string]int64, len(originNames))
+	for tableID, name := range originNames {
+		renamed[tableID] = tblInfos[tableID]
+		renamedRefs[dbNames[originSchemaIDs[tableID]].L+"."+name.L] = tableID
+	}
+	err = updateFKRefs(t, renamed, func(db *model.DBInfo, tblInfo *model.TableInfo, fk *model.FKInfo) bool {
+		if tableID, ok := renamedRefs[getFKRefSchema(db, fk).L+"."+fk.RefTable.L]; ok {
+			fk.RefSchema = dbNames[schemaIDs[tableID]]
+			fk.RefTable = tblInfos[tableID].Name
+			return true
+		}
+		if _, ok := renamed[tblInfo.ID]; ok && fk.RefSchema.L == "" && schemaIDs[tblInfo.ID] != db.ID {
+			// The table is moved to another database, the referenced table is still in the origin database.
+			fk.RefSchema = db.Name
+			return true
+		}
+		return false
+	})
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	updated := make(map[int64]bool, len(renamedIDs))
+	for _, tableID := range renamedIDs {
+		if updated[tableID] {
+			continue
+		}
+		updated[tableID] = true
+		err = renameTableInfo(t, originSchemaIDs[tableID], schemaIDs[tableID], tblInfos[tableID])
+		if err != nil {
+			return errors.Trace(err)
+		}
+	}
+
+	// finish this job
+	job.SchemaState = model.StatePublic
+	job.State = model.JobDone
+	return nil
+}
+
+// privilegeRename is a rename of the table privileges, the names are the names saved in the privilege tables.
+type privilegeRename struct {
+	oldDB    string
+	oldTable string
+	newDB    string
+	newTable string
+}
+
+// moveTablesPrivileges moves the table and column scope privileges of the renamed tables in the order of the renames.
+// The privileges are saved to txn only if all of them are moved.
+func (d *ddl) moveTablesPrivileges(txn kv.Transaction, t *meta.Meta, renames []privilegeRename) error {
+	dbs, err := t.ListDatabases()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	var sysDB *model.DBInfo
+	for _, db := range dbs {
+		if db.Name.L == strings.ToLower(mysql.SystemDB) {
+			sysDB = db
+			break
+		}
+	}
+	if sysDB == nil {
+		// The system database doesn't exist if the store isn't bootstrapped.
+		return nil
+	}
+	tblInfos, err := t.ListTables(sysDB.ID)
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	bufTxn := &bufferTxn{Transaction: txn, bs: kv.NewBufferStore(txn)}
+	defer bufTxn.bs.Release()
+	ctx := &reorgContext{
+		store: d.store,
+		m:     make(map[fmt.Stringer]interface{}),
+		txn:   bufTxn,
+	}
+	for _, tblInfo := range tblInfos {
+		if tblInfo.Name.L != strings.ToLower(mysql.TablePrivTable) && tblInfo.Name.L != strings.ToLower(mysql.ColumnPrivTable) {
+			continue
+		}
+		tbl, err := d.getTable(sysDB.ID, tblInfo)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		for _, rename := range renames {
+			if err = moveTablePrivileges(ctx, tbl, rename); err != nil {
+				return errors.Trace(err)
+			}
+		}
+	}
+	return errors.Trace(bufTxn.bs.SaveTo(txn))
+}
+
+// moveTablePrivileges updates the rows of the old table to the new table in the privilege table tbl.
+func moveTablePrivileges(ctx *reorgContext, tbl table.Table, rename privilegeRename) error {
+	dbCol := table.FindCol(tbl.Cols(), "DB")
+	tableCol := table.FindCol(tbl.Cols(), "Table_name")
+	if dbCol == nil || tableCol == nil {
+		return infoschema.ErrColumnNotExists.Gen("privilege table %s has no DB or Table_name column", tbl.Meta().Name)
+	}
+
+	var (
+		handles []int64
+		rows    [][]types.Datum
+	)
+	err := tbl.IterRecords(ctx, tbl.FirstKey(), tbl.Cols(), func(h int64, data []types.Datum, cols []*table.Column) (bool, error) {
+		if data[dbCol.Offset].GetString() == rename.oldDB && data[tableCol.Offset].GetString() == rename.oldTable {
+			handles = append(handles, h)
+			rows = append(rows, data)
+		}
+		return true, nil
+	})
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	touched := map[int]bool{dbCol.Offset: true, tableCol.Offset: true}
+	for i, h := range handles {
+		newData := append([]types.Datum(nil), rows[i]...)
+		newData[dbCol.Offset].SetString(rename.newDB)
+		newData[tableCol.Offset].SetString(rename.newTable)
+		if err = tbl.UpdateRecord(ctx, h, rows[i], newData, touched); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	return nil
+}
+
+// bufferTxn is a transaction whose reads and writes go through a BufferStore,
+// the writes are saved to the transaction by the BufferStore.
+type bufferTxn struct {
+	kv.Transaction
+	bs *kv.BufferStore
+}
+
+// Get implements the kv.Retriever interface.
+func (txn *bufferTxn) Get(k kv.Key) ([]byte, error) {
+	return txn.bs.Get(k)
+}
+
+// Seek implements the kv.Retriever interface.
+func (txn *bufferTxn) Seek(k kv.Key) (kv.Iterator, error) {
+	return txn.bs.Seek(k)
+}
+
+// SeekReverse implements the kv.Retriever interface.
+func (txn *bufferTxn) SeekReverse(k kv.Key) (kv.Iterator, error) {
+	return txn.bs.SeekReverse(k)
+}
+
+// Set implements the kv.Mutator interface.
+func (txn *bufferTxn) Set(k kv.Key, v []byte) error {
+	return txn.bs.Set(k, v)
+}
+
+// Delete implements the kv.Mutator interface.
+func (txn *bufferTxn) Delete(k kv.Key) error {
+	return txn.bs.Delete(k)
+}
+
+// renameTableInfo saves the renamed table info, the table is moved with its auto ID
+// if the database of the table is changed.
+func renameTableInfo(t *meta.Meta, oldSchemaID, newSchemaID int64, tblInfo *model.TableInfo) error {
+	if oldSchemaID == newSchemaID {
+		err := t.UpdateTable(newSchemaID, tblInfo)
+		return errors.Trace(err)
+	}
+
+	baseID, err := t.GetAutoTableID(oldSchemaID, tblInfo.ID)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if err = t.DropTable(oldSchemaID, tblInfo.ID); err != nil {
+		return errors.Trace(err)
+	}
+	if err = t.CreateTable(newSchemaID, tblInfo); err != nil {
+		return errors.Trace(err)
+	}
+	_, err = t.GenAutoTableID(newSchemaID, tblInfo.ID, baseID)
+	return errors.Trace(err)
+}
+
 func (d *ddl) getTable(schemaID int64, tblInfo *model.TableInfo) (table.Table, error) {
 	alloc := autoid.NewAllocator(d.store, schemaID)
 	tbl, err := table.TableFromMeta(alloc, tblInfo)
@@ -17,6 +17,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/juju/errors"
 	. "github.com/pingcap/check"
 	"github.com/pingcap/tidb/context"
 	"github.com/pingcap/tidb/kv"
@@ -26,6 +27,7 @@ import (
 	"github.com/pingcap/tidb/mysql"
 	"github.com/pingcap/tidb/sessionctx/variable"
 	"github.com/pingcap/tidb/table"
+	"github.com/pingcap/tidb/tablecodec"
 	"github.com/pingcap/tidb/util/mock"
 	"github.com/pingcap/tidb/util/testleak"
 	"github.com/pingcap/tidb/util/types"
@@ -186,6 +188,177 @@ func (s *testTableSuite) TestTable(c *C) {
 	testCheckJobDone(c, d, job, false)
 }
 
+func (s *testTableSuite) TestTruncateTable(c *C) {
+	defer testleak.AfterTest(c)()
+	d := newDDL(s.store, nil, nil, 100*time.Millisecond)
+
+	ctx := testNewContext(c, d)
+	defer ctx.RollbackTxn()
+
+	tblInfo := testTableInfo(c, d, "truncate_t", 2)
+	tblInfo.AutoIncID = 5
+	testCreateTable(c, ctx, d, s.dbInfo, tblInfo)
+	tbl := testGetTable(c, d, s.dbInfo.ID, tblInfo.ID)
+	_, err := tbl.AddRecord(ctx, types.MakeDatums(1, 1))
+	c.Assert(err, IsNil)
+	c.Assert(ctx.CommitTxn(), IsNil)
+
+	oldTblInfo := *tblInfo
+
+	// The table is replaced before the job is done, a server on the old schema may still write the old
+	// table ID then, and the data written is deleted by the background job queued after the job is done.
+	var (
+		checkOK  bool
+		checkErr error
+	)
+	tc := &testDDLCallback{}
+	tc.onJobUpdated = func(job *model.Job) {
+		if checkOK || job.Type != model.ActionTruncateTable || job.State != model.JobRunning {
+			return
+		}
+		checkOK = true
+		checkErr = kv.RunInNewTxn(d.store, false, func(txn kv.Transaction) error {
+			bgJob, err1 := meta.NewMeta(txn).GetBgJob(0)
+			if err1 != nil {
+				return errors.Trace(err1)
+			}
+			if bgJob != nil {
+				return errors.Errorf("background job %v is queued before the truncate job is done", bgJob)
+			}
+			writeCtx := testNewContext(c, d)
+			_, err1 = tbl.AddRecord(writeCtx, types.MakeDatums(2, 2))
+			if err1 != nil {
+				return errors.Trace(err1)
+			}
+			return errors.Trace(writeCtx.CommitTxn())
+		})
+	}
+	d.hook = tc
+
+	// Use local ddl for callback test.
+	s.d.close()
+	defer s.d.start()
+
+	d.close()
+	d.start()
+	defer d.close()
+	newTableID, err := d.genGlobalID()
+	c.Assert(err, IsNil)
+	job := &model.Job{
+		SchemaID: s.dbInfo.ID,
+		TableID:  tblInfo.ID,
+		Type:     model.ActionTruncateTable,
+		Args:     []interface{}{newTableID},
+	}
+	err = d.doDDLJob(ctx, job)
+	c.Assert(err, IsNil)
+	testCheckJobDone(c, d, job, true)
+	c.Assert(checkOK, IsTrue)
+	c.Assert(checkErr, IsNil)
+
+	tblInfo.ID = newTableID
+	testCheckTableState(c, d, s.dbInfo, tblInfo, model.StatePublic)
+	kv.RunInNewTxn(d.store, false, func(txn kv.Transaction) error {
+		t := meta.NewMeta(txn)
+		info, err1 := t.GetTable(s.dbInfo.ID, oldTblInfo.ID)
+		c.Assert(err1, IsNil)
+		c.Assert(info, IsNil)
+		autoID, err1 := t.GetAutoTableID(s.dbInfo.ID, newTableID)
+		c.Assert(err1, IsNil)
+		c.Assert(autoID, Equals, int64(4))
+		return nil
+	})
+
+	// The data of the old table ID is deleted by the background job.
+	for {
+		isDropped := true
+		kv.RunInNewTxn(d.store, false, func(txn kv.Transaction) error {
+			isDropped = checkDrop(c, meta.NewMeta(txn))
+			return nil
+		})
+		if isDropped {
+			break
+		}
+	}
+	kv.RunInNewTxn(d.store, false, func(txn kv.Transaction) error {
+		prefix := tablecodec.EncodeTablePrefix(oldTblInfo.ID)
+		it, err1 := txn.Seek(prefix)
+		c.Assert(err1, IsNil)
+		defer it.Close()
+		c.Assert(it.Valid() && it.Key().HasPrefix(prefix), IsFalse)
+		return nil
+	})
+
+	testDropTable(c, ctx, d, s.dbInfo, tblInfo)
+}
+
+func testRenameTable(c *C, ctx context.Context, d *ddl, args []*renameTableArg) (*model.Job, error) {
+	job := &model.Job{
+		SchemaID: args[0].OldSchemaID,
+		TableID:  args[0].TableID,
+		Type:     model.ActionRenameTable,
+		Args:     []interface{}{args},
+	}
+
+	err := d.doDDLJob(ctx, job)
+	return job, err
+}
+
+func (s *testTableSuite) TestRenameTable(c *C) {
+	defer testleak.AfterTest(c)()
+	d := s.d
+
+	ctx := testNewContext(c, d)
+	defer ctx.RollbackTxn()
+
+	newDBInfo := testSchemaInfo(c, d, "test_rename")
+	testCreateSchema(c, ctx, d, newDBInfo)
+	tblInfo1 := testTableInfo(c, d, "rename_t1", 1)
+	testCreateTable(c, ctx, d, s.dbInfo, tblInfo1)
+	tblInfo2 := testTableInfo(c, d, "rename_t2", 1)
+	testCreateTable(c, ctx, d, s.dbInfo, tblInfo2)
+	err := kv.RunInNewTxn(d.store, false, func(txn kv.Transaction) error {
+		_, err1 := meta.NewMeta(txn).GenAutoTableID(s.dbInfo.ID, tblInfo1.ID, 10)
+		return err1
+	})
+	c.Assert(err, IsNil)
+
+	// The second rename fails, so the first one is not applied either.
+	job, err := testRenameTable(c, ctx, d, []*renameTableArg{
+		{OldSchemaID: s.dbInfo.ID, TableID: tblInfo1.ID, NewSchemaID: s.dbInfo.ID, NewName: model.NewCIStr("rename_t3")},
+		{OldSchemaID: s.dbInfo.ID, TableID: tblInfo2.ID, NewSchemaID: s.dbInfo.ID, NewName: model.NewCIStr("rename_t3")},
+	})
+	c.Assert(err, NotNil)
+	testCheckJobCancelled(c, d, job)
+	testCheckTableState(c, d, s.dbInfo, tblInfo1, model.StatePublic)
+
+	// Move rename_t1 to the new database and take the name of it for rename_t2.
+	job, err = testRenameTable(c, ctx, d, []*renameTableArg{
+		{OldSchemaID: s.dbInfo.ID, TableID: tblInfo1.ID, NewSchemaID: newDBInfo.ID, NewName: model.NewCIStr("rename_t2")},
+		{OldSchemaID: s.dbInfo.ID, TableID: tblInfo2.ID, NewSchemaID: s.dbInfo.ID, NewName: model.NewCIStr("rename_t1")},
+	})
+	c.Assert(err, IsNil)
+	testCheckJobDone(c, d, job, true)
+	tblInfo1.Name = model.NewCIStr("rename_t2")
+	testCheckTableState(c, d, newDBInfo, tblInfo1, model.StatePublic)
+	tblInfo2.Name = model.NewCIStr("rename_t1")
+	testCheckTableState(c, d, s.dbInfo, tblInfo2, model.StatePublic)
+	kv.RunInNewTxn(d.store, false, func(txn kv.Transaction) error {
+		t := meta.NewMeta(txn)
+		info, err1 := t.GetTable(s.dbInfo.ID, tblInfo1.ID)
+		c.Assert(err1, IsNil)
+		c.Assert(info, IsNil)
+		autoID, err1 := t.GetAutoTableID(newDBInfo.ID, tblInfo1.ID)
+		c.Assert(err1, IsNil)
+		c.Assert(autoID, Equals, int64(10))
+		return nil
+	})
+
+	testDropTable(c, ctx, d, newDBInfo, tblInfo1)
+	testDropTable(c, ctx, d, s.dbInfo, tblInfo2)
+	testDropSchema(c, ctx, d, newDBInfo)
+}
+
 func (s *testTableSuite) TestTableResume(c *C) {
 	defer testleak.AfterTest(c)()
 	d := s.d
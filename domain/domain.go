@@ -26,6 +26,7 @@ import (
 	"github.com/pingcap/tidb/meta"
 	"github.com/pingcap/tidb/model"
 	"github.com/pingcap/tidb/perfschema"
+	"github.com/pingcap/tidb/plan/statistics"
 	"github.com/pingcap/tidb/sessionctx/variable"
 	"github.com/pingcap/tidb/store/localstore"
 	"github.com/pingcap/tidb/terror"
@@ -38,6 +39,7 @@ var ddlLastReloadSchemaTS = "ddl_last_reload_schema_ts"
 type Domain struct {
 	store       kv.Storage
 	infoHandle  *infoschema.Handle
+	statsHandle *statistics.Handle
 	ddl         ddl.DDL
 	leaseCh     chan time.Duration
 	lastLeaseTS int64 // nano seconds
@@ -46,22 +48,39 @@ type Domain struct {
 
 func (do *Domain) loadInfoSchema(txn kv.Transaction) (err error) {
 	m := meta.NewMeta(txn)
-	schemaMetaVersion, err := m.GetSchemaVersion()
+	schemaChanged, err := do.loadSchemas(m)
 	if err != nil {
 		return errors.Trace(err)
 	}
+	statsChanged, err := do.loadTableStats(m)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if !schemaChanged && !statsChanged {
+		return nil
+	}
+	err = do.infoHandle.RefillStatsTables(m)
+	return errors.Trace(err)
+}
+
+// loadSchemas loads the schemas if the schema version is changed, it returns whether the schemas are loaded.
+func (do *Domain) loadSchemas(m *meta.Meta) (bool, error) {
+	schemaMetaVersion, err := m.GetSchemaVersion()
+	if err != nil {
+		return false, errors.Trace(err)
+	}
 
 	info := do.infoHandle.Get()
 	if info != nil && schemaMetaVersion <= info.SchemaMetaVersion() {
 		// info may be changed by other txn, so here its version may be bigger than schema version,
 		// so we don't need to reload.
 		log.Debugf("[ddl] schema version is still %d, no need reload", schemaMetaVersion)
-		return nil
+		return false, nil
 	}
 
 	schemas, err := m.ListDatabases()
 	if err != nil {
-		return errors.Trace(err)
+		return false, errors.Trace(err)
 	}
 
 	for _, di := range schemas {
@@ -72,7 +91,7 @@ func (do *Domain) loadInfoSchema(txn kv.Transaction) (err error) {
 
 		tables, err1 := m.ListTables(di.ID)
 		if err1 != nil {
-			return errors.Trace(err1)
+			return false, errors.Trace(err1)
 		}
 
 		di.Tables = make([]*model.TableInfo, 0, len(tables))
@@ -87,7 +106,54 @@ func (do *Domain) loadInfoSchema(txn kv.Transaction) (err error) {
 
 	log.Infof("[ddl] loadInfoSchema %d", schemaMetaVersion)
 	err = do.infoHandle.Set(schemas, schemaMetaVersion)
-	return errors.Trace(err)
+	return true, errors.Trace(err)
+}
+
+// loadTableStats loads the statistics saved since the last load if the statistics version is changed,
+// so the statistics analyzed on any server are used without waiting for a schema change.
+// It returns whether any statistics are loaded, outdated statistics are ignored.
+func (do *Domain) loadTableStats(m *meta.Meta) (bool, error) {
+	statsVersion, err := m.GetStatsVersion()
+	if err != nil {
+		return false, errors.Trace(err)
+	}
+	lastVersion := do.statsHandle.Version()
+	if statsVersion <= lastVersion {
+		return false, nil
+	}
+	versions, err := m.GetTableStatsVersions()
+	if err != nil {
+		return false, errors.Trace(err)
+	}
+
+	is := do.infoHandle.Get()
+	var tables []*statistics.Table
+	for id, version := range versions {
+		if version <= lastVersion {
+			continue
+		}
+		tbl, ok := is.TableByID(id)
+		if !ok {
+			continue
+		}
+		tpb, err := m.GetTableStats(id)
+		if err != nil {
+			return false, errors.Trace(err)
+		}
+		if tpb == nil {
+			continue
+		}
+		t, err := statistics.TableFromPB(tbl.Meta(), tpb)
+		if err != nil {
+			// The table has been altered after it was analyzed.
+			log.Warnf("[stats] ignore statistics of table %s: %v", tbl.Meta().Name, err)
+			continue
+		}
+		tables = append(tables, t)
+	}
+	log.Infof("[stats] load statistics of %d tables at version %d", len(tables), statsVersion)
+	do.statsHandle.Update(tables, statsVersion)
+	return true, nil
 }
 
 // InfoSchema gets information schema from domain.
@@ -97,6 +163,19 @@ func (do *Domain) InfoSchema() infoschema.InfoSchema {
 	return do.infoHandle.Get()
 }
 
+// StatsHandle gets the statistics handle from domain.
+func (do *Domain) StatsHandle() *statistics.Handle {
+	return do.statsHandle
+}
+
+// UpdateTableStats caches the new statistics of a table and refreshes the statistics tables in information schema
+// with the statistics read by m, which is in the transaction saving the new statistics.
+func (do *Domain) UpdateTableStats(m *meta.Meta, t *statistics.Table) error {
+	do.statsHandle.SetTableStats(t)
+	err := do.infoHandle.RefillStatsTables(m)
+	return errors.Trace(err)
+}
+
 // PerfSchema gets performance schema from domain.
 func (do *Domain) PerfSchema() perfschema.PerfSchema {
 	return do.infoHandle.GetPerfHandle()
@@ -261,7 +340,7 @@ func (c *ddlCallback) OnChanged(err error) error {
 
 // NewDomain creates a new domain.
 func NewDomain(store kv.Storage, lease time.Duration) (d *Domain, err error) {
-	d = &Domain{store: store}
+	d = &Domain{store: store, statsHandle: statistics.NewHandle()}
 
 	d.infoHandle, err = infoschema.NewHandle(d.store)
 	if err != nil {
@@ -14,17 +14,23 @@
 package domain
 
 import (
+	"fmt"
 	"sync/atomic"
 	"testing"
 	"time"
 
 	. "github.com/pingcap/check"
 	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/kv"
+	"github.com/pingcap/tidb/meta"
 	"github.com/pingcap/tidb/model"
+	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/plan/statistics"
 	"github.com/pingcap/tidb/sessionctx/variable"
 	"github.com/pingcap/tidb/store/localstore"
 	"github.com/pingcap/tidb/store/localstore/goleveldb"
 	"github.com/pingcap/tidb/util/mock"
+	"github.com/pingcap/tidb/util/types"
 )
 
 func TestT(t *testing.T) {
@@ -76,3 +82,61 @@ func (*testSuite) TestT(c *C) {
 	store.Close()
 	time.Sleep(1 * time.Second)
 }
+
+func (*testSuite) TestLoadTableStats(c *C) {
+	driver := localstore.Driver{Driver: goleveldb.MemoryDriver{}}
+	store, err := driver.Open("memory")
+	c.Assert(err, IsNil)
+	defer store.Close()
+	dom, err := NewDomain(store, 0)
+	c.Assert(err, IsNil)
+
+	dbInfo := &model.DBInfo{ID: 1000, Name: model.NewCIStr("stats_db"), State: model.StatePublic}
+	tblInfos := make([]*model.TableInfo, 2)
+	for i := range tblInfos {
+		tblInfos[i] = &model.TableInfo{
+			ID:      int64(1001 + i),
+			Name:    model.NewCIStr(fmt.Sprintf("t%d", i)),
+			Columns: []*model.ColumnInfo{{ID: 1, Name: model.NewCIStr("a"), FieldType: *types.NewFieldType(mysql.TypeLonglong), State: model.StatePublic}},
+			State:   model.StatePublic,
+		}
+	}
+	// saveStats saves the statistics like ANALYZE TABLE on another server, which doesn't change the schema.
+	saveStats := func(tblInfo *model.TableInfo, count int64) {
+		err := kv.RunInNewTxn(store, false, func(txn kv.Transaction) error {
+			t, err := statistics.NewTable(tblInfo, 1, count, 4, [][]types.Datum{types.MakeDatums(1, 2)}, nil)
+			c.Assert(err, IsNil)
+			tpb, err := t.ToPB()
+			c.Assert(err, IsNil)
+			return meta.NewMeta(txn).SetTableStats(tblInfo.ID, tpb)
+		})
+		c.Assert(err, IsNil)
+	}
+	err = kv.RunInNewTxn(store, false, func(txn kv.Transaction) error {
+		m := meta.NewMeta(txn)
+		if err := m.CreateDatabase(dbInfo); err != nil {
+			return err
+		}
+		for _, tblInfo := range tblInfos {
+			if err := m.CreateTable(dbInfo.ID, tblInfo); err != nil {
+				return err
+			}
+		}
+		_, err := m.GenSchemaVersion()
+		return err
+	})
+	c.Assert(err, IsNil)
+	saveStats(tblInfos[0], 10)
+	saveStats(tblInfos[1], 20)
+	c.Assert(dom.reload(), IsNil)
+	h := dom.StatsHandle()
+	c.Assert(h.GetTableStats(tblInfos[0]).Count, Equals, int64(10))
+	stats1 := h.GetTableStats(tblInfos[1])
+	c.Assert(stats1.Count, Equals, int64(20))
+
+	// Only the statistics changed since the last load are loaded again.
+	saveStats(tblInfos[0], 30)
+	c.Assert(dom.reload(), IsNil)
+	c.Assert(h.GetTableStats(tblInfos[0]).Count, Equals, int64(30))
+	c.Assert(h.GetTableStats(tblInfos[1]), Equals, stats1)
+}
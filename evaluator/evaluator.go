@@ -717,6 +717,9 @@ func (e *Evaluator) evalAggAvg(v *ast.AggregateFuncExpr) {
 		t := x.Div(mysql.NewDecimalFromUint(uint64(ctx.Count), 0))
 		ctx.Value = t
 		v.SetMysqlDecimal(t)
+	case nil:
+		// All the values of the group are NULL.
+		v.SetNull()
 	}
 }
 
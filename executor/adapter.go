@@ -27,6 +27,9 @@ type recordSet struct {
 	fields   []*ast.ResultField
 	executor Executor
 	schema   expression.Schema
+	// chunk is the chunk being returned if the executor returns rows in chunks.
+	chunk  *Chunk
+	cursor int
 }
 
 func (a *recordSet) Fields() ([]*ast.ResultField, error) {
@@ -34,6 +37,18 @@ func (a *recordSet) Fields() ([]*ast.ResultField, error) {
 }
 
 func (a *recordSet) Next() (*ast.Row, error) {
+	if be, ok := a.executor.(BatchExecutor); ok && UseBatchExecution {
+		for a.chunk == nil || a.cursor >= a.chunk.NumRows {
+			chk, err := be.NextBatch()
+			if err != nil || chk == nil {
+				return nil, errors.Trace(err)
+			}
+			a.chunk, a.cursor = chk, 0
+		}
+		row := a.chunk.row(a.cursor)
+		a.cursor++
+		return &ast.Row{Data: row.Data}, nil
+	}
 	row, err := a.executor.Next()
 	if err != nil || row == nil {
 		return nil, errors.Trace(err)
@@ -42,6 +57,8 @@ func (a *recordSet) Next() (*ast.Row, error) {
 }
 
 func (a *recordSet) Close() error {
+	a.chunk = nil
+	a.cursor = 0
 	return a.executor.Close()
 }
 
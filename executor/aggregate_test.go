@@ -18,6 +18,7 @@ import (
 	"github.com/pingcap/tidb/ast"
 	"github.com/pingcap/tidb/evaluator"
 	"github.com/pingcap/tidb/expression"
+	"github.com/pingcap/tidb/mysql"
 	"github.com/pingcap/tidb/util/mock"
 	"github.com/pingcap/tidb/util/testleak"
 	"github.com/pingcap/tidb/util/testutil"
@@ -136,3 +137,27 @@ func (s *testAggFuncSuite) TestCount(c *C) {
 	c.Assert(err, IsNil)
 	c.Assert(val, testutil.DatumEquals, types.NewDatum(int64(0)))
 }
+
+func (s *testAggFuncSuite) TestParallel(c *C) {
+	defer testleak.AfterTest(c)()
+	col := &expression.Column{Index: 0, RetType: types.NewFieldType(mysql.TypeLonglong)}
+	plus, err := expression.NewFunction(ast.Plus, []expression.Expression{col, col}, col.RetType)
+	c.Assert(err, IsNil)
+	agg := &AggregationExec{
+		Src:          &mockExec{},
+		GroupByItems: []expression.Expression{plus},
+		AggFuncs:     []expression.AggregationFunction{expression.NewAggFunction(ast.AggFuncSum, []expression.Expression{col}, false)},
+		concurrency:  4,
+		memTracker:   newMemTracker(0),
+	}
+	c.Assert(agg.parallel(), IsTrue)
+
+	// The groups of the workers can't be spilled.
+	agg.memTracker = newMemTracker(1)
+	c.Assert(agg.parallel(), IsFalse)
+
+	// The scalar functions may change the session state if they are evaluated concurrently.
+	agg.memTracker = newMemTracker(0)
+	agg.AggFuncs = []expression.AggregationFunction{expression.NewAggFunction(ast.AggFuncSum, []expression.Expression{plus}, false)}
+	c.Assert(agg.parallel(), IsFalse)
+}
@@ -14,6 +14,7 @@
 package executor
 
 import (
+	"bytes"
 	"strings"
 
 	"github.com/juju/errors"
@@ -43,6 +44,14 @@ func (n *finalAggregater) update(count uint64, value types.Datum) error {
 		return n.updateCount(count)
 	case ast.AggFuncFirstRow:
 		return n.updateFirst(value)
+	case ast.AggFuncSum, ast.AggFuncAvg:
+		return n.updateSum(count, value)
+	case ast.AggFuncMax:
+		return n.updateMaxMin(value, true)
+	case ast.AggFuncMin:
+		return n.updateMaxMin(value, false)
+	case ast.AggFuncGroupConcat:
+		return n.updateGroupConcat(value)
 	}
 	return nil
 }
@@ -75,6 +84,54 @@ func (n *finalAggregater) updateFirst(val types.Datum) error {
 	return nil
 }
 
+func (n *finalAggregater) updateSum(count uint64, val types.Datum) error {
+	ctx := n.getContext()
+	if val.IsNull() {
+		return nil
+	}
+	var err error
+	ctx.Value, err = types.CalculateSum(ctx.Value, val.GetValue())
+	if err != nil {
+		return errors.Trace(err)
+	}
+	ctx.Count += int64(count)
+	return nil
+}
+
+func (n *finalAggregater) updateMaxMin(val types.Datum, max bool) error {
+	ctx := n.getContext()
+	if val.IsNull() {
+		return nil
+	}
+	if !ctx.Evaluated {
+		ctx.Value = val.GetValue()
+		ctx.Evaluated = true
+		return nil
+	}
+	c, err := types.Compare(ctx.Value, val.GetValue())
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if (max && c < 0) || (!max && c > 0) {
+		ctx.Value = val.GetValue()
+	}
+	return nil
+}
+
+func (n *finalAggregater) updateGroupConcat(val types.Datum) error {
+	ctx := n.getContext()
+	if val.IsNull() {
+		return nil
+	}
+	if ctx.Buffer == nil {
+		ctx.Buffer = &bytes.Buffer{}
+	} else {
+		ctx.Buffer.WriteString(",")
+	}
+	ctx.Buffer.WriteString(val.GetString())
+	return nil
+}
+
 // XAggregateExec deals with all the aggregate functions.
 // It is built from Aggregate Plan. When Next() is called, it reads all the data from Src and updates all the items in AggFuncs.
 // TODO: Support having.
@@ -192,7 +249,10 @@ func (e *XAggregateExec) innerNext() (bool, error) {
 			cursor++
 		}
 		agg.currentGroup = groupKey
-		agg.update(count, value)
+		err := agg.update(count, value)
+		if err != nil {
+			return false, errors.Trace(err)
+		}
 	}
 	return true, nil
 }
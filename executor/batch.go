@@ -0,0 +1,117 @@
+// Copyright 2016 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/util/types"
+)
+
+// UseBatchExecution means if the rows are read in chunks from the executors which support it.
+var UseBatchExecution = true
+
+// batchSize is the max number of rows read from the child of a batch executor at a time.
+var batchSize = 1024
+
+// Chunk is a batch of rows stored by columns, the values of a column are evaluated together
+// to reduce the cost of calling Next and evaluating the expressions for every row.
+// A chunk is read-only after it is returned, because its columns may be shared with its child.
+type Chunk struct {
+	// Columns are the values of the columns in the schema, Columns[i][j] is the i-th column of the j-th row.
+	Columns [][]types.Datum
+	// RowKeys are the row keys of the rows.
+	RowKeys [][]*RowKeyEntry
+	NumRows int
+}
+
+func newChunk(numCols int) *Chunk {
+	chk := &Chunk{Columns: make([][]types.Datum, numCols)}
+	for i := range chk.Columns {
+		chk.Columns[i] = make([]types.Datum, 0, batchSize)
+	}
+	return chk
+}
+
+// appendRow appends a row to the chunk, the row data must have the same number of columns as the chunk.
+func (chk *Chunk) appendRow(row *Row) {
+	for i, d := range row.Data {
+		chk.Columns[i] = append(chk.Columns[i], d)
+	}
+	chk.RowKeys = append(chk.RowKeys, row.RowKeys)
+	chk.NumRows++
+}
+
+// row returns the i-th row in the chunk.
+func (chk *Chunk) row(i int) *Row {
+	data := make([]types.Datum, len(chk.Columns))
+	for j, col := range chk.Columns {
+		data[j] = col[i]
+	}
+	return &Row{Data: data, RowKeys: chk.RowKeys[i]}
+}
+
+// filter returns a new chunk of the selected rows.
+func (chk *Chunk) filter(selected []bool) *Chunk {
+	newChk := &Chunk{Columns: make([][]types.Datum, len(chk.Columns))}
+	for i, sel := range selected {
+		if !sel {
+			continue
+		}
+		for j, col := range chk.Columns {
+			newChk.Columns[j] = append(newChk.Columns[j], col[i])
+		}
+		newChk.RowKeys = append(newChk.RowKeys, chk.RowKeys[i])
+		newChk.NumRows++
+	}
+	return newChk
+}
+
+// BatchExecutor is an executor which returns the rows in chunks.
+// An executor must be read by either Next or NextBatch until it is closed.
+type BatchExecutor interface {
+	Executor
+	// NextBatch returns the next chunk of rows, it returns nil if there are no more rows.
+	// The returned chunk is never empty.
+	NextBatch() (*Chunk, error)
+}
+
+// nextBatch reads the next chunk from the executor. If the executor doesn't return rows in chunks,
+// the rows are read by Next and put into the chunk.
+func nextBatch(e Executor) (*Chunk, error) {
+	if be, ok := e.(BatchExecutor); ok {
+		chk, err := be.NextBatch()
+		return chk, errors.Trace(err)
+	}
+	chk, err := collectRows(e.Next)
+	return chk, errors.Trace(err)
+}
+
+// collectRows reads at most batchSize rows by next and puts them into a chunk.
+func collectRows(next func() (*Row, error)) (*Chunk, error) {
+	var chk *Chunk
+	for chk == nil || chk.NumRows < batchSize {
+		row, err := next()
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if row == nil {
+			break
+		}
+		if chk == nil {
+			chk = newChunk(len(row.Data))
+		}
+		chk.appendRow(row)
+	}
+	return chk, nil
+}
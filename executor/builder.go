@@ -42,6 +42,13 @@ type executorBuilder struct {
 	ctx context.Context
 	is  infoschema.InfoSchema
 	err error
+	// memTracker is shared by the executors built for a statement.
+	memTracker *memTracker
+	// spillers maps the plans to their executors which may spill to disk, it is only used by EXPLAIN ANALYZE.
+	spillers map[plan.Plan]spiller
+	// cteExecs maps the recursive common table expressions to their executors, the CTE tables in the
+	// recursive parts read the rows from them.
+	cteExecs map[*plan.RecursiveCTE]*RecursiveCTEExec
 }
 
 func newExecutorBuilder(ctx context.Context, is infoschema.InfoSchema) *executorBuilder {
@@ -94,6 +101,10 @@ func (b *executorBuilder) build(p plan.Plan) Executor {
 		return b.buildSelectLock(v)
 	case *plan.ShowDDL:
 		return b.buildShowDDL(v)
+	case *plan.ShowDDLJobs:
+		return b.buildShowDDLJobs(v)
+	case *plan.CancelDDLJobs:
+		return b.buildCancelDDLJobs(v)
 	case *plan.Show:
 		return b.buildShow(v)
 	case *plan.Simple:
@@ -102,6 +113,8 @@ func (b *executorBuilder) build(p plan.Plan) Executor {
 		return b.buildSort(v)
 	case *plan.NewSort:
 		return b.buildNewSort(v)
+	case *plan.TopN:
+		return b.buildTopN(v)
 	case *plan.TableDual:
 		return b.buildTableDual(v)
 	case *plan.TableScan:
@@ -132,6 +145,12 @@ func (b *executorBuilder) build(p plan.Plan) Executor {
 		return b.buildMaxOneRow(v)
 	case *plan.Trim:
 		return b.buildTrim(v)
+	case *plan.Window:
+		return b.buildWindow(v)
+	case *plan.RecursiveCTE:
+		return b.buildRecursiveCTE(v)
+	case *plan.CTETable:
+		return b.buildCTETable(v)
 	default:
 		b.err = ErrUnknownPlan.Gen("Unknown Plan %T", p)
 		return nil
@@ -214,6 +233,21 @@ func (b *executorBuilder) buildShowDDL(v *plan.ShowDDL) Executor {
 	}
 }
 
+func (b *executorBuilder) buildShowDDLJobs(v *plan.ShowDDLJobs) Executor {
+	return &ShowDDLJobsExec{
+		fields: v.Fields(),
+		ctx:    b.ctx,
+	}
+}
+
+func (b *executorBuilder) buildCancelDDLJobs(v *plan.CancelDDLJobs) Executor {
+	return &CancelDDLJobsExec{
+		fields: v.Fields(),
+		ctx:    b.ctx,
+		JobIDs: v.JobIDs,
+	}
+}
+
 func (b *executorBuilder) buildCheckTable(v *plan.CheckTable) Executor {
 	return &CheckTableExec{
 		tables: v.Tables,
@@ -249,10 +283,17 @@ func (b *executorBuilder) buildIndexScan(v *plan.IndexScan) Executor {
 			ctx:         b.ctx,
 			indexPlan:   v,
 			supportDesc: supportDesc,
+			// The index-only rows are returned in the index order, so it requires desc support.
+			indexOnly: v.IndexOnly && (!v.Desc || supportDesc),
 		}
-		where, remained := b.conditionsToPBExpr(client, v.FilterConditions, v.TableName)
-		if where != nil {
-			e.where = where
+		// The conditions can not be pushed to the index request.
+		remained := v.FilterConditions
+		if !e.indexOnly {
+			var where *tipb.Expr
+			where, remained = b.conditionsToPBExpr(client, v.FilterConditions, v.TableName)
+			if where != nil {
+				e.where = where
+			}
 		}
 		var ex Executor
 		if txn.IsReadOnly() {
@@ -278,6 +319,7 @@ func (b *executorBuilder) buildIndexScan(v *plan.IndexScan) Executor {
 		ctx:         b.ctx,
 		Desc:        v.Desc,
 		valueTypes:  make([]*types.FieldType, len(idx.Meta().Columns)),
+		indexOnly:   v.IndexOnly,
 	}
 
 	for i, ic := range idx.Meta().Columns {
@@ -385,6 +427,10 @@ func (b *executorBuilder) buildAggregate(v *plan.Aggregate) Executor {
 	if !ok {
 		return e
 	}
+	if x, ok := src.(*XSelectIndexExec); ok && x.indexOnly {
+		// The aggregates are pushed with the table request, which is not sent for index-only reads.
+		return e
+	}
 	txn, err := b.ctx.GetTxn(false)
 	if err != nil {
 		b.err = err
@@ -442,7 +488,7 @@ func (b *executorBuilder) buildAggregate(v *plan.Aggregate) Executor {
 		}
 		if needValue(name) {
 			// value partial result field
-			fields = append(fields, agg.GetType())
+			fields = append(fields, partialValueType(agg))
 		}
 	}
 	xSrc.AddAggregate(pbAggFuncs, pbByItems, fields)
@@ -457,6 +503,23 @@ func (b *executorBuilder) buildAggregate(v *plan.Aggregate) Executor {
 	return xe
 }
 
+// partialValueType returns the type of the value partial result field of the aggregate function.
+// The partial sum of Sum and Avg is a double for float arguments and a decimal for the others,
+// see types.CalculateSum.
+func partialValueType(agg *ast.AggregateFuncExpr) *types.FieldType {
+	name := strings.ToLower(agg.F)
+	if name != ast.AggFuncSum && name != ast.AggFuncAvg {
+		return agg.GetType()
+	}
+	if tp := agg.Args[0].GetType().Tp; tp != mysql.TypeFloat && tp != mysql.TypeDouble {
+		return agg.GetType()
+	}
+	ft := types.NewFieldType(mysql.TypeDouble)
+	ft.Charset = charset.CharsetBin
+	ft.Collate = charset.CollationBin
+	return ft
+}
+
 func (b *executorBuilder) buildHaving(v *plan.Having) Executor {
 	src := b.build(v.GetChildByIndex(0))
 	return b.buildFilter(src, v.Conditions)
@@ -522,13 +585,14 @@ func (b *executorBuilder) buildExecute(v *plan.Execute) Executor {
 
 func (b *executorBuilder) buildUpdate(v *plan.Update) Executor {
 	selExec := b.build(v.SelectPlan)
-	return &UpdateExec{ctx: b.ctx, SelectExec: selExec, OrderedList: v.OrderedList}
+	return &UpdateExec{ctx: b.ctx, fkChecker: newFKChecker(b.ctx, b.is), SelectExec: selExec, OrderedList: v.OrderedList}
 }
 
 func (b *executorBuilder) buildDelete(v *plan.Delete) Executor {
 	selExec := b.build(v.SelectPlan)
 	return &DeleteExec{
 		ctx:          b.ctx,
+		fkChecker:    newFKChecker(b.ctx, b.is),
 		SelectExec:   selExec,
 		Tables:       v.Tables,
 		IsMultiTable: v.IsMultiTable,
@@ -565,10 +629,11 @@ func (b *executorBuilder) buildSimple(v *plan.Simple) Executor {
 
 func (b *executorBuilder) buildInsert(v *plan.Insert) Executor {
 	ivs := &InsertValues{
-		ctx:     b.ctx,
-		Columns: v.Columns,
-		Lists:   v.Lists,
-		Setlist: v.Setlist,
+		ctx:       b.ctx,
+		fkChecker: newFKChecker(b.ctx, b.is),
+		Columns:   v.Columns,
+		Lists:     v.Lists,
+		Setlist:   v.Setlist,
 	}
 	if v.SelectPlan != nil {
 		ivs.SelectExec = b.build(v.SelectPlan)
@@ -625,10 +690,34 @@ func (b *executorBuilder) buildDDL(v *plan.DDL) Executor {
 }
 
 func (b *executorBuilder) buildExplain(v *plan.Explain) Executor {
-	return &ExplainExec{
+	e := &ExplainExec{
 		StmtPlan: v.StmtPlan,
 		fields:   v.Fields(),
 	}
+	if v.Analyze {
+		b.spillers = make(map[plan.Plan]spiller)
+		e.analyzeExec = b.build(v.StmtPlan)
+		e.spillers = b.spillers
+	}
+	return e
+}
+
+func (b *executorBuilder) getMemTracker() *memTracker {
+	if b.memTracker == nil {
+		var quota int64
+		if sessVars := variable.GetSessionVars(b.ctx); sessVars != nil {
+			quota = sessVars.MemQuotaQuery
+		}
+		b.memTracker = newMemTracker(quota)
+	}
+	return b.memTracker
+}
+
+// recordSpiller records the executor built for the plan if it is built for EXPLAIN ANALYZE.
+func (b *executorBuilder) recordSpiller(p plan.Plan, e spiller) {
+	if b.spillers != nil {
+		b.spillers[p] = e
+	}
 }
 
 // buildUnionScanExec builds a union scan executor, the src Executor is either
@@ -0,0 +1,159 @@
+// Copyright 2016 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/expression"
+	"github.com/pingcap/tidb/util/distinct"
+	"github.com/pingcap/tidb/util/types"
+)
+
+// RecursiveCTEExec evaluates a recursive common table expression iteratively.
+// It returns the rows of Seed first, then runs Recursive repeatedly, every iteration reads the rows
+// produced by the previous one through the CTETableExecs, until an iteration produces no new rows.
+type RecursiveCTEExec struct {
+	schema    expression.Schema
+	Seed      Executor
+	Recursive Executor
+	// Distinct is true if the parts are combined by UNION DISTINCT, the duplicated rows are dropped then.
+	Distinct bool
+	// maxDepth is the max number of the recursive iterations which may produce rows.
+	maxDepth int
+
+	checker *distinct.Checker
+	// cur is the executor the rows are read from, it is nil after the last iteration.
+	cur     Executor
+	started bool
+	depth   int
+	// iterRows are the rows produced by the last iteration, the CTETableExecs read them.
+	iterRows []*Row
+	// nextRows are the rows produced by the current iteration so far.
+	nextRows []*Row
+}
+
+// Schema implements Executor Schema interface.
+func (e *RecursiveCTEExec) Schema() expression.Schema {
+	return e.schema
+}
+
+// Fields implements Executor Fields interface.
+func (e *RecursiveCTEExec) Fields() []*ast.ResultField {
+	return nil
+}
+
+// Next implements Executor Next interface.
+func (e *RecursiveCTEExec) Next() (*Row, error) {
+	if !e.started {
+		e.started = true
+		e.cur = e.Seed
+		if e.Distinct {
+			e.checker = distinct.CreateDistinctChecker()
+		}
+	}
+	for e.cur != nil {
+		row, err := e.cur.Next()
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if row == nil {
+			if err = e.nextIteration(); err != nil {
+				return nil, errors.Trace(err)
+			}
+			continue
+		}
+		if e.checker != nil {
+			ok, err := e.checker.Check(types.DatumsToInterfaces(row.Data))
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+			if !ok {
+				continue
+			}
+		}
+		if e.depth > e.maxDepth {
+			return nil, ErrCTEMaxRecursionDepth.Gen("Recursive query aborted after %d iterations. Try increasing @@cte_max_recursion_depth to a larger value.", e.maxDepth)
+		}
+		row = &Row{Data: append([]types.Datum(nil), row.Data...)}
+		e.nextRows = append(e.nextRows, row)
+		return row, nil
+	}
+	return nil, nil
+}
+
+// nextIteration makes the rows of the finished iteration visible to the recursive part and restarts it.
+func (e *RecursiveCTEExec) nextIteration() error {
+	if e.cur == e.Recursive {
+		if err := e.Recursive.Close(); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	if len(e.nextRows) == 0 {
+		e.cur = nil
+		e.iterRows = nil
+		return nil
+	}
+	e.iterRows, e.nextRows = e.nextRows, nil
+	e.depth++
+	e.cur = e.Recursive
+	return nil
+}
+
+// Close implements Executor Close interface.
+func (e *RecursiveCTEExec) Close() error {
+	e.started = false
+	e.cur = nil
+	e.depth = 0
+	e.checker = nil
+	e.iterRows = nil
+	e.nextRows = nil
+	if err := e.Seed.Close(); err != nil {
+		return errors.Trace(err)
+	}
+	return e.Recursive.Close()
+}
+
+// CTETableExec reads the rows produced by the last iteration of a recursive common table expression.
+type CTETableExec struct {
+	schema expression.Schema
+	cte    *RecursiveCTEExec
+	cursor int
+}
+
+// Schema implements Executor Schema interface.
+func (e *CTETableExec) Schema() expression.Schema {
+	return e.schema
+}
+
+// Fields implements Executor Fields interface.
+func (e *CTETableExec) Fields() []*ast.ResultField {
+	return nil
+}
+
+// Next implements Executor Next interface.
+func (e *CTETableExec) Next() (*Row, error) {
+	if e.cursor >= len(e.cte.iterRows) {
+		return nil, nil
+	}
+	row := e.cte.iterRows[e.cursor]
+	e.cursor++
+	return &Row{Data: append([]types.Datum(nil), row.Data...)}, nil
+}
+
+// Close implements Executor Close interface.
+func (e *CTETableExec) Close() error {
+	e.cursor = 0
+	return nil
+}
@@ -14,6 +14,7 @@
 package executor
 
 import (
+	"fmt"
 	"sort"
 
 	"github.com/juju/errors"
@@ -25,11 +26,13 @@ import (
 	"github.com/pingcap/tidb/inspectkv"
 	"github.com/pingcap/tidb/kv"
 	"github.com/pingcap/tidb/model"
+	"github.com/pingcap/tidb/mysql"
 	"github.com/pingcap/tidb/plan"
 	"github.com/pingcap/tidb/sessionctx"
 	"github.com/pingcap/tidb/sessionctx/db"
 	"github.com/pingcap/tidb/sessionctx/forupdate"
 	"github.com/pingcap/tidb/table"
+	"github.com/pingcap/tidb/tablecodec"
 	"github.com/pingcap/tidb/terror"
 	"github.com/pingcap/tidb/util/codec"
 	"github.com/pingcap/tidb/util/distinct"
@@ -46,6 +49,8 @@ var (
 	_ Executor = &SelectFieldsExec{}
 	_ Executor = &SelectLockExec{}
 	_ Executor = &ShowDDLExec{}
+	_ Executor = &ShowDDLJobsExec{}
+	_ Executor = &CancelDDLJobsExec{}
 	_ Executor = &SortExec{}
 	_ Executor = &TableDualExec{}
 	_ Executor = &TableScanExec{}
@@ -59,6 +64,12 @@ var (
 	ErrSchemaChanged   = terror.ClassExecutor.New(CodeSchemaChanged, "Schema has changed")
 	ErrWrongParamCount = terror.ClassExecutor.New(CodeWrongParamCount, "Wrong parameter count")
 	ErrRowKeyCount     = terror.ClassExecutor.New(CodeRowKeyCount, "Wrong row key entry count")
+
+	ErrCTEMaxRecursionDepth = terror.ClassExecutor.New(CodeCTEMaxRecursionDepth, "Recursive query aborted after too many iterations")
+
+	ErrNoReferencedRow = terror.ClassExecutor.New(CodeNoReferencedRow, "Cannot add or update a child row: a foreign key constraint fails")
+	ErrRowIsReferenced = terror.ClassExecutor.New(CodeRowIsReferenced, "Cannot delete or update a parent row: a foreign key constraint fails")
+	ErrFKNoIndex       = terror.ClassExecutor.New(CodeFKNoIndex, "Missing index for the foreign key")
 )
 
 // Error codes.
@@ -69,6 +80,12 @@ const (
 	CodeSchemaChanged   terror.ErrCode = 4
 	CodeWrongParamCount terror.ErrCode = 5
 	CodeRowKeyCount     terror.ErrCode = 6
+
+	CodeCTEMaxRecursionDepth terror.ErrCode = 7
+
+	CodeNoReferencedRow terror.ErrCode = 8
+	CodeRowIsReferenced terror.ErrCode = 9
+	CodeFKNoIndex       terror.ErrCode = 10
 )
 
 // Row represents a record row.
@@ -173,6 +190,122 @@ func (e *ShowDDLExec) Close() error {
 	return nil
 }
 
+// CancelDDLJobsExec represents a cancel DDL jobs executor.
+type CancelDDLJobsExec struct {
+	fields []*ast.ResultField
+	ctx    context.Context
+	JobIDs []int64
+
+	cursor int
+	errs   []error
+}
+
+// Schema implements Executor Schema interface.
+func (e *CancelDDLJobsExec) Schema() expression.Schema {
+	return nil
+}
+
+// Fields implements Executor Fields interface.
+func (e *CancelDDLJobsExec) Fields() []*ast.ResultField {
+	return e.fields
+}
+
+// Next implements Executor Next interface.
+func (e *CancelDDLJobsExec) Next() (*Row, error) {
+	if e.errs == nil {
+		txn, err := e.ctx.GetTxn(false)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		e.errs, err = inspectkv.CancelJobs(txn, e.JobIDs)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+	if e.cursor >= len(e.JobIDs) {
+		return nil, nil
+	}
+
+	ret := "successful"
+	if e.errs[e.cursor] != nil {
+		ret = e.errs[e.cursor].Error()
+	}
+	row := &Row{}
+	row.Data = types.MakeDatums(fmt.Sprintf("%d", e.JobIDs[e.cursor]), ret)
+	for i, f := range e.fields {
+		f.Expr.SetValue(row.Data[i].GetValue())
+	}
+	e.cursor++
+
+	return row, nil
+}
+
+// Close implements Executor Close interface.
+func (e *CancelDDLJobsExec) Close() error {
+	return nil
+}
+
+// maxHistoryDDLJobs is the max number of the history DDL jobs shown by ShowDDLJobsExec.
+const maxHistoryDDLJobs = 10
+
+// ShowDDLJobsExec represents a show DDL jobs executor.
+type ShowDDLJobsExec struct {
+	fields []*ast.ResultField
+	ctx    context.Context
+
+	cursor int
+	jobs   []*model.Job
+	done   bool
+}
+
+// Schema implements Executor Schema interface.
+func (e *ShowDDLJobsExec) Schema() expression.Schema {
+	return nil
+}
+
+// Fields implements Executor Fields interface.
+func (e *ShowDDLJobsExec) Fields() []*ast.ResultField {
+	return e.fields
+}
+
+// Next implements Executor Next interface.
+func (e *ShowDDLJobsExec) Next() (*Row, error) {
+	if !e.done {
+		txn, err := e.ctx.GetTxn(false)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		jobs, err := inspectkv.GetDDLJobs(txn)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		historyJobs, err := inspectkv.GetHistoryDDLJobs(txn, maxHistoryDDLJobs)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		e.jobs = append(jobs, historyJobs...)
+		e.done = true
+	}
+	if e.cursor >= len(e.jobs) {
+		return nil, nil
+	}
+
+	job := e.jobs[e.cursor]
+	row := &Row{}
+	row.Data = types.MakeDatums(job.String(), job.State.String())
+	for i, f := range e.fields {
+		f.Expr.SetValue(row.Data[i].GetValue())
+	}
+	e.cursor++
+
+	return row, nil
+}
+
+// Close implements Executor Close interface.
+func (e *ShowDDLJobsExec) Close() error {
+	return nil
+}
+
 // CheckTableExec represents a check table executor.
 type CheckTableExec struct {
 	tables []*ast.TableName
@@ -457,7 +590,11 @@ func (e *IndexRangeExec) Next() (*Row, error) {
 			continue
 		}
 		var row *Row
-		row, err = e.lookupRow(h)
+		if e.scan.indexOnly {
+			row, err = e.rowFromIndex(idxKey, h)
+		} else {
+			row, err = e.lookupRow(h)
+		}
 		if err != nil {
 			return nil, errors.Trace(err)
 		}
@@ -501,6 +638,70 @@ func (e *IndexRangeExec) lookupRow(h int64) (*Row, error) {
 	return row, nil
 }
 
+// rowFromIndex builds the row from the index values instead of looking up the table row.
+func (e *IndexRangeExec) rowFromIndex(idxKey []types.Datum, h int64) (*Row, error) {
+	data, err := indexRowData(e.scan.fields, e.scan.idx.Meta(), e.scan.tbl.Meta().PKIsHandle, h, idxKey)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	rowKey := &RowKeyEntry{
+		Tbl:    e.scan.tbl,
+		Handle: h,
+	}
+	return &Row{Data: data, RowKeys: []*RowKeyEntry{rowKey}}, nil
+}
+
+// indexRowData builds the row data from the index values and the handle, all the referenced fields
+// must be covered by the index. The fields not in the index are left null, but all the index columns
+// are filled even if they are not referenced, so the rows can be compared in the index order.
+func indexRowData(fields []*ast.ResultField, idxInfo *model.IndexInfo, pkIsHandle bool, h int64, vals []types.Datum) ([]types.Datum, error) {
+	data := make([]types.Datum, len(fields))
+	for i, field := range fields {
+		col := field.Column
+		if pkIsHandle && mysql.HasPriKeyFlag(col.Flag) {
+			if mysql.HasUnsignedFlag(col.Flag) {
+				data[i].SetUint64(uint64(h))
+			} else {
+				data[i].SetInt64(h)
+			}
+			continue
+		}
+		for j, ic := range idxInfo.Columns {
+			if ic.Name.L != col.Name.L {
+				continue
+			}
+			d, err := indexValueToDatum(vals[j], &col.FieldType)
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+			data[i] = d
+			break
+		}
+	}
+	return data, nil
+}
+
+// indexValueToDatum converts the value decoded from the index to the column type.
+// Unlike the row values, the time values are encoded as strings in the index.
+func indexValueToDatum(d types.Datum, ft *types.FieldType) (types.Datum, error) {
+	if d.IsNull() {
+		return d, nil
+	}
+	switch ft.Tp {
+	case mysql.TypeDate, mysql.TypeDatetime, mysql.TypeTimestamp:
+		t, err := mysql.ParseTime(d.GetString(), ft.Tp, ft.Decimal)
+		if err != nil {
+			return d, errors.Trace(err)
+		}
+		d.SetMysqlTime(t)
+		return d, nil
+	case mysql.TypeDuration:
+		d.SetInt64(int64(d.GetMysqlDuration().Duration))
+	}
+	d, err := tablecodec.Unflatten(d, ft)
+	return d, errors.Trace(err)
+}
+
 // Close implements Executor Close interface.
 func (e *IndexRangeExec) Close() error {
 	if e.iter != nil {
@@ -523,6 +724,8 @@ type IndexScanExec struct {
 	rangeIdx    int
 	ctx         context.Context
 	valueTypes  []*types.FieldType
+	// indexOnly indicates that the rows are read from the index without looking up the table.
+	indexOnly bool
 }
 
 // Schema implements Executor Schema interface.
@@ -1366,6 +1569,12 @@ func (e *ReverseExec) Close() error {
 }
 
 func init() {
+	mySQLErrCodes := map[terror.ErrCode]uint16{
+		CodeNoReferencedRow: mysql.ErrNoReferencedRow2,
+		CodeRowIsReferenced: mysql.ErrRowIsReferenced2,
+	}
+	terror.ErrClassToMySQLCodes[terror.ClassExecutor] = mySQLErrCodes
+
 	plan.EvalSubquery = func(p plan.Plan, is infoschema.InfoSchema, ctx context.Context) (d []types.Datum, err error) {
 		e := &executorBuilder{is: is, ctx: ctx}
 		exec := e.build(p)
@@ -67,6 +67,8 @@ func (e *DDLExec) Next() (*Row, error) {
 		err = e.executeDropTable(x)
 	case *ast.DropIndexStmt:
 		err = e.executeDropIndex(x)
+	case *ast.RenameTableStmt:
+		err = e.executeRenameTable(x)
 	case *ast.AlterTableStmt:
 		err = e.executeAlterTable(x)
 	}
@@ -83,16 +85,9 @@ func (e *DDLExec) Close() error {
 }
 
 func (e *DDLExec) executeTruncateTable(s *ast.TruncateTableStmt) error {
-	table, ok := e.is.TableByID(s.Table.TableInfo.ID)
-	if !ok {
-		return errors.New("table not found, should never happen")
-	}
-	err := table.Truncate(e.ctx)
-	if err != nil {
-		return errors.Trace(err)
-	}
-	getDirtyDB(e.ctx).truncateTable(table.Meta().ID)
-	return nil
+	ident := ast.Ident{Schema: s.Table.Schema, Name: s.Table.Name}
+	err := sessionctx.GetDomain(e.ctx).DDL().TruncateTable(e.ctx, ident)
+	return errors.Trace(err)
 }
 
 func (e *DDLExec) executeCreateDatabase(s *ast.CreateDatabaseStmt) error {
@@ -203,6 +198,49 @@ func (e *DDLExec) executeAlterTable(s *ast.AlterTableStmt) error {
 	return errors.Trace(err)
 }
 
+func (e *DDLExec) executeRenameTable(s *ast.RenameTableStmt) error {
+	oldIdents := make([]ast.Ident, 0, len(s.TableToTables))
+	newIdents := make([]ast.Ident, 0, len(s.TableToTables))
+	privChecker := privilege.GetPrivilegeChecker(e.ctx)
+	for _, tt := range s.TableToTables {
+		oldIdent := ast.Ident{Schema: tt.OldTable.Schema, Name: tt.OldTable.Name}
+		newIdent := ast.Ident{Schema: tt.NewTable.Schema, Name: tt.NewTable.Name}
+		// Check Privilege
+		// The old table may not exist before the statement if it is renamed by a previous pair,
+		// the DDL reports the error if it doesn't exist at all.
+		if schema, ok := e.is.SchemaByName(oldIdent.Schema); ok {
+			if tb, err := e.is.TableByName(oldIdent.Schema, oldIdent.Name); err == nil {
+				for _, priv := range []mysql.PrivilegeType{mysql.AlterPriv, mysql.DropPriv} {
+					hasPriv, err := privChecker.Check(e.ctx, schema, tb.Meta(), priv)
+					if err != nil {
+						return errors.Trace(err)
+					}
+					if !hasPriv {
+						return errors.Errorf("You do not have the privilege to rename table %s.", oldIdent)
+					}
+				}
+			}
+		}
+		if schema, ok := e.is.SchemaByName(newIdent.Schema); ok {
+			for _, priv := range []mysql.PrivilegeType{mysql.CreatePriv, mysql.InsertPriv} {
+				hasPriv, err := privChecker.Check(e.ctx, schema, nil, priv)
+				if err != nil {
+					return errors.Trace(err)
+				}
+				if !hasPriv {
+					return errors.Errorf("You do not have the privilege to create table %s.", newIdent)
+				}
+			}
+		}
+		oldIdents = append(oldIdents, oldIdent)
+		newIdents = append(newIdents, newIdent)
+	}
+
+	// The privileges of the tables are moved by the DDL job.
+	err := sessionctx.GetDomain(e.ctx).DDL().RenameTable(e.ctx, oldIdents, newIdents)
+	return errors.Trace(err)
+}
+
 func joinColumnName(columnName *ast.ColumnName) string {
 	var originStrs []string
 	if columnName.Schema.O != "" {
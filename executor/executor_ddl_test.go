@@ -33,6 +33,17 @@ func (s *testSuite) TestTruncateTable(c *C) {
 	tk.MustExec("truncate table truncate_test")
 	result = tk.MustQuery("select * from truncate_test")
 	result.Check(nil)
+
+	// The auto ID starts from the beginning after truncate.
+	tk.MustExec(`drop table if exists truncate_test2;`)
+	tk.MustExec(`create table truncate_test2 (a int auto_increment primary key, b int)`)
+	tk.MustExec(`insert truncate_test2 (b) values (1),(2),(3)`)
+	tk.MustExec("truncate table truncate_test2")
+	tk.MustExec(`insert truncate_test2 (b) values (4)`)
+	result = tk.MustQuery("select * from truncate_test2")
+	result.Check(testkit.Rows("1 4"))
+	_, err := tk.Exec("truncate table truncate_test3")
+	c.Assert(err, NotNil)
 }
 
 func (s *testSuite) TestCreateTable(c *C) {
@@ -140,4 +151,80 @@ func (s *testSuite) TestAlterTable(c *C) {
 	tk.MustExec("use test")
 	tk.MustExec("create table if not exists alter_test (c1 int)")
 	tk.MustExec("alter table alter_test add column c2 int")
+	tk.MustExec("insert alter_test values (1, 200)")
+	tk.MustExec("alter table alter_test modify column c2 bigint not null")
+	tk.MustExec("alter table alter_test change c2 c3 tinyint unsigned first")
+	tk.MustQuery("select * from alter_test").Check(testkit.Rows("200 1"))
+	_, err := tk.Exec("alter table alter_test modify column c3 tinyint")
+	c.Assert(err, NotNil)
+	_, err = tk.Exec("alter table alter_test modify column c3 varchar(10)")
+	c.Assert(err, NotNil)
+}
+
+func (s *testSuite) TestRenameTable(c *C) {
+	defer testleak.AfterTest(c)()
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("create database rename1")
+	tk.MustExec("create database rename2")
+	tk.MustExec("use rename1")
+	tk.MustExec("create table t1 (c1 int auto_increment primary key, c2 int)")
+	tk.MustExec("insert t1 (c2) values (1), (2)")
+	tk.MustExec("create table t2 (c1 int)")
+	tk.MustExec("insert t2 values (3)")
+
+	// Rename in the same database.
+	tk.MustExec("rename table t1 to t3")
+	tk.MustQuery("select c2 from t3").Check(testkit.Rows("1", "2"))
+	_, err := tk.Exec("select * from t1")
+	c.Assert(err, NotNil)
+
+	// Swap two tables in one statement.
+	tk.MustExec("rename table t3 to tmp, t2 to t3, tmp to t2")
+	tk.MustQuery("select * from t3").Check(testkit.Rows("3"))
+	tk.MustQuery("select c2 from t2").Check(testkit.Rows("1", "2"))
+
+	// Move a table to another database, the auto ID goes with the table.
+	tk.MustExec("alter table t2 rename to rename2.t1")
+	tk.MustExec("insert rename2.t1 (c2) values (3)")
+	tk.MustQuery("select * from rename2.t1").Check(testkit.Rows("1 1", "2 2", "3 3"))
+	tk.MustExec("alter table rename2.t1 rename as t1")
+	tk.MustQuery("select c2 from t1").Check(testkit.Rows("1", "2", "3"))
+
+	// The statement fails as a whole.
+	_, err = tk.Exec("rename table t1 to t4, t5 to t6")
+	c.Assert(err, NotNil)
+	_, err = tk.Exec("rename table t1 to t4, t4 to t3")
+	c.Assert(err, NotNil)
+	_, err = tk.Exec("rename table t1 to rename3.t1")
+	c.Assert(err, NotNil)
+	tk.MustQuery("select c2 from t1").Check(testkit.Rows("1", "2", "3"))
+	tk.MustQuery("select * from t3").Check(testkit.Rows("3"))
+
+	// The table and column privileges follow the table.
+	tk.MustExec(`CREATE USER 'renameTbl'@'localhost' IDENTIFIED BY '123';`)
+	tk.MustExec(`GRANT Select ON rename1.t3 TO 'renameTbl'@'localhost';`)
+	tk.MustExec(`GRANT Select(c1) ON rename1.t3 TO 'renameTbl'@'localhost';`)
+	tk.MustExec("rename table t3 to rename2.t5")
+	tk.MustQuery(`SELECT Table_priv FROM mysql.Tables_priv WHERE User="renameTbl" AND DB="rename2" AND Table_name="t5"`).Check(testkit.Rows("Select"))
+	tk.MustQuery(`SELECT Column_priv FROM mysql.Columns_priv WHERE User="renameTbl" AND DB="rename2" AND Table_name="t5"`).Check(testkit.Rows("Select"))
+	tk.MustQuery(`SELECT * FROM mysql.Tables_priv WHERE User="renameTbl" AND DB="rename1"`).Check(testkit.Rows())
+
+	// The names which need escaping in a statement are moved too.
+	tk.MustExec("rename table rename2.t5 to rename2.`t\\\"5`")
+	tk.MustQuery(`SELECT Table_priv FROM mysql.Tables_priv WHERE User="renameTbl" AND DB="rename2" AND Table_name='t\\"5'`).Check(testkit.Rows("Select"))
+	tk.MustExec("rename table rename2.`t\\\"5` to rename2.t5")
+	tk.MustQuery(`SELECT Column_priv FROM mysql.Columns_priv WHERE User="renameTbl" AND DB="rename2" AND Table_name="t5"`).Check(testkit.Rows("Select"))
+
+	// The tables aren't renamed if the privileges can't be moved, and no privilege is moved.
+	tk.MustExec(`INSERT INTO mysql.Columns_priv (Host, User, DB, Table_name, Column_name, Column_priv) VALUES ("localhost", "renameTbl", "rename2", "t6", "c1", "Insert")`)
+	_, err = tk.Exec("rename table rename2.t5 to rename2.t6")
+	c.Assert(err, NotNil)
+	tk.MustQuery("select * from rename2.t5").Check(testkit.Rows("3"))
+	tk.MustQuery(`SELECT Table_priv FROM mysql.Tables_priv WHERE User="renameTbl" AND DB="rename2" AND Table_name="t5"`).Check(testkit.Rows("Select"))
+	tk.MustQuery(`SELECT Column_priv FROM mysql.Columns_priv WHERE User="renameTbl" AND DB="rename2" AND Table_name="t5"`).Check(testkit.Rows("Select"))
+	tk.MustQuery(`SELECT * FROM mysql.Tables_priv WHERE User="renameTbl" AND DB="rename2" AND Table_name="t6"`).Check(testkit.Rows())
+	tk.MustExec(`DELETE FROM mysql.Columns_priv WHERE User="renameTbl" AND DB="rename2" AND Table_name="t6"`)
+
+	tk.MustExec("drop database rename1")
+	tk.MustExec("drop database rename2")
 }
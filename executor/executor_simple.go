@@ -15,27 +15,36 @@ package executor
 
 import (
 	"fmt"
+	"io/ioutil"
+	"math"
 	"math/rand"
 	"strings"
 	"time"
 
+	"github.com/golang/protobuf/proto"
 	"github.com/juju/errors"
 	"github.com/pingcap/tidb/ast"
 	"github.com/pingcap/tidb/context"
 	"github.com/pingcap/tidb/evaluator"
 	"github.com/pingcap/tidb/expression"
 	"github.com/pingcap/tidb/infoschema"
+	"github.com/pingcap/tidb/kv"
 	"github.com/pingcap/tidb/meta"
 	"github.com/pingcap/tidb/model"
 	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/plan"
 	"github.com/pingcap/tidb/plan/statistics"
 	"github.com/pingcap/tidb/sessionctx"
 	"github.com/pingcap/tidb/sessionctx/db"
 	"github.com/pingcap/tidb/sessionctx/variable"
+	"github.com/pingcap/tidb/tablecodec"
 	"github.com/pingcap/tidb/util"
 	"github.com/pingcap/tidb/util/charset"
+	"github.com/pingcap/tidb/util/codec"
 	"github.com/pingcap/tidb/util/sqlexec"
 	"github.com/pingcap/tidb/util/types"
+	"github.com/pingcap/tidb/xapi"
+	"github.com/pingcap/tipb/go-tipb"
 )
 
 // SimpleExec represents simple statement executor.
@@ -329,6 +338,20 @@ const (
 )
 
 func (e *SimpleExec) createStatisticsForTable(tn *ast.TableName) error {
+	txn, err := e.ctx.GetTxn(false)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	client := txn.GetClient()
+	var memDB bool
+	switch tn.DBInfo.Name.L {
+	case "information_schema", "performance_schema":
+		memDB = true
+	}
+	// The uncommitted data in the transaction can't be seen by the coprocessor.
+	if !memDB && txn.IsReadOnly() && client.SupportRequestType(kv.ReqTypeAnalyze, kv.ReqSubTypeBasic) {
+		return e.createStatisticsByCoprocessor(tn, client, txn.StartTS())
+	}
 	var tableName string
 	if tn.Schema.L == "" {
 		tableName = tn.Name.L
@@ -345,13 +368,82 @@ func (e *SimpleExec) createStatisticsForTable(tn *ast.TableName) error {
 	if err != nil {
 		return errors.Trace(err)
 	}
-	err = e.buildStatisticsAndSaveToKV(tn, count, samples)
+	err = e.buildStatisticsAndSaveToKV(tn, count, samples, nil)
 	if err != nil {
 		return errors.Trace(err)
 	}
 	return nil
 }
 
+// createStatisticsByCoprocessor makes each region sample its rows and build the FM sketches of the columns,
+// then merges the results of the regions to build the statistics.
+func (e *SimpleExec) createStatisticsByCoprocessor(tn *ast.TableName, client kv.Client, startTS uint64) error {
+	tblInfo := tn.TableInfo
+	selReq := new(tipb.SelectRequest)
+	selReq.StartTs = proto.Uint64(startTS)
+	selReq.TableInfo = &tipb.TableInfo{
+		TableId: proto.Int64(tblInfo.ID),
+	}
+	selReq.TableInfo.Columns = xapi.ColumnsToProto(tblInfo.Columns, tblInfo.PKIsHandle)
+	selReq.Ranges = tableRangesToPBRanges([]plan.TableRange{{LowVal: math.MinInt64, HighVal: math.MaxInt64}})
+	// The limit is the max sample size of each region.
+	selReq.Limit = proto.Int64(maxSampleCount)
+	kvReq := &kv.Request{
+		Tp:          kv.ReqTypeAnalyze,
+		KeyRanges:   xapi.EncodeTableRanges(tblInfo.ID, selReq.Ranges),
+		Concurrency: defaultConcurrency,
+	}
+	var err error
+	kvReq.Data, err = proto.Marshal(selReq)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	resp := client.Send(kvReq)
+	if resp == nil {
+		return errors.New("client returns nil response")
+	}
+	defer resp.Close()
+	var collectors []*statistics.SampleCollector
+	for {
+		reader, err := resp.Next()
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if reader == nil {
+			break
+		}
+		data, err := ioutil.ReadAll(reader)
+		reader.Close()
+		if err != nil {
+			return errors.Trace(err)
+		}
+		selResp := new(tipb.SelectResponse)
+		err = proto.Unmarshal(data, selResp)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if selResp.Error != nil {
+			return errors.Errorf("analyze table %s failed: %s", tblInfo.Name, selResp.Error.GetMsg())
+		}
+		c, err := statistics.SampleCollectorFromRows(selResp.Rows)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		collectors = append(collectors, c)
+	}
+	merged := statistics.MergeSampleCollectors(collectors, maxSampleCount, len(tblInfo.Columns))
+	fieldTypes := xapi.ProtoColumnsToFieldTypes(selReq.TableInfo.Columns)
+	samples := make([]*ast.Row, 0, len(merged.Samples))
+	for _, row := range merged.Samples {
+		values, err := tablecodec.DecodeValues(row.Data, fieldTypes, false)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		samples = append(samples, &ast.Row{Data: values})
+	}
+	return errors.Trace(e.buildStatisticsAndSaveToKV(tn, merged.Count, samples, merged.Sketches))
+}
+
 // collectSamples collects sample from the result set, using Reservoir Sampling algorithm.
 // See https://en.wikipedia.org/wiki/Reservoir_sampling
 func (e *SimpleExec) collectSamples(result ast.RecordSet) (count int64, samples []*ast.Row, err error) {
@@ -379,16 +471,25 @@ func (e *SimpleExec) collectSamples(result ast.RecordSet) (count int64, samples
 	return count, samples, nil
 }
 
-func (e *SimpleExec) buildStatisticsAndSaveToKV(tn *ast.TableName, count int64, sampleRows []*ast.Row) error {
+// buildStatisticsAndSaveToKV builds the statistics from the sample rows, the number of distinct values of
+// the columns are estimated by the sketches if they are not nil.
+func (e *SimpleExec) buildStatisticsAndSaveToKV(tn *ast.TableName, count int64, sampleRows []*ast.Row, sketches []*statistics.FMSketch) error {
 	txn, err := e.ctx.GetTxn(false)
 	if err != nil {
 		return errors.Trace(err)
 	}
-	columnSamples := rowsToColumnSamples(sampleRows)
-	t, err := statistics.NewTable(tn.TableInfo, int64(txn.StartTS()), count, defaultBucketCount, columnSamples)
+	columnSamples := rowsToColumnSamples(sampleRows, len(tn.TableInfo.Columns))
+	indexSamples, err := rowsToIndexSamples(sampleRows, tn.TableInfo.Indices)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	t, err := statistics.NewTable(tn.TableInfo, int64(txn.StartTS()), count, defaultBucketCount, columnSamples, indexSamples)
 	if err != nil {
 		return errors.Trace(err)
 	}
+	for i, sketch := range sketches {
+		t.Columns[i].NDV = sketch.NDV()
+	}
 	tpb, err := t.ToPB()
 	if err != nil {
 		return errors.Trace(err)
@@ -398,14 +499,12 @@ func (e *SimpleExec) buildStatisticsAndSaveToKV(tn *ast.TableName, count int64,
 	if err != nil {
 		return errors.Trace(err)
 	}
-	return nil
+	err = sessionctx.GetDomain(e.ctx).UpdateTableStats(m, t)
+	return errors.Trace(err)
 }
 
-func rowsToColumnSamples(rows []*ast.Row) [][]types.Datum {
-	if len(rows) == 0 {
-		return nil
-	}
-	columnSamples := make([][]types.Datum, len(rows[0].Data))
+func rowsToColumnSamples(rows []*ast.Row, columnCount int) [][]types.Datum {
+	columnSamples := make([][]types.Datum, columnCount)
 	for i := range columnSamples {
 		columnSamples[i] = make([]types.Datum, len(rows))
 	}
@@ -416,3 +515,23 @@ func rowsToColumnSamples(rows []*ast.Row) [][]types.Datum {
 	}
 	return columnSamples
 }
+
+// rowsToIndexSamples encodes the index column values of the rows to index keys for each index.
+func rowsToIndexSamples(rows []*ast.Row, indices []*model.IndexInfo) ([][]types.Datum, error) {
+	indexSamples := make([][]types.Datum, len(indices))
+	for i, idxInfo := range indices {
+		indexSamples[i] = make([]types.Datum, len(rows))
+		vals := make([]types.Datum, len(idxInfo.Columns))
+		for j, row := range rows {
+			for k, idxCol := range idxInfo.Columns {
+				vals[k] = row.Data[idxCol.Offset]
+			}
+			key, err := codec.EncodeKey(nil, vals...)
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+			indexSamples[i][j].SetBytes(key)
+		}
+	}
+	return indexSamples, nil
+}
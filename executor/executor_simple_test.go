@@ -212,3 +212,75 @@ func (s *testSuite) TestAnalyzeTable(c *C) {
 	c.Check(err, IsNil)
 	c.Check(tStats, NotNil)
 }
+
+func (s *testSuite) TestAnalyzeTableStatsUsedByPlanner(c *C) {
+	defer testleak.AfterTest(c)()
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t, t1")
+	tk.MustExec("create table t (a int, b int, index b (b), index a (a))")
+	for i := 0; i < 20; i++ {
+		tk.MustExec(fmt.Sprintf("insert into t values (1, %d)", i))
+	}
+	// Without statistics, the equal conditions on a and b are considered as selective as each other.
+	tk.MustQuery("explain select * from t where a = 1 and b = 5").Check(testkit.Rows(
+		"1 SIMPLE t range a a -1 <nil> 0 Using where",
+	))
+
+	tk.MustExec("analyze table t")
+	ctx := tk.Se.(context.Context)
+	is := sessionctx.GetDomain(ctx).InfoSchema()
+	t, err := is.TableByName(model.NewCIStr("test"), model.NewCIStr("t"))
+	c.Assert(err, IsNil)
+	statsTbl := sessionctx.GetDomain(ctx).StatsHandle().GetTableStats(t.Meta())
+	c.Assert(statsTbl, NotNil)
+	c.Assert(statsTbl.Count, Equals, int64(20))
+
+	// All the rows have a = 1, so index b is chosen.
+	tk.MustQuery("explain select * from t where a = 1 and b = 5").Check(testkit.Rows(
+		"1 SIMPLE t range b b -1 <nil> 0 Using where",
+	))
+
+	// The statistics saved by ANALYZE are loaded from storage again by the next reload.
+	tk.MustExec("create table t1 (c int)")
+	reloaded := sessionctx.GetDomain(ctx).StatsHandle().GetTableStats(t.Meta())
+	c.Assert(reloaded, NotNil)
+	c.Assert(reloaded, Not(Equals), statsTbl)
+	c.Assert(reloaded.Count, Equals, int64(20))
+	tk.MustQuery("explain select * from t where a = 1 and b = 5").Check(testkit.Rows(
+		"1 SIMPLE t range b b -1 <nil> 0 Using where",
+	))
+}
+
+func (s *testSuite) TestAnalyzeTableIndexStats(c *C) {
+	defer testleak.AfterTest(c)()
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t")
+	tk.MustExec("create table t (a int, b int, c int, index ab (a, b), index c (c))")
+	for i := 0; i < 20; i++ {
+		tk.MustExec(fmt.Sprintf("insert into t values (1, %d, %d)", i, i%2))
+	}
+	tk.MustExec("analyze table t")
+	ctx := tk.Se.(context.Context)
+	is := sessionctx.GetDomain(ctx).InfoSchema()
+	t, err := is.TableByName(model.NewCIStr("test"), model.NewCIStr("t"))
+	c.Assert(err, IsNil)
+	statsTbl := sessionctx.GetDomain(ctx).StatsHandle().GetTableStats(t.Meta())
+	c.Assert(statsTbl, NotNil)
+	c.Assert(statsTbl.Indices, HasLen, 2)
+	idxStats := statsTbl.IndexByID(t.Meta().Indices[0].ID)
+	c.Assert(idxStats, NotNil)
+	c.Assert(idxStats.NDVs, DeepEquals, []int64{1, 20})
+	idxStats = statsTbl.IndexByID(t.Meta().Indices[1].ID)
+	c.Assert(idxStats, NotNil)
+	c.Assert(idxStats.NDVs, DeepEquals, []int64{2})
+
+	// The distinct count of the index prefix a is used to estimate the range of b.
+	tk.MustQuery("explain select * from t where a = 1 and b > 15 and c = 1").Check(testkit.Rows(
+		"1 SIMPLE t range ab ab -2 <nil> 0 Using where",
+	))
+	tk.MustQuery("explain select * from t where a = 1 and b > 0 and c = 1").Check(testkit.Rows(
+		"1 SIMPLE t range c c -1 <nil> 0 Using where",
+	))
+}
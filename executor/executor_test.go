@@ -16,6 +16,7 @@ package executor_test
 import (
 	"flag"
 	"fmt"
+	"sort"
 	"strings"
 	"testing"
 	"time"
@@ -24,6 +25,7 @@ import (
 	. "github.com/pingcap/check"
 	"github.com/pingcap/tidb"
 	"github.com/pingcap/tidb/context"
+	"github.com/pingcap/tidb/ddl"
 	"github.com/pingcap/tidb/domain"
 	"github.com/pingcap/tidb/executor"
 	"github.com/pingcap/tidb/inspectkv"
@@ -32,6 +34,7 @@ import (
 	"github.com/pingcap/tidb/parser"
 	"github.com/pingcap/tidb/plan"
 	"github.com/pingcap/tidb/store/tikv"
+	"github.com/pingcap/tidb/terror"
 	"github.com/pingcap/tidb/util/testkit"
 	"github.com/pingcap/tidb/util/testleak"
 	"github.com/pingcap/tidb/util/types"
@@ -101,6 +104,34 @@ func (s *testSuite) TestAdmin(c *C) {
 	c.Assert(err, IsNil)
 	c.Assert(row, IsNil)
 
+	// show DDL jobs test
+	r, err = tk.Exec("admin show ddl jobs")
+	c.Assert(err, IsNil)
+	row, err = r.Next()
+	c.Assert(err, IsNil)
+	c.Assert(row.Data, HasLen, 2)
+	historyJobs, err := inspectkv.GetHistoryDDLJobs(txn, 1)
+	c.Assert(err, IsNil)
+	c.Assert(historyJobs, HasLen, 1)
+	c.Assert(row.Data[0].GetString(), Equals, historyJobs[0].String())
+	c.Assert(row.Data[1].GetString(), Equals, model.JobDone.String())
+
+	// cancel DDL jobs test
+	r, err = tk.Exec(fmt.Sprintf("admin cancel ddl jobs %d, 0", historyJobs[0].ID))
+	c.Assert(err, IsNil)
+	row, err = r.Next()
+	c.Assert(err, IsNil)
+	c.Assert(row.Data, HasLen, 2)
+	c.Assert(row.Data[0].GetString(), Equals, fmt.Sprintf("%d", historyJobs[0].ID))
+	c.Assert(row.Data[1].GetString(), Matches, ".*has been done")
+	row, err = r.Next()
+	c.Assert(err, IsNil)
+	c.Assert(row.Data[0].GetString(), Equals, "0")
+	c.Assert(row.Data[1].GetString(), Matches, ".*doesn't exist")
+	row, err = r.Next()
+	c.Assert(err, IsNil)
+	c.Assert(row, IsNil)
+
 	// check table test
 	tk.MustExec("create table admin_test1 (c1 int, c2 int default 1, index (c1))")
 	tk.MustExec("insert admin_test1 (c1) values (21),(22)")
@@ -931,6 +962,148 @@ func (s *testSuite) TestMultiUpdate(c *C) {
 	r.Check(testkit.Rows("10", "10"))
 }
 
+func (s *testSuite) TestForeignKey(c *C) {
+	defer testleak.AfterTest(c)()
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists fk_child, fk_set_null, fk_restrict, fk_parent")
+	tk.MustExec("create table fk_parent (id int primary key, name varchar(20), index (name))")
+	tk.MustExec(`create table fk_child (id int primary key, pid int, index (pid),
+		foreign key fk_pid (pid) references fk_parent (id) on delete cascade on update cascade)`)
+	tk.MustExec(`create table fk_set_null (id int primary key, pid int,
+		foreign key fk_pid (pid) references fk_parent (id) on delete set null on update set null)`)
+	tk.MustExec(`create table fk_restrict (id int primary key, pname varchar(20),
+		foreign key fk_pname (pname) references fk_parent (name))`)
+	tk.MustExec("insert into fk_parent values (1, 'a'), (2, 'b'), (3, 'c')")
+
+	// The child rows must reference the existing parent rows.
+	tk.MustExec("insert into fk_child values (1, 1), (2, 2), (3, null)")
+	_, err := tk.Exec("insert into fk_child values (4, 4)")
+	c.Assert(terror.ErrorEqual(err, executor.ErrNoReferencedRow), IsTrue)
+	_, err = tk.Exec("update fk_child set pid = 4 where id = 1")
+	c.Assert(terror.ErrorEqual(err, executor.ErrNoReferencedRow), IsTrue)
+	tk.MustExec("insert into fk_set_null values (1, 1), (2, 3)")
+	tk.MustExec("insert into fk_restrict values (1, 'b')")
+	_, err = tk.Exec("insert into fk_restrict values (2, 'x')")
+	c.Assert(terror.ErrorEqual(err, executor.ErrNoReferencedRow), IsTrue)
+
+	// ON UPDATE CASCADE and ON UPDATE SET NULL.
+	tk.MustExec("update fk_parent set id = 10 where id = 1")
+	tk.MustQuery("select * from fk_child").Check(testkit.Rows("1 10", "2 2", "3 <nil>"))
+	tk.MustQuery("select * from fk_set_null").Check(testkit.Rows("1 <nil>", "2 3"))
+
+	// ON DELETE CASCADE and ON DELETE SET NULL.
+	tk.MustExec("delete from fk_parent where id = 10")
+	tk.MustExec("delete from fk_parent where id = 3")
+	tk.MustQuery("select * from fk_child").Check(testkit.Rows("2 2", "3 <nil>"))
+	tk.MustQuery("select * from fk_set_null").Check(testkit.Rows("1 <nil>", "2 <nil>"))
+
+	// RESTRICT is the default action.
+	_, err = tk.Exec("delete from fk_parent where name = 'b'")
+	c.Assert(terror.ErrorEqual(err, executor.ErrRowIsReferenced), IsTrue)
+	_, err = tk.Exec("update fk_parent set name = 'd' where id = 2")
+	c.Assert(terror.ErrorEqual(err, executor.ErrRowIsReferenced), IsTrue)
+	_, err = tk.Exec("replace into fk_parent values (2, 'z')")
+	c.Assert(terror.ErrorEqual(err, executor.ErrRowIsReferenced), IsTrue)
+	tk.MustQuery("select id from fk_parent").Check(testkit.Rows("2"))
+
+	// The parent row inserted in the same transaction can be referenced.
+	tk.MustExec("begin")
+	tk.MustExec("insert into fk_parent values (5, 'e')")
+	tk.MustExec("insert into fk_child values (5, 5)")
+	tk.MustExec("commit")
+	tk.MustQuery("select * from fk_child where pid = 5").Check(testkit.Rows("5 5"))
+
+	// The foreign keys are not checked and their actions are not applied if foreign_key_checks is off.
+	tk.MustExec("set foreign_key_checks = 0")
+	tk.MustExec("insert into fk_child values (6, 6)")
+	tk.MustExec("delete from fk_parent where id = 2")
+	tk.MustQuery("select * from fk_child").Check(testkit.Rows("2 2", "3 <nil>", "5 5", "6 6"))
+	tk.MustExec("set foreign_key_checks = 1")
+	_, err = tk.Exec("insert into fk_child values (7, 7)")
+	c.Assert(terror.ErrorEqual(err, executor.ErrNoReferencedRow), IsTrue)
+
+	// The referenced table can be in another database.
+	tk.MustExec("drop database if exists fk_db")
+	tk.MustExec("create database fk_db")
+	tk.MustExec("create table fk_db.fk_parent (id int primary key)")
+	tk.MustExec("drop table if exists fk_other")
+	tk.MustExec("create table fk_other (id int, foreign key fk_id (id) references fk_db.fk_parent (id))")
+	_, err = tk.Exec("insert into fk_other values (5)")
+	c.Assert(terror.ErrorEqual(err, executor.ErrNoReferencedRow), IsTrue)
+	tk.MustExec("insert into fk_db.fk_parent values (5)")
+	tk.MustExec("insert into fk_other values (5)")
+	_, err = tk.Exec("delete from fk_db.fk_parent")
+	c.Assert(terror.ErrorEqual(err, executor.ErrRowIsReferenced), IsTrue)
+
+	// The cascading actions are applied recursively and limited by the max depth.
+	tk.MustExec("drop table if exists fk_tree")
+	tk.MustExec(`create table fk_tree (id int primary key, pid int,
+		foreign key fk_pid (pid) references fk_tree (id) on delete cascade)`)
+	values := make([]string, 0, 20)
+	values = append(values, "(1, null)")
+	for i := 2; i <= 20; i++ {
+		values = append(values, fmt.Sprintf("(%d, %d)", i, i-1))
+	}
+	tk.MustExec("insert into fk_tree values " + strings.Join(values, ", "))
+	_, err = tk.Exec("delete from fk_tree where id = 1")
+	c.Assert(terror.ErrorEqual(err, executor.ErrRowIsReferenced), IsTrue)
+	tk.MustQuery("select count(*) from fk_tree").Check(testkit.Rows("20"))
+	tk.MustExec("delete from fk_tree where id = 10")
+	tk.MustQuery("select count(*) from fk_tree").Check(testkit.Rows("9"))
+
+	// The foreign keys follow the renamed referenced tables and columns.
+	tk.MustExec("rename table fk_parent to fk_renamed")
+	tk.MustExec("alter table fk_renamed change name pname varchar(20)")
+	tk.MustExec("insert into fk_restrict values (3, 'e')")
+	_, err = tk.Exec("insert into fk_restrict values (4, 'x')")
+	c.Assert(terror.ErrorEqual(err, executor.ErrNoReferencedRow), IsTrue)
+	_, err = tk.Exec("update fk_renamed set pname = 'x' where id = 5")
+	c.Assert(terror.ErrorEqual(err, executor.ErrRowIsReferenced), IsTrue)
+	tk.MustExec("update fk_renamed set id = 50 where id = 5")
+	tk.MustQuery("select * from fk_child where id = 5").Check(testkit.Rows("5 50"))
+	tk.MustExec("rename table fk_db.fk_parent to fk_db_parent")
+	_, err = tk.Exec("delete from fk_db_parent")
+	c.Assert(terror.ErrorEqual(err, executor.ErrRowIsReferenced), IsTrue)
+	tk.MustExec("rename table fk_other to fk_db.fk_other")
+	_, err = tk.Exec("delete from fk_db_parent")
+	c.Assert(terror.ErrorEqual(err, executor.ErrRowIsReferenced), IsTrue)
+	tk.MustExec("drop table fk_db.fk_other, fk_db_parent")
+
+	// The index is created for the foreign key columns, the foreign keys are not checked without the index.
+	tk.MustExec("alter table fk_set_null drop index fk_pid")
+	_, err = tk.Exec("delete from fk_renamed")
+	c.Assert(terror.ErrorEqual(err, executor.ErrFKNoIndex), IsTrue)
+	_, err = tk.Exec("alter table fk_set_null add foreign key fk_id (id) references fk_renamed (id)")
+	c.Assert(err, IsNil)
+	_, err = tk.Exec("alter table fk_restrict add foreign key fk_pname2 (pname) references fk_set_null (pid)")
+	c.Assert(terror.ErrorEqual(err, ddl.ErrFKNoIndexParent), IsTrue)
+	_, err = tk.Exec("alter table fk_tree add foreign key fk_id (id) references fk_renamed (id)")
+	c.Assert(err, IsNil)
+	_, err = tk.Exec("alter table fk_set_null add foreign key fk_pid2 (pid) references fk_renamed (id)")
+	c.Assert(terror.ErrorEqual(err, ddl.ErrFKNoIndexChild), IsTrue)
+	_, err = tk.Exec("create table fk_no_index (pid int, foreign key fk_pid (pid) references fk_set_null (pid))")
+	c.Assert(terror.ErrorEqual(err, ddl.ErrFKNoIndexParent), IsTrue)
+
+	// The parent row is locked, so the transaction conflicts with the one removing the parent row.
+	tk.MustExec("drop table if exists fk_lock_child, fk_lock_parent")
+	tk.MustExec("create table fk_lock_parent (id int primary key)")
+	tk.MustExec("create table fk_lock_child (pid int, foreign key fk_pid (pid) references fk_lock_parent (id))")
+	tk.MustExec("insert into fk_lock_parent values (1)")
+	tk.MustExec("begin")
+	tk.MustExec("insert into fk_lock_child values (1)")
+	tk2 := testkit.NewTestKit(c, s.store)
+	tk2.MustExec("use test")
+	tk2.MustExec("delete from fk_lock_parent")
+	_, err = tk.Exec("commit")
+	c.Assert(err, NotNil)
+	tk.MustQuery("select count(*) from fk_lock_child").Check(testkit.Rows("0"))
+
+	tk.MustExec("drop table fk_lock_child, fk_lock_parent")
+	tk.MustExec("drop table fk_tree, fk_child, fk_set_null, fk_restrict, fk_renamed")
+	tk.MustExec("drop database fk_db")
+}
+
 func (s *testSuite) TestUnion(c *C) {
 	plan.UseNewPlanner = true
 	defer testleak.AfterTest(c)()
@@ -1173,6 +1346,93 @@ func (s *testSuite) TestNewJoin(c *C) {
 	result = tk.MustQuery("select * from t1 a join t1 b on a.c1 = b.c1;")
 	result.Check(testkit.Rows("1 1", "1 1", "1 1", "1 1", "1 1", "1 1", "1 1", "1 1", "1 1"))
 
+	// The inner joins are reordered, the output columns keep the original order.
+	tk.MustExec("drop table if exists t1")
+	tk.MustExec("create table t1 (c1 int, c2 int)")
+	tk.MustExec("insert into t1 values (1,1), (2,2), (3,3)")
+	result = tk.MustQuery("select * from t1 join t2 join t3 on t1.c1 = t3.c1 and t2.c2 = t3.c2 where t3.c1 < 5")
+	result.Check(testkit.Rows("1 1 1 1 1 1"))
+	result = tk.MustQuery("select t3.c1, t1.c2 from t1 join t2 on t1.c1 = t2.c1 join t3 on t2.c2 = t3.c2 and t1.c1 != t3.c2")
+	result.Check(testkit.Rows())
+	result = tk.MustQuery("select t2.c1, t1.c1 from t1 join t2 on t1.c1 < t2.c1 join t3 on t2.c2 = t3.c2 order by t1.c1")
+	result.Check(testkit.Rows("5 1", "5 2", "5 3"))
+
+	plan.UseNewPlanner = false
+}
+
+func (s *testSuite) TestIndexLookUpJoin(c *C) {
+	plan.UseNewPlanner = true
+	defer testleak.AfterTest(c)()
+	defer func(batchSize int) {
+		executor.LookUpJoinBatchSize = batchSize
+	}(executor.LookUpJoinBatchSize)
+	executor.LookUpJoinBatchSize = 2
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t1")
+	tk.MustExec("drop table if exists t2")
+	tk.MustExec("create table t1 (c1 int, c2 int, c3 int, c4 int)")
+	tk.MustExec("create table t2 (c1 int primary key, c2 int, c3 int, index idx_c2_c3(c2, c3))")
+	tk.MustExec("insert into t1 values (1, 1, 10, 100), (2, 1, 20, 200), (3, 1, 30, 300), (null, 1, null, null), (1, 2, 10, 100), (5, 1, 50, 500)")
+	tk.MustExec("insert into t2 values (1, 10, 100), (2, 20, 200), (3, 10, 300), (4, 40, 400), (5, null, 500)")
+
+	// Look up the inner table by the handle.
+	result := tk.MustQuery("select t1.c1, t2.c2 from t1 join t2 on t1.c1 = t2.c1 where t1.c2 = 1 order by t1.c1")
+	result.Check(testkit.Rows("1 10", "2 20", "3 10", "5 <nil>"))
+	result = tk.MustQuery("select t1.c1, t2.c3 from t1 left join t2 on t1.c1 = t2.c1 and t2.c3 > 100 where t1.c2 = 1 order by t1.c1")
+	result.Check(testkit.Rows("<nil> <nil>", "1 <nil>", "2 200", "3 300", "5 500"))
+	result = tk.MustQuery("select t1.c1, t2.c1 from t2 right join t1 on t1.c1 = t2.c1 and t1.c1 < 3 where t1.c2 = 1 order by t1.c1")
+	result.Check(testkit.Rows("<nil> <nil>", "1 1", "2 2", "3 <nil>", "5 <nil>"))
+	result = tk.MustQuery("select t2.c1, t1.c1 from t2 join t1 on t1.c1 = t2.c1 and t1.c2 + t2.c3 > 200 where t1.c2 = 1 order by t1.c1")
+	result.Check(testkit.Rows("2 2", "3 3", "5 5"))
+
+	// Look up the inner table by the index prefix.
+	result = tk.MustQuery("select t1.c1, t2.c1 from t1 join t2 on t1.c3 = t2.c2 where t1.c2 = 1 and t1.c1 < 10 order by t1.c1, t2.c1")
+	result.Check(testkit.Rows("1 1", "1 3", "2 2"))
+	result = tk.MustQuery("select t1.c1, t2.c1 from t1 join t2 on t1.c3 = t2.c2 and t1.c4 = t2.c3 where t1.c2 = 1 and t1.c1 < 10 order by t1.c1")
+	result.Check(testkit.Rows("1 1", "2 2"))
+	result = tk.MustQuery("select t1.c1, t2.c1 from t1 left join t2 on t1.c3 = t2.c2 where t1.c2 = 1 and t1.c1 < 10 order by t1.c1, t2.c1")
+	result.Check(testkit.Rows("1 1", "1 3", "2 2", "3 <nil>", "5 <nil>"))
+
+	// The uncommitted changes are visible to the join.
+	tk.MustExec("begin")
+	tk.MustExec("insert into t2 values (6, 30, 600)")
+	result = tk.MustQuery("select t1.c1, t2.c1 from t1 join t2 on t1.c3 = t2.c2 where t1.c2 = 1 and t1.c1 < 10 order by t1.c1, t2.c1")
+	result.Check(testkit.Rows("1 1", "1 3", "2 2", "3 6"))
+	tk.MustExec("rollback")
+	plan.UseNewPlanner = false
+}
+
+func (s *testSuite) TestMergeJoin(c *C) {
+	plan.UseNewPlanner = true
+	defer testleak.AfterTest(c)()
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t1")
+	tk.MustExec("drop table if exists t2")
+	tk.MustExec("create table t1 (c1 int primary key, c2 int)")
+	tk.MustExec("create table t2 (c1 int primary key, c2 int)")
+	tk.MustExec("insert into t1 values (1, 1), (2, 2), (4, 4), (5, 5), (7, 7)")
+	tk.MustExec("insert into t2 values (2, 2), (3, 3), (4, 40), (5, 5), (8, 8)")
+
+	result := tk.MustQuery("select * from t1 join t2 on t1.c1 = t2.c1")
+	result.Check(testkit.Rows("2 2 2 2", "4 4 4 40", "5 5 5 5"))
+	result = tk.MustQuery("select * from t1 join t2 on t1.c1 = t2.c1 and t1.c2 = t2.c2")
+	result.Check(testkit.Rows("2 2 2 2", "5 5 5 5"))
+	result = tk.MustQuery("select * from t1 left join t2 on t1.c1 = t2.c1 and t1.c2 > 2")
+	result.Check(testkit.Rows("1 1 <nil> <nil>", "2 2 <nil> <nil>", "4 4 4 40", "5 5 5 5", "7 7 <nil> <nil>"))
+	result = tk.MustQuery("select * from t1 right join t2 on t1.c1 = t2.c1 and t1.c2 + t2.c2 > 10")
+	result.Check(testkit.Rows("<nil> <nil> 2 2", "<nil> <nil> 3 3", "4 4 4 40", "<nil> <nil> 5 5", "<nil> <nil> 8 8"))
+	result = tk.MustQuery("select * from t1 left join t2 on t1.c1 = t2.c1 and t2.c2 < 10")
+	result.Check(testkit.Rows("1 1 <nil> <nil>", "2 2 2 2", "4 4 <nil> <nil>", "5 5 5 5", "7 7 <nil> <nil>"))
+
+	// The uncommitted changes are merged in the handle order.
+	tk.MustExec("begin")
+	tk.MustExec("insert into t1 values (3, 3), (9, 9)")
+	tk.MustExec("delete from t2 where c1 = 4")
+	result = tk.MustQuery("select * from t1 join t2 on t1.c1 = t2.c1")
+	result.Check(testkit.Rows("2 2 2 2", "3 3 3 3", "5 5 5 5"))
+	tk.MustExec("rollback")
 	plan.UseNewPlanner = false
 }
 
@@ -1219,6 +1479,80 @@ func (s *testSuite) TestIndexReverseOrder(c *C) {
 	result.Check(testkit.Rows("0 2", "0 1", "0 0", "1 2", "1 1", "1 0", "2 2", "2 1", "2 0"))
 }
 
+func (s *testSuite) TestIndexDoubleRead(c *C) {
+	defer testleak.AfterTest(c)()
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t")
+	tk.MustExec("create table t (a int primary key, b int, c int, index idx_b (b))")
+	var (
+		values []string
+		asc    []string
+		desc   []string
+	)
+	for i := 0; i < 100; i++ {
+		// The handle order is different from the index order.
+		values = append(values, fmt.Sprintf("(%d, %d, %d)", i, 100-i, i%7))
+		asc = append(asc, fmt.Sprintf("%d %d", 100-(99-i), 99-i))
+		desc = append(desc, fmt.Sprintf("%d %d", 100-i, i))
+	}
+	tk.MustExec("insert t values " + strings.Join(values, ", "))
+	// The handles are looked up in several tasks, and the rows are returned in the index order.
+	tk.MustQuery("select b, a from t where b > 0 order by b").Check(testkit.Rows(asc...))
+	tk.MustQuery("select b, a from t where b > 0 order by b desc").Check(testkit.Rows(desc...))
+	tk.MustQuery("select b, a from t where b > 0 and c = 0 order by b limit 3").Check(testkit.Rows("2 98", "9 91", "16 84"))
+	tk.MustQuery("select b, a from t where b > 0 order by b desc limit 2").Check(testkit.Rows("100 0", "99 1"))
+	tk.MustQuery("select count(c), sum(c) from t where b > 50").Check(testkit.Rows("50 147"))
+}
+
+func (s *testSuite) TestIndexOnlyRead(c *C) {
+	defer testleak.AfterTest(c)()
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t")
+	tk.MustExec(`create table t (id int primary key, a int, b varchar(20), c datetime, d time, e decimal(10, 2), f int, g varchar(20),
+		index idx_abcde (a, b, c, d, e), unique index idx_f (f), index idx_g (g(5)))`)
+	tk.MustExec(`insert t values (1, 1, 'a', '2016-01-01 10:00:00', '10:00:00', 1.5, 10, 'abcdefg'),
+		(2, 2, 'b', '2016-02-01 11:00:00', '-11:00:00', 2.25, 20, 'bcdefgh'),
+		(3, 2, 'c', '2016-03-01 12:00:00', '12:00:00', -3.75, 30, 'cdefghi'),
+		(4, null, null, null, null, null, null, null)`)
+
+	tk.MustQuery("explain select id, a, c, d, e from t where a > 0 and b < 'c'").Check(testkit.RowsWithSep(" | ",
+		"1 | SIMPLE | t | range | idx_abcde | idx_abcde | -1 | <nil> | 0 | Using where; Using index"))
+	result := tk.MustQuery("select id, a, c, d, e from t where a > 0 and b < 'c'")
+	result.Check(testkit.Rows("1 1 2016-01-01 10:00:00 10:00:00 1.50", "2 2 2016-02-01 11:00:00 -11:00:00 2.25"))
+	result = tk.MustQuery("select a, id from t order by a desc, b desc")
+	result.Check(testkit.Rows("2 3", "2 2", "1 1", "<nil> 4"))
+	result = tk.MustQuery("select a, e from t where a = 2 and e > 0")
+	result.Check(testkit.Rows("2 2.25"))
+	result = tk.MustQuery("select count(*), sum(e) from t where a >= 1")
+	result.Check(testkit.Rows("3 0.00"))
+	result = tk.MustQuery("select f from t where f = 20")
+	result.Check(testkit.Rows("20"))
+
+	// The prefix index and the columns not in the index need to look up the table rows.
+	tk.MustQuery("explain select id from t where g = 'abcdefg'").Check(testkit.RowsWithSep(" | ",
+		"1 | SIMPLE | t | range | idx_g | idx_g | 5 | <nil> | 0 | Using where"))
+	tk.MustQuery("select id from t where g = 'abcdefg'").Check(testkit.Rows("1"))
+	tk.MustQuery("select f, id from t where f = 30 and g = 'cdefghi'").Check(testkit.Rows("30 3"))
+
+	// The dirty rows in the transaction are merged with the index rows.
+	tk.MustExec("begin")
+	tk.MustExec("insert t (id, a, b) values (5, 2, 'a')")
+	tk.MustExec("delete from t where id = 2")
+	tk.MustQuery("select a, id from t where a = 2").Check(testkit.Rows("2 5", "2 3"))
+	tk.MustExec("commit")
+
+	tk.MustExec("drop table if exists t")
+	tk.MustExec("create table t (a bigint unsigned primary key, b int, index idx_b (b))")
+	tk.MustExec("insert t values (18446744073709551615, 1), (1, 2)")
+	tk.MustQuery("select a, b from t where b > 0").Check(testkit.Rows("18446744073709551615 1", "1 2"))
+	tk.MustExec("update t set b = b + 10 where b = 2")
+	tk.MustExec("delete from t where b = 1")
+	tk.MustQuery("select a, b from t where b > 0").Check(testkit.Rows("1 12"))
+	tk.MustExec("admin check table t")
+}
+
 func (s *testSuite) TestTableReverseOrder(c *C) {
 	defer testleak.AfterTest(c)()
 	tk := testkit.NewTestKit(c, s.store)
@@ -1508,6 +1842,288 @@ func (s *testSuite) TestAggregation(c *C) {
 	plan.UseNewPlanner = false
 }
 
+func (s *testSuite) TestAggregationPushDown(c *C) {
+	defer testleak.AfterTest(c)()
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t")
+	tk.MustExec("create table t (k int, v int, f double, d decimal(10, 2), s varchar(10), index idx_k (k))")
+	tk.MustExec("insert t values (1, 1, 1.5, 1.25, 'a'), (1, 2, 2.5, 10.5, 'b'), (2, 3, null, 9.5, 'c')")
+	tk.MustExec("insert t values (2, null, 1, null, null), (3, null, null, null, null)")
+	result := tk.MustQuery("select k, count(v), sum(v), avg(v), max(v), min(v), group_concat(v) from t group by k order by k")
+	result.Check(testkit.Rows("1 2 3 1.5000 2 1 1,2", "2 1 3 3.0000 3 3 3", "3 0 <nil> <nil> <nil> <nil> <nil>"))
+	result = tk.MustQuery("select k, sum(f), avg(f), max(f), min(f) from t group by k order by k")
+	result.Check(testkit.Rows("1 4 2 2.5 1.5", "2 1 1 1 1", "3 <nil> <nil> <nil> <nil>"))
+	result = tk.MustQuery("select k, sum(d), max(d), min(d), group_concat(d) from t group by k order by k")
+	result.Check(testkit.Rows("1 11.75 10.50 1.25 1.25,10.50", "2 9.50 9.50 9.50 9.50", "3 <nil> <nil> <nil> <nil>"))
+	result = tk.MustQuery("select k, group_concat(s, v) from t use index(idx_k) where k < 3 group by k order by k")
+	result.Check(testkit.Rows("1 a1,b2", "2 c3"))
+	result = tk.MustQuery("select sum(v), avg(d), max(f), group_concat(s) from t")
+	result.Check(testkit.Rows("6 7.083333 2.5 a,b,c"))
+	result = tk.MustQuery("select sum(v), avg(d), max(f), group_concat(s) from t where k > 3")
+	result.Check(testkit.Rows("<nil> <nil> <nil> <nil>"))
+}
+
+func (s *testSuite) TestWindowFunction(c *C) {
+	plan.UseNewPlanner = true
+	defer func() {
+		plan.UseNewPlanner = false
+	}()
+	defer testleak.AfterTest(c)()
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t")
+	tk.MustExec("create table t (id int primary key, g int, v int)")
+	tk.MustExec("insert t values (1, 1, 10), (2, 1, 20), (3, 1, 20), (4, 2, 5), (5, 2, null)")
+	result := tk.MustQuery("select id, row_number() over (partition by g order by v desc) from t order by id")
+	result.Check(testkit.Rows("1 3", "2 1", "3 2", "4 1", "5 2"))
+	result = tk.MustQuery("select id, rank() over (partition by g order by v), dense_rank() over (order by v) from t order by id")
+	result.Check(testkit.Rows("1 1 3", "2 2 4", "3 2 4", "4 2 2", "5 1 1"))
+	result = tk.MustQuery("select id, lead(v) over (partition by g order by id), lag(v, 1, 0) over (partition by g order by id) from t order by id")
+	result.Check(testkit.Rows("1 20 0", "2 20 10", "3 <nil> 20", "4 <nil> 0", "5 <nil> 5"))
+	result = tk.MustQuery("select id, first_value(v) over (partition by g order by id rows between 1 preceding and current row) from t order by id")
+	result.Check(testkit.Rows("1 10", "2 10", "3 20", "4 5", "5 5"))
+	result = tk.MustQuery("select id, sum(v) over (order by id rows between 1 preceding and 1 following) from t order by id")
+	result.Check(testkit.Rows("1 30", "2 50", "3 45", "4 25", "5 5"))
+	// The huge offsets don't overflow.
+	result = tk.MustQuery("select id, count(v) over (order by id rows between 18446744073709551615 preceding and 9223372036854775808 following) from t order by id")
+	result.Check(testkit.Rows("1 4", "2 4", "3 4", "4 4", "5 4"))
+	result = tk.MustQuery("select id, sum(v) over (partition by g order by v), count(*) over (partition by g) from t order by id")
+	result.Check(testkit.Rows("1 10 3", "2 50 3", "3 50 3", "4 5 2", "5 <nil> 2"))
+	result = tk.MustQuery("select g, sum(v), rank() over (order by sum(v) desc) from t group by g")
+	result.Check(testkit.Rows("1 50 1", "2 5 2"))
+
+	plan.UseNewPlanner = false
+	_, err := tk.Exec("select row_number() over () from t")
+	c.Assert(err, NotNil)
+}
+
+func (s *testSuite) TestCommonTableExpression(c *C) {
+	plan.UseNewPlanner = true
+	defer func() {
+		plan.UseNewPlanner = false
+	}()
+	defer testleak.AfterTest(c)()
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t, emp")
+	tk.MustExec("create table t (a int, b int)")
+	tk.MustExec("insert t values (1, 10), (2, 20), (3, 30)")
+	result := tk.MustQuery("with c as (select a, b from t where a > 1) select b from c order by b")
+	result.Check(testkit.Rows("20", "30"))
+	result = tk.MustQuery("with c (x, y) as (select a, b from t) select c.y from c where c.x = 2")
+	result.Check(testkit.Rows("20"))
+	result = tk.MustQuery("with c as (select a from t), d as (select a + 1 as a from c) select c.a from c join d on c.a = d.a order by c.a")
+	result.Check(testkit.Rows("2", "3"))
+	result = tk.MustQuery("select y from (select a, b as y from t union all select 4, 40) z order by y")
+	result.Check(testkit.Rows("10", "20", "30", "40"))
+	result = tk.MustQuery("with c as (select a from t where a > 2) select a from c union all select a from t where a < 2")
+	result.Check(testkit.Rows("3", "1"))
+	result = tk.MustQuery("select x from (with c as (select a + 1 as x from t) select x from c where x > 2) z order by x")
+	result.Check(testkit.Rows("3", "4"))
+	result = tk.MustQuery("select y from (with c as (select a from t) select a as y from c where a < 2 union all select 5) z order by y")
+	result.Check(testkit.Rows("1", "5"))
+
+	result = tk.MustQuery("with recursive c (n) as (select 1 union all select n + 1 from c where n < 5) select n from c")
+	result.Check(testkit.Rows("1", "2", "3", "4", "5"))
+	result = tk.MustQuery("with recursive c (n) as (select 1 union all select n + 1 from c where n < 5) select sum(n) from c")
+	result.Check(testkit.Rows("15"))
+	// The cycle stops since UNION drops the rows which have been produced.
+	result = tk.MustQuery("with recursive c (n) as (select 1 union select n % 3 + 1 from c) select n from c order by n")
+	result.Check(testkit.Rows("1", "2", "3"))
+
+	tk.MustExec("create table emp (id int primary key, manager int)")
+	tk.MustExec("insert emp values (1, null), (2, 1), (3, 1), (4, 2), (5, 4), (6, 3)")
+	result = tk.MustQuery(`with recursive chain (id, lvl) as (
+		select id, 0 from emp where manager is null
+		union all
+		select emp.id, chain.lvl + 1 from emp join chain on emp.manager = chain.id
+	) select id, lvl from chain order by lvl, id`)
+	result.Check(testkit.Rows("1 0", "2 1", "3 1", "4 2", "6 2", "5 3"))
+
+	tk.MustExec("set @@cte_max_recursion_depth = 3")
+	result = tk.MustQuery("with recursive c (n) as (select 1 union all select n + 1 from c where n < 4) select n from c")
+	result.Check(testkit.Rows("1", "2", "3", "4"))
+	rs, err := tk.Exec("with recursive c (n) as (select 1 union all select n + 1 from c where n < 5) select n from c")
+	c.Assert(err, IsNil)
+	_, err = tidb.GetRows(rs)
+	c.Assert(terror.ErrorEqual(err, executor.ErrCTEMaxRecursionDepth), IsTrue)
+	rs, err = tk.Exec("with recursive c (n) as (select 1 union all select n + 1 from c) select n from c")
+	c.Assert(err, IsNil)
+	_, err = tidb.GetRows(rs)
+	c.Assert(terror.ErrorEqual(err, executor.ErrCTEMaxRecursionDepth), IsTrue)
+
+	plan.UseNewPlanner = false
+	_, err = tk.Exec("with c as (select a from t) select a from c")
+	c.Assert(err, NotNil)
+	_, err = tk.Exec("with c as (select a from t) select a from c union select 1")
+	c.Assert(err, NotNil)
+}
+
+func (s *testSuite) TestBuiltinPushDown(c *C) {
+	defer testleak.AfterTest(c)()
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t")
+	tk.MustExec("create table t (a int, b int, c varchar(20), d varchar(20))")
+	tk.MustExec("insert t values (1, 2, 'Abc', '2015-01-02'), (4, 3, 'de', '2016-03-04'), (7, null, null, null)")
+	sqls := []struct {
+		sql    string
+		result []string
+	}{
+		{"select a from t where a - b > 0", []string{"4"}},
+		{"select a from t where a * 2 = 8", []string{"4"}},
+		{"select a from t where a % 2 = 1", []string{"1", "7"}},
+		{"select a from t where a div 2 = 2", []string{"4"}},
+		{"select a from t where a & 3 = 3", []string{"7"}},
+		{"select a from t where a | b = 3 or a ^ 2 = 5", []string{"1", "7"}},
+		{"select a from t where a << 1 = 8 or -a = -7 or ~a = 18446744073709551614", []string{"1", "4", "7"}},
+		{"select a from t where if(b > 2, 1, 0)", []string{"4"}},
+		{"select a from t where ifnull(b, 10) = 10", []string{"7"}},
+		{"select a from t where coalesce(c, d, 'x') = 'x'", []string{"7"}},
+		{"select a from t where b is null", []string{"7"}},
+		{"select a from t where isnull(b) xor a = 1", []string{"1", "7"}},
+		{"select a from t where length(c) = 2 or lower(c) = 'abc'", []string{"1", "4"}},
+		{"select a from t where concat(c, '-', a) = 'de-4'", []string{"4"}},
+		{"select a from t where substring(c, 2) = 'bc'", []string{"1"}},
+		{"select a from t where year(d) = 2016 or month(d) = 1", []string{"1", "4"}},
+	}
+	for _, t := range sqls {
+		tk.MustQuery(t.sql).Check(testkit.Rows(t.result...))
+	}
+	plan.UseNewPlanner = true
+	for _, t := range sqls {
+		tk.MustQuery(t.sql).Check(testkit.Rows(t.result...))
+	}
+	plan.UseNewPlanner = false
+
+	// The new planner doesn't support the case expression yet.
+	result := tk.MustQuery("select a from t where case when b > 2 then 0 when a < 2 then 1 else 0 end = 1")
+	result.Check(testkit.Rows("1"))
+	result = tk.MustQuery("select a from t where case a when 4 then 'x' else 'y' end = 'x'")
+	result.Check(testkit.Rows("4"))
+}
+
+func (s *testSuite) TestTopNPushDown(c *C) {
+	plan.UseNewPlanner = true
+	defer testleak.AfterTest(c)()
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t")
+	tk.MustExec("create table t (a int primary key, b int, c varchar(10))")
+	tk.MustExec("insert t values (1, 3, 'x'), (2, null, 'y'), (3, 1, 'z'), (4, 3, null), (5, 2, 'x'), (6, 5, 'w')")
+	result := tk.MustQuery("select a from t order by b limit 2")
+	result.Check(testkit.Rows("2", "3"))
+	result = tk.MustQuery("select a from t order by b desc, a limit 1, 3")
+	result.Check(testkit.Rows("1", "4", "5"))
+	result = tk.MustQuery("select a from t where a > 1 order by c, a desc limit 3")
+	result.Check(testkit.Rows("4", "6", "5"))
+	result = tk.MustQuery("select a + 1 from t order by a - b, a limit 2")
+	result.Check(testkit.Rows("3", "2"))
+	result = tk.MustQuery("select a from t order by b limit 10, 2")
+	result.Check(testkit.Rows())
+	result = tk.MustQuery("select * from (select a, b from t order by b desc limit 3) k where k.a > 1 order by a")
+	result.Check(testkit.Rows("4 3", "6 5"))
+	tk.MustExec("begin")
+	tk.MustExec("insert t values (7, 0, 'v')")
+	result = tk.MustQuery("select a from t order by b limit 2")
+	result.Check(testkit.Rows("2", "7"))
+	tk.MustExec("rollback")
+	plan.UseNewPlanner = false
+}
+
+func (s *testSuite) TestBatchExecution(c *C) {
+	plan.UseNewPlanner = true
+	defer func() {
+		plan.UseNewPlanner = false
+		executor.UseBatchExecution = true
+	}()
+	defer testleak.AfterTest(c)()
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t1")
+	tk.MustExec("drop table if exists t2")
+	tk.MustExec("create table t1 (c1 int, c2 int)")
+	tk.MustExec("create table t2 (c1 int, c2 int)")
+	var values1, values2 []string
+	// More rows than a chunk, so the executors return several chunks.
+	for i := 0; i < 2500; i++ {
+		values1 = append(values1, fmt.Sprintf("(%d, %d)", i, i%10))
+		if i%2 == 0 {
+			values2 = append(values2, fmt.Sprintf("(%d, %d)", i, i%3))
+		}
+	}
+	values1 = append(values1, "(null, null)")
+	tk.MustExec("insert into t1 values " + strings.Join(values1, ", "))
+	tk.MustExec("insert into t2 values " + strings.Join(values2, ", "))
+
+	sqls := []string{
+		"select c1 + c2, c2 from t1 where c1 > 100 and c2 < 5",
+		"select c1 from t1 where c2 is null",
+		"select 1 + 2",
+		"select c2, count(*), sum(c1), max(c1) from t1 where c1 > 10 group by c2",
+		"select count(c1), avg(c2) from t1",
+		"select t1.c1, t2.c2 from t1 join t2 on t1.c1 = t2.c1 where t2.c2 > 0",
+		"select t1.c1, t2.c2 from t1 left join t2 on t1.c1 = t2.c1 and t2.c2 = 1 where t1.c2 < 3",
+		"select t2.c2, count(*) from t1 join t2 on t1.c1 = t2.c1 and t1.c2 < t2.c2 group by t2.c2",
+	}
+	for _, sql := range sqls {
+		executor.UseBatchExecution = false
+		expected := tk.MustQuery(sql).Rows()
+		executor.UseBatchExecution = true
+		c.Assert(tk.MustQuery(sql).Rows(), DeepEquals, expected, Commentf("for %s", sql))
+	}
+	tk.MustQuery("select count(*), sum(c1) from t1 where c1 >= 1000 and c2 < 2 group by c2").Check(
+		testkit.Rows("150 261750", "150 261900"))
+	tk.MustQuery("select count(*) from t1 join t2 on t1.c1 = t2.c1 where t1.c2 = 4").Check(testkit.Rows("250"))
+}
+
+func (s *testSuite) TestParallelAggregation(c *C) {
+	plan.UseNewPlanner = true
+	defer func() {
+		plan.UseNewPlanner = false
+	}()
+	defer testleak.AfterTest(c)()
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t")
+	tk.MustExec("create table t (c1 int, c2 int, c3 int)")
+	var values []string
+	for i := 0; i < 2000; i++ {
+		values = append(values, fmt.Sprintf("(%d, %d, %d)", i, i%37, i%5))
+	}
+	values = append(values, "(null, null, null)", "(2000, null, 1)")
+	tk.MustExec("insert into t values " + strings.Join(values, ", "))
+
+	sqls := []string{
+		"select c2, count(*), count(c1), sum(c1), avg(c1), max(c1), min(c1) from t group by c2",
+		"select c1, count(distinct c3), sum(distinct c3), group_concat(c1) from t where c1 < 500 group by c2, c3",
+		"select c3 + 1, count(*) from t group by c3 + 1",
+		"select count(*) from t where c1 > 3000 group by c2",
+	}
+	for _, sql := range sqls {
+		tk.MustExec("set @@tidb_agg_concurrency = 1")
+		expected := sortedRows(tk.MustQuery(sql).Rows())
+		tk.MustExec("set @@tidb_agg_concurrency = 4")
+		c.Assert(sortedRows(tk.MustQuery(sql).Rows()), DeepEquals, expected, Commentf("for %s", sql))
+		// The rows are aggregated serially under the memory quota.
+		tk.MustExec("set @@tidb_mem_quota_query = 1")
+		c.Assert(sortedRows(tk.MustQuery(sql).Rows()), DeepEquals, expected, Commentf("for %s", sql))
+		tk.MustExec("set @@tidb_mem_quota_query = 0")
+	}
+	tk.MustQuery("select count(*), sum(c1) from t").Check(testkit.Rows("2002 2001000"))
+	tk.MustQuery("select count(*) from t where c2 = 36 group by c2").Check(testkit.Rows("54"))
+}
+
+func sortedRows(rows [][]interface{}) []string {
+	strs := make([]string, 0, len(rows))
+	for _, row := range rows {
+		strs = append(strs, fmt.Sprintf("%v", row))
+	}
+	sort.Strings(strs)
+	return strs
+}
+
 func (s *testSuite) TestAdapterStatement(c *C) {
 	defer testleak.AfterTest(c)()
 	se, err := tidb.CreateSession(s.store)
@@ -45,6 +45,7 @@ type UpdateExec struct {
 	// Map for unique (Table, handle) pair.
 	updatedRowKeys map[table.Table]map[int64]struct{}
 	ctx            context.Context
+	fkChecker      *fkChecker
 
 	rows        []*Row          // The rows fetched from TableExec.
 	newRowsData [][]types.Datum // The new values to be set.
@@ -95,7 +96,7 @@ func (e *UpdateExec) Next() (*Row, error) {
 			continue
 		}
 		// Update row
-		err1 := updateRecord(e.ctx, handle, oldData, newTableData, columns, tbl, offset, false)
+		err1 := updateRecord(e.ctx, e.fkChecker, handle, oldData, newTableData, columns, tbl, offset, false)
 		if err1 != nil {
 			return nil, errors.Trace(err1)
 		}
@@ -154,7 +155,7 @@ func (e *UpdateExec) getTableOffset(t table.Table) int {
 	return 0
 }
 
-func updateRecord(ctx context.Context, h int64, oldData, newData []types.Datum, updateColumns map[int]*ast.Assignment, t table.Table, offset int, onDuplicateUpdate bool) error {
+func updateRecord(ctx context.Context, fkChecker *fkChecker, h int64, oldData, newData []types.Datum, updateColumns map[int]*ast.Assignment, t table.Table, offset int, onDuplicateUpdate bool) error {
 	if err := t.LockRow(ctx, h, false); err != nil {
 		return errors.Trace(err)
 	}
@@ -250,6 +251,13 @@ func updateRecord(ctx context.Context, h int64, oldData, newData []types.Datum,
 	dirtyDB.deleteRow(tid, h)
 	dirtyDB.addRow(tid, h, newData)
 
+	if err = fkChecker.checkParentRows(t, newData, touched); err != nil {
+		return errors.Trace(err)
+	}
+	if err = fkChecker.onParentRowUpdated(t, oldData, newData, 0); err != nil {
+		return errors.Trace(err)
+	}
+
 	// Record affected rows.
 	if !onDuplicateUpdate {
 		variable.GetSessionVars(ctx).AddAffectedRows(1)
@@ -276,6 +284,7 @@ type DeleteExec struct {
 	SelectExec Executor
 
 	ctx          context.Context
+	fkChecker    *fkChecker
 	Tables       []*ast.TableName
 	IsMultiTable bool
 
@@ -390,6 +399,9 @@ func (e *DeleteExec) removeRow(ctx context.Context, t table.Table, h int64, data
 		return errors.Trace(err)
 	}
 	getDirtyDB(ctx).deleteRow(t.Meta().ID, h)
+	if err = e.fkChecker.onParentRowRemoved(t, data, 0); err != nil {
+		return errors.Trace(err)
+	}
 	variable.GetSessionVars(ctx).AddAffectedRows(1)
 	return nil
 }
@@ -410,6 +422,7 @@ type InsertValues struct {
 	currRow      int
 	lastInsertID uint64
 	ctx          context.Context
+	fkChecker    *fkChecker
 	SelectExec   Executor
 
 	Table     table.Table
@@ -472,6 +485,9 @@ func (e *InsertExec) Next() (*Row, error) {
 		txn.DelOption(kv.PresumeKeyNotExists)
 		if err == nil {
 			getDirtyDB(e.ctx).addRow(e.Table.Meta().ID, h, row)
+			if err = e.fkChecker.checkParentRows(e.Table, row, nil); err != nil {
+				return nil, errors.Trace(err)
+			}
 			continue
 		}
 
@@ -788,7 +804,7 @@ func (e *InsertExec) onDuplicateUpdate(row []types.Datum, h int64, cols map[int]
 		}
 		newData[i] = val
 	}
-	if err = updateRecord(e.ctx, h, data, newData, cols, e.Table, 0, true); err != nil {
+	if err = updateRecord(e.ctx, e.fkChecker, h, data, newData, cols, e.Table, 0, true); err != nil {
 		return errors.Trace(err)
 	}
 	return nil
@@ -889,6 +905,9 @@ func (e *ReplaceExec) Next() (*Row, error) {
 		h, err1 := e.Table.AddRecord(e.ctx, row)
 		if err1 == nil {
 			getDirtyDB(e.ctx).addRow(e.Table.Meta().ID, h, row)
+			if err1 = e.fkChecker.checkParentRows(e.Table, row, nil); err1 != nil {
+				return nil, errors.Trace(err1)
+			}
 			idx++
 			continue
 		}
@@ -915,6 +934,9 @@ func (e *ReplaceExec) Next() (*Row, error) {
 			return nil, errors.Trace(err1)
 		}
 		getDirtyDB(e.ctx).deleteRow(e.Table.Meta().ID, h)
+		if err1 = e.fkChecker.onParentRowRemoved(e.Table, oldRow, 0); err1 != nil {
+			return nil, errors.Trace(err1)
+		}
 		variable.GetSessionVars(e.ctx).AddAffectedRows(1)
 	}
 
@@ -193,7 +193,7 @@ func (e *XSelectTableExec) doRequest() error {
 	// Aggregate Info
 	selReq.Aggregates = e.aggFuncs
 	selReq.GroupBy = e.byItems
-	e.result, err = xapi.Select(txn.GetClient(), selReq, defaultConcurrency)
+	e.result, err = xapi.Select(txn.GetClient(), selReq, defaultConcurrency, true)
 	if err != nil {
 		return errors.Trace(err)
 	}
@@ -213,6 +213,11 @@ type XSelectIndexExec struct {
 	where       *tipb.Expr
 	supportDesc bool
 
+	// indexOnly indicates that the rows are read from the index without looking up the table.
+	indexOnly bool
+	idxResult *xapi.SelectResult
+	subResult *xapi.SubResult
+
 	byItems []*tipb.ByItem
 	// Aggregate functions.
 	aggFuncs []*tipb.Expr
@@ -221,11 +226,14 @@ type XSelectIndexExec struct {
 	// Indicate if the exec is handling aggregate result.
 	aggregate bool
 
-	tasks      []*lookupTableTask
-	taskCursor int
-	indexOrder map[int64]int
-
-	mu sync.Mutex
+	// taskCh passes the lookup table tasks to Next in the index order.
+	taskCh chan *lookupTableTask
+	// workCh passes the lookup table tasks to the table workers.
+	workCh chan *lookupTableTask
+	// closeCh is closed when the executor is closed to stop the workers.
+	closeCh chan struct{}
+	workers sync.WaitGroup
+	task    *lookupTableTask
 }
 
 // BaseLookupTableTaskSize represents base number of handles for a lookupTableTask.
@@ -234,19 +242,13 @@ var BaseLookupTableTaskSize = 1024
 // MaxLookupTableTaskSize represents max number of handles for a lookupTableTask.
 var MaxLookupTableTaskSize = 1024
 
-const (
-	taskNew int = iota
-	taskRunning
-	taskDone
-)
-
 type lookupTableTask struct {
 	handles []int64
 	rows    []*Row
 	cursor  int
-	status  int
-	done    bool
-	doneCh  chan error
+	// indexOrder maps the handles to their positions in the index, it is nil if the order is not kept.
+	indexOrder map[int64]int
+	doneCh     chan error
 }
 
 // Schema implements Executor Schema interface.
@@ -274,175 +276,279 @@ func (e *XSelectIndexExec) Fields() []*ast.ResultField {
 
 // Next implements Executor Next interface.
 func (e *XSelectIndexExec) Next() (*Row, error) {
-	if e.tasks == nil {
-		startTs := time.Now()
-		handles, err := e.fetchHandles()
+	if e.indexOnly {
+		return e.nextFromIndex()
+	}
+	if e.taskCh == nil {
+		txn, err := e.ctx.GetTxn(false)
 		if err != nil {
 			return nil, errors.Trace(err)
 		}
-		log.Debugf("[TIME_INDEX_SCAN] time: %v handles: %d", time.Now().Sub(startTs), len(handles))
-		e.buildTableTasks(handles)
-		limitCount := int64(-1)
-		if e.indexPlan.LimitCount != nil {
-			limitCount = *e.indexPlan.LimitCount
-		}
-		concurrency := 2
-		if e.indexPlan.NoLimit {
-			concurrency = len(e.tasks)
-		} else if limitCount > int64(BaseLookupTableTaskSize) {
-			concurrency = int(limitCount/int64(BaseLookupTableTaskSize) + 1)
-		}
-		log.Debugf("[TIME_INDEX_TABLE_CONCURRENT_SCAN] start %d workers", concurrency)
-		e.runTableTasks(concurrency)
+		e.startWorkers(txn)
 	}
 	for {
-		if e.taskCursor >= len(e.tasks) {
-			return nil, nil
-		}
-		task := e.tasks[e.taskCursor]
-		if !task.done {
+		if e.task == nil {
+			task, ok := <-e.taskCh
+			if !ok {
+				return nil, nil
+			}
 			startTs := time.Now()
 			err := <-task.doneCh
 			if err != nil {
 				return nil, errors.Trace(err)
 			}
-			task.done = true
 			log.Debugf("[TIME_INDEX_TABLE_SCAN] time: %v handles: %d", time.Now().Sub(startTs), len(task.handles))
+			e.task = task
 		}
-		if task.cursor < len(task.rows) {
-			row := task.rows[task.cursor]
+		if e.task.cursor < len(e.task.rows) {
+			row := e.task.rows[e.task.cursor]
 			if !e.aggregate {
 				for i, field := range e.indexPlan.Fields() {
 					field.Expr.SetDatum(row.Data[i])
 				}
 			}
-			task.cursor++
+			e.task.cursor++
 			return row, nil
 		}
-		e.taskCursor++
+		e.task = nil
 	}
 }
 
-func (e *XSelectIndexExec) pickAndExecTask() {
+// nextFromIndex returns the next row built from the index result, the table rows are not looked up.
+func (e *XSelectIndexExec) nextFromIndex() (*Row, error) {
+	if e.idxResult == nil {
+		txn, err := e.ctx.GetTxn(false)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		e.idxResult, err = e.doIndexRequest(txn)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
 	for {
-		// Pick a new task.
-		e.mu.Lock()
-		var task *lookupTableTask
-		for _, t := range e.tasks {
-			if t.status == taskNew {
-				task = t
-				task.status = taskRunning
-				break
+		if e.subResult == nil {
+			var err error
+			e.subResult, err = e.idxResult.Next()
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+			if e.subResult == nil {
+				return nil, nil
 			}
 		}
-		e.mu.Unlock()
-		if task == nil {
-			// No more task to run.
-			break
+		h, vals, err := e.subResult.Next()
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if vals == nil {
+			e.subResult = nil
+			continue
+		}
+		data, err := indexRowData(e.indexPlan.Fields(), e.indexPlan.Index, e.table.Meta().PKIsHandle, h, vals)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		for i, field := range e.indexPlan.Fields() {
+			if field.Referenced {
+				field.Expr.SetDatum(data[i])
+			}
 		}
-		// Execute the picked task.
-		err := e.executeTask(task)
-		e.mu.Lock()
-		task.status = taskDone
-		e.mu.Unlock()
-		task.doneCh <- err
+		return resultRowToRow(e.table, h, data, e.indexPlan.TableAsName), nil
 	}
 }
 
-func (e *XSelectIndexExec) runTableTasks(n int) {
-	for i := 0; i < n && i < len(e.tasks); i++ {
-		go e.pickAndExecTask()
+// startWorkers starts the index worker and the table workers. The index worker reads the handles from the index
+// and sends them in batches to the table workers, so the table rows are looked up while the index is being read.
+// The tasks are passed to Next in the index order.
+func (e *XSelectIndexExec) startWorkers(txn kv.Transaction) {
+	concurrency := e.lookupConcurrency()
+	log.Debugf("[TIME_INDEX_TABLE_CONCURRENT_SCAN] start %d workers", concurrency)
+	e.taskCh = make(chan *lookupTableTask, concurrency)
+	e.workCh = make(chan *lookupTableTask, concurrency)
+	e.closeCh = make(chan struct{})
+	e.workers.Add(concurrency + 1)
+	go e.fetchHandles(txn, e.workCh, e.taskCh, e.closeCh)
+	for i := 0; i < concurrency; i++ {
+		go e.pickAndExecTask(txn, e.workCh, e.closeCh)
 	}
 }
 
-func (e *XSelectIndexExec) executeTask(task *lookupTableTask) error {
-	sort.Sort(int64Slice(task.handles))
-	tblResult, err := e.doTableRequest(task.handles)
+// lookupConcurrency returns the number of the table workers, fewer workers are used for a small limit.
+func (e *XSelectIndexExec) lookupConcurrency() int {
+	if e.indexPlan.NoLimit {
+		return defaultConcurrency
+	}
+	limitCount := e.indexPlan.LimitCount
+	if limitCount == nil || *limitCount <= int64(BaseLookupTableTaskSize) {
+		return 2
+	}
+	concurrency := int(*limitCount/int64(BaseLookupTableTaskSize) + 1)
+	if concurrency > defaultConcurrency {
+		concurrency = defaultConcurrency
+	}
+	return concurrency
+}
+
+// fetchHandles runs in the index worker, it builds the lookup table tasks from the index handles.
+// The error is passed to Next by a task.
+func (e *XSelectIndexExec) fetchHandles(txn kv.Transaction, workCh, taskCh chan<- *lookupTableTask, closeCh <-chan struct{}) {
+	defer func() {
+		close(workCh)
+		close(taskCh)
+		e.workers.Done()
+	}()
+	startTs := time.Now()
+	err := e.buildTableTasks(txn, workCh, taskCh, closeCh)
 	if err != nil {
-		return errors.Trace(err)
+		task := &lookupTableTask{doneCh: make(chan error, 1)}
+		task.doneCh <- errors.Trace(err)
+		select {
+		case taskCh <- task:
+		case <-closeCh:
+		}
+		return
 	}
-	task.rows, err = e.extractRowsFromTableResult(e.table, tblResult)
+	log.Debugf("[TIME_INDEX_SCAN] time: %v", time.Now().Sub(startTs))
+}
+
+// buildTableTasks reads the handles from the index, and sends the tasks with increasing batch size
+// to the table workers and Next.
+func (e *XSelectIndexExec) buildTableTasks(txn kv.Transaction, workCh, taskCh chan<- *lookupTableTask, closeCh <-chan struct{}) error {
+	idxResult, err := e.doIndexRequest(txn)
 	if err != nil {
 		return errors.Trace(err)
 	}
-	// TODO: check this
-	if !e.indexPlan.OutOfOrder && !e.aggregate {
-		// Restore the index order.
-		sorter := &rowsSorter{order: e.indexOrder, rows: task.rows}
-		if e.indexPlan.Desc && !e.supportDesc {
-			sort.Sort(sort.Reverse(sorter))
-		} else {
-			sort.Sort(sorter)
+	defer idxResult.Close()
+
+	var handles []int64
+	batchSize := BaseLookupTableTaskSize
+	// addTasks sends the handles in batches, the handles less than a batch are kept unless all is true.
+	// It returns false if the executor is closed.
+	addTasks := func(all bool) bool {
+		for len(handles) >= batchSize || (all && len(handles) > 0) {
+			size := batchSize
+			if size > len(handles) {
+				size = len(handles)
+			}
+			task := e.newLookupTableTask(handles[:size])
+			select {
+			case workCh <- task:
+			case <-closeCh:
+				return false
+			}
+			select {
+			case taskCh <- task:
+			case <-closeCh:
+				return false
+			}
+			handles = handles[size:]
+			if batchSize < MaxLookupTableTaskSize {
+				batchSize *= 2
+			}
 		}
+		return true
 	}
-	return nil
-}
 
-// Close implements Executor Close interface.
-func (e *XSelectIndexExec) Close() error {
+	if e.indexPlan.Desc && !e.supportDesc {
+		// The index is read in ascending order, so all the handles are fetched and then reversed.
+		handles, err = extractHandlesFromIndexResult(idxResult)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		for i, j := 0, len(handles)-1; i < j; i, j = i+1, j-1 {
+			handles[i], handles[j] = handles[j], handles[i]
+		}
+		addTasks(true)
+		return nil
+	}
+	for {
+		subResult, err := idxResult.Next()
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if subResult == nil {
+			break
+		}
+		subHandles, err := extractHandlesFromIndexSubResult(subResult)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		handles = append(handles, subHandles...)
+		if !addTasks(false) {
+			return nil
+		}
+	}
+	addTasks(true)
 	return nil
 }
 
-// fetchHandle fetches all handles from the index.
-// This should be optimized to fetch handles in batch.
-func (e *XSelectIndexExec) fetchHandles() ([]int64, error) {
-	idxResult, err := e.doIndexRequest()
-	if err != nil {
-		return nil, errors.Trace(err)
-	}
-	handles, err := extractHandlesFromIndexResult(idxResult)
-	if err != nil {
-		return nil, errors.Trace(err)
+func (e *XSelectIndexExec) newLookupTableTask(handles []int64) *lookupTableTask {
+	task := &lookupTableTask{
+		handles: handles,
+		doneCh:  make(chan error, 1),
 	}
-	// TODO: check this
 	if !e.indexPlan.OutOfOrder && !e.aggregate {
 		// Save the index order.
-		e.indexOrder = make(map[int64]int)
+		task.indexOrder = make(map[int64]int, len(handles))
 		for i, h := range handles {
-			e.indexOrder[h] = i
+			task.indexOrder[h] = i
 		}
 	}
-	return handles, nil
+	return task
 }
 
-func (e *XSelectIndexExec) buildTableTasks(handles []int64) {
-	// Build tasks with increasing batch size.
-	var taskSizes []int
-	total := len(handles)
-	batchSize := BaseLookupTableTaskSize
-	for total > 0 {
-		if batchSize > total {
-			batchSize = total
-		}
-		taskSizes = append(taskSizes, batchSize)
-		total -= batchSize
-		if batchSize < MaxLookupTableTaskSize {
-			batchSize *= 2
+// pickAndExecTask runs in the table workers, it executes the tasks until all the tasks are done or the executor is closed.
+func (e *XSelectIndexExec) pickAndExecTask(txn kv.Transaction, workCh <-chan *lookupTableTask, closeCh <-chan struct{}) {
+	defer e.workers.Done()
+	for {
+		select {
+		case task, ok := <-workCh:
+			if !ok {
+				return
+			}
+			task.doneCh <- e.executeTask(txn, task)
+		case <-closeCh:
+			return
 		}
 	}
-	if e.indexPlan.Desc && !e.supportDesc {
-		// Reverse tasks sizes.
-		for i := 0; i < len(taskSizes)/2; i++ {
-			j := len(taskSizes) - i - 1
-			taskSizes[i], taskSizes[j] = taskSizes[j], taskSizes[i]
-		}
+}
+
+func (e *XSelectIndexExec) executeTask(txn kv.Transaction, task *lookupTableTask) error {
+	sort.Sort(int64Slice(task.handles))
+	tblResult, err := e.doTableRequest(txn, task.handles)
+	if err != nil {
+		return errors.Trace(err)
 	}
-	e.tasks = make([]*lookupTableTask, len(taskSizes))
-	for i, size := range taskSizes {
-		task := &lookupTableTask{
-			handles: handles[:size],
-		}
-		task.doneCh = make(chan error, 1)
-		handles = handles[size:]
-		e.tasks[i] = task
+	defer tblResult.Close()
+	task.rows, err = e.extractRowsFromTableResult(e.table, tblResult)
+	if err != nil {
+		return errors.Trace(err)
 	}
-	if e.indexPlan.Desc && !e.supportDesc {
-		// Reverse tasks order.
-		for i := 0; i < len(e.tasks)/2; i++ {
-			j := len(e.tasks) - i - 1
-			e.tasks[i], e.tasks[j] = e.tasks[j], e.tasks[i]
-		}
+	if task.indexOrder != nil {
+		// Restore the index order.
+		sort.Sort(&rowsSorter{order: task.indexOrder, rows: task.rows})
 	}
+	return nil
+}
+
+// Close implements Executor Close interface.
+func (e *XSelectIndexExec) Close() error {
+	if e.idxResult != nil {
+		e.idxResult.Close()
+		e.idxResult = nil
+		e.subResult = nil
+	}
+	if e.closeCh != nil {
+		// Wait for the workers to exit, so the transaction is not used after the executor is closed.
+		close(e.closeCh)
+		e.workers.Wait()
+		e.closeCh = nil
+		e.taskCh = nil
+		e.workCh = nil
+		e.task = nil
+	}
+	return nil
 }
 
 type rowsSorter struct {
@@ -464,11 +570,7 @@ func (s *rowsSorter) Swap(i, j int) {
 	s.rows[i], s.rows[j] = s.rows[j], s.rows[i]
 }
 
-func (e *XSelectIndexExec) doIndexRequest() (*xapi.SelectResult, error) {
-	txn, err := e.ctx.GetTxn(false)
-	if err != nil {
-		return nil, errors.Trace(err)
-	}
+func (e *XSelectIndexExec) doIndexRequest(txn kv.Transaction) (*xapi.SelectResult, error) {
 	selIdxReq := new(tipb.SelectRequest)
 	startTs := txn.StartTS()
 	selIdxReq.StartTs = &startTs
@@ -484,22 +586,19 @@ func (e *XSelectIndexExec) doIndexRequest() (*xapi.SelectResult, error) {
 	for i, v := range e.indexPlan.Index.Columns {
 		fieldTypes[i] = &(e.table.Cols()[v.Offset].FieldType)
 	}
+	var err error
 	selIdxReq.Ranges, err = indexRangesToPBRanges(e.indexPlan.Ranges, fieldTypes)
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
 	concurrency := 1
 	if e.indexPlan.OutOfOrder {
-		concurrency = 10
+		concurrency = defaultConcurrency
 	}
-	return xapi.Select(txn.GetClient(), selIdxReq, concurrency)
+	return xapi.Select(txn.GetClient(), selIdxReq, concurrency, !e.indexPlan.OutOfOrder)
 }
 
-func (e *XSelectIndexExec) doTableRequest(handles []int64) (*xapi.SelectResult, error) {
-	txn, err := e.ctx.GetTxn(false)
-	if err != nil {
-		return nil, errors.Trace(err)
-	}
+func (e *XSelectIndexExec) doTableRequest(txn kv.Transaction, handles []int64) (*xapi.SelectResult, error) {
 	// The handles are not in original index order, so we can't push limit here.
 	selTableReq := new(tipb.SelectRequest)
 	startTs := txn.StartTS()
@@ -529,7 +628,8 @@ func (e *XSelectIndexExec) doTableRequest(handles []int64) (*xapi.SelectResult,
 	// Aggregate Info
 	selTableReq.Aggregates = e.aggFuncs
 	selTableReq.GroupBy = e.byItems
-	resp, err := xapi.Select(txn.GetClient(), selTableReq, defaultConcurrency)
+	// The index order of the rows is restored by the task, so the regions are not required to return in order.
+	resp, err := xapi.Select(txn.GetClient(), selTableReq, defaultConcurrency, false)
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
@@ -775,6 +875,12 @@ func (b *executorBuilder) exprToPBExpr(client kv.Client, expr ast.ExprNode, tn *
 		return b.subqueryToPBExpr(client, x)
 	case *ast.AggregateFuncExpr:
 		return b.aggFuncToPBExpr(client, x, tn)
+	case *ast.FuncCallExpr:
+		return b.funcCallToPBExpr(client, x, tn)
+	case *ast.IsNullExpr:
+		return b.isNullToPBExpr(client, x, tn)
+	case *ast.CaseExpr:
+		return b.caseToPBExpr(client, x, tn)
 	default:
 		return nil
 	}
@@ -807,6 +913,10 @@ func (b *executorBuilder) aggFuncToPBExpr(client kv.Client, af *ast.AggregateFun
 	case ast.AggFuncAvg:
 		tp = tipb.ExprType_Avg
 	}
+	if (tp == tipb.ExprType_Sum || tp == tipb.ExprType_Avg) && !isNumericType(af.Args[0].GetType().Tp) {
+		// The region can only sum numbers, the other values are converted and summed in the SQL layer.
+		return nil
+	}
 	if !client.SupportRequestType(kv.ReqTypeSelect, int64(tp)) {
 		return nil
 	}
@@ -822,6 +932,15 @@ func (b *executorBuilder) aggFuncToPBExpr(client kv.Client, af *ast.AggregateFun
 	return &tipb.Expr{Tp: tp.Enum(), Children: children}
 }
 
+func isNumericType(tp byte) bool {
+	switch tp {
+	case mysql.TypeTiny, mysql.TypeShort, mysql.TypeInt24, mysql.TypeLong, mysql.TypeLonglong,
+		mysql.TypeFloat, mysql.TypeDouble, mysql.TypeNewDecimal:
+		return true
+	}
+	return false
+}
+
 func (b *executorBuilder) columnNameToPBExpr(client kv.Client, column *ast.ColumnNameExpr, tn *ast.TableName) *tipb.Expr {
 	if !client.SupportRequestType(kv.ReqTypeSelect, int64(tipb.ExprType_ColumnRef)) {
 		return nil
@@ -913,10 +1032,30 @@ func (b *executorBuilder) binopToPBExpr(client kv.Client, expr *ast.BinaryOperat
 		tp = tipb.ExprType_And
 	case opcode.OrOr:
 		tp = tipb.ExprType_Or
+	case opcode.LogicXor:
+		tp = tipb.ExprType_Xor
 	case opcode.Plus:
 		tp = tipb.ExprType_Plus
+	case opcode.Minus:
+		tp = tipb.ExprType_Minus
+	case opcode.Mul:
+		tp = tipb.ExprType_Mul
 	case opcode.Div:
 		tp = tipb.ExprType_Div
+	case opcode.IntDiv:
+		tp = tipb.ExprType_IntDiv
+	case opcode.Mod:
+		tp = tipb.ExprType_Mod
+	case opcode.And:
+		tp = tipb.ExprType_BitAnd
+	case opcode.Or:
+		tp = tipb.ExprType_BitOr
+	case opcode.Xor:
+		tp = tipb.ExprType_BitXor
+	case opcode.LeftShift:
+		tp = tipb.ExprType_LeftShift
+	case opcode.RightShift:
+		tp = tipb.ExprType_RighShift
 	default:
 		return nil
 	}
@@ -970,19 +1109,142 @@ func (b *executorBuilder) likeToPBExpr(client kv.Client, expr *ast.PatternLikeEx
 }
 
 func (b *executorBuilder) unaryToPBExpr(client kv.Client, expr *ast.UnaryOperationExpr, tn *ast.TableName) *tipb.Expr {
+	var tp tipb.ExprType
 	switch expr.Op {
 	case opcode.Not:
-		if !client.SupportRequestType(kv.ReqTypeSelect, int64(tipb.ExprType_Not)) {
-			return nil
-		}
+		tp = tipb.ExprType_Not
+	case opcode.BitNeg:
+		tp = tipb.ExprType_BitNeg
+	case opcode.Minus:
+		tp = tipb.ExprType_Neg
 	default:
 		return nil
 	}
+	if !client.SupportRequestType(kv.ReqTypeSelect, int64(tp)) {
+		return nil
+	}
 	child := b.exprToPBExpr(client, expr.V, tn)
 	if child == nil {
 		return nil
 	}
-	return &tipb.Expr{Tp: tipb.ExprType_Not.Enum(), Children: []*tipb.Expr{child}}
+	return &tipb.Expr{Tp: tp.Enum(), Children: []*tipb.Expr{child}}
+}
+
+// funcNameToPBType maps the names of the builtin functions which can be evaluated by the coprocessor to the expression types.
+var funcNameToPBType = map[string]tipb.ExprType{
+	"abs":             tipb.ExprType_Abs,
+	"pow":             tipb.ExprType_Pow,
+	"power":           tipb.ExprType_Pow,
+	"coalesce":        tipb.ExprType_Coalesce,
+	"isnull":          tipb.ExprType_IsNull,
+	"if":              tipb.ExprType_If,
+	"ifnull":          tipb.ExprType_IfNull,
+	"nullif":          tipb.ExprType_NullIf,
+	"concat":          tipb.ExprType_Concat,
+	"concat_ws":       tipb.ExprType_ConcatWS,
+	"left":            tipb.ExprType_Left,
+	"length":          tipb.ExprType_Length,
+	"lower":           tipb.ExprType_Lower,
+	"lcase":           tipb.ExprType_Lower,
+	"repeat":          tipb.ExprType_Repeat,
+	"replace":         tipb.ExprType_Replace,
+	"upper":           tipb.ExprType_Upper,
+	"ucase":           tipb.ExprType_Upper,
+	"strcmp":          tipb.ExprType_Strcmp,
+	"substring":       tipb.ExprType_Substring,
+	"substring_index": tipb.ExprType_SubstringIndex,
+	"locate":          tipb.ExprType_Locate,
+	"trim":            tipb.ExprType_Trim,
+	"date":            tipb.ExprType_Date,
+	"year":            tipb.ExprType_Year,
+	"yearweek":        tipb.ExprType_YearWeek,
+	"month":           tipb.ExprType_Month,
+	"week":            tipb.ExprType_Week,
+	"weekday":         tipb.ExprType_Weekday,
+	"weekofyear":      tipb.ExprType_WeekOfYear,
+	"day":             tipb.ExprType_Day,
+	"dayname":         tipb.ExprType_DayName,
+	"dayofyear":       tipb.ExprType_DayOfYear,
+	"dayofmonth":      tipb.ExprType_DayOfMonth,
+	"dayofweek":       tipb.ExprType_DayOfWeek,
+	"hour":            tipb.ExprType_Hour,
+	"minute":          tipb.ExprType_Minute,
+	"second":          tipb.ExprType_Second,
+	"microsecond":     tipb.ExprType_Microsecond,
+	"extract":         tipb.ExprType_Extract,
+}
+
+func (b *executorBuilder) funcCallToPBExpr(client kv.Client, expr *ast.FuncCallExpr, tn *ast.TableName) *tipb.Expr {
+	tp, ok := funcNameToPBType[expr.FnName.L]
+	if !ok || !client.SupportRequestType(kv.ReqTypeSelect, int64(tp)) {
+		return nil
+	}
+	children := make([]*tipb.Expr, 0, len(expr.Args))
+	for _, arg := range expr.Args {
+		child := b.exprToPBExpr(client, arg, tn)
+		if child == nil {
+			return nil
+		}
+		children = append(children, child)
+	}
+	return &tipb.Expr{Tp: tp.Enum(), Children: children}
+}
+
+func (b *executorBuilder) isNullToPBExpr(client kv.Client, expr *ast.IsNullExpr, tn *ast.TableName) *tipb.Expr {
+	if !client.SupportRequestType(kv.ReqTypeSelect, int64(tipb.ExprType_IsNull)) {
+		return nil
+	}
+	child := b.exprToPBExpr(client, expr.Expr, tn)
+	if child == nil {
+		return nil
+	}
+	isNullExpr := &tipb.Expr{Tp: tipb.ExprType_IsNull.Enum(), Children: []*tipb.Expr{child}}
+	if !expr.Not {
+		return isNullExpr
+	}
+	return &tipb.Expr{Tp: tipb.ExprType_Not.Enum(), Children: []*tipb.Expr{isNullExpr}}
+}
+
+// caseToPBExpr converts the case expression to a searched case expression whose children are the pairs of
+// the when condition and the result, followed by the optional else result.
+// The value of a simple case expression is compared with the when values by EQ conditions.
+func (b *executorBuilder) caseToPBExpr(client kv.Client, expr *ast.CaseExpr, tn *ast.TableName) *tipb.Expr {
+	if !client.SupportRequestType(kv.ReqTypeSelect, int64(tipb.ExprType_Case)) {
+		return nil
+	}
+	var value *tipb.Expr
+	if expr.Value != nil {
+		if !client.SupportRequestType(kv.ReqTypeSelect, int64(tipb.ExprType_EQ)) {
+			return nil
+		}
+		value = b.exprToPBExpr(client, expr.Value, tn)
+		if value == nil {
+			return nil
+		}
+	}
+	children := make([]*tipb.Expr, 0, 2*len(expr.WhenClauses)+1)
+	for _, when := range expr.WhenClauses {
+		cond := b.exprToPBExpr(client, when.Expr, tn)
+		if cond == nil {
+			return nil
+		}
+		if value != nil {
+			cond = &tipb.Expr{Tp: tipb.ExprType_EQ.Enum(), Children: []*tipb.Expr{value, cond}}
+		}
+		result := b.exprToPBExpr(client, when.Result, tn)
+		if result == nil {
+			return nil
+		}
+		children = append(children, cond, result)
+	}
+	if expr.ElseClause != nil {
+		elseExpr := b.exprToPBExpr(client, expr.ElseClause, tn)
+		if elseExpr == nil {
+			return nil
+		}
+		children = append(children, elseExpr)
+	}
+	return &tipb.Expr{Tp: tipb.ExprType_Case.Enum(), Children: children}
 }
 
 func (b *executorBuilder) subqueryToPBExpr(client kv.Client, expr *ast.SubqueryExpr) *tipb.Expr {
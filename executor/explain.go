@@ -14,9 +14,11 @@
 package executor
 
 import (
+	"fmt"
 	"strconv"
 	"strings"
 
+	"github.com/juju/errors"
 	"github.com/pingcap/tidb/ast"
 	"github.com/pingcap/tidb/expression"
 	"github.com/pingcap/tidb/parser/opcode"
@@ -88,6 +90,10 @@ type ExplainExec struct {
 	fields   []*ast.ResultField
 	rows     []*Row
 	cursor   int
+
+	// analyzeExec executes the statement for EXPLAIN ANALYZE.
+	analyzeExec Executor
+	spillers    map[plan.Plan]spiller
 }
 
 // Schema implements Executor Schema interface.
@@ -103,7 +109,13 @@ func (e *ExplainExec) Fields() []*ast.ResultField {
 // Next implements Execution Next interface.
 func (e *ExplainExec) Next() (*Row, error) {
 	if e.rows == nil {
-		e.fetchRows()
+		if e.analyzeExec != nil {
+			if err := e.analyze(); err != nil {
+				return nil, errors.Trace(err)
+			}
+		} else {
+			e.fetchRows()
+		}
 	}
 	if e.cursor >= len(e.rows) {
 		return nil, nil
@@ -139,6 +151,54 @@ func (e *ExplainExec) fetchRows() {
 	}
 }
 
+// analyze executes the statement, and explains every operator of the plan with its spill count.
+func (e *ExplainExec) analyze() error {
+	for {
+		row, err := e.analyzeExec.Next()
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if row == nil {
+			break
+		}
+	}
+	if err := e.analyzeExec.Close(); err != nil {
+		return errors.Trace(err)
+	}
+	e.appendAnalyzeRows(e.StmtPlan)
+	return nil
+}
+
+func (e *ExplainExec) appendAnalyzeRows(p plan.Plan) {
+	var spillCount int64
+	if s, ok := e.spillers[p]; ok {
+		spillCount = int64(s.spillCount())
+	}
+	row := &Row{Data: types.MakeDatums(int64(len(e.rows)+1), explainOperator(p), spillCount)}
+	e.rows = append(e.rows, row)
+	for _, child := range p.GetChildren() {
+		e.appendAnalyzeRows(child)
+	}
+}
+
+func explainOperator(p plan.Plan) string {
+	switch x := p.(type) {
+	case *plan.Join:
+		switch x.Algorithm {
+		case plan.IndexLookUpJoinAlgorithm:
+			return "IndexLookUpJoin"
+		case plan.MergeJoinAlgorithm:
+			return "MergeJoin"
+		}
+		return "HashJoin"
+	case *plan.NewTableScan:
+		return fmt.Sprintf("TableScan(%s)", x.Table.Name.L)
+	case *plan.NewSort:
+		return "Sort"
+	}
+	return strings.TrimPrefix(fmt.Sprintf("%T", p), "*plan.")
+}
+
 // Close implements Executor Close interface.
 func (e *ExplainExec) Close() error {
 	return nil
@@ -209,6 +269,9 @@ func (v *explainVisitor) newEntryForIndexScan(p *plan.IndexScan) *explainEntry {
 	if len(p.AccessConditions)+len(p.FilterConditions) > 0 {
 		entry.extra = append(entry.extra, "Using where")
 	}
+	if p.IndexOnly {
+		entry.extra = append(entry.extra, "Using index")
+	}
 
 	v.setSortExtra(entry)
 	return entry
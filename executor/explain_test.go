@@ -40,7 +40,7 @@ func (s *testSuite) TestExplain(c *C) {
 		{
 			"select * from t1 order by c2",
 			[]string{
-				"1 | SIMPLE | t1 | index | c2 | c2 | <nil> | <nil> | 0 | <nil>",
+				"1 | SIMPLE | t1 | index | c2 | c2 | <nil> | <nil> | 0 | Using index",
 			},
 		},
 		{
@@ -64,7 +64,19 @@ func (s *testSuite) TestExplain(c *C) {
 		{
 			"select * from t1 where t1.c2 = 1",
 			[]string{
-				"1 | SIMPLE | t1 | range | c2 | c2 | -1 | <nil> | 0 | Using where",
+				"1 | SIMPLE | t1 | range | c2 | c2 | -1 | <nil> | 0 | Using where; Using index",
+			},
+		},
+		{
+			"select c1 from t2 where t2.c1 = 1",
+			[]string{
+				"1 | SIMPLE | t2 | const | c1 | c1 | -1 | <nil> | 0 | Using where; Using index",
+			},
+		},
+		{
+			"select c2 from t2 where t2.c1 = 1",
+			[]string{
+				"1 | SIMPLE | t2 | const | c1 | c1 | -1 | <nil> | 0 | Using where",
 			},
 		},
 		{
@@ -83,7 +95,7 @@ func (s *testSuite) TestExplain(c *C) {
 		{
 			"delete from t1 where t1.c2 = 1",
 			[]string{
-				"1 | SIMPLE | t1 | range | c2 | c2 | -1 | <nil> | 0 | Using where",
+				"1 | SIMPLE | t1 | range | c2 | c2 | -1 | <nil> | 0 | Using where; Using index",
 			},
 		},
 	}
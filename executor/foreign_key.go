@@ -0,0 +1,438 @@
+// Copyright 2016 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/context"
+	"github.com/pingcap/tidb/infoschema"
+	"github.com/pingcap/tidb/kv"
+	"github.com/pingcap/tidb/model"
+	"github.com/pingcap/tidb/sessionctx/variable"
+	"github.com/pingcap/tidb/table"
+	"github.com/pingcap/tidb/terror"
+	"github.com/pingcap/tidb/util/types"
+)
+
+// maxFKCascadeDepth is the max depth of the cascading foreign key actions, the same as MySQL.
+const maxFKCascadeDepth = 15
+
+// fkChecker checks the foreign keys and applies their actions for the rows changed by a statement.
+// The tables are resolved by the info schema of the statement.
+type fkChecker struct {
+	ctx context.Context
+	is  infoschema.InfoSchema
+
+	// schemas maps the table IDs to their databases, it is built for the first table which has foreign keys.
+	schemas map[int64]*model.DBInfo
+}
+
+func newFKChecker(ctx context.Context, is infoschema.InfoSchema) *fkChecker {
+	return &fkChecker{ctx: ctx, is: is}
+}
+
+// enabled returns whether the foreign keys are checked.
+func (c *fkChecker) enabled() bool {
+	return variable.GetSessionVars(c.ctx).ForeignKeyChecks
+}
+
+// schemaOfTable returns the database of the table, the map of the tables is built on the first call.
+func (c *fkChecker) schemaOfTable(tableID int64) (*model.DBInfo, bool) {
+	if c.schemas == nil {
+		c.schemas = make(map[int64]*model.DBInfo)
+		for _, dbInfo := range c.is.AllSchemas() {
+			for _, tblInfo := range dbInfo.Tables {
+				c.schemas[tblInfo.ID] = dbInfo
+			}
+		}
+	}
+	dbInfo, ok := c.schemas[tableID]
+	return dbInfo, ok
+}
+
+// checkParentRows checks that the parent rows referenced by the foreign keys of t exist for row.
+// Only the foreign keys on the touched columns are checked, all of them are checked if touched is nil.
+func (c *fkChecker) checkParentRows(t table.Table, row []types.Datum, touched map[int]bool) error {
+	if len(t.Meta().ForeignKeys) == 0 || !c.enabled() {
+		return nil
+	}
+	dbInfo, ok := c.schemaOfTable(t.Meta().ID)
+	if !ok {
+		return nil
+	}
+
+	for _, fk := range t.Meta().ForeignKeys {
+		if fk.State != model.StatePublic {
+			continue
+		}
+		cols, err := findFKCols(t, fk.Cols)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if touched != nil && !isAnyColTouched(cols, touched) {
+			continue
+		}
+		vals := getColValues(row, cols)
+		if hasNullValue(vals) {
+			// MySQL doesn't check the foreign key if any of its columns is NULL.
+			continue
+		}
+
+		parent, err := c.is.TableByName(getFKRefSchema(dbInfo, fk), fk.RefTable)
+		if err != nil {
+			return ErrNoReferencedRow.Gen("Cannot add or update a child row: a foreign key constraint fails (%s)",
+				fkConstraintString(dbInfo, t, fk))
+		}
+		refCols, err := findFKCols(parent, fk.RefCols)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		handles, _, err := fetchRowsByCols(c.ctx, parent, refCols, vals, 1)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if len(handles) == 0 {
+			return ErrNoReferencedRow.Gen("Cannot add or update a child row: a foreign key constraint fails (%s)",
+				fkConstraintString(dbInfo, t, fk))
+		}
+		// Lock the parent row like SELECT FOR UPDATE, so that the transaction conflicts with
+		// the concurrent one which removes or updates the parent row.
+		if err = parent.LockRow(c.ctx, handles[0], true); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	return nil
+}
+
+// onParentRowRemoved applies the ON DELETE actions of the foreign keys referencing t after row is removed.
+func (c *fkChecker) onParentRowRemoved(t table.Table, row []types.Datum, depth int) error {
+	return errors.Trace(c.onParentRowChanged(t, row, nil, depth))
+}
+
+// onParentRowUpdated applies the ON UPDATE actions of the foreign keys referencing t after oldRow is updated to newRow.
+func (c *fkChecker) onParentRowUpdated(t table.Table, oldRow, newRow []types.Datum, depth int) error {
+	return errors.Trace(c.onParentRowChanged(t, oldRow, newRow, depth))
+}
+
+// onParentRowChanged applies the actions of the foreign keys referencing t to the child rows of oldRow.
+// The row is removed if newRow is nil, otherwise it is updated to newRow.
+func (c *fkChecker) onParentRowChanged(t table.Table, oldRow, newRow []types.Datum, depth int) error {
+	// The foreign keys referencing the table are indexed by the info schema.
+	refs := c.is.ReferringFKs(t.Meta().ID)
+	if len(refs) == 0 || !c.enabled() {
+		return nil
+	}
+	for _, ref := range refs {
+		if err := c.onChildRowsChanged(t, ref, oldRow, newRow, depth); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	return nil
+}
+
+// onChildRowsChanged applies the action of the foreign key ref to the child rows of oldRow in t.
+func (c *fkChecker) onChildRowsChanged(t table.Table, ref *infoschema.ReferringFK, oldRow, newRow []types.Datum, depth int) error {
+	fk, child := ref.FK, ref.Child
+	refCols, err := findFKCols(t, fk.RefCols)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	oldVals := getColValues(oldRow, refCols)
+	if hasNullValue(oldVals) {
+		return nil
+	}
+
+	action := ast.ReferOptionType(fk.OnDelete)
+	var newVals []types.Datum
+	if newRow != nil {
+		newVals = getColValues(newRow, refCols)
+		equal, err := types.EqualDatums(oldVals, newVals)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if equal {
+			return nil
+		}
+		action = ast.ReferOptionType(fk.OnUpdate)
+	}
+
+	cols, err := findFKCols(child, fk.Cols)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	handles, rows, err := fetchRowsByCols(c.ctx, child, cols, oldVals, 0)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if len(handles) == 0 {
+		return nil
+	}
+
+	switch action {
+	case ast.ReferOptionCascade, ast.ReferOptionSetNull:
+		if depth >= maxFKCascadeDepth {
+			return ErrRowIsReferenced.Gen("Foreign key cascade delete/update exceeds max depth of %d", maxFKCascadeDepth)
+		}
+	default:
+		// RESTRICT, NO ACTION and the omitted option reject the change of the parent row.
+		return ErrRowIsReferenced.Gen("Cannot delete or update a parent row: a foreign key constraint fails (%s)",
+			fkConstraintString(ref.ChildSchema, child, fk))
+	}
+
+	for i, h := range handles {
+		switch {
+		case action == ast.ReferOptionSetNull:
+			err = c.updateChildRow(child, h, rows[i], cols, nil, depth+1)
+		case newRow == nil:
+			err = c.removeChildRow(child, h, rows[i], depth+1)
+		default:
+			err = c.updateChildRow(child, h, rows[i], cols, newVals, depth+1)
+		}
+		if err != nil {
+			return errors.Trace(err)
+		}
+	}
+	return nil
+}
+
+// removeChildRow removes a child row by the ON DELETE CASCADE action.
+func (c *fkChecker) removeChildRow(t table.Table, h int64, row []types.Datum, depth int) error {
+	err := t.RemoveRecord(c.ctx, h, row)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	getDirtyDB(c.ctx).deleteRow(t.Meta().ID, h)
+	return errors.Trace(c.onParentRowRemoved(t, row, depth))
+}
+
+// updateChildRow sets the foreign key columns of a child row to vals by the CASCADE action,
+// or to NULL by the SET NULL action if vals is nil.
+func (c *fkChecker) updateChildRow(t table.Table, h int64, oldRow []types.Datum, cols []*table.Column, vals []types.Datum, depth int) error {
+	newRow := make([]types.Datum, len(oldRow))
+	copy(newRow, oldRow)
+	touched := make(map[int]bool, len(cols))
+	handleChanged := false
+	for i, col := range cols {
+		if vals != nil {
+			val, err := table.CastValue(c.ctx, vals[i], col)
+			if err != nil {
+				return errors.Trace(err)
+			}
+			newRow[col.Offset] = val
+		} else {
+			newRow[col.Offset].SetNull()
+		}
+		touched[col.Offset] = true
+		if col.IsPKHandleColumn(t.Meta()) {
+			handleChanged = true
+		}
+	}
+	if err := table.CheckNotNull(t.Cols(), newRow); err != nil {
+		return errors.Trace(err)
+	}
+
+	dirtyDB := getDirtyDB(c.ctx)
+	tid := t.Meta().ID
+	if handleChanged {
+		err := t.RemoveRecord(c.ctx, h, oldRow)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		dirtyDB.deleteRow(tid, h)
+		h, err = t.AddRecord(c.ctx, newRow)
+		if err != nil {
+			return errors.Trace(err)
+		}
+	} else {
+		err := t.UpdateRecord(c.ctx, h, oldRow, newRow, touched)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		dirtyDB.deleteRow(tid, h)
+	}
+	dirtyDB.addRow(tid, h, newRow)
+	return errors.Trace(c.onParentRowUpdated(t, oldRow, newRow, depth))
+}
+
+// fetchRowsByCols returns at most limit rows of t whose cols are equal to vals, there is no limit if limit <= 0.
+// It looks up the rows by the handle or an index on cols, the foreign keys can't be checked without the index.
+func fetchRowsByCols(ctx context.Context, t table.Table, cols []*table.Column, vals []types.Datum, limit int) ([]int64, [][]types.Datum, error) {
+	txn, err := ctx.GetTxn(false)
+	if err != nil {
+		return nil, nil, errors.Trace(err)
+	}
+	casted := make([]types.Datum, len(vals))
+	for i, val := range vals {
+		casted[i], err = table.CastValue(ctx, val, cols[i])
+		if err != nil {
+			return nil, nil, errors.Trace(err)
+		}
+	}
+
+	var (
+		handles []int64
+		rows    [][]types.Datum
+	)
+	// addRow adds the row if it matches and returns whether to fetch more rows.
+	addRow := func(h int64, row []types.Datum) (bool, error) {
+		match, err := types.EqualDatums(getColValues(row, cols), casted)
+		if err != nil {
+			return false, errors.Trace(err)
+		}
+		if match {
+			handles = append(handles, h)
+			rows = append(rows, row)
+		}
+		return limit <= 0 || len(handles) < limit, nil
+	}
+
+	if len(cols) == 1 && cols[0].IsPKHandleColumn(t.Meta()) {
+		h, err := casted[0].ToInt64()
+		if err != nil {
+			return nil, nil, errors.Trace(err)
+		}
+		_, err = txn.Get(t.RecordKey(h, nil))
+		if terror.ErrorEqual(err, kv.ErrNotExist) {
+			return nil, nil, nil
+		} else if err != nil {
+			return nil, nil, errors.Trace(err)
+		}
+		row, err := t.Row(ctx, h)
+		if err != nil {
+			return nil, nil, errors.Trace(err)
+		}
+		_, err = addRow(h, row)
+		return handles, rows, errors.Trace(err)
+	}
+
+	idx := findIndexOnCols(t, cols)
+	if idx == nil {
+		return nil, nil, ErrFKNoIndex.Gen("Missing index on the foreign key columns of table %s", t.Meta().Name)
+	}
+	iter, _, err := idx.Seek(txn, casted)
+	if err != nil {
+		return nil, nil, errors.Trace(err)
+	}
+	defer iter.Close()
+	for {
+		idxVals, h, err := iter.Next()
+		if terror.ErrorEqual(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, nil, errors.Trace(err)
+		}
+		equal, err := types.EqualDatums(idxVals[:len(casted)], casted)
+		if err != nil {
+			return nil, nil, errors.Trace(err)
+		}
+		if !equal {
+			break
+		}
+		row, err := t.Row(ctx, h)
+		if err != nil {
+			return nil, nil, errors.Trace(err)
+		}
+		more, err := addRow(h, row)
+		if !more || err != nil {
+			return handles, rows, errors.Trace(err)
+		}
+	}
+	return handles, rows, nil
+}
+
+// findIndexOnCols finds a public index whose leading columns are cols.
+func findIndexOnCols(t table.Table, cols []*table.Column) table.Index {
+	for _, idx := range t.Indices() {
+		idxInfo := idx.Meta()
+		if idxInfo.State != model.StatePublic || len(idxInfo.Columns) < len(cols) {
+			continue
+		}
+		match := true
+		for i, col := range cols {
+			if idxInfo.Columns[i].Name.L != col.Name.L {
+				match = false
+				break
+			}
+		}
+		if match {
+			return idx
+		}
+	}
+	return nil
+}
+
+// getFKRefSchema returns the database of the table referenced by fk, dbInfo is the database of the child table.
+func getFKRefSchema(dbInfo *model.DBInfo, fk *model.FKInfo) model.CIStr {
+	if fk.RefSchema.L != "" {
+		return fk.RefSchema
+	}
+	return dbInfo.Name
+}
+
+func findFKCols(t table.Table, names []model.CIStr) ([]*table.Column, error) {
+	cols := make([]*table.Column, 0, len(names))
+	for _, name := range names {
+		col := table.FindCol(t.Cols(), name.O)
+		if col == nil {
+			return nil, infoschema.ErrColumnNotExists.Gen("foreign key column %s doesn't exist in table %s", name, t.Meta().Name)
+		}
+		cols = append(cols, col)
+	}
+	return cols, nil
+}
+
+func isAnyColTouched(cols []*table.Column, touched map[int]bool) bool {
+	for _, col := range cols {
+		if touched[col.Offset] {
+			return true
+		}
+	}
+	return false
+}
+
+func getColValues(row []types.Datum, cols []*table.Column) []types.Datum {
+	vals := make([]types.Datum, 0, len(cols))
+	for _, col := range cols {
+		vals = append(vals, row[col.Offset])
+	}
+	return vals
+}
+
+func hasNullValue(vals []types.Datum) bool {
+	for _, val := range vals {
+		if val.IsNull() {
+			return true
+		}
+	}
+	return false
+}
+
+// fkConstraintString returns the description of the foreign key in the error message like MySQL.
+func fkConstraintString(dbInfo *model.DBInfo, t table.Table, fk *model.FKInfo) string {
+	cols := make([]string, 0, len(fk.Cols))
+	for _, col := range fk.Cols {
+		cols = append(cols, "`"+col.O+"`")
+	}
+	refCols := make([]string, 0, len(fk.RefCols))
+	for _, col := range fk.RefCols {
+		refCols = append(refCols, "`"+col.O+"`")
+	}
+	return fmt.Sprintf("`%s`.`%s`, CONSTRAINT `%s` FOREIGN KEY (%s) REFERENCES `%s` (%s)", dbInfo.Name.O, t.Meta().Name.O,
+		fk.Name.O, strings.Join(cols, ", "), fk.RefTable.O, strings.Join(refCols, ", "))
+}
@@ -0,0 +1,353 @@
+// Copyright 2016 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"sort"
+
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/context"
+	"github.com/pingcap/tidb/expression"
+	"github.com/pingcap/tidb/model"
+	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/plan"
+	"github.com/pingcap/tidb/util/codec"
+	"github.com/pingcap/tidb/util/types"
+	"github.com/pingcap/tidb/xapi"
+	"github.com/pingcap/tipb/go-tipb"
+)
+
+// LookUpJoinBatchSize is the number of outer rows whose join keys are looked up in one batch.
+var LookUpJoinBatchSize = 256
+
+// IndexLookUpJoinExec implements the index nested loop join algorithm.
+// It reads a batch of outer rows, looks up the inner table by the handles or the index prefixes
+// built from the join keys of the outer rows, and joins the batch with the inner rows by a hash table.
+type IndexLookUpJoinExec struct {
+	schema    expression.Schema
+	ctx       context.Context
+	outerExec Executor
+	// innerExec scans the inner table by the ranges built from the join keys.
+	innerExec *NewTableScanExec
+	// innerIndex is nil if the inner table is looked up by the handle.
+	innerIndex    *model.IndexInfo
+	lookUpKeys    []*expression.Column
+	outerHashKeys []*expression.Column
+	innerHashKeys []*expression.Column
+	outerFilter   expression.Expression
+	innerFilter   expression.Expression
+	otherFilter   expression.Expression
+	outer         bool
+	leftInner     bool
+
+	outerDone   bool
+	resultRows  []*Row
+	resultIndex int
+}
+
+// Schema implements Executor Schema interface.
+func (e *IndexLookUpJoinExec) Schema() expression.Schema {
+	return e.schema
+}
+
+// Fields implements Executor Fields interface.
+func (e *IndexLookUpJoinExec) Fields() []*ast.ResultField {
+	return nil
+}
+
+// Close implements Executor Close interface.
+func (e *IndexLookUpJoinExec) Close() error {
+	e.outerDone = false
+	e.resultRows = nil
+	e.resultIndex = 0
+	err := e.innerExec.Close()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	return e.outerExec.Close()
+}
+
+// Next implements Executor Next interface.
+func (e *IndexLookUpJoinExec) Next() (*Row, error) {
+	for e.resultIndex >= len(e.resultRows) {
+		if e.outerDone {
+			return nil, nil
+		}
+		if err := e.joinNextBatch(); err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+	row := e.resultRows[e.resultIndex]
+	e.resultIndex++
+	return row, nil
+}
+
+// joinNextBatch reads the next batch of outer rows and joins them with the inner rows looked up by their join keys.
+func (e *IndexLookUpJoinExec) joinNextBatch() error {
+	e.resultRows = e.resultRows[:0]
+	e.resultIndex = 0
+	var outerRows []*Row
+	// outerMatched[i] is false if the i-th outer row doesn't satisfy the outer filter.
+	var outerMatched []bool
+	var keys [][]types.Datum
+	keySet := make(map[string]struct{})
+	for len(outerRows) < LookUpJoinBatchSize {
+		row, err := e.outerExec.Next()
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if row == nil {
+			e.outerDone = true
+			break
+		}
+		matched := true
+		if e.outerFilter != nil {
+			matched, err = expression.EvalBool(e.outerFilter, row.Data, e.ctx)
+			if err != nil {
+				return errors.Trace(err)
+			}
+		}
+		outerRows = append(outerRows, row)
+		outerMatched = append(outerMatched, matched)
+		if !matched {
+			continue
+		}
+		key, err := e.evalLookUpKey(row)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if key == nil {
+			continue
+		}
+		encoded, err := codec.EncodeValue(nil, key...)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if _, ok := keySet[string(encoded)]; !ok {
+			keySet[string(encoded)] = struct{}{}
+			keys = append(keys, key)
+		}
+	}
+	innerRows, err := e.lookUpInnerRows(keys)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	hashTable := make(map[string][]*Row, len(innerRows))
+	for _, row := range innerRows {
+		hashKey, hasNull, err := e.getHashKey(e.innerHashKeys, row)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if hasNull {
+			continue
+		}
+		hashTable[string(hashKey)] = append(hashTable[string(hashKey)], row)
+	}
+	for i, outerRow := range outerRows {
+		var matchedRows []*Row
+		if outerMatched[i] {
+			matchedRows, err = e.joinOuterRow(hashTable, outerRow)
+			if err != nil {
+				return errors.Trace(err)
+			}
+		}
+		if len(matchedRows) == 0 && e.outer {
+			matchedRows = append(matchedRows, e.joinRows(outerRow, e.nullInnerRow()))
+		}
+		e.resultRows = append(e.resultRows, matchedRows...)
+	}
+	return nil
+}
+
+// evalLookUpKey evaluates the look up key of an outer row, it returns nil if the key contains null,
+// because null never equals to anything.
+func (e *IndexLookUpJoinExec) evalLookUpKey(row *Row) ([]types.Datum, error) {
+	key := make([]types.Datum, 0, len(e.lookUpKeys))
+	for _, col := range e.lookUpKeys {
+		d, err := col.Eval(row.Data, e.ctx)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if d.IsNull() {
+			return nil, nil
+		}
+		key = append(key, d)
+	}
+	return key, nil
+}
+
+// lookUpInnerRows fetches the inner rows which satisfy the inner filter by the look up keys.
+func (e *IndexLookUpJoinExec) lookUpInnerRows(keys [][]types.Datum) ([]*Row, error) {
+	if len(keys) == 0 {
+		return nil, nil
+	}
+	var handles []int64
+	if e.innerIndex == nil {
+		handles = e.convertToHandles(keys)
+	} else {
+		var err error
+		handles, err = e.fetchHandlesByIndex(keys)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+	if len(handles) == 0 {
+		return nil, nil
+	}
+	sort.Sort(int64Slice(handles))
+	ranges := make([]plan.TableRange, 0, len(handles))
+	for i, h := range handles {
+		if i > 0 && h == handles[i-1] {
+			continue
+		}
+		ranges = append(ranges, plan.TableRange{LowVal: h, HighVal: h})
+	}
+	e.innerExec.ranges = ranges
+	defer e.innerExec.Close()
+	var rows []*Row
+	for {
+		row, err := e.innerExec.Next()
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if row == nil {
+			return rows, nil
+		}
+		if e.innerFilter != nil {
+			matched, err := expression.EvalBool(e.innerFilter, row.Data, e.ctx)
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+			if !matched {
+				continue
+			}
+		}
+		rows = append(rows, row)
+	}
+}
+
+// convertToHandles converts the look up keys to handles, the keys which can't be converted are skipped.
+func (e *IndexLookUpJoinExec) convertToHandles(keys [][]types.Datum) []int64 {
+	var pkType *types.FieldType
+	for _, col := range e.innerExec.tableInfo.Columns {
+		if mysql.HasPriKeyFlag(col.Flag) {
+			pkType = &col.FieldType
+			break
+		}
+	}
+	handles := make([]int64, 0, len(keys))
+	for _, key := range keys {
+		d, err := key[0].ConvertTo(pkType)
+		if err != nil {
+			continue
+		}
+		switch d.Kind() {
+		case types.KindInt64:
+			handles = append(handles, d.GetInt64())
+		case types.KindUint64:
+			handles = append(handles, int64(d.GetUint64()))
+		}
+	}
+	return handles
+}
+
+// fetchHandlesByIndex fetches the handles of the index entries whose prefixes equal to the look up keys.
+func (e *IndexLookUpJoinExec) fetchHandlesByIndex(keys [][]types.Datum) ([]int64, error) {
+	txn, err := e.ctx.GetTxn(false)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	tblInfo := e.innerExec.tableInfo
+	selIdxReq := new(tipb.SelectRequest)
+	startTs := txn.StartTS()
+	selIdxReq.StartTs = &startTs
+	selIdxReq.IndexInfo = xapi.IndexToProto(tblInfo, e.innerIndex)
+	fieldTypes := make([]*types.FieldType, len(e.lookUpKeys))
+	for i := range fieldTypes {
+		fieldTypes[i] = &tblInfo.Columns[e.innerIndex.Columns[i].Offset].FieldType
+	}
+	ranges := make([]*plan.IndexRange, 0, len(keys))
+	for _, key := range keys {
+		ranges = append(ranges, &plan.IndexRange{
+			LowVal:  key,
+			HighVal: append([]types.Datum(nil), key...),
+		})
+	}
+	selIdxReq.Ranges, err = indexRangesToPBRanges(ranges, fieldTypes)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	idxResult, err := xapi.Select(txn.GetClient(), selIdxReq, defaultConcurrency, true)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	defer idxResult.Close()
+	handles, err := extractHandlesFromIndexResult(idxResult)
+	return handles, errors.Trace(err)
+}
+
+// joinOuterRow joins an outer row with the inner rows of the same join key.
+func (e *IndexLookUpJoinExec) joinOuterRow(hashTable map[string][]*Row, outerRow *Row) ([]*Row, error) {
+	hashKey, hasNull, err := e.getHashKey(e.outerHashKeys, outerRow)
+	if err != nil || hasNull {
+		return nil, errors.Trace(err)
+	}
+	var matchedRows []*Row
+	for _, innerRow := range hashTable[string(hashKey)] {
+		joinedRow := e.joinRows(outerRow, innerRow)
+		if e.otherFilter != nil {
+			matched, err := expression.EvalBool(e.otherFilter, joinedRow.Data, e.ctx)
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+			if !matched {
+				continue
+			}
+		}
+		matchedRows = append(matchedRows, joinedRow)
+	}
+	return matchedRows, nil
+}
+
+func (e *IndexLookUpJoinExec) joinRows(outerRow, innerRow *Row) *Row {
+	if e.leftInner {
+		return joinTwoRow(innerRow, outerRow)
+	}
+	return joinTwoRow(outerRow, innerRow)
+}
+
+func (e *IndexLookUpJoinExec) nullInnerRow() *Row {
+	return &Row{
+		RowKeys: make([]*RowKeyEntry, len(e.innerExec.Schema())),
+		Data:    make([]types.Datum, len(e.innerExec.Schema())),
+	}
+}
+
+// getHashKey encodes the values of the columns in the row as a hash key, the row can't be joined if a value is null.
+func (e *IndexLookUpJoinExec) getHashKey(cols []*expression.Column, row *Row) (key []byte, hasNull bool, err error) {
+	vals := make([]types.Datum, 0, len(cols))
+	for _, col := range cols {
+		v, err := col.Eval(row.Data, e.ctx)
+		if err != nil {
+			return nil, false, errors.Trace(err)
+		}
+		if v.IsNull() {
+			return nil, true, nil
+		}
+		vals = append(vals, v)
+	}
+	key, err = codec.EncodeValue(nil, vals...)
+	return key, false, errors.Trace(err)
+}
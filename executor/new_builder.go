@@ -14,12 +14,14 @@
 package executor
 
 import (
+	"github.com/golang/protobuf/proto"
 	"github.com/juju/errors"
 	"github.com/pingcap/tidb/ast"
 	"github.com/pingcap/tidb/expression"
 	"github.com/pingcap/tidb/kv"
 	"github.com/pingcap/tidb/model"
 	"github.com/pingcap/tidb/plan"
+	"github.com/pingcap/tidb/sessionctx/variable"
 	"github.com/pingcap/tipb/go-tipb"
 )
 
@@ -37,14 +39,24 @@ func composeCondition(conditions []expression.Expression) expression.Expression
 	return expr
 }
 
-//TODO: select join algorithm during cbo phase.
 func (b *executorBuilder) buildJoin(v *plan.Join) Executor {
+	switch v.Algorithm {
+	case plan.IndexLookUpJoinAlgorithm:
+		e := b.buildIndexLookUpJoin(v)
+		if e != nil || b.err != nil {
+			return e
+		}
+	case plan.MergeJoinAlgorithm:
+		return b.buildMergeJoin(v)
+	}
 	e := &HashJoinExec{
 		schema:      v.GetSchema(),
 		otherFilter: composeCondition(v.OtherConditions),
 		prepared:    false,
 		ctx:         b.ctx,
+		memTracker:  b.getMemTracker(),
 	}
+	b.recordSpiller(v, e)
 	var leftHashKey, rightHashKey []*expression.Column
 	for _, eqCond := range v.EqualConditions {
 		ln, _ := eqCond.Args[0].(*expression.Column)
@@ -89,14 +101,111 @@ func (b *executorBuilder) buildJoin(v *plan.Join) Executor {
 	return e
 }
 
+// buildIndexLookUpJoin builds the index look up join executor. It returns nil if the transaction is dirty,
+// because the looked up inner rows don't contain the uncommitted changes, or if the inner plan is not a
+// table scan, a hash join is used instead.
+func (b *executorBuilder) buildIndexLookUpJoin(v *plan.Join) Executor {
+	txn, err := b.ctx.GetTxn(false)
+	if err != nil {
+		b.err = errors.Trace(err)
+		return nil
+	}
+	if !txn.IsReadOnly() {
+		return nil
+	}
+	innerIdx := v.LookUpInnerIdx
+	var innerConditions []expression.Expression
+	var ts *plan.NewTableScan
+	switch x := v.GetChildByIndex(innerIdx).(type) {
+	case *plan.NewTableScan:
+		ts = x
+	case *plan.Selection:
+		ts, _ = x.GetChildByIndex(0).(*plan.NewTableScan)
+		innerConditions = append(innerConditions, x.Conditions...)
+	}
+	if ts == nil {
+		// The inner plan is not a table scan, a hash join is used instead.
+		return nil
+	}
+	// The inner table is looked up by the join keys instead of the ranges, so the access conditions are filters now.
+	innerConditions = append(innerConditions, ts.AccessCondition...)
+	table, _ := b.is.TableByID(ts.Table.ID)
+	innerExec := &NewTableScanExec{
+		tableInfo: ts.Table,
+		ctx:       b.ctx,
+		asName:    ts.TableAsName,
+		table:     table,
+		schema:    ts.GetSchema(),
+		Columns:   ts.Columns,
+	}
+	innerExec.where, innerConditions = b.toPBExpr(innerConditions, ts.Table)
+	e := &IndexLookUpJoinExec{
+		schema:      v.GetSchema(),
+		ctx:         b.ctx,
+		outerExec:   b.build(v.GetChildByIndex(1 - innerIdx)),
+		innerExec:   innerExec,
+		innerIndex:  v.LookUpIndex,
+		lookUpKeys:  v.LookUpOuterKeys,
+		otherFilter: composeCondition(v.OtherConditions),
+		outer:       v.JoinType != plan.InnerJoin,
+		leftInner:   innerIdx == 0,
+	}
+	for _, eqCond := range v.EqualConditions {
+		e.outerHashKeys = append(e.outerHashKeys, eqCond.Args[1-innerIdx].(*expression.Column))
+		e.innerHashKeys = append(e.innerHashKeys, eqCond.Args[innerIdx].(*expression.Column))
+	}
+	if innerIdx == 0 {
+		e.outerFilter = composeCondition(v.RightConditions)
+		innerConditions = append(innerConditions, v.LeftConditions...)
+	} else {
+		e.outerFilter = composeCondition(v.LeftConditions)
+		innerConditions = append(innerConditions, v.RightConditions...)
+	}
+	e.innerFilter = composeCondition(innerConditions)
+	return e
+}
+
+// buildMergeJoin builds the merge join executor, the left child is the outer side except for right outer join.
+func (b *executorBuilder) buildMergeJoin(v *plan.Join) Executor {
+	e := &MergeJoinExec{
+		schema:      v.GetSchema(),
+		ctx:         b.ctx,
+		otherFilter: composeCondition(v.OtherConditions),
+		outer:       v.JoinType != plan.InnerJoin,
+		leftInner:   v.JoinType == plan.RightOuterJoin,
+	}
+	var leftKeys, rightKeys []*expression.Column
+	for _, eqCond := range v.EqualConditions {
+		leftKeys = append(leftKeys, eqCond.Args[0].(*expression.Column))
+		rightKeys = append(rightKeys, eqCond.Args[1].(*expression.Column))
+	}
+	leftExec, rightExec := b.build(v.GetChildByIndex(0)), b.build(v.GetChildByIndex(1))
+	if e.leftInner {
+		e.outerExec, e.innerExec = rightExec, leftExec
+		e.outerKeys, e.innerKeys = rightKeys, leftKeys
+		e.outerFilter, e.innerFilter = composeCondition(v.RightConditions), composeCondition(v.LeftConditions)
+	} else {
+		e.outerExec, e.innerExec = leftExec, rightExec
+		e.outerKeys, e.innerKeys = leftKeys, rightKeys
+		e.outerFilter, e.innerFilter = composeCondition(v.LeftConditions), composeCondition(v.RightConditions)
+	}
+	return e
+}
+
 func (b *executorBuilder) buildAggregation(v *plan.Aggregation) Executor {
-	return &AggregationExec{
+	e := &AggregationExec{
 		Src:          b.build(v.GetChildByIndex(0)),
 		schema:       v.GetSchema(),
 		ctx:          b.ctx,
 		AggFuncs:     v.AggFuncs,
 		GroupByItems: v.GroupByItems,
+		memTracker:   b.getMemTracker(),
 	}
+	if sessVars := variable.GetSessionVars(b.ctx); sessVars != nil {
+		e.concurrency = sessVars.AggConcurrency
+	}
+	b.recordSpiller(v, e)
+	return e
 }
 
 func (b *executorBuilder) toPBExpr(conditions []expression.Expression, tbl *model.TableInfo) (
@@ -189,12 +298,65 @@ func (b *executorBuilder) buildNewTableScan(v *plan.NewTableScan, s *plan.Select
 
 func (b *executorBuilder) buildNewSort(v *plan.NewSort) Executor {
 	src := b.build(v.GetChildByIndex(0))
-	return &NewSortExec{
-		Src:     src,
-		ByItems: v.ByItems,
-		ctx:     b.ctx,
-		schema:  v.GetSchema(),
+	e := &NewSortExec{
+		Src:        src,
+		ByItems:    v.ByItems,
+		ctx:        b.ctx,
+		schema:     v.GetSchema(),
+		memTracker: b.getMemTracker(),
+	}
+	b.recordSpiller(v, e)
+	return e
+}
+
+func (b *executorBuilder) buildWindow(v *plan.Window) Executor {
+	return &WindowExec{
+		Src:         b.build(v.GetChildByIndex(0)),
+		WindowFuncs: v.WindowFuncs,
+		schema:      v.GetSchema(),
+		ctx:         b.ctx,
+	}
+}
+
+// buildTopN builds a sort executor with the limit. If the rows come from a table scan directly,
+// the top-n is pushed to the coprocessor, so every region returns its top rows, which are merged by the sort executor.
+func (b *executorBuilder) buildTopN(v *plan.TopN) Executor {
+	src := b.build(v.GetChildByIndex(0))
+	if ts, ok := src.(*NewTableScanExec); ok {
+		b.pushTopNToTableScan(v, ts)
 	}
+	e := &NewSortExec{
+		Src:        src,
+		ByItems:    v.ByItems,
+		ctx:        b.ctx,
+		schema:     v.GetSchema(),
+		Limit:      &plan.Limit{Offset: v.Offset, Count: v.Count},
+		memTracker: b.getMemTracker(),
+	}
+	b.recordSpiller(v, e)
+	return e
+}
+
+func (b *executorBuilder) pushTopNToTableScan(v *plan.TopN, ts *NewTableScanExec) {
+	txn, err := b.ctx.GetTxn(false)
+	if err != nil {
+		b.err = err
+		return
+	}
+	client := txn.GetClient()
+	if !client.SupportRequestType(kv.ReqTypeSelect, kv.ReqSubTypeTopN) {
+		return
+	}
+	orderBy := make([]*tipb.ByItem, 0, len(v.ByItems))
+	for _, item := range v.ByItems {
+		expr := b.newExprToPBExpr(client, item.Expr, ts.tableInfo)
+		if expr == nil {
+			return
+		}
+		orderBy = append(orderBy, &tipb.ByItem{Expr: expr, Desc: proto.Bool(item.Desc)})
+	}
+	ts.orderBy = orderBy
+	ts.limit = proto.Int64(int64(v.Offset + v.Count))
 }
 
 func (b *executorBuilder) buildApply(v *plan.Apply) Executor {
@@ -229,6 +391,33 @@ func (b *executorBuilder) buildTrim(v *plan.Trim) Executor {
 	}
 }
 
+func (b *executorBuilder) buildRecursiveCTE(v *plan.RecursiveCTE) Executor {
+	e := &RecursiveCTEExec{
+		schema:   v.GetSchema(),
+		Distinct: v.Distinct,
+		maxDepth: variable.GetSessionVars(b.ctx).CTEMaxRecursionDepth,
+	}
+	if b.cteExecs == nil {
+		b.cteExecs = make(map[*plan.RecursiveCTE]*RecursiveCTEExec)
+	}
+	b.cteExecs[v] = e
+	e.Seed = b.build(v.GetChildByIndex(0))
+	e.Recursive = b.build(v.GetChildByIndex(1))
+	return e
+}
+
+func (b *executorBuilder) buildCTETable(v *plan.CTETable) Executor {
+	cte, ok := b.cteExecs[v.CTE]
+	if !ok {
+		b.err = ErrUnknownPlan.Gen("Unknown recursive common table expression of %T", v)
+		return nil
+	}
+	return &CTETableExec{
+		schema: v.GetSchema(),
+		cte:    cte,
+	}
+}
+
 func (b *executorBuilder) buildNewUnion(v *plan.NewUnion) Executor {
 	e := &NewUnionExec{
 		schema: v.GetSchema(),
@@ -0,0 +1,48 @@
+// Copyright 2016 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	. "github.com/pingcap/check"
+	"github.com/pingcap/tidb/plan"
+	"github.com/pingcap/tidb/store/localstore"
+	"github.com/pingcap/tidb/store/localstore/goleveldb"
+	"github.com/pingcap/tidb/util/mock"
+	"github.com/pingcap/tidb/util/testleak"
+)
+
+var _ = Suite(&testNewBuilderSuite{})
+
+type testNewBuilderSuite struct {
+}
+
+func (s *testNewBuilderSuite) TestIndexLookUpJoinWithoutTableScan(c *C) {
+	defer testleak.AfterTest(c)()
+	store, err := localstore.Driver{Driver: goleveldb.MemoryDriver{}}.Open("memory:")
+	c.Assert(err, IsNil)
+	defer store.Close()
+	ctx := mock.NewContext()
+	ctx.Store = store
+
+	// The inner plan is a selection on a limit, the join can't look up the inner table.
+	sel := &plan.Selection{}
+	sel.AddChild(&plan.Limit{Count: 1})
+	join := &plan.Join{Algorithm: plan.IndexLookUpJoinAlgorithm, LookUpInnerIdx: 1}
+	join.AddChild(&plan.NewTableScan{})
+	join.AddChild(sel)
+	b := newExecutorBuilder(ctx, nil)
+	c.Assert(b.buildIndexLookUpJoin(join), IsNil)
+	c.Assert(b.err, IsNil)
+	ctx.RollbackTxn()
+}
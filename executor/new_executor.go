@@ -14,6 +14,7 @@
 package executor
 
 import (
+	"container/heap"
 	"sort"
 	"time"
 
@@ -35,6 +36,8 @@ import (
 )
 
 // HashJoinExec implements the hash join algorithm.
+// When the memory quota is exceeded, the rows of both tables are spilled to partitions by their hash keys,
+// and the partitions are joined one by one.
 type HashJoinExec struct {
 	hashTable    map[string][]*Row
 	smallHashKey []*expression.Column
@@ -53,6 +56,16 @@ type HashJoinExec struct {
 	leftSmall   bool
 	matchedRows []*Row
 	cursor      int
+
+	memTracker    *memTracker
+	memUsage      int64
+	hashTableRows int
+	spilled       int
+	// smallPartitions and bigPartitions are the spilled rows, they are nil if the hash table is not spilled.
+	smallPartitions []*spillFile
+	bigPartitions   []*spillFile
+	// partitionIdx is the partition being joined.
+	partitionIdx int
 }
 
 // Close implements Executor Close interface.
@@ -60,6 +73,11 @@ func (e *HashJoinExec) Close() error {
 	e.prepared = false
 	e.cursor = 0
 	e.matchedRows = nil
+	e.releaseHashTable()
+	closePartitions(e.smallPartitions)
+	closePartitions(e.bigPartitions)
+	e.smallPartitions = nil
+	e.bigPartitions = nil
 	err := e.smallExec.Close()
 	if err != nil {
 		return errors.Trace(err)
@@ -105,8 +123,12 @@ func (e *HashJoinExec) Fields() []*ast.ResultField {
 	return e.fields
 }
 
+func (e *HashJoinExec) spillCount() int {
+	return e.spilled
+}
+
 func (e *HashJoinExec) prepare() error {
-	e.hashTable = make(map[string][]*Row)
+	e.releaseHashTable()
 	e.cursor = 0
 	for {
 		row, err := e.smallExec.Next()
@@ -132,10 +154,26 @@ func (e *HashJoinExec) prepare() error {
 		if err != nil {
 			return errors.Trace(err)
 		}
-		if rows, ok := e.hashTable[string(hashcode)]; !ok {
-			e.hashTable[string(hashcode)] = []*Row{row}
-		} else {
-			e.hashTable[string(hashcode)] = append(rows, row)
+		if e.smallPartitions != nil {
+			err = spillToPartition(e.smallPartitions, spillPartitionIdx(hashcode, 0), row)
+			if err != nil {
+				return errors.Trace(err)
+			}
+			continue
+		}
+		e.addToHashTable(hashcode, row)
+		if e.memTracker.exceeded() && e.hashTableRows >= minSpillRows {
+			if err = e.spillHashTable(); err != nil {
+				return errors.Trace(err)
+			}
+		}
+	}
+	if e.smallPartitions != nil {
+		if err := e.spillBigTable(); err != nil {
+			return errors.Trace(err)
+		}
+		if err := e.loadPartition(0); err != nil {
+			return errors.Trace(err)
 		}
 	}
 
@@ -143,12 +181,121 @@ func (e *HashJoinExec) prepare() error {
 	return nil
 }
 
+func (e *HashJoinExec) addToHashTable(hashcode []byte, row *Row) {
+	if rows, ok := e.hashTable[string(hashcode)]; !ok {
+		e.hashTable[string(hashcode)] = []*Row{row}
+	} else {
+		e.hashTable[string(hashcode)] = append(rows, row)
+	}
+	usage := int64(len(hashcode)) + rowMemUsage(row)
+	e.memUsage += usage
+	e.memTracker.consume(usage)
+	e.hashTableRows++
+}
+
+func (e *HashJoinExec) releaseHashTable() {
+	e.hashTable = make(map[string][]*Row)
+	e.memTracker.release(e.memUsage)
+	e.memUsage = 0
+	e.hashTableRows = 0
+}
+
+// spillHashTable writes the rows in the hash table to the partitions of the small table.
+func (e *HashJoinExec) spillHashTable() error {
+	e.smallPartitions = make([]*spillFile, spillPartitions)
+	e.spilled++
+	for hashcode, rows := range e.hashTable {
+		idx := spillPartitionIdx([]byte(hashcode), 0)
+		for _, row := range rows {
+			if err := spillToPartition(e.smallPartitions, idx, row); err != nil {
+				return errors.Trace(err)
+			}
+		}
+	}
+	e.releaseHashTable()
+	return nil
+}
+
+// spillBigTable writes all the rows of the big table to the partitions.
+func (e *HashJoinExec) spillBigTable() error {
+	if err := rewindPartitions(e.smallPartitions); err != nil {
+		return errors.Trace(err)
+	}
+	e.bigPartitions = make([]*spillFile, spillPartitions)
+	for {
+		row, err := e.bigExec.Next()
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if row == nil {
+			break
+		}
+		hashcode, err := e.getHashKey(e.bigHashKey, row)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		err = spillToPartition(e.bigPartitions, spillPartitionIdx(hashcode, 0), row)
+		if err != nil {
+			return errors.Trace(err)
+		}
+	}
+	return errors.Trace(rewindPartitions(e.bigPartitions))
+}
+
+// loadPartition builds the hash table with the small rows of the idx-th partition.
+// A partition is always built in memory even if it exceeds the memory quota.
+func (e *HashJoinExec) loadPartition(idx int) error {
+	e.partitionIdx = idx
+	e.releaseHashTable()
+	if idx >= spillPartitions || e.bigPartitions[idx] == nil || e.smallPartitions[idx] == nil {
+		return nil
+	}
+	for {
+		row, err := e.smallPartitions[idx].read()
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if row == nil {
+			return nil
+		}
+		hashcode, err := e.getHashKey(e.smallHashKey, row)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		e.addToHashTable(hashcode, row)
+	}
+}
+
+// nextBigRow returns the next row of the big table, the rows are read partition by partition if they are spilled.
+func (e *HashJoinExec) nextBigRow() (*Row, error) {
+	if e.bigPartitions == nil {
+		row, err := e.bigExec.Next()
+		return row, errors.Trace(err)
+	}
+	for e.partitionIdx < spillPartitions {
+		if file := e.bigPartitions[e.partitionIdx]; file != nil {
+			row, err := file.read()
+			if err != nil || row != nil {
+				return row, errors.Trace(err)
+			}
+		}
+		if err := e.loadPartition(e.partitionIdx + 1); err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+	return nil, nil
+}
+
 func (e *HashJoinExec) constructMatchedRows(bigRow *Row) (matchedRows []*Row, err error) {
 	hashcode, err := e.getHashKey(e.bigHashKey, bigRow)
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
+	return e.matchHashTable(bigRow, hashcode)
+}
 
+// matchHashTable joins the big row with the small rows of the hash code, and filters the joined rows.
+func (e *HashJoinExec) matchHashTable(bigRow *Row, hashcode []byte) (matchedRows []*Row, err error) {
 	rows, ok := e.hashTable[string(hashcode)]
 	if !ok {
 		return
@@ -216,7 +363,7 @@ func (e *HashJoinExec) Next() (*Row, error) {
 	}
 
 	for {
-		bigRow, err := e.bigExec.Next()
+		bigRow, err := e.nextBigRow()
 		if err != nil {
 			return nil, errors.Trace(err)
 		}
@@ -251,8 +398,337 @@ func (e *HashJoinExec) Next() (*Row, error) {
 	}
 }
 
+// NextBatch implements BatchExecutor NextBatch interface.
+// The big rows are read in chunks, and the filter and the hash keys are evaluated over the whole chunk.
+func (e *HashJoinExec) NextBatch() (*Chunk, error) {
+	if !e.prepared {
+		if err := e.prepare(); err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+	if e.bigPartitions != nil {
+		// The spilled big rows are read from the partitions one by one.
+		chk, err := collectRows(e.Next)
+		return chk, errors.Trace(err)
+	}
+	for {
+		bigChk, err := nextBatch(e.bigExec)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if bigChk == nil {
+			e.bigExec.Close()
+			return nil, nil
+		}
+		bigMatched := make([]bool, bigChk.NumRows)
+		if e.bigFilter != nil {
+			bigMatched, err = expression.VectorizedFilter(e.bigFilter, bigChk.Columns, bigChk.NumRows, e.ctx)
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+		} else {
+			for i := range bigMatched {
+				bigMatched[i] = true
+			}
+		}
+		keyCols := make([][]types.Datum, 0, len(e.bigHashKey))
+		for _, key := range e.bigHashKey {
+			col, err := expression.EvalBatch(key, bigChk.Columns, bigChk.NumRows, e.ctx)
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+			keyCols = append(keyCols, col)
+		}
+		chk := newChunk(len(e.schema))
+		vals := make([]types.Datum, len(keyCols))
+		for i := 0; i < bigChk.NumRows; i++ {
+			bigRow := bigChk.row(i)
+			var matchedRows []*Row
+			if bigMatched[i] {
+				hashcode := []byte{}
+				if len(keyCols) > 0 {
+					for j, col := range keyCols {
+						vals[j] = col[i]
+					}
+					hashcode, err = codec.EncodeValue(hashcode, vals...)
+					if err != nil {
+						return nil, errors.Trace(err)
+					}
+				}
+				matchedRows, err = e.matchHashTable(bigRow, hashcode)
+				if err != nil {
+					return nil, errors.Trace(err)
+				}
+			}
+			if len(matchedRows) == 0 && e.outter {
+				matchedRows = append(matchedRows, e.fillNullRow(bigRow))
+			}
+			for _, row := range matchedRows {
+				chk.appendRow(row)
+			}
+		}
+		if chk.NumRows > 0 {
+			return chk, nil
+		}
+	}
+}
+
+// MergeJoinExec implements the sort merge join algorithm.
+// Both children must be ordered by the first join key, the rows of the inner child with the same first key
+// are grouped, and every outer row is joined with the group of its key.
+type MergeJoinExec struct {
+	schema    expression.Schema
+	ctx       context.Context
+	outerExec Executor
+	innerExec Executor
+	// outerKeys and innerKeys are the join keys, the children are ordered by the first one.
+	outerKeys   []*expression.Column
+	innerKeys   []*expression.Column
+	outerFilter expression.Expression
+	innerFilter expression.Expression
+	otherFilter expression.Expression
+	outer       bool
+	leftInner   bool
+
+	// innerRow is the next inner row which is not in the inner group.
+	innerRow      *Row
+	innerRowKeys  []types.Datum
+	innerDone     bool
+	innerGroup    []*Row
+	innerGroupKey *types.Datum
+	resultRows    []*Row
+	resultIndex   int
+}
+
+// Schema implements Executor Schema interface.
+func (e *MergeJoinExec) Schema() expression.Schema {
+	return e.schema
+}
+
+// Fields implements Executor Fields interface.
+func (e *MergeJoinExec) Fields() []*ast.ResultField {
+	return nil
+}
+
+// Close implements Executor Close interface.
+func (e *MergeJoinExec) Close() error {
+	e.innerRow = nil
+	e.innerRowKeys = nil
+	e.innerDone = false
+	e.innerGroup = nil
+	e.innerGroupKey = nil
+	e.resultRows = nil
+	e.resultIndex = 0
+	err := e.innerExec.Close()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	return e.outerExec.Close()
+}
+
+// Next implements Executor Next interface.
+func (e *MergeJoinExec) Next() (*Row, error) {
+	for e.resultIndex >= len(e.resultRows) {
+		outerRow, err := e.outerExec.Next()
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if outerRow == nil {
+			return nil, nil
+		}
+		e.resultRows, err = e.joinOuterRow(outerRow)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		e.resultIndex = 0
+	}
+	row := e.resultRows[e.resultIndex]
+	e.resultIndex++
+	return row, nil
+}
+
+func (e *MergeJoinExec) joinOuterRow(outerRow *Row) ([]*Row, error) {
+	matched := true
+	var err error
+	if e.outerFilter != nil {
+		matched, err = expression.EvalBool(e.outerFilter, outerRow.Data, e.ctx)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+	var outerKeys []types.Datum
+	if matched {
+		outerKeys, err = e.evalKeys(e.outerKeys, outerRow)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+	var joinedRows []*Row
+	if outerKeys != nil {
+		group, err := e.findInnerGroup(outerKeys[0])
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		for _, innerRow := range group {
+			joinedRow, err := e.joinRows(outerRow, outerKeys, innerRow)
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+			if joinedRow != nil {
+				joinedRows = append(joinedRows, joinedRow)
+			}
+		}
+	}
+	if len(joinedRows) == 0 && e.outer {
+		innerRow := &Row{
+			RowKeys: make([]*RowKeyEntry, len(e.innerExec.Schema())),
+			Data:    make([]types.Datum, len(e.innerExec.Schema())),
+		}
+		joinedRows = append(joinedRows, e.concatRows(outerRow, innerRow))
+	}
+	return joinedRows, nil
+}
+
+// findInnerGroup finds the inner rows whose first join key equals to key.
+// The outer rows are in ascending order, so the inner rows before the key are skipped.
+func (e *MergeJoinExec) findInnerGroup(key types.Datum) ([]*Row, error) {
+	if e.innerGroupKey != nil {
+		cmp, err := e.innerGroupKey.CompareDatum(key)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if cmp == 0 {
+			return e.innerGroup, nil
+		}
+	}
+	e.innerGroup = e.innerGroup[:0]
+	e.innerGroupKey = nil
+	for {
+		if e.innerRow == nil {
+			if err := e.fetchInnerRow(); err != nil {
+				return nil, errors.Trace(err)
+			}
+			if e.innerRow == nil {
+				return nil, nil
+			}
+		}
+		cmp, err := e.innerRowKeys[0].CompareDatum(key)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if cmp > 0 {
+			return nil, nil
+		}
+		if cmp == 0 {
+			break
+		}
+		e.innerRow = nil
+	}
+	groupKey := e.innerRowKeys[0]
+	e.innerGroupKey = &groupKey
+	for e.innerRow != nil {
+		cmp, err := e.innerRowKeys[0].CompareDatum(*e.innerGroupKey)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if cmp != 0 {
+			break
+		}
+		e.innerGroup = append(e.innerGroup, e.innerRow)
+		if err = e.fetchInnerRow(); err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+	return e.innerGroup, nil
+}
+
+// fetchInnerRow fetches the next inner row which satisfies the inner filter and has no null join key.
+// The inner row is set to nil if there are no more inner rows.
+func (e *MergeJoinExec) fetchInnerRow() error {
+	e.innerRow = nil
+	for !e.innerDone {
+		row, err := e.innerExec.Next()
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if row == nil {
+			e.innerDone = true
+			return nil
+		}
+		if e.innerFilter != nil {
+			matched, err := expression.EvalBool(e.innerFilter, row.Data, e.ctx)
+			if err != nil {
+				return errors.Trace(err)
+			}
+			if !matched {
+				continue
+			}
+		}
+		keys, err := e.evalKeys(e.innerKeys, row)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if keys != nil {
+			e.innerRow, e.innerRowKeys = row, keys
+			return nil
+		}
+	}
+	return nil
+}
+
+// evalKeys evaluates the join keys of a row, it returns nil if a key is null, because null never equals to anything.
+func (e *MergeJoinExec) evalKeys(cols []*expression.Column, row *Row) ([]types.Datum, error) {
+	keys := make([]types.Datum, 0, len(cols))
+	for _, col := range cols {
+		d, err := col.Eval(row.Data, e.ctx)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if d.IsNull() {
+			return nil, nil
+		}
+		keys = append(keys, d)
+	}
+	return keys, nil
+}
+
+// joinRows joins the outer row with an inner row of the same first key, it returns nil if the other join keys
+// are not equal or the other filter is not satisfied.
+func (e *MergeJoinExec) joinRows(outerRow *Row, outerKeys []types.Datum, innerRow *Row) (*Row, error) {
+	for i := 1; i < len(e.innerKeys); i++ {
+		innerKey, err := e.innerKeys[i].Eval(innerRow.Data, e.ctx)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		cmp, err := outerKeys[i].CompareDatum(innerKey)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if cmp != 0 {
+			return nil, nil
+		}
+	}
+	joinedRow := e.concatRows(outerRow, innerRow)
+	if e.otherFilter != nil {
+		matched, err := expression.EvalBool(e.otherFilter, joinedRow.Data, e.ctx)
+		if err != nil || !matched {
+			return nil, errors.Trace(err)
+		}
+	}
+	return joinedRow, nil
+}
+
+func (e *MergeJoinExec) concatRows(outerRow, innerRow *Row) *Row {
+	if e.leftInner {
+		return joinTwoRow(innerRow, outerRow)
+	}
+	return joinTwoRow(outerRow, innerRow)
+}
+
 // AggregationExec deals with all the aggregate functions.
 // It is built from Aggregate Plan. When Next() is called, it reads all the data from Src and updates all the items in AggFuncs.
+// When the memory quota is exceeded, the rows of the new groups are spilled to partitions by their group keys,
+// and the partitions are aggregated one by one after the groups in memory are returned.
 type AggregationExec struct {
 	Src               Executor
 	schema            expression.Schema
@@ -264,8 +740,27 @@ type AggregationExec struct {
 	groups            [][]byte
 	currentGroupIndex int
 	GroupByItems      []expression.Expression
+
+	memTracker *memTracker
+	memUsage   int64
+	spilled    int
+	// partitions are the spilled rows of the groups not in memory, they are nil if no row is spilled.
+	partitions []*spillFile
+	// pending are the partitions to be aggregated.
+	pending []*spillFile
+	// round is the number of the partitions aggregated, it is used to partition the spilled rows differently.
+	round int
+
+	// concurrency is the number of the workers if the rows are aggregated in parallel.
+	concurrency int
+	// workers are the workers aggregated the rows, they are nil if the rows are not aggregated in parallel.
+	workers   []*aggWorker
+	workerIdx int
 }
 
+// aggGroupMemUsage is the estimated memory used by the state of an aggregate function for a group.
+const aggGroupMemUsage = 64
+
 // Close implements Executor Close interface.
 func (e *AggregationExec) Close() error {
 	e.executed = false
@@ -274,6 +769,14 @@ func (e *AggregationExec) Close() error {
 	for _, agg := range e.AggFuncs {
 		agg.Clear()
 	}
+	e.memTracker.release(e.memUsage)
+	e.memUsage = 0
+	closePartitions(e.partitions)
+	closePartitions(e.pending)
+	e.partitions = nil
+	e.pending = nil
+	e.workers = nil
+	e.workerIdx = 0
 	return e.Src.Close()
 }
 
@@ -287,39 +790,93 @@ func (e *AggregationExec) Fields() []*ast.ResultField {
 	return e.ResultFields
 }
 
+func (e *AggregationExec) spillCount() int {
+	return e.spilled
+}
+
 // Next implements Executor Next interface.
 func (e *AggregationExec) Next() (*Row, error) {
-	// In this stage we consider all data from src as a single group.
 	if !e.executed {
-		e.groupMap = make(map[string]bool)
+		if err := e.execute(false); err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+	return e.nextGroup()
+}
+
+// NextBatch implements BatchExecutor NextBatch interface.
+// The rows of Src are read in chunks, and the group by items are evaluated over the whole chunk.
+func (e *AggregationExec) NextBatch() (*Chunk, error) {
+	if !e.executed {
+		if err := e.execute(true); err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+	chk, err := collectRows(e.nextGroup)
+	return chk, errors.Trace(err)
+}
+
+// execute reads all the rows from Src and updates the aggregate functions.
+func (e *AggregationExec) execute(batch bool) error {
+	// In this stage we consider all data from src as a single group.
+	e.groupMap = make(map[string]bool)
+	if e.parallel() {
+		if err := e.aggregateParallel(); err != nil {
+			return errors.Trace(err)
+		}
+		e.executed = true
+		return nil
+	}
+	if batch && e.Src != nil {
+		if err := e.aggregateBatches(); err != nil {
+			return errors.Trace(err)
+		}
+	} else {
 		for {
 			hasMore, err := e.innerNext()
 			if err != nil {
-				return nil, errors.Trace(err)
+				return errors.Trace(err)
 			}
 			if !hasMore {
 				break
 			}
 		}
-		e.executed = true
-		if (len(e.groups) == 0) && (len(e.GroupByItems) == 0) {
-			// If no groupby and no data, we should add an empty group.
-			// For example:
-			// "select count(c) from t;" should return one row [0]
-			// "select count(c) from t group by c1;" should return empty result set.
-			e.groups = append(e.groups, []byte{})
-		}
 	}
-	if e.currentGroupIndex >= len(e.groups) {
-		return nil, nil
+	e.executed = true
+	if (len(e.groups) == 0) && (len(e.GroupByItems) == 0) {
+		// If no groupby and no data, we should add an empty group.
+		// For example:
+		// "select count(c) from t;" should return one row [0]
+		// "select count(c) from t group by c1;" should return empty result set.
+		e.groups = append(e.groups, []byte{})
+	}
+	return errors.Trace(e.finishPartitions())
+}
+
+// nextGroup returns the result of the next group.
+func (e *AggregationExec) nextGroup() (*Row, error) {
+	if e.workers != nil {
+		return e.nextWorkerGroup(), nil
+	}
+	for e.currentGroupIndex >= len(e.groups) {
+		if len(e.pending) == 0 {
+			return nil, nil
+		}
+		if err := e.aggregatePartition(); err != nil {
+			return nil, errors.Trace(err)
+		}
 	}
-	retRow := &Row{Data: make([]types.Datum, 0, len(e.AggFuncs))}
 	groupKey := e.groups[e.currentGroupIndex]
-	for _, af := range e.AggFuncs {
+	e.currentGroupIndex++
+	return groupResult(e.AggFuncs, groupKey), nil
+}
+
+func groupResult(aggFuncs []expression.AggregationFunction, groupKey []byte) *Row {
+	retRow := &Row{Data: make([]types.Datum, 0, len(aggFuncs))}
+	for _, af := range aggFuncs {
 		retRow.Data = append(retRow.Data, af.GetGroupResult(groupKey))
 	}
-	e.currentGroupIndex++
-	return retRow, nil
+	return retRow
 }
 
 func (e *AggregationExec) getGroupKey(row *Row) ([]byte, error) {
@@ -360,18 +917,127 @@ func (e *AggregationExec) innerNext() (ret bool, err error) {
 		}
 	}
 	e.executed = true
-	groupKey, err := e.getGroupKey(srcRow)
+	return true, errors.Trace(e.aggregate(srcRow))
+}
+
+// aggregateBatches reads the rows from Src in chunks and updates each aggregate function with them.
+func (e *AggregationExec) aggregateBatches() error {
+	for {
+		chk, err := nextBatch(e.Src)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if chk == nil {
+			return nil
+		}
+		keyCols := make([][]types.Datum, 0, len(e.GroupByItems))
+		for _, item := range e.GroupByItems {
+			col, err := expression.EvalBatch(item, chk.Columns, chk.NumRows, e.ctx)
+			if err != nil {
+				return errors.Trace(err)
+			}
+			keyCols = append(keyCols, col)
+		}
+		vals := make([]types.Datum, len(keyCols))
+		// The row is reused because the aggregate functions and the spill files copy the values they need.
+		row := &Row{Data: make([]types.Datum, len(chk.Columns))}
+		for i := 0; i < chk.NumRows; i++ {
+			for j, col := range chk.Columns {
+				row.Data[j] = col[i]
+			}
+			row.RowKeys = chk.RowKeys[i]
+			groupKey := []byte{}
+			if len(keyCols) > 0 {
+				for j, col := range keyCols {
+					vals[j] = col[i]
+				}
+				groupKey, err = codec.EncodeValue(groupKey, vals...)
+				if err != nil {
+					return errors.Trace(err)
+				}
+			}
+			if err = e.aggregateGroup(row, groupKey); err != nil {
+				return errors.Trace(err)
+			}
+		}
+	}
+}
+
+// aggregate updates each aggregate function with the row. If the group of the row is not in memory
+// and the memory quota is exceeded, the row is spilled to its partition.
+func (e *AggregationExec) aggregate(row *Row) error {
+	groupKey, err := e.getGroupKey(row)
 	if err != nil {
-		return false, errors.Trace(err)
+		return errors.Trace(err)
 	}
+	return errors.Trace(e.aggregateGroup(row, groupKey))
+}
+
+func (e *AggregationExec) aggregateGroup(row *Row, groupKey []byte) error {
 	if _, ok := e.groupMap[string(groupKey)]; !ok {
+		if e.partitions == nil && e.memTracker.exceeded() && len(e.groups) >= minSpillRows {
+			e.partitions = make([]*spillFile, spillPartitions)
+			e.spilled++
+		}
+		if e.partitions != nil {
+			return errors.Trace(spillToPartition(e.partitions, spillPartitionIdx(groupKey, e.round), row))
+		}
 		e.groupMap[string(groupKey)] = true
 		e.groups = append(e.groups, groupKey)
+		usage := int64(2*len(groupKey) + aggGroupMemUsage*len(e.AggFuncs))
+		e.memUsage += usage
+		e.memTracker.consume(usage)
 	}
 	for _, af := range e.AggFuncs {
-		af.Update(srcRow.Data, groupKey, e.ctx)
+		af.Update(row.Data, groupKey, e.ctx)
 	}
-	return true, nil
+	return nil
+}
+
+// finishPartitions finishes writing the spilled partitions and adds them to the pending partitions.
+func (e *AggregationExec) finishPartitions() error {
+	if e.partitions == nil {
+		return nil
+	}
+	if err := rewindPartitions(e.partitions); err != nil {
+		return errors.Trace(err)
+	}
+	for _, file := range e.partitions {
+		if file != nil {
+			e.pending = append(e.pending, file)
+		}
+	}
+	e.partitions = nil
+	return nil
+}
+
+// aggregatePartition clears the groups returned and aggregates the rows of the next pending partition.
+func (e *AggregationExec) aggregatePartition() error {
+	for _, af := range e.AggFuncs {
+		af.Clear()
+	}
+	e.memTracker.release(e.memUsage)
+	e.memUsage = 0
+	e.groupMap = make(map[string]bool)
+	e.groups = nil
+	e.currentGroupIndex = 0
+	e.round++
+	file := e.pending[0]
+	e.pending = e.pending[1:]
+	defer file.close()
+	for {
+		row, err := file.read()
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if row == nil {
+			break
+		}
+		if err = e.aggregate(row); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	return errors.Trace(e.finishPartitions())
 }
 
 // ProjectionExec represents a select fields executor.
@@ -428,6 +1094,37 @@ func (e *ProjectionExec) Next() (retRow *Row, err error) {
 	return row, nil
 }
 
+// NextBatch implements BatchExecutor NextBatch interface.
+func (e *ProjectionExec) NextBatch() (*Chunk, error) {
+	srcChk := &Chunk{NumRows: 1, RowKeys: [][]*RowKeyEntry{nil}}
+	if e.Src != nil {
+		var err error
+		srcChk, err = nextBatch(e.Src)
+		if err != nil || srcChk == nil {
+			return nil, errors.Trace(err)
+		}
+	} else {
+		// If Src is nil, only one row should be returned.
+		if e.executed {
+			return nil, nil
+		}
+	}
+	e.executed = true
+	chk := &Chunk{
+		Columns: make([][]types.Datum, 0, len(e.exprs)),
+		RowKeys: srcChk.RowKeys,
+		NumRows: srcChk.NumRows,
+	}
+	for _, expr := range e.exprs {
+		col, err := expression.EvalBatch(expr, srcChk.Columns, srcChk.NumRows, e.ctx)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		chk.Columns = append(chk.Columns, col)
+	}
+	return chk, nil
+}
+
 // Close implements Executor Close interface.
 func (e *ProjectionExec) Close() error {
 	if e.Src != nil {
@@ -468,6 +1165,7 @@ func (e *NewTableDualExec) Next() (*Row, error) {
 
 // Close implements Executor interface.
 func (e *NewTableDualExec) Close() error {
+	e.executed = false
 	return nil
 }
 
@@ -509,6 +1207,24 @@ func (e *SelectionExec) Next() (*Row, error) {
 	}
 }
 
+// NextBatch implements BatchExecutor NextBatch interface.
+func (e *SelectionExec) NextBatch() (*Chunk, error) {
+	for {
+		srcChk, err := nextBatch(e.Src)
+		if err != nil || srcChk == nil {
+			return nil, errors.Trace(err)
+		}
+		selected, err := expression.VectorizedFilter(e.Condition, srcChk.Columns, srcChk.NumRows, e.ctx)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		chk := srcChk.filter(selected)
+		if chk.NumRows > 0 {
+			return chk, nil
+		}
+	}
+}
+
 // Close implements Executor Close interface.
 func (e *SelectionExec) Close() error {
 	return e.Src.Close()
@@ -528,6 +1244,9 @@ type NewTableScanExec struct {
 	Columns     []*model.ColumnInfo
 	schema      expression.Schema
 	ranges      []plan.TableRange
+	// orderBy and limit are set if the top-n is pushed down, the rows are sorted and limited in every region.
+	orderBy []*tipb.ByItem
+	limit   *int64
 }
 
 // Schema implements Executor Schema interface.
@@ -544,13 +1263,15 @@ func (e *NewTableScanExec) doRequest() error {
 	startTs := txn.StartTS()
 	selReq.StartTs = &startTs
 	selReq.Where = e.where
+	selReq.OrderBy = e.orderBy
+	selReq.Limit = e.limit
 	selReq.Ranges = tableRangesToPBRanges(e.ranges)
 	columns := e.Columns
 	selReq.TableInfo = &tipb.TableInfo{
 		TableId: proto.Int64(e.tableInfo.ID),
 	}
 	selReq.TableInfo.Columns = xapi.ColumnsToProto(columns, e.tableInfo.PKIsHandle)
-	e.result, err = xapi.Select(txn.GetClient(), selReq, defaultConcurrency)
+	e.result, err = xapi.Select(txn.GetClient(), selReq, defaultConcurrency, true)
 	if err != nil {
 		return errors.Trace(err)
 	}
@@ -597,12 +1318,54 @@ func (e *NewTableScanExec) Next() (*Row, error) {
 	}
 }
 
+// NextBatch implements BatchExecutor NextBatch interface.
+func (e *NewTableScanExec) NextBatch() (*Chunk, error) {
+	if e.result == nil {
+		err := e.doRequest()
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+	chk := newChunk(len(e.Columns))
+	for chk.NumRows < batchSize {
+		if e.subResult == nil {
+			var err error
+			e.subResult, err = e.result.Next()
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+			if e.subResult == nil {
+				break
+			}
+		}
+		h, rowData, err := e.subResult.Next()
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if rowData == nil {
+			e.subResult = nil
+			continue
+		}
+		for i, d := range rowData {
+			chk.Columns[i] = append(chk.Columns[i], d)
+		}
+		chk.RowKeys = append(chk.RowKeys, []*RowKeyEntry{{Handle: h, Tbl: e.table, TableAsName: e.asName}})
+		chk.NumRows++
+	}
+	if chk.NumRows == 0 {
+		return nil, nil
+	}
+	return chk, nil
+}
+
 // Fields implements Executor interface.
 func (e *NewTableScanExec) Fields() []*ast.ResultField {
 	return nil
 }
 
 // NewSortExec represents sorting executor.
+// When the memory quota is exceeded, the sorted rows in memory are spilled to a file as a run,
+// and the runs are merged at last.
 type NewSortExec struct {
 	Src     Executor
 	ByItems []plan.ByItems
@@ -613,12 +1376,28 @@ type NewSortExec struct {
 	fetched bool
 	err     error
 	schema  expression.Schema
+
+	memTracker *memTracker
+	memUsage   int64
+	runs       []*spillFile
+	spilled    int
+	// mergeHeap merges the sorted runs if any run is spilled.
+	mergeHeap *sortMergeHeap
+	// remained is the number of rows can be returned from the merged runs, -1 means no limit.
+	remained int
 }
 
 // Close implements Executor Close interface.
 func (e *NewSortExec) Close() error {
 	e.fetched = false
 	e.Rows = nil
+	e.memTracker.release(e.memUsage)
+	e.memUsage = 0
+	for _, run := range e.runs {
+		run.close()
+	}
+	e.runs = nil
+	e.mergeHeap = nil
 	return e.Src.Close()
 }
 
@@ -644,9 +1423,13 @@ func (e *NewSortExec) Swap(i, j int) {
 
 // Less implements sort.Interface Less interface.
 func (e *NewSortExec) Less(i, j int) bool {
+	return e.lessRow(e.Rows[i], e.Rows[j])
+}
+
+func (e *NewSortExec) lessRow(rowI, rowJ *orderByRow) bool {
 	for index, by := range e.ByItems {
-		v1 := e.Rows[i].key[index]
-		v2 := e.Rows[j].key[index]
+		v1 := rowI.key[index]
+		v2 := rowJ.key[index]
 
 		ret, err := v1.CompareDatum(v2)
 		if err != nil {
@@ -668,6 +1451,69 @@ func (e *NewSortExec) Less(i, j int) bool {
 	return false
 }
 
+func (e *NewSortExec) spillCount() int {
+	return e.spilled
+}
+
+func (e *NewSortExec) newOrderByRow(row *Row) (*orderByRow, error) {
+	orderRow := &orderByRow{
+		row: row,
+		key: make([]types.Datum, len(e.ByItems)),
+	}
+	for i, byItem := range e.ByItems {
+		var err error
+		orderRow.key[i], err = byItem.Expr.Eval(row.Data, e.ctx)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+	return orderRow, nil
+}
+
+func orderByRowMemUsage(row *orderByRow) int64 {
+	return orderRowSize + int64(len(row.key))*datumSize + rowMemUsage(row.row)
+}
+
+// truncate keeps the first n rows in memory.
+func (e *NewSortExec) truncate(n int) {
+	var released int64
+	for _, row := range e.Rows[n:] {
+		released += orderByRowMemUsage(row)
+	}
+	e.Rows = e.Rows[:n]
+	e.memUsage -= released
+	e.memTracker.release(released)
+}
+
+// spillRun sorts the rows in memory and writes them to a new file as a sorted run.
+// Only the first totalCount rows are written because the others can't be returned.
+func (e *NewSortExec) spillRun(totalCount int) error {
+	sort.Sort(e)
+	if e.err != nil {
+		return errors.Trace(e.err)
+	}
+	if totalCount != -1 && e.Len() > totalCount {
+		e.truncate(totalCount)
+	}
+	run, err := newSpillFile()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	e.runs = append(e.runs, run)
+	for _, row := range e.Rows {
+		if err = run.write(row.row); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	if err = run.rewind(); err != nil {
+		return errors.Trace(err)
+	}
+	e.truncate(0)
+	e.Rows = nil
+	e.spilled++
+	return nil
+}
+
 // Next implements Executor Next interface.
 func (e *NewSortExec) Next() (*Row, error) {
 	if !e.fetched {
@@ -685,24 +1531,30 @@ func (e *NewSortExec) Next() (*Row, error) {
 			if srcRow == nil {
 				break
 			}
-			orderRow := &orderByRow{
-				row: srcRow,
-				key: make([]types.Datum, len(e.ByItems)),
-			}
-			for i, byItem := range e.ByItems {
-				orderRow.key[i], err = byItem.Expr.Eval(srcRow.Data, e.ctx)
-				if err != nil {
-					return nil, errors.Trace(err)
-				}
+			orderRow, err := e.newOrderByRow(srcRow)
+			if err != nil {
+				return nil, errors.Trace(err)
 			}
 			e.Rows = append(e.Rows, orderRow)
+			usage := orderByRowMemUsage(orderRow)
+			e.memUsage += usage
+			e.memTracker.consume(usage)
 			if totalCount != -1 && e.Len() >= totalCount+SortBufferSize {
 				sort.Sort(e)
-				e.Rows = e.Rows[:totalCount]
+				e.truncate(totalCount)
+			}
+			if e.memTracker.exceeded() && e.Len() >= minSpillRows {
+				if err = e.spillRun(totalCount); err != nil {
+					return nil, errors.Trace(err)
+				}
 			}
 		}
 		sort.Sort(e)
-		if offset >= 0 && offset < e.Len() {
+		if len(e.runs) > 0 {
+			if err := e.initMerge(offset, totalCount); err != nil {
+				return nil, errors.Trace(err)
+			}
+		} else if offset >= 0 && offset < e.Len() {
 			if totalCount > e.Len() {
 				e.Rows = e.Rows[offset:]
 			} else {
@@ -716,6 +1568,9 @@ func (e *NewSortExec) Next() (*Row, error) {
 	if e.err != nil {
 		return nil, errors.Trace(e.err)
 	}
+	if e.mergeHeap != nil {
+		return e.nextMerged()
+	}
 	if e.Idx >= len(e.Rows) {
 		return nil, nil
 	}
@@ -724,6 +1579,114 @@ func (e *NewSortExec) Next() (*Row, error) {
 	return row, nil
 }
 
+// initMerge prepares to merge the spilled runs and the sorted rows in memory, and skips the offset rows.
+func (e *NewSortExec) initMerge(offset, totalCount int) error {
+	e.mergeHeap = &sortMergeHeap{e: e}
+	runs := make([]*sortRun, 0, len(e.runs)+1)
+	for _, file := range e.runs {
+		runs = append(runs, &sortRun{file: file})
+	}
+	runs = append(runs, &sortRun{rows: e.Rows})
+	for _, run := range runs {
+		ok, err := run.next(e)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if ok {
+			e.mergeHeap.runs = append(e.mergeHeap.runs, run)
+		}
+	}
+	heap.Init(e.mergeHeap)
+	e.remained = totalCount
+	for i := 0; i < offset; i++ {
+		row, err := e.nextMerged()
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if row == nil {
+			break
+		}
+	}
+	return nil
+}
+
+// nextMerged returns the smallest row of the runs.
+func (e *NewSortExec) nextMerged() (*Row, error) {
+	if e.remained == 0 || e.mergeHeap.Len() == 0 {
+		return nil, nil
+	}
+	run := e.mergeHeap.runs[0]
+	row := run.cur.row
+	ok, err := run.next(e)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if ok {
+		heap.Fix(e.mergeHeap, 0)
+	} else {
+		heap.Pop(e.mergeHeap)
+	}
+	if e.err != nil {
+		return nil, errors.Trace(e.err)
+	}
+	if e.remained > 0 {
+		e.remained--
+	}
+	return row, nil
+}
+
+// sortRun is a sorted run of rows, which is either spilled to a file or kept in memory.
+type sortRun struct {
+	file *spillFile
+	rows []*orderByRow
+	cur  *orderByRow
+}
+
+// next moves to the next row of the run, it returns false if there is no more row.
+func (r *sortRun) next(e *NewSortExec) (bool, error) {
+	if r.file == nil {
+		if len(r.rows) == 0 {
+			return false, nil
+		}
+		r.cur, r.rows = r.rows[0], r.rows[1:]
+		return true, nil
+	}
+	row, err := r.file.read()
+	if err != nil || row == nil {
+		return false, errors.Trace(err)
+	}
+	r.cur, err = e.newOrderByRow(row)
+	return err == nil, errors.Trace(err)
+}
+
+// sortMergeHeap is a heap of the sorted runs ordered by their current rows.
+type sortMergeHeap struct {
+	e    *NewSortExec
+	runs []*sortRun
+}
+
+func (h *sortMergeHeap) Len() int {
+	return len(h.runs)
+}
+
+func (h *sortMergeHeap) Less(i, j int) bool {
+	return h.e.lessRow(h.runs[i].cur, h.runs[j].cur)
+}
+
+func (h *sortMergeHeap) Swap(i, j int) {
+	h.runs[i], h.runs[j] = h.runs[j], h.runs[i]
+}
+
+func (h *sortMergeHeap) Push(x interface{}) {
+	h.runs = append(h.runs, x.(*sortRun))
+}
+
+func (h *sortMergeHeap) Pop() interface{} {
+	last := h.runs[len(h.runs)-1]
+	h.runs = h.runs[:len(h.runs)-1]
+	return last
+}
+
 func (b *executorBuilder) newConditionExprToPBExpr(client kv.Client, exprs []expression.Expression,
 	tbl *model.TableInfo) (pbExpr *tipb.Expr, remained []expression.Expression) {
 	for _, expr := range exprs {
@@ -751,6 +1714,8 @@ func (b *executorBuilder) newExprToPBExpr(client kv.Client, expr expression.Expr
 		return b.columnToPBExpr(client, x, tbl)
 	case *expression.ScalarFunction:
 		return b.scalarFuncToPBExpr(client, x, tbl)
+	case *expression.Constant:
+		return b.datumToPBExpr(client, x.Value)
 	}
 
 	return nil
@@ -806,42 +1771,64 @@ func (b *executorBuilder) scalarFuncToPBExpr(client kv.Client, expr *expression.
 		tp = tipb.ExprType_GT
 	case ast.NullEQ:
 		tp = tipb.ExprType_NullEQ
-	case ast.And:
+	case ast.AndAnd:
 		tp = tipb.ExprType_And
-	case ast.Or:
+	case ast.OrOr:
 		tp = tipb.ExprType_Or
+	case ast.LogicXor:
+		tp = tipb.ExprType_Xor
 	// It's the operation for unary operator.
 	case ast.UnaryNot:
 		tp = tipb.ExprType_Not
+	case ast.UnaryMinus:
+		tp = tipb.ExprType_Neg
+	case ast.BitNeg:
+		tp = tipb.ExprType_BitNeg
+	case ast.Plus:
+		tp = tipb.ExprType_Plus
+	case ast.Minus:
+		tp = tipb.ExprType_Minus
+	case ast.Mul:
+		tp = tipb.ExprType_Mul
+	case ast.Div:
+		tp = tipb.ExprType_Div
+	case ast.IntDiv:
+		tp = tipb.ExprType_IntDiv
+	case ast.Mod:
+		tp = tipb.ExprType_Mod
+	case ast.And:
+		tp = tipb.ExprType_BitAnd
+	case ast.Or:
+		tp = tipb.ExprType_BitOr
+	case ast.Xor:
+		tp = tipb.ExprType_BitXor
+	case ast.LeftShift:
+		tp = tipb.ExprType_LeftShift
+	case ast.RightShift:
+		tp = tipb.ExprType_RighShift
 	default:
-		return nil
+		var ok bool
+		tp, ok = funcNameToPBType[expr.FuncName.L]
+		if !ok {
+			return nil
+		}
 	}
 
 	if !client.SupportRequestType(kv.ReqTypeSelect, int64(tp)) {
 		return nil
 	}
 
-	if len(expr.Args) == 1 {
-		child := b.newExprToPBExpr(client, expr.Args[0], tbl)
+	children := make([]*tipb.Expr, 0, len(expr.Args))
+	for _, arg := range expr.Args {
+		child := b.newExprToPBExpr(client, arg, tbl)
 		if child == nil {
 			return nil
 		}
-		return &tipb.Expr{
-			Tp:       tp.Enum(),
-			Children: []*tipb.Expr{child}}
-	}
-
-	leftExpr := b.newExprToPBExpr(client, expr.Args[0], tbl)
-	if leftExpr == nil {
-		return nil
-	}
-	rightExpr := b.newExprToPBExpr(client, expr.Args[1], tbl)
-	if rightExpr == nil {
-		return nil
+		children = append(children, child)
 	}
 	return &tipb.Expr{
 		Tp:       tp.Enum(),
-		Children: []*tipb.Expr{leftExpr, rightExpr}}
+		Children: children}
 }
 
 // ApplyExec represents apply executor.
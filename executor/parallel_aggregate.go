@@ -0,0 +1,194 @@
+// Copyright 2016 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"hash/fnv"
+	"sync"
+
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/context"
+	"github.com/pingcap/tidb/expression"
+	"github.com/pingcap/tidb/util/codec"
+	"github.com/pingcap/tidb/util/types"
+)
+
+// aggWorker aggregates the rows of the groups sharded to it, with its own copies of the aggregate functions.
+// The rows of a group are always sharded to the same worker in the order of Src,
+// so the partial results of the workers are the final results of their groups.
+// All the workers evaluate the arguments with the same ctx, see parallel for why it is safe.
+type aggWorker struct {
+	ctx      context.Context
+	aggFuncs []expression.AggregationFunction
+	groupMap map[string]bool
+	groups   [][]byte
+	taskCh   chan *aggTask
+	err      error
+}
+
+// aggTask is the rows of a chunk sharded to a worker, with their group keys.
+type aggTask struct {
+	rows      []*Row
+	groupKeys [][]byte
+}
+
+func newAggWorker(ctx context.Context, aggFuncs []expression.AggregationFunction) *aggWorker {
+	w := &aggWorker{
+		ctx:      ctx,
+		aggFuncs: make([]expression.AggregationFunction, 0, len(aggFuncs)),
+		groupMap: make(map[string]bool),
+		taskCh:   make(chan *aggTask, 4),
+	}
+	for _, af := range aggFuncs {
+		w.aggFuncs = append(w.aggFuncs, af.Clone())
+	}
+	return w
+}
+
+// run updates the aggregate functions with the rows of the tasks until the task channel is closed.
+// The tasks are drained after an error, so the sender is never blocked.
+func (w *aggWorker) run(wg *sync.WaitGroup) {
+	defer wg.Done()
+	for task := range w.taskCh {
+		if w.err == nil {
+			w.err = w.aggregate(task)
+		}
+	}
+}
+
+func (w *aggWorker) aggregate(task *aggTask) error {
+	for i, row := range task.rows {
+		groupKey := task.groupKeys[i]
+		if !w.groupMap[string(groupKey)] {
+			w.groupMap[string(groupKey)] = true
+			w.groups = append(w.groups, groupKey)
+		}
+		for _, af := range w.aggFuncs {
+			if err := af.Update(row.Data, groupKey, w.ctx); err != nil {
+				return errors.Trace(err)
+			}
+		}
+	}
+	return nil
+}
+
+// parallel checks whether the rows are aggregated by the workers. The groups of the workers are never spilled,
+// so they are not used if the memory quota is set. The workers evaluate the arguments of the aggregate functions
+// concurrently with the session context, so they are used only if all the arguments are columns or constants,
+// which never read or write the session and statement state.
+func (e *AggregationExec) parallel() bool {
+	if e.concurrency <= 1 || e.Src == nil || len(e.GroupByItems) == 0 || e.memTracker.quota > 0 {
+		return false
+	}
+	for _, af := range e.AggFuncs {
+		for _, arg := range af.GetArgs() {
+			switch arg.(type) {
+			case *expression.Column, *expression.Constant:
+			default:
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// aggregateParallel reads the rows from Src in chunks, and shards them to the workers by their group keys.
+// It is the partial phase of the aggregation, and the groups of all the workers are returned in the final phase.
+func (e *AggregationExec) aggregateParallel() error {
+	e.workers = make([]*aggWorker, 0, e.concurrency)
+	wg := &sync.WaitGroup{}
+	for i := 0; i < e.concurrency; i++ {
+		w := newAggWorker(e.ctx, e.AggFuncs)
+		e.workers = append(e.workers, w)
+		wg.Add(1)
+		go w.run(wg)
+	}
+	err := e.shardRows()
+	for _, w := range e.workers {
+		close(w.taskCh)
+	}
+	wg.Wait()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	for _, w := range e.workers {
+		if w.err != nil {
+			return errors.Trace(w.err)
+		}
+	}
+	return nil
+}
+
+func (e *AggregationExec) shardRows() error {
+	for {
+		chk, err := nextBatch(e.Src)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if chk == nil {
+			return nil
+		}
+		keyCols := make([][]types.Datum, 0, len(e.GroupByItems))
+		for _, item := range e.GroupByItems {
+			col, err := expression.EvalBatch(item, chk.Columns, chk.NumRows, e.ctx)
+			if err != nil {
+				return errors.Trace(err)
+			}
+			keyCols = append(keyCols, col)
+		}
+		tasks := make([]*aggTask, len(e.workers))
+		vals := make([]types.Datum, len(keyCols))
+		for i := 0; i < chk.NumRows; i++ {
+			for j, col := range keyCols {
+				vals[j] = col[i]
+			}
+			groupKey, err := codec.EncodeValue([]byte{}, vals...)
+			if err != nil {
+				return errors.Trace(err)
+			}
+			idx := aggWorkerIdx(groupKey, len(e.workers))
+			if tasks[idx] == nil {
+				tasks[idx] = &aggTask{}
+			}
+			tasks[idx].rows = append(tasks[idx].rows, chk.row(i))
+			tasks[idx].groupKeys = append(tasks[idx].groupKeys, groupKey)
+		}
+		for i, task := range tasks {
+			if task != nil {
+				e.workers[i].taskCh <- task
+			}
+		}
+	}
+}
+
+func aggWorkerIdx(groupKey []byte, workers int) int {
+	h := fnv.New32a()
+	h.Write(groupKey)
+	return int(h.Sum32() % uint32(workers))
+}
+
+// nextWorkerGroup returns the result of the next group of the workers, the groups are returned worker by worker.
+func (e *AggregationExec) nextWorkerGroup() *Row {
+	for e.workerIdx < len(e.workers) {
+		w := e.workers[e.workerIdx]
+		if e.currentGroupIndex < len(w.groups) {
+			groupKey := w.groups[e.currentGroupIndex]
+			e.currentGroupIndex++
+			return groupResult(w.aggFuncs, groupKey)
+		}
+		e.workerIdx++
+		e.currentGroupIndex = 0
+	}
+	return nil
+}
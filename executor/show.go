@@ -24,6 +24,7 @@ import (
 	"github.com/pingcap/tidb/context"
 	"github.com/pingcap/tidb/expression"
 	"github.com/pingcap/tidb/infoschema"
+	"github.com/pingcap/tidb/meta"
 	"github.com/pingcap/tidb/model"
 	"github.com/pingcap/tidb/mysql"
 	"github.com/pingcap/tidb/privilege"
@@ -112,6 +113,10 @@ func (e *ShowExec) fetchAll() error {
 		return e.fetchShowTableStatus()
 	case ast.ShowTriggers:
 		return e.fetchShowTriggers()
+	case ast.ShowStatsMeta:
+		return e.fetchShowStatsMeta()
+	case ast.ShowStatsHistograms:
+		return e.fetchShowStatsHistograms()
 	case ast.ShowVariables:
 		return e.fetchShowVariables()
 	case ast.ShowWarnings:
@@ -505,6 +510,63 @@ func (e *ShowExec) fetchShowProcedureStatus() error {
 	return nil
 }
 
+func (e *ShowExec) fetchShowStatsMeta() error {
+	txn, err := e.ctx.GetTxn(false)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	rows, err := infoschema.DataForStatsMeta(e.sortedSchemas(), meta.NewMeta(txn))
+	if err != nil {
+		return errors.Trace(err)
+	}
+	for _, data := range rows {
+		e.rows = append(e.rows, &Row{Data: data})
+	}
+	return nil
+}
+
+func (e *ShowExec) fetchShowStatsHistograms() error {
+	txn, err := e.ctx.GetTxn(false)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	rows, err := infoschema.DataForStatsHistograms(e.sortedSchemas(), meta.NewMeta(txn))
+	if err != nil {
+		return errors.Trace(err)
+	}
+	for _, data := range rows {
+		e.rows = append(e.rows, &Row{Data: data})
+	}
+	return nil
+}
+
+// sortedSchemas returns the schemas ordered by name, the tables of every schema are ordered by name too.
+func (e *ShowExec) sortedSchemas() []*model.DBInfo {
+	dbs := e.is.AllSchemas()
+	sort.Sort(dbInfosByName(dbs))
+	sorted := make([]*model.DBInfo, 0, len(dbs))
+	for _, db := range dbs {
+		dbCopy := *db
+		dbCopy.Tables = make([]*model.TableInfo, len(db.Tables))
+		copy(dbCopy.Tables, db.Tables)
+		sort.Sort(tableInfosByName(dbCopy.Tables))
+		sorted = append(sorted, &dbCopy)
+	}
+	return sorted
+}
+
+type dbInfosByName []*model.DBInfo
+
+func (s dbInfosByName) Len() int           { return len(s) }
+func (s dbInfosByName) Less(i, j int) bool { return s[i].Name.L < s[j].Name.L }
+func (s dbInfosByName) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+
+type tableInfosByName []*model.TableInfo
+
+func (s tableInfosByName) Len() int           { return len(s) }
+func (s tableInfosByName) Less(i, j int) bool { return s[i].Name.L < s[j].Name.L }
+func (s tableInfosByName) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+
 func (e *ShowExec) getTable() (table.Table, error) {
 	if e.Table == nil {
 		return nil, errors.New("table not found")
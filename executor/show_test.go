@@ -74,6 +74,50 @@ func (s *testSuite) TestShow(c *C) {
 	c.Check(result.Rows(), NotNil)
 }
 
+func (s *testSuite) TestShowStats(c *C) {
+	defer testleak.AfterTest(c)()
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists show_stats")
+	tk.MustExec("create table show_stats (a int, b varchar(10), index idx(a, b))")
+	tk.MustExec("insert show_stats values (1, 'x'), (2, 'y'), (2, 'z')")
+	tk.MustQuery("show stats_meta where table_name = 'show_stats'").Check(testkit.Rows())
+
+	tk.MustExec("analyze table show_stats")
+	result := tk.MustQuery("show stats_meta where table_name = 'show_stats'")
+	c.Check(result.Rows(), HasLen, 1)
+	row := result.Rows()[0]
+	c.Check(row[0], Equals, "test")
+	c.Check(row[3], Equals, int64(3))
+	tk.MustQuery("show stats_histograms where table_name = 'show_stats' and column_name = 'a'").Check(testkit.Rows(
+		"test show_stats a 0 2 0 1 1 1",
+		"test show_stats a 0 2 1 3 2 2",
+	))
+	tk.MustQuery("show stats_histograms where table_name = 'show_stats' and is_index = 1").Check(testkit.Rows(
+		"test show_stats idx 1 3 0 1 1 (1, x)",
+		"test show_stats idx 1 3 1 2 1 (2, y)",
+		"test show_stats idx 1 3 2 3 1 (2, z)",
+	))
+
+	tk.MustQuery("select table_schema, row_count from information_schema.stats_meta where table_name = 'show_stats'").Check(testkit.Rows(
+		"test 3",
+	))
+	tk.MustQuery("select column_name, bucket_id, count, upper_bound from information_schema.stats_histograms where table_name = 'show_stats' and column_name = 'b'").Check(testkit.Rows(
+		"b 0 1 x",
+		"b 1 2 y",
+		"b 2 3 z",
+	))
+
+	// The saved statistics are still shown after a column is added, though they can't be used by the planner.
+	tk.MustExec("alter table show_stats add column c int")
+	c.Check(tk.MustQuery("show stats_meta where table_name = 'show_stats'").Rows(), DeepEquals, result.Rows())
+	tk.MustQuery("show stats_histograms where table_name = 'show_stats' and column_name = 'a'").Check(testkit.Rows(
+		"test show_stats a 0 2 0 1 1 1",
+		"test show_stats a 0 2 1 3 2 2",
+	))
+	tk.MustExec("drop table show_stats")
+}
+
 type stats struct {
 }
 
@@ -99,7 +143,7 @@ func (s *testSuite) TestForeignKeyInShowCreateTable(c *C) {
 	testSQL = `CREATE TABLE t1 (id int PRIMARY KEY AUTO_INCREMENT)`
 	tk.MustExec(testSQL)
 
-	testSQL = "create table show_test (`id` int PRIMARY KEY AUTO_INCREMENT, FOREIGN KEY `fk` (`id`) REFERENCES `t1` (`a`) ON DELETE CASCADE ON UPDATE CASCADE) ENGINE=InnoDB"
+	testSQL = "create table show_test (`id` int PRIMARY KEY AUTO_INCREMENT, FOREIGN KEY `fk` (`id`) REFERENCES `t1` (`id`) ON DELETE CASCADE ON UPDATE CASCADE) ENGINE=InnoDB"
 	tk.MustExec(testSQL)
 	testSQL = "show create table show_test;"
 	result := tk.MustQuery(testSQL)
@@ -135,4 +179,6 @@ func (s *testSuite) TestForeignKeyInShowCreateTable(c *C) {
 		c.Check(r, Equals, expectedRow[i])
 	}
 
+	// The foreign key is enforced, drop it with t1 so that t1 can be recreated by the other tests.
+	tk.MustExec("drop table show_test, t1")
 }
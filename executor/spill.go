@@ -0,0 +1,348 @@
+// Copyright 2016 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"bufio"
+	"encoding/binary"
+	"hash/fnv"
+	"io"
+	"io/ioutil"
+	"os"
+	"sync/atomic"
+	"time"
+	"unsafe"
+
+	"github.com/juju/errors"
+	"github.com/ngaut/log"
+	"github.com/pingcap/tidb/model"
+	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/table"
+	"github.com/pingcap/tidb/util/codec"
+	"github.com/pingcap/tidb/util/types"
+)
+
+// SpillDir is the directory of the temporary files written by the executors which exceed the memory quota.
+var SpillDir = os.TempDir()
+
+var (
+	rowSize      = int64(unsafe.Sizeof(Row{}))
+	datumSize    = int64(unsafe.Sizeof(types.Datum{}))
+	rowKeySize   = int64(unsafe.Sizeof(RowKeyEntry{}))
+	orderRowSize = int64(unsafe.Sizeof(orderByRow{}))
+)
+
+// minSpillRows is the minimum number of rows held by an executor to spill, it avoids too many small temporary files
+// when the memory is mostly consumed by other executors.
+var minSpillRows = 64
+
+// spillPartitions is the number of partitions the rows are spilled to by their hash keys.
+const spillPartitions = 16
+
+// memTracker tracks the memory consumed by the executors of a statement.
+type memTracker struct {
+	// quota is the memory quota in bytes, a non-positive value means no limit.
+	quota    int64
+	consumed int64
+}
+
+func newMemTracker(quota int64) *memTracker {
+	return &memTracker{quota: quota}
+}
+
+func (t *memTracker) consume(bytes int64) {
+	atomic.AddInt64(&t.consumed, bytes)
+}
+
+func (t *memTracker) release(bytes int64) {
+	atomic.AddInt64(&t.consumed, -bytes)
+}
+
+// exceeded returns true if the consumed memory is more than the quota.
+func (t *memTracker) exceeded() bool {
+	return t.quota > 0 && atomic.LoadInt64(&t.consumed) > t.quota
+}
+
+// rowMemUsage estimates the memory used by a row.
+func rowMemUsage(row *Row) int64 {
+	usage := rowSize + int64(len(row.Data))*datumSize + int64(len(row.RowKeys))*rowKeySize
+	for _, d := range row.Data {
+		switch d.Kind() {
+		case types.KindString, types.KindBytes:
+			usage += int64(len(d.GetBytes()))
+		}
+	}
+	return usage
+}
+
+// spiller is implemented by the executors which spill data to disk when the memory quota is exceeded.
+type spiller interface {
+	// spillCount returns the number of times the executor spilled data to disk.
+	spillCount() int
+}
+
+// spillFile stores rows in a temporary file, the rows are read back in the written order.
+type spillFile struct {
+	file   *os.File
+	writer *bufio.Writer
+	reader *bufio.Reader
+	// tables are the tables of the row keys written, a row key is written as the offset of its table and its handle.
+	tables []rowKeyTable
+	buf    []byte
+	vals   []types.Datum
+}
+
+type rowKeyTable struct {
+	tbl    table.Table
+	asName *model.CIStr
+}
+
+func newSpillFile() (*spillFile, error) {
+	file, err := ioutil.TempFile(SpillDir, "tidb-spill-")
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return &spillFile{file: file, writer: bufio.NewWriter(file)}, nil
+}
+
+// write appends a row to the file.
+func (f *spillFile) write(row *Row) error {
+	vals := append(f.vals[:0], types.NewIntDatum(int64(len(row.Data))))
+	for _, d := range row.Data {
+		var err error
+		vals, err = appendSpillDatum(vals, d)
+		if err != nil {
+			return errors.Trace(err)
+		}
+	}
+	for _, key := range row.RowKeys {
+		if key == nil {
+			vals = append(vals, types.NewIntDatum(-1))
+			continue
+		}
+		vals = append(vals, types.NewIntDatum(f.tableOffset(key)), types.NewIntDatum(key.Handle))
+	}
+	f.vals = vals
+	var err error
+	f.buf, err = codec.EncodeValue(f.buf[:0], vals...)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	var lenBuf [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(lenBuf[:], uint64(len(f.buf)))
+	if _, err = f.writer.Write(lenBuf[:n]); err != nil {
+		return errors.Trace(err)
+	}
+	_, err = f.writer.Write(f.buf)
+	return errors.Trace(err)
+}
+
+func (f *spillFile) tableOffset(key *RowKeyEntry) int64 {
+	for i, t := range f.tables {
+		if t.tbl == key.Tbl && t.asName == key.TableAsName {
+			return int64(i)
+		}
+	}
+	f.tables = append(f.tables, rowKeyTable{tbl: key.Tbl, asName: key.TableAsName})
+	return int64(len(f.tables) - 1)
+}
+
+// rewind finishes writing and moves to the beginning of the file to read the rows.
+func (f *spillFile) rewind() error {
+	if err := f.writer.Flush(); err != nil {
+		return errors.Trace(err)
+	}
+	if _, err := f.file.Seek(0, 0); err != nil {
+		return errors.Trace(err)
+	}
+	f.reader = bufio.NewReader(f.file)
+	return nil
+}
+
+// read reads the next row, it returns nil if there is no more row.
+func (f *spillFile) read() (*Row, error) {
+	size, err := binary.ReadUvarint(f.reader)
+	if err == io.EOF {
+		return nil, nil
+	} else if err != nil {
+		return nil, errors.Trace(err)
+	}
+	// The decoded bytes refer to the buffer, so it can't be reused.
+	buf := make([]byte, size)
+	if _, err = io.ReadFull(f.reader, buf); err != nil {
+		return nil, errors.Trace(err)
+	}
+	vals, err := codec.Decode(buf)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	row := &Row{Data: make([]types.Datum, vals[0].GetInt64())}
+	vals = vals[1:]
+	for i := range row.Data {
+		row.Data[i], vals, err = decodeSpillDatum(vals)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+	for len(vals) > 0 {
+		offset := vals[0].GetInt64()
+		if offset == -1 {
+			row.RowKeys = append(row.RowKeys, nil)
+			vals = vals[1:]
+			continue
+		}
+		t := f.tables[offset]
+		row.RowKeys = append(row.RowKeys, &RowKeyEntry{Tbl: t.tbl, Handle: vals[1].GetInt64(), TableAsName: t.asName})
+		vals = vals[2:]
+	}
+	return row, nil
+}
+
+// close closes and removes the file.
+func (f *spillFile) close() {
+	if err := f.file.Close(); err != nil {
+		log.Warnf("close spill file %s failed: %v", f.file.Name(), err)
+	}
+	if err := os.Remove(f.file.Name()); err != nil {
+		log.Warnf("remove spill file %s failed: %v", f.file.Name(), err)
+	}
+}
+
+// spillPartitionIdx returns the partition of a hash key, different seeds partition the same keys differently.
+func spillPartitionIdx(key []byte, seed int) int {
+	h := fnv.New32a()
+	h.Write([]byte{byte(seed)})
+	h.Write(key)
+	return int(h.Sum32() % spillPartitions)
+}
+
+// spillToPartition writes the row to the partition file, which is created when the first row is written.
+func spillToPartition(partitions []*spillFile, idx int, row *Row) error {
+	if partitions[idx] == nil {
+		file, err := newSpillFile()
+		if err != nil {
+			return errors.Trace(err)
+		}
+		partitions[idx] = file
+	}
+	return errors.Trace(partitions[idx].write(row))
+}
+
+// rewindPartitions finishes writing the partition files.
+func rewindPartitions(partitions []*spillFile) error {
+	for _, file := range partitions {
+		if file == nil {
+			continue
+		}
+		if err := file.rewind(); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	return nil
+}
+
+// closePartitions closes and removes the partition files.
+func closePartitions(partitions []*spillFile) {
+	for _, file := range partitions {
+		if file != nil {
+			file.close()
+		}
+	}
+}
+
+// appendSpillDatum appends the kind of the datum and the values that can be encoded by codec to rebuild it.
+func appendSpillDatum(vals []types.Datum, d types.Datum) ([]types.Datum, error) {
+	vals = append(vals, types.NewUintDatum(uint64(d.Kind())))
+	switch d.Kind() {
+	case types.KindNull:
+	case types.KindInt64, types.KindUint64, types.KindFloat64, types.KindString, types.KindBytes:
+		vals = append(vals, d)
+	case types.KindFloat32:
+		vals = append(vals, types.NewFloat64Datum(d.GetFloat64()))
+	case types.KindMysqlTime:
+		t := d.GetMysqlTime()
+		b, err := t.Marshal()
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		vals = append(vals, types.NewBytesDatum(b), types.NewIntDatum(int64(t.Type)), types.NewIntDatum(int64(t.Fsp)))
+	case types.KindMysqlDuration:
+		dur := d.GetMysqlDuration()
+		vals = append(vals, types.NewIntDatum(int64(dur.Duration)), types.NewIntDatum(int64(dur.Fsp)))
+	case types.KindMysqlDecimal:
+		vals = append(vals, types.NewStringDatum(d.GetMysqlDecimal().String()))
+	case types.KindMysqlEnum:
+		enum := d.GetMysqlEnum()
+		vals = append(vals, types.NewStringDatum(enum.Name), types.NewUintDatum(enum.Value))
+	case types.KindMysqlSet:
+		set := d.GetMysqlSet()
+		vals = append(vals, types.NewStringDatum(set.Name), types.NewUintDatum(set.Value))
+	case types.KindMysqlBit:
+		bit := d.GetMysqlBit()
+		vals = append(vals, types.NewUintDatum(bit.Value), types.NewIntDatum(int64(bit.Width)))
+	case types.KindMysqlHex:
+		vals = append(vals, types.NewIntDatum(d.GetMysqlHex().Value))
+	default:
+		return nil, errors.Errorf("unsupported spill datum kind %d", d.Kind())
+	}
+	return vals, nil
+}
+
+// decodeSpillDatum rebuilds a datum written by appendSpillDatum, it returns the remained values.
+func decodeSpillDatum(vals []types.Datum) (d types.Datum, remained []types.Datum, err error) {
+	kind := byte(vals[0].GetUint64())
+	vals = vals[1:]
+	switch kind {
+	case types.KindNull:
+		return d, vals, nil
+	case types.KindInt64, types.KindUint64, types.KindFloat64, types.KindBytes:
+		return vals[0], vals[1:], nil
+	case types.KindString:
+		d.SetString(string(vals[0].GetBytes()))
+		return d, vals[1:], nil
+	case types.KindFloat32:
+		d.SetFloat32(float32(vals[0].GetFloat64()))
+		return d, vals[1:], nil
+	case types.KindMysqlTime:
+		t := mysql.Time{Type: uint8(vals[1].GetInt64()), Fsp: int(vals[2].GetInt64())}
+		if err = t.Unmarshal(vals[0].GetBytes()); err != nil {
+			return d, nil, errors.Trace(err)
+		}
+		d.SetMysqlTime(t)
+		return d, vals[3:], nil
+	case types.KindMysqlDuration:
+		d.SetMysqlDuration(mysql.Duration{Duration: time.Duration(vals[0].GetInt64()), Fsp: int(vals[1].GetInt64())})
+		return d, vals[2:], nil
+	case types.KindMysqlDecimal:
+		dec, err := mysql.ParseDecimal(string(vals[0].GetBytes()))
+		if err != nil {
+			return d, nil, errors.Trace(err)
+		}
+		d.SetMysqlDecimal(dec)
+		return d, vals[1:], nil
+	case types.KindMysqlEnum:
+		d.SetMysqlEnum(mysql.Enum{Name: string(vals[0].GetBytes()), Value: vals[1].GetUint64()})
+		return d, vals[2:], nil
+	case types.KindMysqlSet:
+		d.SetMysqlSet(mysql.Set{Name: string(vals[0].GetBytes()), Value: vals[1].GetUint64()})
+		return d, vals[2:], nil
+	case types.KindMysqlBit:
+		d.SetMysqlBit(mysql.Bit{Value: vals[0].GetUint64(), Width: int(vals[1].GetInt64())})
+		return d, vals[2:], nil
+	case types.KindMysqlHex:
+		d.SetMysqlHex(mysql.Hex{Value: vals[0].GetInt64()})
+		return d, vals[1:], nil
+	}
+	return d, nil, errors.Errorf("unsupported spill datum kind %d", kind)
+}
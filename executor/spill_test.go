@@ -0,0 +1,83 @@
+// Copyright 2016 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/tidb/executor"
+	"github.com/pingcap/tidb/plan"
+	"github.com/pingcap/tidb/util/testkit"
+	"github.com/pingcap/tidb/util/testleak"
+)
+
+func (s *testSuite) TestSpill(c *C) {
+	plan.UseNewPlanner = true
+	defer testleak.AfterTest(c)()
+	dir, err := ioutil.TempDir("", "spill-test")
+	c.Assert(err, IsNil)
+	defer os.RemoveAll(dir)
+	oldDir := executor.SpillDir
+	executor.SpillDir = dir
+	defer func() {
+		executor.SpillDir = oldDir
+	}()
+
+	tk := testkit.NewTestKit(c, s.store)
+	tk.MustExec("use test")
+	tk.MustExec("drop table if exists t1")
+	tk.MustExec("drop table if exists t2")
+	tk.MustExec("create table t1 (c1 int, c2 varchar(20), c3 datetime, c4 decimal(10, 2))")
+	tk.MustExec("create table t2 (c1 int, c2 int)")
+	var values1, values2 []string
+	for i := 0; i < 300; i++ {
+		values1 = append(values1, fmt.Sprintf("(%d, 'v%d', '2016-10-%02d 10:00:00', %d.25)", i, i%100, i%28+1, i%7))
+		values2 = append(values2, fmt.Sprintf("(%d, %d)", i%150, i))
+	}
+	tk.MustExec("insert into t1 values " + strings.Join(values1, ", "))
+	tk.MustExec("insert into t2 values " + strings.Join(values2, ", "))
+
+	sqls := []string{
+		"select * from t1 order by c2, c3 desc, c1",
+		"select * from t1 order by c4, c1 desc",
+		"select c2, count(*), sum(c4), max(c3) from t1 group by c2 order by min(c1)",
+		"select t1.c1, t2.c2 from t1 join t2 on t1.c1 = t2.c1 order by t2.c2",
+		"select t1.c1, t2.c2 from t1 left join t2 on t1.c1 = t2.c1 and t2.c2 > 100 order by t1.c1, t2.c2",
+	}
+	var expected [][][]interface{}
+	for _, sql := range sqls {
+		expected = append(expected, tk.MustQuery(sql).Rows())
+	}
+	tk.MustExec("set @@tidb_mem_quota_query = 1")
+	for i, sql := range sqls {
+		c.Assert(tk.MustQuery(sql).Rows(), DeepEquals, expected[i], Commentf("for %s", sql))
+	}
+	files, err := ioutil.ReadDir(dir)
+	c.Assert(err, IsNil)
+	c.Assert(files, HasLen, 0)
+
+	result := tk.MustQuery("explain analyze select c2, count(*) from t1 group by c2 order by min(c1)")
+	result.Check(testkit.Rows("1 Trim 0", "2 Sort 1", "3 Projection 0", "4 Aggregation 1", "5 TableScan(t1) 0"))
+	result = tk.MustQuery("explain analyze select t1.c1 from t1 join t2 on t1.c1 = t2.c1")
+	result.Check(testkit.Rows("1 Projection 0", "2 HashJoin 1", "3 TableScan(t1) 0", "4 TableScan(t2) 0"))
+
+	tk.MustExec("set @@tidb_mem_quota_query = 0")
+	result = tk.MustQuery("explain analyze select c2, count(*) from t1 group by c2 order by min(c1)")
+	result.Check(testkit.Rows("1 Trim 0", "2 Sort 0", "3 Projection 0", "4 Aggregation 0", "5 TableScan(t1) 0"))
+	plan.UseNewPlanner = false
+}
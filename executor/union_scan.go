@@ -49,12 +49,6 @@ func (udb *dirtyDB) deleteRow(tid int64, handle int64) {
 	dt.deletedRows[handle] = struct{}{}
 }
 
-func (udb *dirtyDB) truncateTable(tid int64) {
-	dt := udb.getDirtyTable(tid)
-	dt.addedRows = make(map[int64][]types.Datum)
-	dt.truncated = true
-}
-
 func (udb *dirtyDB) getDirtyTable(tid int64) *dirtyTable {
 	dt, ok := udb.tables[tid]
 	if !ok {
@@ -71,7 +65,6 @@ type dirtyTable struct {
 	// key is handle.
 	addedRows   map[int64][]types.Datum
 	deletedRows map[int64]struct{}
-	truncated   bool
 }
 
 type dirtyDBKeyType int
@@ -158,9 +151,6 @@ func (us *UnionScanExec) Next() (*Row, error) {
 }
 
 func (us *UnionScanExec) getSnapshotRow() (*Row, error) {
-	if us.dirty.truncated {
-		return nil, nil
-	}
 	var err error
 	if us.snapshotRow == nil {
 		for {
@@ -0,0 +1,374 @@
+// Copyright 2016 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"bytes"
+	"sort"
+
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/context"
+	"github.com/pingcap/tidb/expression"
+	"github.com/pingcap/tidb/plan"
+	"github.com/pingcap/tidb/util/codec"
+	"github.com/pingcap/tidb/util/types"
+)
+
+// WindowExec evaluates the window functions for all the rows of Src.
+// The rows are returned in the order of Src, with the results of the window functions appended.
+type WindowExec struct {
+	Src         Executor
+	WindowFuncs []*plan.WindowFuncDesc
+	schema      expression.Schema
+	ctx         context.Context
+
+	rows    []*Row
+	fetched bool
+	idx     int
+}
+
+// Schema implements Executor Schema interface.
+func (e *WindowExec) Schema() expression.Schema {
+	return e.schema
+}
+
+// Fields implements Executor Fields interface.
+func (e *WindowExec) Fields() []*ast.ResultField {
+	return nil
+}
+
+// Close implements Executor Close interface.
+func (e *WindowExec) Close() error {
+	e.rows = nil
+	e.fetched = false
+	e.idx = 0
+	return e.Src.Close()
+}
+
+// Next implements Executor Next interface.
+func (e *WindowExec) Next() (*Row, error) {
+	if !e.fetched {
+		if err := e.fetchAll(); err != nil {
+			return nil, errors.Trace(err)
+		}
+		e.fetched = true
+	}
+	if e.idx >= len(e.rows) {
+		return nil, nil
+	}
+	row := e.rows[e.idx]
+	e.idx++
+	return row, nil
+}
+
+// fetchAll reads all the rows from Src, because the result of a row depends on the other rows in its partition.
+func (e *WindowExec) fetchAll() error {
+	var srcRows []*Row
+	for {
+		row, err := e.Src.Next()
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if row == nil {
+			break
+		}
+		srcRows = append(srcRows, row)
+	}
+	results := make([][]types.Datum, 0, len(e.WindowFuncs))
+	for _, desc := range e.WindowFuncs {
+		vals, err := e.evalWindowFunc(desc, srcRows)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		results = append(results, vals)
+	}
+	e.rows = make([]*Row, 0, len(srcRows))
+	for i, srcRow := range srcRows {
+		data := make([]types.Datum, 0, len(srcRow.Data)+len(results))
+		data = append(data, srcRow.Data...)
+		for _, vals := range results {
+			data = append(data, vals[i])
+		}
+		e.rows = append(e.rows, &Row{Data: data, RowKeys: srcRow.RowKeys})
+	}
+	return nil
+}
+
+// windowSorter sorts the row offsets by the partition keys and then the order by items.
+// The sort is stable, so the peers are kept in the order of Src.
+type windowSorter struct {
+	offsets   []int
+	partKeys  [][]byte
+	orderKeys [][]types.Datum
+	orderBy   []plan.ByItems
+	err       error
+}
+
+// Len implements sort.Interface Len interface.
+func (s *windowSorter) Len() int {
+	return len(s.offsets)
+}
+
+// Swap implements sort.Interface Swap interface.
+func (s *windowSorter) Swap(i, j int) {
+	s.offsets[i], s.offsets[j] = s.offsets[j], s.offsets[i]
+}
+
+// Less implements sort.Interface Less interface.
+func (s *windowSorter) Less(i, j int) bool {
+	a, b := s.offsets[i], s.offsets[j]
+	if cmp := bytes.Compare(s.partKeys[a], s.partKeys[b]); cmp != 0 {
+		return cmp < 0
+	}
+	return s.compareOrderKeys(a, b) < 0
+}
+
+func (s *windowSorter) compareOrderKeys(a, b int) int {
+	for i, item := range s.orderBy {
+		cmp, err := s.orderKeys[a][i].CompareDatum(s.orderKeys[b][i])
+		if err != nil {
+			s.err = errors.Trace(err)
+			return 0
+		}
+		if item.Desc {
+			cmp = -cmp
+		}
+		if cmp != 0 {
+			return cmp
+		}
+	}
+	return 0
+}
+
+// evalWindowFunc returns the results of the window function for the rows.
+func (e *WindowExec) evalWindowFunc(desc *plan.WindowFuncDesc, rows []*Row) ([]types.Datum, error) {
+	s := &windowSorter{
+		offsets:   make([]int, len(rows)),
+		partKeys:  make([][]byte, len(rows)),
+		orderKeys: make([][]types.Datum, len(rows)),
+		orderBy:   desc.OrderBy,
+	}
+	vals := make([]types.Datum, len(desc.PartitionBy))
+	for i, row := range rows {
+		s.offsets[i] = i
+		for j, expr := range desc.PartitionBy {
+			v, err := expr.Eval(row.Data, e.ctx)
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+			vals[j] = v
+		}
+		key, err := codec.EncodeValue([]byte{}, vals...)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		s.partKeys[i] = key
+		s.orderKeys[i] = make([]types.Datum, len(desc.OrderBy))
+		for j, item := range desc.OrderBy {
+			s.orderKeys[i][j], err = item.Expr.Eval(row.Data, e.ctx)
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+		}
+	}
+	sort.Stable(s)
+	if s.err != nil {
+		return nil, errors.Trace(s.err)
+	}
+	results := make([]types.Datum, len(rows))
+	for start := 0; start < len(s.offsets); {
+		end := start + 1
+		for end < len(s.offsets) && bytes.Equal(s.partKeys[s.offsets[start]], s.partKeys[s.offsets[end]]) {
+			end++
+		}
+		p := &windowPartition{
+			e:       e,
+			desc:    desc,
+			sorter:  s,
+			rows:    rows,
+			offsets: s.offsets[start:end],
+			results: results,
+		}
+		if err := p.eval(); err != nil {
+			return nil, errors.Trace(err)
+		}
+		start = end
+	}
+	return results, nil
+}
+
+// windowPartition evaluates a window function for the rows in a partition.
+type windowPartition struct {
+	e      *WindowExec
+	desc   *plan.WindowFuncDesc
+	sorter *windowSorter
+	rows   []*Row
+	// offsets are the offsets of the rows in the partition in order.
+	offsets []int
+	// results are the results of all the rows, the result of rows[i] is results[i].
+	results []types.Datum
+}
+
+func (p *windowPartition) row(k int) *Row {
+	return p.rows[p.offsets[k]]
+}
+
+// isPeer checks whether the k-th row is a peer of the (k-1)-th row, which has the same order by values.
+func (p *windowPartition) isPeer(k int) bool {
+	return k > 0 && p.sorter.compareOrderKeys(p.offsets[k-1], p.offsets[k]) == 0
+}
+
+func (p *windowPartition) eval() error {
+	switch p.desc.Name {
+	case ast.WindowFuncRowNumber:
+		for k, offset := range p.offsets {
+			p.results[offset].SetInt64(int64(k + 1))
+		}
+	case ast.WindowFuncRank, ast.WindowFuncDenseRank:
+		var rank int64
+		for k, offset := range p.offsets {
+			if !p.isPeer(k) {
+				if p.desc.Name == ast.WindowFuncRank {
+					rank = int64(k + 1)
+				} else {
+					rank++
+				}
+			}
+			p.results[offset].SetInt64(rank)
+		}
+	case ast.WindowFuncLead, ast.WindowFuncLag:
+		return errors.Trace(p.evalLeadLag())
+	case ast.WindowFuncFirstValue:
+		for k, offset := range p.offsets {
+			start, end := p.frame(k)
+			if start > end {
+				p.results[offset].SetNull()
+				continue
+			}
+			d, err := p.desc.Args[0].Eval(p.row(start).Data, p.e.ctx)
+			if err != nil {
+				return errors.Trace(err)
+			}
+			p.results[offset] = d
+		}
+	default:
+		return errors.Trace(p.evalAggregate())
+	}
+	return errors.Trace(p.sorter.err)
+}
+
+func (p *windowPartition) evalLeadLag() error {
+	offset := int64(1)
+	if len(p.desc.Args) > 1 {
+		d, err := p.desc.Args[1].Eval(nil, p.e.ctx)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		offset, err = d.ToInt64()
+		if err != nil {
+			return errors.Trace(err)
+		}
+	}
+	if p.desc.Name == ast.WindowFuncLag {
+		offset = -offset
+	}
+	for k, rowOffset := range p.offsets {
+		target := int64(k) + offset
+		var err error
+		if target >= 0 && target < int64(len(p.offsets)) {
+			p.results[rowOffset], err = p.desc.Args[0].Eval(p.row(int(target)).Data, p.e.ctx)
+		} else if len(p.desc.Args) > 2 {
+			// The default value is evaluated on the current row.
+			p.results[rowOffset], err = p.desc.Args[2].Eval(p.row(k).Data, p.e.ctx)
+		} else {
+			p.results[rowOffset].SetNull()
+		}
+		if err != nil {
+			return errors.Trace(err)
+		}
+	}
+	return nil
+}
+
+// evalAggregate evaluates the aggregate function over the frame of every row. If the frames start from the first
+// row of the partition, the rows are added to the aggregate function incrementally as the frame end moves forward.
+func (p *windowPartition) evalAggregate() error {
+	af := expression.NewAggFunction(p.desc.Name, p.desc.Args, false)
+	if af == nil {
+		return errors.Errorf("unknown window function %s", p.desc.Name)
+	}
+	incremental := p.desc.Frame == nil || p.desc.Frame.Start.Type == ast.UnboundedPreceding
+	added := -1
+	for k, offset := range p.offsets {
+		start, end := p.frame(k)
+		if !incremental {
+			af.Clear()
+			added = start - 1
+		}
+		for added < end {
+			added++
+			if err := af.Update(p.row(added).Data, nil, p.e.ctx); err != nil {
+				return errors.Trace(err)
+			}
+		}
+		p.results[offset] = af.GetGroupResult(nil)
+	}
+	return nil
+}
+
+// frame returns the first and the last row of the frame of the k-th row, the frame is empty if start > end.
+// Without the frame clause, the frame is the whole partition if there is no order by item, otherwise it is
+// from the first row to the last peer of the current row.
+func (p *windowPartition) frame(k int) (start, end int) {
+	last := len(p.offsets) - 1
+	frame := p.desc.Frame
+	if frame == nil {
+		if len(p.desc.OrderBy) == 0 {
+			return 0, last
+		}
+		end = k
+		for end < last && p.isPeer(end+1) {
+			end++
+		}
+		return 0, end
+	}
+	start, end = frameBound(frame.Start, k, last), frameBound(frame.End, k, last)
+	if start < 0 {
+		start = 0
+	}
+	if end > last {
+		end = last
+	}
+	return start, end
+}
+
+func frameBound(bound ast.FrameBound, k, last int) int {
+	// The offset is clamped to the partition size, so it doesn't overflow when it is converted to int.
+	offset := last + 1
+	if bound.Offset < uint64(offset) {
+		offset = int(bound.Offset)
+	}
+	switch bound.Type {
+	case ast.UnboundedPreceding:
+		return 0
+	case ast.Preceding:
+		return k - offset
+	case ast.Following:
+		return k + offset
+	case ast.UnboundedFollowing:
+		return last
+	}
+	return k
+}
@@ -42,6 +42,10 @@ type AggregationFunction interface {
 
 	// Clear collects the mapper's memory.
 	Clear()
+
+	// Clone copies the aggregate function without its results. The copy shares the arguments,
+	// so it can be updated in another goroutine.
+	Clone() AggregationFunction
 }
 
 // NewAggFunction creates a new AggregationFunction.
@@ -84,6 +88,10 @@ func (af *aggFunction) Clear() {
 	af.resultMapper = make(aggCtxMapper, 0)
 }
 
+func (af *aggFunction) clone() aggFunction {
+	return newAggFunc(af.Args, af.Distinct)
+}
+
 // GetArgs implements AggregationFunction interface.
 func (af *aggFunction) GetArgs() []Expression {
 	return af.Args
@@ -137,6 +145,11 @@ type sumFunction struct {
 	aggFunction
 }
 
+// Clone implements AggregationFunction interface.
+func (sf *sumFunction) Clone() AggregationFunction {
+	return &sumFunction{aggFunction: sf.aggFunction.clone()}
+}
+
 // Update implements AggregationFunction interface.
 func (sf *sumFunction) Update(row []types.Datum, groupKey []byte, ctx context.Context) error {
 	return sf.updateSum(row, groupKey, ctx)
@@ -152,6 +165,11 @@ type countFunction struct {
 	aggFunction
 }
 
+// Clone implements AggregationFunction interface.
+func (cf *countFunction) Clone() AggregationFunction {
+	return &countFunction{aggFunction: cf.aggFunction.clone()}
+}
+
 // Update implements AggregationFunction interface.
 func (cf *countFunction) Update(row []types.Datum, groupKey []byte, ectx context.Context) error {
 	ctx := cf.getContext(groupKey)
@@ -194,6 +212,11 @@ type avgFunction struct {
 	aggFunction
 }
 
+// Clone implements AggregationFunction interface.
+func (af *avgFunction) Clone() AggregationFunction {
+	return &avgFunction{aggFunction: af.aggFunction.clone()}
+}
+
 // Update implements AggregationFunction interface.
 func (af *avgFunction) Update(row []types.Datum, groupKey []byte, ctx context.Context) error {
 	return af.updateSum(row, groupKey, ctx)
@@ -204,13 +227,9 @@ func (af *avgFunction) GetGroupResult(groupKey []byte) (d types.Datum) {
 	ctx := af.getContext(groupKey)
 	switch x := ctx.Value.(type) {
 	case float64:
-		t := x / float64(ctx.Count)
-		ctx.Value = t
-		d.SetFloat64(t)
+		d.SetFloat64(x / float64(ctx.Count))
 	case mysql.Decimal:
-		t := x.Div(mysql.NewDecimalFromUint(uint64(ctx.Count), 0))
-		ctx.Value = t
-		d.SetMysqlDecimal(t)
+		d.SetMysqlDecimal(x.Div(mysql.NewDecimalFromUint(uint64(ctx.Count), 0)))
 	}
 	return
 }
@@ -219,6 +238,11 @@ type concatFunction struct {
 	aggFunction
 }
 
+// Clone implements AggregationFunction interface.
+func (cf *concatFunction) Clone() AggregationFunction {
+	return &concatFunction{aggFunction: cf.aggFunction.clone()}
+}
+
 // Update implements AggregationFunction interface.
 func (cf *concatFunction) Update(row []types.Datum, groupKey []byte, ectx context.Context) error {
 	ctx := cf.getContext(groupKey)
@@ -271,6 +295,11 @@ type maxMinFunction struct {
 	isMax bool
 }
 
+// Clone implements AggregationFunction interface.
+func (mmf *maxMinFunction) Clone() AggregationFunction {
+	return &maxMinFunction{aggFunction: mmf.aggFunction.clone(), isMax: mmf.isMax}
+}
+
 // GetGroupResult implements AggregationFunction interface.
 func (mmf *maxMinFunction) GetGroupResult(groupKey []byte) (d types.Datum) {
 	d.SetValue(mmf.getContext(groupKey).Value)
@@ -310,6 +339,11 @@ type firstRowFunction struct {
 	aggFunction
 }
 
+// Clone implements AggregationFunction interface.
+func (ff *firstRowFunction) Clone() AggregationFunction {
+	return &firstRowFunction{aggFunction: ff.aggFunction.clone()}
+}
+
 // Update implements AggregationFunction interface.
 func (ff *firstRowFunction) Update(row []types.Datum, groupKey []byte, ectx context.Context) error {
 	ctx := ff.getContext(groupKey)
@@ -19,6 +19,7 @@ import (
 	. "github.com/pingcap/check"
 	"github.com/pingcap/tidb/ast"
 	"github.com/pingcap/tidb/model"
+	"github.com/pingcap/tidb/mysql"
 	"github.com/pingcap/tidb/util/testleak"
 	"github.com/pingcap/tidb/util/types"
 )
@@ -64,3 +65,56 @@ func (s *testExpressionSuite) TestExpression(c *C) {
 	index, err = getColIndex([]Expression{tc1, kc1, con}, schema, col)
 	c.Check(err, NotNil)
 }
+
+func (s *testExpressionSuite) TestEvalBatch(c *C) {
+	defer testleak.AfterTest(c)()
+
+	columns := [][]types.Datum{
+		types.MakeDatums(1, 2, 3, nil),
+		types.MakeDatums(10, 20, 30, 40),
+	}
+	col0 := &Column{Index: 0, RetType: types.NewFieldType(mysql.TypeLonglong)}
+	col1 := &Column{Index: 1, RetType: types.NewFieldType(mysql.TypeLonglong)}
+	corCol := &Column{Correlated: true}
+	corCol.SetValue(&types.Datum{})
+	corCol.data.SetInt64(25)
+	con := &Constant{Value: types.NewIntDatum(2)}
+
+	plus, err := NewFunction(ast.Plus, []Expression{col0, con}, types.NewFieldType(mysql.TypeLonglong))
+	c.Assert(err, IsNil)
+	gt, err := NewFunction(ast.GT, []Expression{col1, corCol}, types.NewFieldType(mysql.TypeLonglong))
+	c.Assert(err, IsNil)
+	cases := []struct {
+		expr     Expression
+		expected []types.Datum
+	}{
+		{col1, columns[1]},
+		{con, types.MakeDatums(2, 2, 2, 2)},
+		{corCol, types.MakeDatums(25, 25, 25, 25)},
+		{plus, types.MakeDatums(3, 4, 5, nil)},
+		{gt, types.MakeDatums(0, 0, 1, 1)},
+	}
+	for _, ca := range cases {
+		vals, err := EvalBatch(ca.expr, columns, 4, nil)
+		c.Assert(err, IsNil)
+		c.Assert(vals, HasLen, 4)
+		for i, val := range vals {
+			// The results are the same as the expression evaluated row by row.
+			d, err := ca.expr.Eval([]types.Datum{columns[0][i], columns[1][i]}, nil)
+			c.Assert(err, IsNil)
+			cmp, err := val.CompareDatum(d)
+			c.Assert(err, IsNil)
+			c.Assert(cmp, Equals, 0)
+			cmp, err = val.CompareDatum(ca.expected[i])
+			c.Assert(err, IsNil)
+			c.Assert(cmp, Equals, 0, Commentf("for %s row %d", ca.expr.ToString(), i))
+		}
+	}
+
+	selected, err := VectorizedFilter(gt, columns, 4, nil)
+	c.Assert(err, IsNil)
+	c.Assert(selected, DeepEquals, []bool{false, false, true, true})
+	selected, err = VectorizedFilter(plus, columns, 4, nil)
+	c.Assert(err, IsNil)
+	c.Assert(selected, DeepEquals, []bool{true, true, true, false})
+}
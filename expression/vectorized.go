@@ -0,0 +1,104 @@
+// Copyright 2016 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package expression
+
+import (
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/context"
+	"github.com/pingcap/tidb/util/types"
+)
+
+// EvalBatch evaluates an expression through a batch of rows stored by columns, columns[i] holds the values
+// of the i-th column of the rows. It returns the values of the expression for all the rows.
+// The returned values of a column are the column itself, so they must not be modified.
+func EvalBatch(expr Expression, columns [][]types.Datum, numRows int, ctx context.Context) ([]types.Datum, error) {
+	switch x := expr.(type) {
+	case *Column:
+		if x.Correlated {
+			return repeatDatum(*x.data, numRows), nil
+		}
+		return columns[x.Index], nil
+	case *Constant:
+		return repeatDatum(x.Value, numRows), nil
+	case *ScalarFunction:
+		return x.evalBatch(columns, numRows, ctx)
+	}
+	results := make([]types.Datum, numRows)
+	row := make([]types.Datum, len(columns))
+	for i := range results {
+		for j, col := range columns {
+			row[j] = col[i]
+		}
+		d, err := expr.Eval(row, ctx)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		results[i] = d
+	}
+	return results, nil
+}
+
+// VectorizedFilter evaluates the filter through a batch of rows stored by columns,
+// and returns whether each row is selected.
+func VectorizedFilter(filter Expression, columns [][]types.Datum, numRows int, ctx context.Context) ([]bool, error) {
+	vals, err := EvalBatch(filter, columns, numRows, ctx)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	selected := make([]bool, numRows)
+	for i, d := range vals {
+		if d.IsNull() {
+			continue
+		}
+		b, err := d.ToBool()
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		selected[i] = b != 0
+	}
+	return selected, nil
+}
+
+// evalBatch evaluates all the arguments through the batch first, then calls the function row by row.
+func (sf *ScalarFunction) evalBatch(columns [][]types.Datum, numRows int, ctx context.Context) ([]types.Datum, error) {
+	argVals := make([][]types.Datum, 0, len(sf.Args))
+	for _, arg := range sf.Args {
+		vals, err := EvalBatch(arg, columns, numRows, ctx)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		argVals = append(argVals, vals)
+	}
+	results := make([]types.Datum, numRows)
+	args := make([]types.Datum, len(sf.Args))
+	for i := range results {
+		for j, vals := range argVals {
+			args[j] = vals[i]
+		}
+		d, err := sf.Function(args, ctx)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		results[i] = d
+	}
+	return results, nil
+}
+
+func repeatDatum(d types.Datum, n int) []types.Datum {
+	vals := make([]types.Datum, n)
+	for i := range vals {
+		vals[i] = d
+	}
+	return vals
+}
@@ -15,10 +15,12 @@ package infoschema
 
 import (
 	"strings"
+	"sync"
 	"sync/atomic"
 
 	"github.com/juju/errors"
 	"github.com/pingcap/tidb/kv"
+	"github.com/pingcap/tidb/meta"
 	"github.com/pingcap/tidb/meta/autoid"
 	"github.com/pingcap/tidb/model"
 	"github.com/pingcap/tidb/mysql"
@@ -79,6 +81,14 @@ type InfoSchema interface {
 	Clone() (result []*model.DBInfo)
 	SchemaTables(schema model.CIStr) []table.Table
 	SchemaMetaVersion() int64
+	ReferringFKs(tableID int64) []*ReferringFK
+}
+
+// ReferringFK is a public foreign key of the child table which references a parent table.
+type ReferringFK struct {
+	ChildSchema *model.DBInfo
+	Child       table.Table
+	FK          *model.FKInfo
 }
 
 // Information Schema Name.
@@ -96,6 +106,8 @@ type infoSchema struct {
 	columns         map[int64]*model.ColumnInfo
 	indices         map[indexName]*model.IndexInfo
 	columnIndices   map[int64][]*model.IndexInfo
+	// referringFKs maps the table IDs to the foreign keys referencing the tables.
+	referringFKs map[int64][]*ReferringFK
 
 	// We should check version when change schema.
 	schemaMetaVersion int64
@@ -148,6 +160,10 @@ func (is *infoSchema) SchemaMetaVersion() int64 {
 	return is.schemaMetaVersion
 }
 
+func (is *infoSchema) ReferringFKs(tableID int64) []*ReferringFK {
+	return is.referringFKs[tableID]
+}
+
 func (is *infoSchema) SchemaExists(schema model.CIStr) bool {
 	_, ok := is.schemaNameToID[schema.L]
 	return ok
@@ -248,6 +264,8 @@ type Handle struct {
 	value     atomic.Value
 	store     kv.Storage
 	memSchema *memSchemaHandle
+	// statsMu protects the statistics tables from being refilled concurrently.
+	statsMu sync.Mutex
 }
 
 // NewHandle creates a new Handle.
@@ -295,6 +313,8 @@ type memSchemaHandle struct {
 	defTbl        table.Table
 	profilingTbl  table.Table
 	partitionsTbl table.Table
+	statsMetaTbl  table.Table
+	statsHistsTbl table.Table
 	nameToTable   map[string]table.Table
 	// Performance Schema
 	perfHandle perfschema.PerfSchema
@@ -328,6 +348,8 @@ func initMemoryTables(h *memSchemaHandle) error {
 	h.statisticsTbl = h.nameToTable[strings.ToLower(tableStatistics)]
 	h.charsetTbl = h.nameToTable[strings.ToLower(tableCharacterSets)]
 	h.collationsTbl = h.nameToTable[strings.ToLower(tableCollations)]
+	h.statsMetaTbl = h.nameToTable[strings.ToLower(tableStatsMeta)]
+	h.statsHistsTbl = h.nameToTable[strings.ToLower(tableStatsHists)]
 
 	// CharacterSets/Collations contain static data. Init them now.
 	err = insertData(h.charsetTbl, dataForCharacterSets())
@@ -379,6 +401,7 @@ func (h *Handle) Set(newInfo []*model.DBInfo, schemaMetaVersion int64) error {
 		columns:           map[int64]*model.ColumnInfo{},
 		indices:           map[indexName]*model.IndexInfo{},
 		columnIndices:     map[int64][]*model.IndexInfo{},
+		referringFKs:      map[int64][]*ReferringFK{},
 		schemaMetaVersion: schemaMetaVersion,
 	}
 	var err error
@@ -419,6 +442,28 @@ func (h *Handle) Set(newInfo []*model.DBInfo, schemaMetaVersion int64) error {
 			}
 		}
 	}
+	// The foreign keys are indexed by the referenced tables after all the tables are built.
+	for _, di := range newInfo {
+		for _, t := range di.Tables {
+			for _, fk := range t.ForeignKeys {
+				if fk.State != model.StatePublic {
+					continue
+				}
+				refSchema := fk.RefSchema
+				if refSchema.L == "" {
+					refSchema = di.Name
+				}
+				parentID, ok := info.tableNameToID[tableName{refSchema.L, fk.RefTable.L}]
+				if !ok {
+					// The referenced table doesn't exist, no row can be referenced.
+					continue
+				}
+				ref := &ReferringFK{ChildSchema: di, Child: info.tables[t.ID], FK: fk}
+				info.referringFKs[parentID] = append(info.referringFKs[parentID], ref)
+			}
+		}
+	}
+
 	// Build Information_Schema
 	info.schemaNameToID[h.memSchema.isDB.Name.L] = h.memSchema.isDB.ID
 	info.schemas[h.memSchema.isDB.ID] = h.memSchema.isDB
@@ -481,6 +526,31 @@ func (h *Handle) Set(newInfo []*model.DBInfo, schemaMetaVersion int64) error {
 	return nil
 }
 
+// RefillStatsTables refills the statistics tables in Information_Schema with the statistics read by m.
+func (h *Handle) RefillStatsTables(m *meta.Meta) error {
+	is := h.Get()
+	if is == nil {
+		return nil
+	}
+	h.statsMu.Lock()
+	defer h.statsMu.Unlock()
+	dbInfos := is.AllSchemas()
+	rows, err := DataForStatsMeta(dbInfos, m)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	err = refillTable(h.memSchema.statsMetaTbl, rows)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	rows, err = DataForStatsHistograms(dbInfos, m)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	err = refillTable(h.memSchema.statsHistsTbl, rows)
+	return errors.Trace(err)
+}
+
 // Get gets information schema from Handle.
 func (h *Handle) Get() InfoSchema {
 	v := h.value.Load()
@@ -82,14 +82,24 @@ func (*testSuite) TestT(c *C) {
 		State:   model.StatePublic,
 	}
 
+	fkInfo := &model.FKInfo{
+		ID:       1,
+		Name:     model.NewCIStr("fk"),
+		RefTable: tbName,
+		RefCols:  []model.CIStr{colName},
+		Cols:     []model.CIStr{colName},
+		State:    model.StatePublic,
+	}
+
 	tbID, err := genGlobalID(store)
 	c.Assert(err, IsNil)
 	tblInfo := &model.TableInfo{
-		ID:      tbID,
-		Name:    tbName,
-		Columns: []*model.ColumnInfo{colInfo},
-		Indices: []*model.IndexInfo{idxInfo},
-		State:   model.StatePublic,
+		ID:          tbID,
+		Name:        tbName,
+		Columns:     []*model.ColumnInfo{colInfo},
+		Indices:     []*model.IndexInfo{idxInfo},
+		ForeignKeys: []*model.FKInfo{fkInfo},
+		State:       model.StatePublic,
 	}
 
 	dbID, err := genGlobalID(store)
@@ -189,6 +199,14 @@ func (*testSuite) TestT(c *C) {
 	c.Assert(ok, IsTrue)
 	c.Assert(idx, NotNil)
 
+	// The foreign key references the table itself.
+	refs := is.ReferringFKs(tbID)
+	c.Assert(refs, HasLen, 1)
+	c.Assert(refs[0].FK, Equals, fkInfo)
+	c.Assert(refs[0].ChildSchema.ID, Equals, dbID)
+	c.Assert(refs[0].Child.Meta().ID, Equals, tbID)
+	c.Assert(is.ReferringFKs(dbID), HasLen, 0)
+
 	// Make sure partitions table exists
 	tb, err = is.TableByName(model.NewCIStr("information_schema"), model.NewCIStr("partitions"))
 	c.Assert(err, IsNil)
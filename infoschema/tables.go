@@ -17,9 +17,12 @@ import (
 	"fmt"
 	"sort"
 
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/meta"
 	"github.com/pingcap/tidb/meta/autoid"
 	"github.com/pingcap/tidb/model"
 	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/plan/statistics"
 	"github.com/pingcap/tidb/table"
 	"github.com/pingcap/tidb/table/tables"
 	"github.com/pingcap/tidb/util/charset"
@@ -37,6 +40,8 @@ const (
 	catalogVal         = "def"
 	tableProfiling     = "PROFILING"
 	tablePartitions    = "PARTITIONS"
+	tableStatsMeta     = "STATS_META"
+	tableStatsHists    = "STATS_HISTOGRAMS"
 )
 
 type columnInfo struct {
@@ -160,6 +165,25 @@ var statisticsCols = []columnInfo{
 	{"INDEX_COMMENT", mysql.TypeVarchar, 1024, 0, nil, nil},
 }
 
+var statsMetaCols = []columnInfo{
+	{"TABLE_SCHEMA", mysql.TypeVarchar, 64, 0, nil, nil},
+	{"TABLE_NAME", mysql.TypeVarchar, 64, 0, nil, nil},
+	{"UPDATE_TIME", mysql.TypeDatetime, 19, 0, nil, nil},
+	{"ROW_COUNT", mysql.TypeLonglong, 21, 0, nil, nil},
+}
+
+var statsHistsCols = []columnInfo{
+	{"TABLE_SCHEMA", mysql.TypeVarchar, 64, 0, nil, nil},
+	{"TABLE_NAME", mysql.TypeVarchar, 64, 0, nil, nil},
+	{"COLUMN_NAME", mysql.TypeVarchar, 64, 0, nil, nil},
+	{"IS_INDEX", mysql.TypeLonglong, 1, 0, nil, nil},
+	{"DISTINCT_COUNT", mysql.TypeLonglong, 21, 0, nil, nil},
+	{"BUCKET_ID", mysql.TypeLonglong, 21, 0, nil, nil},
+	{"COUNT", mysql.TypeLonglong, 21, 0, nil, nil},
+	{"REPEATS", mysql.TypeLonglong, 21, 0, nil, nil},
+	{"UPPER_BOUND", mysql.TypeBlob, 196606, 0, nil, nil},
+}
+
 var profilingCols = []columnInfo{
 	{"QUERY_ID", mysql.TypeLong, 20, 0, nil, nil},
 	{"SEQ", mysql.TypeLong, 20, 0, nil, nil},
@@ -471,6 +495,124 @@ func dataForStatisticsInTable(schema *model.DBInfo, table *model.TableInfo) [][]
 	return rows
 }
 
+// DataForStatsMeta returns the rows of the STATS_META table, one row for every analyzed table in schemas.
+// The statistics are read from the storage by m, so they are the ones saved by the last ANALYZE.
+func DataForStatsMeta(schemas []*model.DBInfo, m *meta.Meta) ([][]types.Datum, error) {
+	rows := [][]types.Datum{}
+	for _, schema := range schemas {
+		for _, table := range schema.Tables {
+			tpb, err := m.GetTableStats(table.ID)
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+			if tpb == nil {
+				continue
+			}
+			t := &statistics.Table{TS: tpb.GetTs()}
+			record := types.MakeDatums(
+				schema.Name.O, // TABLE_SCHEMA
+				table.Name.O,  // TABLE_NAME
+				mysql.Time{Time: t.BuildTime(), Type: mysql.TypeDatetime}, // UPDATE_TIME
+				tpb.GetCount(), // ROW_COUNT
+			)
+			rows = append(rows, record)
+		}
+	}
+	return rows, nil
+}
+
+// DataForStatsHistograms returns the rows of the STATS_HISTOGRAMS table, one row for every bucket of
+// the column and index histograms of the analyzed tables in schemas, which are read from the storage by m.
+func DataForStatsHistograms(schemas []*model.DBInfo, m *meta.Meta) ([][]types.Datum, error) {
+	rows := [][]types.Datum{}
+	for _, schema := range schemas {
+		for _, table := range schema.Tables {
+			tpb, err := m.GetTableStats(table.ID)
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+			if tpb == nil {
+				continue
+			}
+			for _, cpb := range tpb.GetColumns() {
+				// The column may have been dropped after the statistics was built.
+				colInfo := findColumnByID(table, cpb.GetId())
+				if colInfo == nil {
+					continue
+				}
+				col, err := statistics.ColumnFromPB(cpb, &colInfo.FieldType)
+				if err != nil {
+					// The values can't be converted to the type the column is modified to.
+					continue
+				}
+				rs, err := dataForHistogram(schema, table, colInfo.Name.O, false, col, col.ValueToString)
+				if err != nil {
+					return nil, errors.Trace(err)
+				}
+				rows = append(rows, rs...)
+			}
+			for _, ipb := range tpb.GetIndices() {
+				idxInfo := findIndexByID(table, ipb.GetId())
+				if idxInfo == nil {
+					continue
+				}
+				idx, err := statistics.IndexFromPB(ipb)
+				if err != nil {
+					return nil, errors.Trace(err)
+				}
+				rs, err := dataForHistogram(schema, table, idxInfo.Name.O, true, &idx.Column, idx.ValueToString)
+				if err != nil {
+					return nil, errors.Trace(err)
+				}
+				rows = append(rows, rs...)
+			}
+		}
+	}
+	return rows, nil
+}
+
+func dataForHistogram(schema *model.DBInfo, table *model.TableInfo, name string, isIndex bool,
+	col *statistics.Column, valueToString func(int) (string, error)) ([][]types.Datum, error) {
+	rows := make([][]types.Datum, 0, len(col.Numbers))
+	for i := range col.Numbers {
+		upper, err := valueToString(i)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		record := types.MakeDatums(
+			schema.Name.O,  // TABLE_SCHEMA
+			table.Name.O,   // TABLE_NAME
+			name,           // COLUMN_NAME
+			isIndex,        // IS_INDEX
+			col.NDV,        // DISTINCT_COUNT
+			i,              // BUCKET_ID
+			col.Numbers[i], // COUNT
+			col.Repeats[i], // REPEATS
+			upper,          // UPPER_BOUND
+		)
+		rows = append(rows, record)
+	}
+	return rows, nil
+}
+
+func findColumnByID(table *model.TableInfo, id int64) *model.ColumnInfo {
+	for _, col := range table.Columns {
+		if col.ID == id {
+			return col
+		}
+	}
+	return nil
+}
+
+func findIndexByID(table *model.TableInfo, id int64) *model.IndexInfo {
+	for _, idx := range table.Indices {
+		if idx.ID == id {
+			return idx
+		}
+	}
+	return nil
+}
+
 var tableNameToColumns = map[string]([]columnInfo){
 	tableSchemata:      schemataCols,
 	tableTables:        tablesCols,
@@ -481,6 +623,8 @@ var tableNameToColumns = map[string]([]columnInfo){
 	tableFiles:         filesCols,
 	tableProfiling:     profilingCols,
 	tablePartitions:    partitionsCols,
+	tableStatsMeta:     statsMetaCols,
+	tableStatsHists:    statsHistsCols,
 }
 
 func createMemoryTable(meta *model.TableInfo, alloc autoid.Allocator) (table.Table, error) {
@@ -90,6 +90,92 @@ func GetBgDDLInfo(txn kv.Transaction) (*DDLInfo, error) {
 	return info, nil
 }
 
+// GetDDLJobs returns the DDL jobs in the queue, the first one is the running job.
+func GetDDLJobs(txn kv.Transaction) ([]*model.Job, error) {
+	t := meta.NewMeta(txn)
+	cnt, err := t.DDLJobQueueLen()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+
+	jobs := make([]*model.Job, 0, cnt)
+	for i := int64(0); i < cnt; i++ {
+		job, err := t.GetDDLJob(i)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, nil
+}
+
+// GetHistoryDDLJobs returns at most maxNumJobs history DDL jobs, the latest job first.
+func GetHistoryDDLJobs(txn kv.Transaction, maxNumJobs int) ([]*model.Job, error) {
+	t := meta.NewMeta(txn)
+	jobs, err := t.GetLastNHistoryDDLJobs(maxNumJobs)
+	return jobs, errors.Trace(err)
+}
+
+// isJobRollbackable checks whether the job can be rolled back after it has changed the schema.
+func isJobRollbackable(job *model.Job) bool {
+	switch job.Type {
+	case model.ActionAddColumn, model.ActionAddIndex:
+		return true
+	case model.ActionModifyColumn:
+		// the origin column is replaced in the public state, it can't be restored after that.
+		return job.SchemaState != model.StatePublic
+	}
+	return job.SchemaState == model.StateNone
+}
+
+// CancelJobs marks the DDL jobs in the queue as cancelling, the DDL worker rolls them back later.
+// It returns an error for each job which can't be cancelled, the error is nil if the job is marked.
+func CancelJobs(txn kv.Transaction, ids []int64) ([]error, error) {
+	t := meta.NewMeta(txn)
+	jobs, err := GetDDLJobs(txn)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+
+	errs := make([]error, len(ids))
+	for i, id := range ids {
+		found := false
+		for j, job := range jobs {
+			if job.ID != id {
+				continue
+			}
+			found = true
+			if job.IsCancelling() || job.IsRollingback() || job.IsFinished() {
+				errs[i] = errCancelledDDLJob.Gen("DDL job %d has been cancelled", id)
+				break
+			}
+			if !isJobRollbackable(job) {
+				errs[i] = errCannotCancelDDLJob.Gen("DDL job %d can't be cancelled in state %s", id, job.SchemaState)
+				break
+			}
+			job.State = model.JobCancelling
+			if err = t.UpdateDDLJob(int64(j), job); err != nil {
+				return nil, errors.Trace(err)
+			}
+			break
+		}
+		if found {
+			continue
+		}
+
+		job, err := t.GetHistoryDDLJob(id)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if job != nil {
+			errs[i] = errFinishedDDLJob.Gen("DDL job %d has been %s", id, job.State)
+		} else {
+			errs[i] = errDDLJobNotFound.Gen("DDL job %d doesn't exist", id)
+		}
+	}
+	return errs, nil
+}
+
 func nextIndexVals(data []types.Datum) []types.Datum {
 	// Add 0x0 to the end of data.
 	return append(data, types.Datum{})
@@ -456,10 +542,18 @@ const (
 	codeDataNotEqual       terror.ErrCode = 1
 	codeRepeatHandle                      = 2
 	codeInvalidColumnState                = 3
+	codeDDLJobNotFound                    = 4
+	codeFinishedDDLJob                    = 5
+	codeCancelledDDLJob                   = 6
+	codeCannotCancelDDLJob                = 7
 )
 
 var (
 	errDateNotEqual       = terror.ClassInspectkv.New(codeDataNotEqual, "data isn't equal")
 	errRepeatHandle       = terror.ClassInspectkv.New(codeRepeatHandle, "handle is repeated")
 	errInvalidColumnState = terror.ClassInspectkv.New(codeInvalidColumnState, "invalid column state")
+	errDDLJobNotFound     = terror.ClassInspectkv.New(codeDDLJobNotFound, "DDL job not found")
+	errFinishedDDLJob     = terror.ClassInspectkv.New(codeFinishedDDLJob, "DDL job is finished")
+	errCancelledDDLJob    = terror.ClassInspectkv.New(codeCancelledDDLJob, "DDL job is cancelled")
+	errCannotCancelDDLJob = terror.ClassInspectkv.New(codeCannotCancelDDLJob, "DDL job can't be cancelled")
 )
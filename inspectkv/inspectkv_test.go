@@ -177,6 +177,54 @@ func (s *testSuite) TestGetBgDDLInfo(c *C) {
 	c.Assert(err, IsNil)
 }
 
+func (s *testSuite) TestCancelJobs(c *C) {
+	defer testleak.AfterTest(c)()
+	txn, err := s.store.Begin()
+	c.Assert(err, IsNil)
+	t := meta.NewMeta(txn)
+
+	jobs := []*model.Job{
+		{ID: 101, SchemaID: 1, Type: model.ActionCreateTable},
+		{ID: 102, SchemaID: 1, Type: model.ActionAddIndex, SchemaState: model.StateWriteReorganization},
+		{ID: 103, SchemaID: 1, Type: model.ActionDropColumn, SchemaState: model.StateWriteOnly},
+		{ID: 104, SchemaID: 1, Type: model.ActionAddColumn, State: model.JobCancelling},
+	}
+	for _, job := range jobs {
+		err = t.EnQueueDDLJob(job)
+		c.Assert(err, IsNil)
+	}
+	err = t.AddHistoryDDLJob(&model.Job{ID: 105, SchemaID: 1, Type: model.ActionDropTable, State: model.JobDone})
+	c.Assert(err, IsNil)
+
+	errs, err := CancelJobs(txn, []int64{101, 102, 103, 104, 105, 106})
+	c.Assert(err, IsNil)
+	c.Assert(errs, HasLen, 6)
+	c.Assert(errs[0], IsNil)
+	c.Assert(errs[1], IsNil)
+	c.Assert(errCannotCancelDDLJob.Equal(errs[2]), IsTrue)
+	c.Assert(errCancelledDDLJob.Equal(errs[3]), IsTrue)
+	c.Assert(errFinishedDDLJob.Equal(errs[4]), IsTrue)
+	c.Assert(errDDLJobNotFound.Equal(errs[5]), IsTrue)
+
+	queueJobs, err := GetDDLJobs(txn)
+	c.Assert(err, IsNil)
+	states := make(map[int64]model.JobState)
+	for _, job := range queueJobs {
+		states[job.ID] = job.State
+	}
+	c.Assert(states[101], Equals, model.JobCancelling)
+	c.Assert(states[102], Equals, model.JobCancelling)
+	c.Assert(states[103], Equals, model.JobNone)
+
+	historyJobs, err := GetHistoryDDLJobs(txn, 10)
+	c.Assert(err, IsNil)
+	c.Assert(historyJobs, HasLen, 1)
+	c.Assert(historyJobs[0].ID, Equals, int64(105))
+
+	err = txn.Rollback()
+	c.Assert(err, IsNil)
+}
+
 func (s *testSuite) TestScan(c *C) {
 	defer testleak.AfterTest(c)()
 	alloc := autoid.NewAllocator(s.store, s.dbInfo.ID)
@@ -106,12 +106,14 @@ type Client interface {
 
 // ReqTypes.
 const (
-	ReqTypeSelect = 101
-	ReqTypeIndex  = 102
+	ReqTypeSelect  = 101
+	ReqTypeIndex   = 102
+	ReqTypeAnalyze = 103
 
 	ReqSubTypeBasic   = 0
 	ReqSubTypeDesc    = 10000
 	ReqSubTypeGroupBy = 10001
+	ReqSubTypeTopN    = 10002
 )
 
 // KeyRange represents a range where StartKey <= key < EndKey.
@@ -145,6 +147,8 @@ type Request struct {
 // Response represents the response returned from KV layer.
 type Response interface {
 	// Next returns a resultSubset from a single storage unit.
+	// A storage unit may return its result in several subsets, each one is a chunk of the rows,
+	// so the rows can be consumed while the storage unit is still scanning.
 	// When full result set is returned, nil is returned.
 	Next() (resultSubset io.ReadCloser, err error)
 	// Close response.
@@ -49,6 +49,11 @@ var (
 //		TID:1 -> int64
 //		TID:2 -> int64
 //	}
+//	StatsVersion -> int64
+//	TableStatsVersions -> {
+//		TableID -> int64
+//	}
+//	TStats:1 -> table statistics []byte
 //
 
 var (
@@ -60,6 +65,10 @@ var (
 	mTableIDPrefix    = "TID"
 	mBootstrapKey     = []byte("BootstrapKey")
 	mTableStatsPrefix = "TStats"
+	// mStatsVersionKey is bumped whenever the statistics of any table are saved, and mTableStatsVersionsKey
+	// records the version of every table, so the servers reload only the statistics changed since their last load.
+	mStatsVersionKey       = []byte("StatsVersion")
+	mTableStatsVersionsKey = []byte("TableStatsVersions")
 )
 
 var (
@@ -418,6 +427,7 @@ func (m *Meta) GetTable(dbID int64, tableID int64) (*model.TableInfo, error) {
 //	DDLOnwer: []byte
 //	DDLJobList: list jobs
 //	DDLJobHistory: hash
+//	DDLJobHistoryIDs: list
 //	DDLJobReorg: hash
 //
 // for multi DDL workers, only one can become the owner
@@ -427,7 +437,10 @@ var (
 	mDDLJobOwnerKey   = []byte("DDLJobOwner")
 	mDDLJobListKey    = []byte("DDLJobList")
 	mDDLJobHistoryKey = []byte("DDLJobHistory")
-	mDDLJobReorgKey   = []byte("DDLJobReorg")
+	// mDDLJobHistoryIDsKey lists the IDs of the history DDL jobs in the order they are finished,
+	// so that the latest jobs can be read without iterating the history hash.
+	mDDLJobHistoryIDsKey = []byte("DDLJobHistoryIDs")
+	mDDLJobReorgKey      = []byte("DDLJobReorg")
 )
 
 func (m *Meta) getJobOwner(key []byte) (*model.Owner, error) {
@@ -542,7 +555,10 @@ func (m *Meta) addHistoryDDLJob(key []byte, job *model.Job) error {
 
 // AddHistoryDDLJob adds DDL job to history.
 func (m *Meta) AddHistoryDDLJob(job *model.Job) error {
-	return m.addHistoryDDLJob(mDDLJobHistoryKey, job)
+	if err := m.addHistoryDDLJob(mDDLJobHistoryKey, job); err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Trace(m.txn.RPush(mDDLJobHistoryIDsKey, m.jobIDKey(job.ID)))
 }
 
 func (m *Meta) getHistoryDDLJob(key []byte, id int64) (*model.Job, error) {
@@ -561,6 +577,30 @@ func (m *Meta) GetHistoryDDLJob(id int64) (*model.Job, error) {
 	return m.getHistoryDDLJob(mDDLJobHistoryKey, id)
 }
 
+// GetLastNHistoryDDLJobs gets at most num history DDL jobs, the latest finished job first.
+func (m *Meta) GetLastNHistoryDDLJobs(num int) ([]*model.Job, error) {
+	jobs := make([]*model.Job, 0, num)
+	for i := 1; i <= num; i++ {
+		id, err := m.txn.LIndex(mDDLJobHistoryIDsKey, int64(-i))
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if id == nil {
+			break
+		}
+
+		job, err := m.getHistoryDDLJob(mDDLJobHistoryKey, int64(binary.BigEndian.Uint64(id)))
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if job == nil {
+			return nil, errors.Errorf("history DDL job %d doesn't exist", binary.BigEndian.Uint64(id))
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, nil
+}
+
 // IsBootstrapped returns whether we have already run bootstrap or not.
 // return true means we don't need doing any other bootstrap.
 func (m *Meta) IsBootstrapped() (bool, error) {
@@ -657,11 +697,17 @@ func (m *Meta) SetBgJobOwner(o *model.Owner) error {
 	return m.setJobOwner(mBgJobOwnerKey, o)
 }
 
+func (m *Meta) tableStatsVersionField(tableID int64) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, uint64(tableID))
+	return b
+}
+
 func (m *Meta) tableStatsKey(tableID int64) []byte {
 	return []byte(fmt.Sprintf("%s:%d", mTableStatsPrefix, tableID))
 }
 
-// SetTableStats sets table statistics.
+// SetTableStats sets table statistics, and bumps the statistics version.
 func (m *Meta) SetTableStats(tableID int64, tpb *statistics.TablePB) error {
 	key := m.tableStatsKey(tableID)
 	data, err := proto.Marshal(tpb)
@@ -672,14 +718,41 @@ func (m *Meta) SetTableStats(tableID int64, tpb *statistics.TablePB) error {
 	if err != nil {
 		return errors.Trace(err)
 	}
-	return nil
+	version, err := m.txn.Inc(mStatsVersionKey, 1)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	err = m.txn.HSet(mTableStatsVersionsKey, m.tableStatsVersionField(tableID), []byte(strconv.FormatInt(version, 10)))
+	return errors.Trace(err)
+}
+
+// GetStatsVersion gets the current statistics version.
+func (m *Meta) GetStatsVersion() (int64, error) {
+	return m.txn.GetInt64(mStatsVersionKey)
+}
+
+// GetTableStatsVersions gets the statistics versions of all the analyzed tables, keyed by the table IDs.
+func (m *Meta) GetTableStatsVersions() (map[int64]int64, error) {
+	pairs, err := m.txn.HGetAll(mTableStatsVersionsKey)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	versions := make(map[int64]int64, len(pairs))
+	for _, pair := range pairs {
+		version, err := strconv.ParseInt(string(pair.Value), 10, 64)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		versions[int64(binary.BigEndian.Uint64(pair.Field))] = version
+	}
+	return versions, nil
 }
 
-// GetTableStats gets table statistics.
+// GetTableStats gets table statistics, it returns nil if the table has no statistics.
 func (m *Meta) GetTableStats(tableID int64) (*statistics.TablePB, error) {
 	key := m.tableStatsKey(tableID)
 	data, err := m.txn.Get(key)
-	if err != nil {
+	if err != nil || data == nil {
 		return nil, errors.Trace(err)
 	}
 	tpb := &statistics.TablePB{}
@@ -220,6 +220,15 @@ func (s *testSuite) TestDDL(c *C) {
 	v, err = t.GetHistoryDDLJob(2)
 	c.Assert(err, IsNil)
 	c.Assert(v, DeepEquals, job)
+	historyJob := &model.Job{ID: 3}
+	err = t.AddHistoryDDLJob(historyJob)
+	c.Assert(err, IsNil)
+	lastJobs, err := t.GetLastNHistoryDDLJobs(1)
+	c.Assert(err, IsNil)
+	c.Assert(lastJobs, DeepEquals, []*model.Job{historyJob})
+	lastJobs, err = t.GetLastNHistoryDDLJobs(3)
+	c.Assert(err, IsNil)
+	c.Assert(lastJobs, DeepEquals, []*model.Job{historyJob, job})
 
 	// DDL background job test
 	err = t.SetBgJobOwner(owner)
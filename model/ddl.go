@@ -36,6 +36,10 @@ const (
 	ActionDropIndex
 	ActionAddForeignKey
 	ActionDropForeignKey
+	ActionAnalyzeTable
+	ActionModifyColumn
+	ActionRenameTable
+	ActionTruncateTable
 )
 
 func (action ActionType) String() string {
@@ -60,6 +64,14 @@ func (action ActionType) String() string {
 		return "add foreign key"
 	case ActionDropForeignKey:
 		return "drop foreign key"
+	case ActionAnalyzeTable:
+		return "analyze table"
+	case ActionModifyColumn:
+		return "modify column"
+	case ActionRenameTable:
+		return "rename table"
+	case ActionTruncateTable:
+		return "truncate table"
 	default:
 		return "none"
 	}
@@ -130,6 +142,16 @@ func (job *Job) IsRunning() bool {
 	return job.State == JobRunning
 }
 
+// IsCancelling returns whether the job is requested to be cancelled but the rollback hasn't started.
+func (job *Job) IsCancelling() bool {
+	return job.State == JobCancelling
+}
+
+// IsRollingback returns whether the job is rolling back the schema changes it has made.
+func (job *Job) IsRollingback() bool {
+	return job.State == JobRollback
+}
+
 // JobState is for job state.
 type JobState byte
 
@@ -139,6 +161,10 @@ const (
 	JobRunning
 	JobDone
 	JobCancelled
+	// JobCancelling is set by the user to cancel a job, the job is rolled back by the DDL worker.
+	JobCancelling
+	// JobRollback means the job is rolling back, it becomes JobCancelled at last.
+	JobRollback
 )
 
 // String implements fmt.Stringer interface.
@@ -150,6 +176,10 @@ func (s JobState) String() string {
 		return "done"
 	case JobCancelled:
 		return "cancelled"
+	case JobCancelling:
+		return "cancelling"
+	case JobRollback:
+		return "rollback"
 	default:
 		return "none"
 	}
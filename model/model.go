@@ -65,6 +65,9 @@ type ColumnInfo struct {
 	types.FieldType `json:"type"`
 	State           SchemaState `json:"state"`
 	Comment         string      `json:"comment"`
+	// ChangeFrom is the ID of the column whose values are converted to this column when they are written,
+	// it is only set for the column which isn't public during MODIFY COLUMN.
+	ChangeFrom int64 `json:"change_from"`
 }
 
 // Clone clones ColumnInfo.
@@ -179,6 +182,9 @@ type FKInfo struct {
 	OnDelete int         `json:"on_delete"`
 	OnUpdate int         `json:"on_update"`
 	State    SchemaState `json:"state"`
+	// RefSchema is the database of the referenced table. It is empty for the foreign keys created
+	// before it was recorded, whose referenced tables are in the same database as the tables.
+	RefSchema CIStr `json:"ref_schema"`
 }
 
 // Clone clones FKInfo.
@@ -82,8 +82,10 @@ import (
 	btree		"BTREE"
 	by		"BY"
 	byteType	"BYTE"
+	cancel		"CANCEL"
 	caseKwd		"CASE"
 	cast		"CAST"
+	change		"CHANGE"
 	character	"CHARACTER"
 	charsetKwd	"CHARSET"
 	check 		"CHECK"
@@ -114,6 +116,7 @@ import (
 	curTime 	"CUR_TIME"
 	currentTime 	"CURRENT_TIME"
 	currentUser	"CURRENT_USER"
+	current		"CURRENT"
 	database	"DATABASE"
 	databases	"DATABASES"
 	dateAdd		"DATE_ADD"
@@ -126,6 +129,7 @@ import (
 	dayofyear	"DAYOFYEAR"
 	ddl		"DDL"
 	deallocate	"DEALLOCATE"
+	denseRank	"DENSE_RANK"
 	defaultKwd	"DEFAULT"
 	delayed		"DELAYED"
 	delayKeyWrite	"DELAY_KEY_WRITE"
@@ -155,10 +159,12 @@ import (
 	falseKwd	"false"
 	fields		"FIELDS"
 	first		"FIRST"
+	firstValue	"FIRST_VALUE"
 	fixed		"FIXED"
 	foreign		"FOREIGN"
 	forKwd		"FOR"
 	force		"FORCE"
+	following	"FOLLOWING"
 	foundRows	"FOUND_ROWS"
 	from		"FROM"
 	full		"FULL"
@@ -186,11 +192,14 @@ import (
 	is		"IS"
 	isNull		"ISNULL"
 	isolation	"ISOLATION"
+	jobs		"JOBS"
 	join		"JOIN"
 	key		"KEY"
 	keyBlockSize	"KEY_BLOCK_SIZE"
 	keys		"KEYS"
 	lastInsertID	"LAST_INSERT_ID"
+	lag		"LAG"
+	lead		"LEAD"
 	le		"<="
 	leading		"LEADING"
 	left		"LEFT"
@@ -214,6 +223,7 @@ import (
 	minRows		"MIN_ROWS"
 	mod 		"MOD"
 	mode		"MODE"
+	modify		"MODIFY"
 	month		"MONTH"
 	monthname	"MONTHNAME"
 	names		"NAMES"
@@ -232,20 +242,26 @@ import (
 	order		"ORDER"
 	oror		"||"
 	outer		"OUTER"
+	over		"OVER"
 	password	"PASSWORD"
+	partition	"PARTITION"
 	placeholder	"PLACEHOLDER"
 	pow 		"POW"
 	power 		"POWER"
 	prepare		"PREPARE"
+	preceding	"PRECEDING"
 	primary		"PRIMARY"
 	procedure	"PROCEDURE"
 	quarter		"QUARTER"
 	quick		"QUICK"
 	rand		"RAND"
+	rank		"RANK"
 	read		"READ"
+	recursive	"RECURSIVE"
 	redundant	"REDUNDANT"
 	references	"REFERENCES"
 	regexpKwd	"REGEXP"
+	rename		"RENAME"
 	repeat		"REPEAT"
 	repeatable	"REPEATABLE"
 	replace		"REPLACE"
@@ -254,6 +270,8 @@ import (
 	rollback	"ROLLBACK"
 	round		"ROUND"
 	row 		"ROW"
+	rows		"ROWS"
+	rowNumber	"ROW_NUMBER"
 	rowFormat	"ROW_FORMAT"
 	rsh		">>"
 	rtrim 		"RTRIM"
@@ -271,6 +289,8 @@ import (
 	some 		"SOME"
 	space 		"SPACE"
 	start		"START"
+	statsHistograms	"STATS_HISTOGRAMS"
+	statsMeta	"STATS_META"
 	status		"STATUS"
 	stringType	"string"
 	subDate		"SUBDATE"
@@ -291,6 +311,7 @@ import (
 	trueKwd		"true"
 	truncate	"TRUNCATE"
 	uncommitted	"UNCOMMITTED"
+	unbounded	"UNBOUNDED"
 	underscoreCS	"UNDERSCORE_CHARSET"
 	unknown 	"UNKNOWN"
 	union		"UNION"
@@ -314,6 +335,7 @@ import (
 	weekofyear	"WEEKOFYEAR"
 	when		"WHEN"
 	where		"WHERE"
+	with		"WITH"
 	write		"WRITE"
 	xor 		"XOR"
 	yearweek	"YEARWEEK"
@@ -420,6 +442,10 @@ import (
 	ColumnSetValueList	"insert statement set value by column name list"
 	CommaOpt		"optional comma"
 	CommitStmt		"COMMIT statement"
+	CommonTableExpr		"Common table expression"
+	CommonTableExprColumnList	"Column name list of common table expression"
+	CommonTableExprColumnListOpt	"Optional column name list of common table expression"
+	CommonTableExprList	"Common table expression list"
 	CompareOp		"Compare opcode"
 	ColumnOption		"column definition option"
 	ColumnOptionList	"column definition option list"
@@ -476,6 +502,7 @@ import (
 	FunctionCallConflict	"Function call with reserved keyword as function name"
 	FunctionCallKeyword	"Function call with keyword as function name"
 	FunctionCallNonKeyword	"Function call with nonkeyword as function name"
+	FunctionCallWindow	"Function call over window"
 	FunctionNameConflict	"Built-in function call names which are conflict with keywords"
 	FuncDatetimePrec	"Function datetime precision"
 	GlobalScope		"The scope of variable"
@@ -548,6 +575,7 @@ import (
 	OnUpdateOpt		"optional ON UPDATE clause"
 	ReferOpt		"reference option"
 	RegexpSym		"REGEXP or RLIKE"
+	RenameTableStmt		"RENAME TABLE statement"
 	ReplaceIntoStmt		"REPLACE INTO statement"
 	ReplacePriority		"replace statement priority"
 	RollbackStmt		"ROLLBACK statement"
@@ -590,6 +618,9 @@ import (
 	TableOptionListOpt	"create table option list opt"
 	TableRef 		"table reference"
 	TableRefs 		"table references"
+	TableToOpt		"optional TO or AS keyword"
+	TableToTable		"rename table to table"
+	TableToTableList	"rename table to table by list"
 	TimeUnit		"Time unit"
 	TransactionChar		"Transaction characteristic"
 	TransactionChars	"Transaction characteristic list"
@@ -613,6 +644,14 @@ import (
 	Variable		"User or system variable"
 	WhereClause		"WHERE clause"
 	WhereClauseOptional	"Optinal WHERE clause"
+	WindowFrameBound	"Window frame bound"
+	WindowFrameClauseOpt	"Optional window frame clause"
+	WindowFrameExtent	"Window frame extent"
+	WindowFrameStart	"Window frame start"
+	WindowPartitionByOpt	"Optional PARTITION BY clause of window specification"
+	WindowSpec		"Window specification in OVER clause"
+	WithClause		"WITH clause"
+	WithSelectStmt		"SELECT statement with WITH clause"
 
 	Identifier		"identifier or unreserved keyword"
 	UnReservedKeyword	"MySQL unreserved keywords"
@@ -649,6 +688,8 @@ import (
 	OptCollate		"Optional Collate setting"
 	NUM			"numbers"
 	LengthNum		"Field length num(uint64)"
+	NumList			"Num list"
+	Int64Num		"Num(int64)"
 
 %token	tableRefPriority
 
@@ -667,6 +708,9 @@ import (
 %precedence lowerThanKey
 %precedence key 
 
+%precedence lowerThanOver
+%precedence over
+
 %left   join inner cross left right full
 /* A dummy token to force the priority of TableRef production in a join. */
 %left   tableRefPriority
@@ -752,6 +796,30 @@ AlterTableSpec:
 			DropColumn: $3.(*ast.ColumnName),
 		}
 	}
+|	"MODIFY" ColumnKeywordOpt ColumnDef ColumnPosition
+	{
+		$$ = &ast.AlterTableSpec{
+			Tp:		ast.AlterTableModifyColumn,
+			Column:		$3.(*ast.ColumnDef),
+			Position:	$4.(*ast.ColumnPosition),
+		}
+	}
+|	"CHANGE" ColumnKeywordOpt ColumnName ColumnDef ColumnPosition
+	{
+		$$ = &ast.AlterTableSpec{
+			Tp:		ast.AlterTableChangeColumn,
+			OldColumnName:	$3.(*ast.ColumnName),
+			Column:		$4.(*ast.ColumnDef),
+			Position:	$5.(*ast.ColumnPosition),
+		}
+	}
+|	"RENAME" TableToOpt TableName
+	{
+		$$ = &ast.AlterTableSpec{
+			Tp:		ast.AlterTableRenameTable,
+			NewTable:	$3.(*ast.TableName),
+		}
+	}
 |	"DROP" "PRIMARY" "KEY"
 	{
 		$$ = &ast.AlterTableSpec{Tp: ast.AlterTableDropPrimaryKey}
@@ -1497,6 +1565,37 @@ TableOrTables:
 	"TABLE"
 |	"TABLES"
 
+TableToOpt:
+	{
+	}
+|	"TO"
+|	"AS"
+
+RenameTableStmt:
+	"RENAME" "TABLE" TableToTableList
+	{
+		$$ = &ast.RenameTableStmt{TableToTables: $3.([]*ast.TableToTable)}
+	}
+
+TableToTableList:
+	TableToTable
+	{
+		$$ = []*ast.TableToTable{$1.(*ast.TableToTable)}
+	}
+|	TableToTableList ',' TableToTable
+	{
+		$$ = append($1.([]*ast.TableToTable), $3.(*ast.TableToTable))
+	}
+
+TableToTable:
+	TableName "TO" TableName
+	{
+		$$ = &ast.TableToTable{
+			OldTable:	$1.(*ast.TableName),
+			NewTable:	$3.(*ast.TableName),
+		}
+	}
+
 EqOpt:
 	{
 	}
@@ -1539,6 +1638,10 @@ ExplainStmt:
 	{
 		$$ = &ast.ExplainStmt{Stmt: $2.(ast.StmtNode)}
 	}
+|	ExplainSym "ANALYZE" ExplainableStmt
+	{
+		$$ = &ast.ExplainStmt{Stmt: $3.(ast.StmtNode), Analyze: true}
+	}
 
 LengthNum:
 	NUM
@@ -1955,7 +2058,8 @@ UnReservedKeyword:
 |	"COMMENT" | "AVG_ROW_LENGTH" | "CONNECTION" | "CHECKSUM" | "COMPRESSION" | "KEY_BLOCK_SIZE" | "MAX_ROWS" | "MIN_ROWS"
 |	"NATIONAL" | "ROW" | "ROW_FORMAT" | "QUARTER" | "ESCAPE" | "GRANTS" | "FIELDS" | "TRIGGERS" | "DELAY_KEY_WRITE"
 |	"ISOLATION" |	"REPEATABLE" | "COMMITTED" | "UNCOMMITTED" | "ONLY" | "SERIALIZABLE" | "LEVEL" | "VARIABLES"
-|	"SQL_CACHE" | "SQL_NO_CACHE" | "ACTION" | "DISABLE" | "ENABLE" | "REVERSE" | "SPACE"
+|	"SQL_CACHE" | "SQL_NO_CACHE" | "ACTION" | "DISABLE" | "ENABLE" | "REVERSE" | "SPACE" | "STATS_META" | "STATS_HISTOGRAMS"
+|	"CURRENT" | "PRECEDING" | "FOLLOWING" | "UNBOUNDED" | "MODIFY" | "JOBS" | "CANCEL" | "OVER" | "ROWS" | "PARTITION" | "RECURSIVE"
 
 NotKeywordToken:
 	"ABS" | "ADDDATE" | "ADMIN" | "COALESCE" | "CONCAT" | "CONCAT_WS" | "CONNECTION_ID" | "CUR_TIME"| "COUNT" | "DAY"
@@ -1963,7 +2067,7 @@ NotKeywordToken:
 |	"IFNULL" | "ISNULL" | "LAST_INSERT_ID" | "LCASE" | "LENGTH" | "LOCATE" | "LOWER" | "LTRIM" | "MAX" | "MICROSECOND" | "MIN"
 |	"MINUTE" | "NULLIF" | "MONTH" | "MONTHNAME" | "NOW" | "POW" | "POWER" | "RAND" | "SECOND" | "SQL_CALC_FOUND_ROWS" | "SUBDATE"
 |	"SUBSTRING" %prec lowerThanLeftParen | "SUBSTRING_INDEX" | "SUM" | "TRIM" | "RTRIM" | "UCASE" | "UPPER" | "VERSION"
-|	"WEEKDAY" | "WEEKOFYEAR" | "YEARWEEK" | "ROUND"
+|	"WEEKDAY" | "WEEKOFYEAR" | "YEARWEEK" | "ROUND" | "ROW_NUMBER" | "RANK" | "DENSE_RANK" | "LEAD" | "LAG" | "FIRST_VALUE"
 
 /************************************************************************************
  *
@@ -2305,7 +2409,8 @@ Function:
 	FunctionCallKeyword
 |	FunctionCallNonKeyword
 |	FunctionCallConflict
-|	FunctionCallAgg
+|	FunctionCallAgg %prec lowerThanOver
+|	FunctionCallWindow
 
 FunctionNameConflict:
 	"DATABASE" | "SCHEMA" | "IF" | "LEFT" | "REPEAT" | "CURRENT_USER" | "CURRENT_DATE" | "VERSION" | "UTC_DATE"
@@ -2841,6 +2946,110 @@ FunctionCallAgg:
 		$$ = &ast.AggregateFuncExpr{F: $1.(string), Args: []ast.ExprNode{$4.(ast.ExprNode)}, Distinct: $3.(bool)}
 	}
 
+FunctionCallWindow:
+	"ROW_NUMBER" '(' ')' "OVER" '(' WindowSpec ')'
+	{
+		$$ = &ast.WindowFuncExpr{F: $1.(string), Spec: $6.(ast.WindowSpec)}
+	}
+|	"RANK" '(' ')' "OVER" '(' WindowSpec ')'
+	{
+		$$ = &ast.WindowFuncExpr{F: $1.(string), Spec: $6.(ast.WindowSpec)}
+	}
+|	"DENSE_RANK" '(' ')' "OVER" '(' WindowSpec ')'
+	{
+		$$ = &ast.WindowFuncExpr{F: $1.(string), Spec: $6.(ast.WindowSpec)}
+	}
+|	"LEAD" '(' ExpressionList ')' "OVER" '(' WindowSpec ')'
+	{
+		$$ = &ast.WindowFuncExpr{F: $1.(string), Args: $3.([]ast.ExprNode), Spec: $7.(ast.WindowSpec)}
+	}
+|	"LAG" '(' ExpressionList ')' "OVER" '(' WindowSpec ')'
+	{
+		$$ = &ast.WindowFuncExpr{F: $1.(string), Args: $3.([]ast.ExprNode), Spec: $7.(ast.WindowSpec)}
+	}
+|	"FIRST_VALUE" '(' Expression ')' "OVER" '(' WindowSpec ')'
+	{
+		$$ = &ast.WindowFuncExpr{F: $1.(string), Args: []ast.ExprNode{$3.(ast.ExprNode)}, Spec: $7.(ast.WindowSpec)}
+	}
+|	FunctionCallAgg "OVER" '(' WindowSpec ')'
+	{
+		agg := $1.(*ast.AggregateFuncExpr)
+		if agg.Distinct {
+			yylex.(*lexer).errf("DISTINCT is not supported in window function %s", agg.F)
+			return 1
+		}
+		$$ = &ast.WindowFuncExpr{F: agg.F, Args: agg.Args, Spec: $4.(ast.WindowSpec)}
+	}
+
+WindowSpec:
+	WindowPartitionByOpt OrderByOptional WindowFrameClauseOpt
+	{
+		spec := ast.WindowSpec{}
+		if $1 != nil {
+			spec.PartitionBy = $1.([]ast.ExprNode)
+		}
+		if $2 != nil {
+			spec.OrderBy = $2.(*ast.OrderByClause)
+		}
+		if $3 != nil {
+			spec.Frame = $3.(*ast.FrameClause)
+		}
+		$$ = spec
+	}
+
+WindowPartitionByOpt:
+	{
+		$$ = nil
+	}
+|	"PARTITION" "BY" ExpressionList
+	{
+		$$ = $3
+	}
+
+WindowFrameClauseOpt:
+	{
+		$$ = nil
+	}
+|	"ROWS" WindowFrameExtent
+	{
+		$$ = $2
+	}
+
+WindowFrameExtent:
+	WindowFrameStart
+	{
+		$$ = &ast.FrameClause{Start: $1.(ast.FrameBound), End: ast.FrameBound{Type: ast.CurrentRow}}
+	}
+|	"BETWEEN" WindowFrameBound "AND" WindowFrameBound
+	{
+		$$ = &ast.FrameClause{Start: $2.(ast.FrameBound), End: $4.(ast.FrameBound)}
+	}
+
+WindowFrameStart:
+	"UNBOUNDED" "PRECEDING"
+	{
+		$$ = ast.FrameBound{Type: ast.UnboundedPreceding}
+	}
+|	LengthNum "PRECEDING"
+	{
+		$$ = ast.FrameBound{Type: ast.Preceding, Offset: $1.(uint64)}
+	}
+|	"CURRENT" "ROW"
+	{
+		$$ = ast.FrameBound{Type: ast.CurrentRow}
+	}
+
+WindowFrameBound:
+	WindowFrameStart
+|	"UNBOUNDED" "FOLLOWING"
+	{
+		$$ = ast.FrameBound{Type: ast.UnboundedFollowing}
+	}
+|	LengthNum "FOLLOWING"
+	{
+		$$ = ast.FrameBound{Type: ast.Following, Offset: $1.(uint64)}
+	}
+
 FuncDatetimePrec:
 	{
 		$$ = nil
@@ -3306,6 +3515,15 @@ TableFactor:
 	{
 		$$ = &ast.TableSource{Source: $2.(*ast.UnionStmt), AsName: $4.(model.CIStr)}
 	}
+|	'(' WithSelectStmt ')' TableAsName
+	{
+		if st, ok := $2.(*ast.SelectStmt); ok {
+			l := yylex.(*lexer)
+			endOffset := l.endOffset(yyS[yypt-1].offset)
+			l.SetLastSelectFieldText(st, endOffset)
+		}
+		$$ = &ast.TableSource{Source: $2.(ast.ResultSetNode), AsName: $4.(model.CIStr)}
+	}
 |	'(' TableRefs ')'
 	{
 		$$ = $2
@@ -3552,6 +3770,18 @@ SubSelect:
 		s.SetText(src[yyS[yypt-1].offset-1:yyS[yypt].offset-1])
 		$$ = &ast.SubqueryExpr{Query: s}
 	}
+|	'(' WithSelectStmt ')'
+	{
+		s := $2.(ast.ResultSetNode)
+		l := yylex.(*lexer)
+		if st, ok := s.(*ast.SelectStmt); ok {
+			endOffset := l.endOffset(yyS[yypt].offset)
+			l.SetLastSelectFieldText(st, endOffset)
+		}
+		// See the implementation of yyParse function
+		s.SetText(l.src[yyS[yypt-1].offset-1:yyS[yypt].offset-1])
+		$$ = &ast.SubqueryExpr{Query: s}
+	}
 
 // See: https://dev.mysql.com/doc/refman/5.7/en/innodb-locking-reads.html
 SelectLockOpt:
@@ -3568,6 +3798,89 @@ SelectLockOpt:
 		$$ = ast.SelectLockInShareMode
 	}
 
+// See: https://dev.mysql.com/doc/refman/8.0/en/with.html
+// The WITH clause is only supported by the new planner, the old planner returns an error for it.
+WithSelectStmt:
+	WithClause SelectStmt
+	{
+		st := $2.(*ast.SelectStmt)
+		st.With = $1.(*ast.WithClause)
+		$$ = st
+	}
+|	WithClause UnionStmt
+	{
+		union := $2.(*ast.UnionStmt)
+		union.With = $1.(*ast.WithClause)
+		$$ = union
+	}
+
+WithClause:
+	"WITH" CommonTableExprList
+	{
+		$$ = &ast.WithClause{CTEs: $2.([]*ast.CommonTableExpression)}
+	}
+|	"WITH" "RECURSIVE" CommonTableExprList
+	{
+		$$ = &ast.WithClause{IsRecursive: true, CTEs: $3.([]*ast.CommonTableExpression)}
+	}
+
+CommonTableExprList:
+	CommonTableExpr
+	{
+		$$ = []*ast.CommonTableExpression{$1.(*ast.CommonTableExpression)}
+	}
+|	CommonTableExprList ',' CommonTableExpr
+	{
+		$$ = append($1.([]*ast.CommonTableExpression), $3.(*ast.CommonTableExpression))
+	}
+
+CommonTableExpr:
+	Identifier CommonTableExprColumnListOpt "AS" '(' SelectStmt ')'
+	{
+		st := $5.(*ast.SelectStmt)
+		l := yylex.(*lexer)
+		endOffset := l.endOffset(yyS[yypt].offset)
+		l.SetLastSelectFieldText(st, endOffset)
+		$$ = &ast.CommonTableExpression{
+			Name:		model.NewCIStr($1.(string)),
+			ColNames:	$2.([]model.CIStr),
+			Query:		st,
+		}
+	}
+|	Identifier CommonTableExprColumnListOpt "AS" '(' UnionStmt ')'
+	{
+		union := $5.(*ast.UnionStmt)
+		lastSelect := union.SelectList.Selects[len(union.SelectList.Selects)-1]
+		l := yylex.(*lexer)
+		endOffset := l.endOffset(yyS[yypt].offset)
+		l.SetLastSelectFieldText(lastSelect, endOffset)
+		$$ = &ast.CommonTableExpression{
+			Name:		model.NewCIStr($1.(string)),
+			ColNames:	$2.([]model.CIStr),
+			Query:		union,
+		}
+	}
+
+CommonTableExprColumnListOpt:
+	{
+		var nameList []model.CIStr
+		$$ = nameList
+	}
+|	'(' CommonTableExprColumnList ')'
+	{
+		$$ = $2
+	}
+
+CommonTableExprColumnList:
+	Identifier
+	{
+		$$ = []model.CIStr{model.NewCIStr($1.(string))}
+	}
+|	CommonTableExprColumnList ',' Identifier
+	{
+		$$ = append($1.([]model.CIStr), model.NewCIStr($3.(string)))
+	}
+
 // See: https://dev.mysql.com/doc/refman/5.7/en/union.html
 UnionStmt:
 	UnionClauseList "UNION" UnionOpt SelectStmt
@@ -3816,6 +4129,17 @@ AdminStmt:
 	{
 		$$ = &ast.AdminStmt{Tp: ast.AdminShowDDL}
 	}
+|	"ADMIN" "SHOW" "DDL" "JOBS"
+	{
+		$$ = &ast.AdminStmt{Tp: ast.AdminShowDDLJobs}
+	}
+|	"ADMIN" "CANCEL" "DDL" "JOBS" NumList
+	{
+		$$ = &ast.AdminStmt{
+			Tp:	ast.AdminCancelDDLJobs,
+			JobIDs:	$5.([]int64),
+		}
+	}
 |	"ADMIN" "CHECK" "TABLE" TableNameList
 	{
 		$$ = &ast.AdminStmt{
@@ -3824,6 +4148,27 @@ AdminStmt:
 		}
 	}
 
+NumList:
+	Int64Num
+	{
+		$$ = []int64{$1.(int64)}
+	}
+|	NumList ',' Int64Num
+	{
+		$$ = append($1.([]int64), $3.(int64))
+	}
+
+Int64Num:
+	NUM
+	{
+		switch v := $1.(type) {
+		case int64:
+			$$ = v
+		case uint64:
+			$$ = int64(v)
+		}
+	}
+
 /****************************Show Statement*******************************/
 ShowStmt:
 	"SHOW" ShowTargetFilterable ShowLikeOrWhereOpt
@@ -3954,6 +4299,18 @@ ShowTargetFilterable:
 			Tp: ast.ShowProcedureStatus,
 		}
 	}
+|	"STATS_META"
+	{
+		$$ = &ast.ShowStmt{
+			Tp: ast.ShowStatsMeta,
+		}
+	}
+|	"STATS_HISTOGRAMS"
+	{
+		$$ = &ast.ShowStmt{
+			Tp: ast.ShowStatsHistograms,
+		}
+	}
 
 ShowLikeOrWhereOpt:
 	{
@@ -4038,10 +4395,12 @@ Statement:
 |	GrantStmt
 |	InsertIntoStmt
 |	PreparedStmt
+|	RenameTableStmt
 |	RollbackStmt
 |	ReplaceIntoStmt
 |	SelectStmt
 |	UnionStmt
+|	WithSelectStmt
 |	SetStmt
 |	ShowStmt
 |	TruncateTableStmt
@@ -4058,6 +4417,7 @@ Statement:
 
 ExplainableStmt:
 	SelectStmt
+|	WithSelectStmt
 |	DeleteFromStmt
 |	UpdateStmt
 |	InsertIntoStmt
@@ -21,6 +21,7 @@ import (
 
 	. "github.com/pingcap/check"
 	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/model"
 	"github.com/pingcap/tidb/util/testleak"
 )
 
@@ -47,7 +48,8 @@ func (s *testParserSuite) TestSimple(c *C) {
 		"delay_key_write", "isolation", "repeatable", "committed", "uncommitted", "only", "serializable", "level",
 		"curtime", "variables", "dayname", "version", "btree", "hash", "row_format", "dynamic", "fixed", "compressed",
 		"compact", "redundant", "sql_no_cache sql_no_cache", "sql_cache sql_cache", "action", "round",
-		"enable", "disable", "reverse", "space",
+		"enable", "disable", "reverse", "space", "stats_meta", "stats_histograms", "current", "preceding",
+		"following", "unbounded", "row_number", "rank", "dense_rank", "lead", "lag", "first_value",
 	}
 	for _, kw := range unreservedKws {
 		src := fmt.Sprintf("SELECT %s FROM tbl;", kw)
@@ -247,6 +249,27 @@ func (s *testParserSuite) TestDMLStmt(c *C) {
 		{"ALTER TABLE t ADD COLUMN a SMALLINT UNSIGNED AFTER b", true},
 		{"ALTER TABLE t DISABLE KEYS", true},
 		{"ALTER TABLE t ENABLE KEYS", true},
+		{"ALTER TABLE t MODIFY COLUMN a varchar(255)", true},
+		{"ALTER TABLE t MODIFY a bigint NOT NULL DEFAULT 1 FIRST", true},
+		{"ALTER TABLE t MODIFY COLUMN a int AFTER b", true},
+		{"ALTER TABLE t MODIFY COLUMN", false},
+		{"ALTER TABLE t CHANGE COLUMN a b varchar(255)", true},
+		{"ALTER TABLE t CHANGE a b int COMMENT 'x' FIRST", true},
+		{"ALTER TABLE t CHANGE COLUMN a varchar(255)", false},
+		{"create table modify (modify int)", true},
+		{"create table t (change int)", false},
+		{"ALTER TABLE t RENAME TO t1", true},
+		{"ALTER TABLE t RENAME AS db.t1", true},
+		{"ALTER TABLE t RENAME t1", true},
+		{"ALTER TABLE t RENAME", false},
+
+		// For rename table statement
+		{"RENAME TABLE t TO t1", true},
+		{"RENAME TABLE t t1", false},
+		{"RENAME TABLE d.t TO d1.t1", true},
+		{"RENAME TABLE t1 TO t2, t2 TO t1", true},
+		{"RENAME TABLE t1 TO t2,", false},
+		{"create table rename (a int)", false},
 
 		// from join
 		{"SELECT * from t1, t2, t3", true},
@@ -261,6 +284,11 @@ func (s *testParserSuite) TestDMLStmt(c *C) {
 		// For admin
 		{"admin show ddl;", true},
 		{"admin check table t1, t2;", true},
+		{"admin show ddl jobs;", true},
+		{"admin cancel ddl jobs 1", true},
+		{"admin cancel ddl jobs 1, 2", true},
+		{"admin cancel ddl jobs", false},
+		{"create table jobs (cancel int)", true},
 
 		// For set names
 		{"set names utf8", true},
@@ -291,6 +319,8 @@ func (s *testParserSuite) TestDMLStmt(c *C) {
 		{`SHOW COLUMNS FROM City;`, true},
 		{`SHOW FIELDS FROM City;`, true},
 		{`SHOW TRIGGERS LIKE 't'`, true},
+		{`SHOW STATS_META`, true},
+		{`SHOW STATS_HISTOGRAMS WHERE table_name = 't'`, true},
 		{`SHOW DATABASES LIKE 'test2'`, true},
 		{`SHOW PROCEDURE STATUS WHERE Db='test'`, true},
 		{`SHOW INDEX FROM t;`, true},
@@ -339,6 +369,8 @@ func (s *testParserSuite) TestDMLStmt(c *C) {
 		{`SELECT /*!40001 SQL_NO_CACHE */ * FROM test WHERE 1 limit 0, 2000;`, true},
 
 		{`ANALYZE TABLE t`, true},
+		{`EXPLAIN ANALYZE SELECT * FROM t`, true},
+		{`EXPLAIN ANALYZE t`, false},
 	}
 	s.RunTest(c, table)
 }
@@ -912,6 +944,92 @@ func (s *testParserSuite) TestUnion(c *C) {
 	s.RunTest(c, table)
 }
 
+func (s *testParserSuite) TestWindowFunction(c *C) {
+	defer testleak.AfterTest(c)()
+	table := []testCase{
+		{"select row_number() over () from t", true},
+		{"select rank() over (order by c1) from t", true},
+		{"select dense_rank() over (partition by c1, c2 order by c3 desc) from t", true},
+		{"select lead(c1) over (order by c2), lag(c1, 2, 0) over (order by c2) from t", true},
+		{"select first_value(c1) over (partition by c2 order by c3 rows 2 preceding) from t", true},
+		{"select sum(c1) over (partition by c2 order by c3 rows between unbounded preceding and current row) from t", true},
+		{"select avg(c1) over (order by c2 rows between 1 preceding and 1 following) as a from t", true},
+		{"select count(*) over (partition by c1) from t", true},
+		{"select max(c1) over (rows between current row and unbounded following) from t", true},
+		{"select rank() over (order by sum(c1)) from t group by c2", true},
+		{"select rank from t where rank = 1", true},
+		{"select count(distinct c1) over () from t", false},
+		{"select rank() from t", false},
+		{"select row_number(c1) over () from t", false},
+		{"select sum(c1) over (rows unbounded following) from t", false},
+		{"select sum(c1) over (rows between c1 preceding and current row) from t", false},
+		// OVER, ROWS and PARTITION are not reserved.
+		{"select rows, over, partition from t", true},
+		{"select sum(c1) as over from t", true},
+		{"create table t (rows int, over int, partition int)", true},
+		{"select * from rows over where partition = 1", true},
+	}
+	s.RunTest(c, table)
+
+	stmt, err := ParseOneStmt("select lag(c1, 1) over (partition by c2 order by c3 desc rows between 2 preceding and unbounded following) from t", "", "")
+	c.Assert(err, IsNil)
+	wf, ok := stmt.(*ast.SelectStmt).Fields.Fields[0].Expr.(*ast.WindowFuncExpr)
+	c.Assert(ok, IsTrue)
+	c.Assert(wf.F, Equals, "lag")
+	c.Assert(wf.Args, HasLen, 2)
+	c.Assert(wf.Spec.PartitionBy, HasLen, 1)
+	c.Assert(wf.Spec.OrderBy.Items, HasLen, 1)
+	c.Assert(wf.Spec.OrderBy.Items[0].Desc, IsTrue)
+	c.Assert(wf.Spec.Frame.Start, Equals, ast.FrameBound{Type: ast.Preceding, Offset: 2})
+	c.Assert(wf.Spec.Frame.End, Equals, ast.FrameBound{Type: ast.UnboundedFollowing})
+}
+
+func (s *testParserSuite) TestCommonTableExpression(c *C) {
+	defer testleak.AfterTest(c)()
+	table := []testCase{
+		{"with cte as (select 1) select * from cte", true},
+		{"with cte (a, b) as (select c1, c2 from t) select a from cte where b > 1", true},
+		{"with c1 as (select 1), c2 as (select * from c1) select * from c1 join c2", true},
+		{"with cte as (select 1 union select 2) select * from cte", true},
+		{"with recursive cte (n) as (select 1 union all select n + 1 from cte where n < 10) select n from cte", true},
+		{"with cte as ((select 1) union (select 2)) select * from cte", true},
+		{"explain with cte as (select 1) select * from cte", true},
+		{"with cte (a, b) as select 1, 2 select * from cte", false},
+		{"with cte () as (select 1) select * from cte", false},
+		{"with recursive as (select 1) select * from recursive", true},
+		{"with recursive recursive as (select 1) select * from recursive", true},
+		{"with cte as (select 1)", false},
+		{"select with from t", false},
+		{"select recursive from t", true},
+		{"create table recursive (recursive int)", true},
+		{"with cte as (select 1) select * from cte union select 2", true},
+		{"with cte as (select 1) select * from cte union all (select 2) order by 1 limit 1", true},
+		{"select * from (with cte as (select 1) select * from cte) t", true},
+		{"select * from (with cte as (select 1) select * from cte union select 2) as t", true},
+		{"select (with cte as (select 1) select * from cte)", true},
+		{"select 1 from t where c in (with cte as (select 1) select * from cte union select 2)", true},
+		{"select * from (with cte as (select 1) select * from cte)", false},
+	}
+	s.RunTest(c, table)
+
+	stmt, err := ParseOneStmt("with cte as (select 1) select * from cte union select 2", "", "")
+	c.Assert(err, IsNil)
+	c.Assert(stmt.(*ast.UnionStmt).With.CTEs, HasLen, 1)
+
+	stmt, err = ParseOneStmt("with recursive cte (n) as (select 1 union all select n + 1 from cte where n < 10) select n from cte", "", "")
+	c.Assert(err, IsNil)
+	with := stmt.(*ast.SelectStmt).With
+	c.Assert(with.IsRecursive, IsTrue)
+	c.Assert(with.CTEs, HasLen, 1)
+	cte := with.CTEs[0]
+	c.Assert(cte.Name.L, Equals, "cte")
+	c.Assert(cte.ColNames, DeepEquals, []model.CIStr{model.NewCIStr("n")})
+	union, ok := cte.Query.(*ast.UnionStmt)
+	c.Assert(ok, IsTrue)
+	c.Assert(union.Distinct, IsFalse)
+	c.Assert(union.SelectList.Selects, HasLen, 2)
+}
+
 func (s *testParserSuite) TestLikeEscape(c *C) {
 	defer testleak.AfterTest(c)()
 	table := []testCase{
@@ -129,8 +129,16 @@ func pruneColumnsAndResolveIndices(p Plan, parentUsedCols []*expression.Column)
 				aggrFunc.SetArgs(i, newArg)
 			}
 		}
+		for i, expr := range v.GroupByItems {
+			v.GroupByItems[i], err = retrieveColumnsInExpression(expr, p.GetChildByIndex(0).GetSchema())
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+		}
 		v.schema.InitIndices()
 		return append(outer, outerCols...), nil
+	case *Window:
+		return pruneWindow(v, parentUsedCols)
 	case *NewSort:
 		var outerCols []*expression.Column
 		for _, item := range v.ByItems {
@@ -148,15 +156,34 @@ func pruneColumnsAndResolveIndices(p Plan, parentUsedCols []*expression.Column)
 			}
 		}
 		return append(outer, outerCols...), nil
-	case *Union:
+	case *TopN:
+		var outerCols []*expression.Column
+		for _, item := range v.ByItems {
+			parentUsedCols, outerCols = extractColumn(item.Expr, parentUsedCols, outerCols)
+		}
+		outer, err := pruneColumnsAndResolveIndices(p.GetChildByIndex(0), parentUsedCols)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		v.SetSchema(p.GetChildByIndex(0).GetSchema())
+		for i, item := range v.ByItems {
+			v.ByItems[i].Expr, err = retrieveColumnsInExpression(item.Expr, p.GetChildByIndex(0).GetSchema())
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+		}
+		return append(outer, outerCols...), nil
+	case *Union, *NewUnion:
 		var outerCols []*expression.Column
 		used := makeUsedList(parentUsedCols, p.GetSchema())
+		schema := p.GetSchema()
 		for i := len(used) - 1; i >= 0; i-- {
 			if !used[i] {
-				v.schema = append(v.schema[:i], v.schema[i+1:]...)
+				schema = append(schema[:i], schema[i+1:]...)
 			}
 		}
-		v.schema.InitIndices()
+		schema.InitIndices()
+		p.SetSchema(schema)
 		for _, child := range p.GetChildren() {
 			schema := child.GetSchema()
 			var newSchema []*expression.Column
@@ -182,10 +209,15 @@ func pruneColumnsAndResolveIndices(p Plan, parentUsedCols []*expression.Column)
 		}
 		v.schema.InitIndices()
 		return nil, nil
-	case *Limit, *MaxOneRow, *Distinct:
+	case *Limit, *MaxOneRow:
 		outer, err := pruneColumnsAndResolveIndices(p.GetChildByIndex(0), parentUsedCols)
 		p.SetSchema(p.GetChildByIndex(0).GetSchema())
 		return outer, errors.Trace(err)
+	case *Distinct:
+		// All the columns are used to distinguish the rows, so none of them can be pruned.
+		outer, err := pruneColumnsAndResolveIndices(p.GetChildByIndex(0), p.GetChildByIndex(0).GetSchema())
+		p.SetSchema(p.GetChildByIndex(0).GetSchema())
+		return outer, errors.Trace(err)
 	case *Trim:
 		used := makeUsedList(parentUsedCols, v.schema)
 		for i := len(used) - 1; i >= 0; i-- {
@@ -197,6 +229,21 @@ func pruneColumnsAndResolveIndices(p Plan, parentUsedCols []*expression.Column)
 		return outer, errors.Trace(err)
 	case *Exists:
 		return pruneColumnsAndResolveIndices(p.GetChildByIndex(0), nil)
+	case *RecursiveCTE:
+		// The rows of an iteration are read by the next one, so none of the columns can be pruned.
+		var outerCols []*expression.Column
+		for _, child := range p.GetChildren() {
+			outer, err := pruneColumnsAndResolveIndices(child, child.GetSchema())
+			outerCols = append(outerCols, outer...)
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+		}
+		v.schema.InitIndices()
+		return outerCols, nil
+	case *CTETable:
+		v.schema.InitIndices()
+		return nil, nil
 	case *Join:
 		var outerCols []*expression.Column
 		for _, eqCond := range v.EqualConditions {
@@ -298,3 +345,65 @@ func pruneApply(v *Apply, parentUsedCols []*expression.Column) ([]*expression.Co
 	v.schema.InitIndices()
 	return outer, nil
 }
+
+// pruneWindow prunes the window functions whose results are not used, the columns of the child are pruned by
+// the columns used by the parent and the remaining window functions.
+func pruneWindow(v *Window, parentUsedCols []*expression.Column) ([]*expression.Column, error) {
+	child := v.GetChildByIndex(0)
+	childLen := len(v.schema) - len(v.WindowFuncs)
+	used := makeUsedList(parentUsedCols, v.schema)
+	var windowCols []*expression.Column
+	var windowFuncs []*WindowFuncDesc
+	for i, desc := range v.WindowFuncs {
+		if used[childLen+i] {
+			windowCols = append(windowCols, v.schema[childLen+i])
+			windowFuncs = append(windowFuncs, desc)
+		}
+	}
+	v.WindowFuncs = windowFuncs
+	var cols, outerCols []*expression.Column
+	for i, use := range used[:childLen] {
+		if use {
+			cols = append(cols, v.schema[i])
+		}
+	}
+	for _, desc := range v.WindowFuncs {
+		for _, arg := range desc.Args {
+			cols, outerCols = extractColumn(arg, cols, outerCols)
+		}
+		for _, expr := range desc.PartitionBy {
+			cols, outerCols = extractColumn(expr, cols, outerCols)
+		}
+		for _, item := range desc.OrderBy {
+			cols, outerCols = extractColumn(item.Expr, cols, outerCols)
+		}
+	}
+	outer, err := pruneColumnsAndResolveIndices(child, cols)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	childSchema := child.GetSchema()
+	for _, desc := range v.WindowFuncs {
+		for i, arg := range desc.Args {
+			desc.Args[i], err = retrieveColumnsInExpression(arg, childSchema)
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+		}
+		for i, expr := range desc.PartitionBy {
+			desc.PartitionBy[i], err = retrieveColumnsInExpression(expr, childSchema)
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+		}
+		for i, item := range desc.OrderBy {
+			desc.OrderBy[i].Expr, err = retrieveColumnsInExpression(item.Expr, childSchema)
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+		}
+	}
+	v.schema = append(childSchema.DeepCopy(), windowCols...)
+	v.schema.InitIndices()
+	return append(outer, outerCols...), nil
+}
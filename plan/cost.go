@@ -15,6 +15,14 @@ package plan
 
 import (
 	"math"
+
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/kv"
+	"github.com/pingcap/tidb/model"
+	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/plan/statistics"
+	"github.com/pingcap/tidb/util/codec"
+	"github.com/pingcap/tidb/util/types"
 )
 
 // Pre-defined cost factors.
@@ -63,15 +71,47 @@ func estimate(p Plan) {
 			v.rowCount = math.Min(child.RowCount(), v.limit)
 		}
 		v.totalCost = v.startupCost + v.rowCount*RowCost
+	case *TopN:
+		// TopN plan must retrieve all the rows before returns the first row.
+		v.startupCost = child.TotalCost() + child.RowCount()*SortCost
+		v.rowCount = math.Min(child.RowCount(), float64(v.Count))
+		v.totalCost = v.startupCost + v.rowCount*RowCost
 	case *TableScan:
 		tableScan(v)
+	case *NewTableScan:
+		newTableScan(v)
+	case *Selection:
+		rowCount := child.RowCount()
+		scan, _ := child.(*NewTableScan)
+		for _, con := range v.Conditions {
+			rowCount *= exprFilterRate(scan, con)
+		}
+		v.startupCost = child.StartupCost()
+		v.rowCount = rowCount
+		v.totalCost = child.TotalCost()
 	}
 }
 
+func newTableScan(v *NewTableScan) {
+	rowCount, ok := statsNewTableScanRowCount(v)
+	if !ok {
+		rowCount = FullRangeCount
+		for _, con := range v.AccessCondition {
+			rowCount *= exprFilterRate(v, con)
+		}
+	}
+	v.startupCost = 0
+	v.rowCount = rowCount
+	v.totalCost = v.rowCount * RowCost
+}
+
 func tableScan(v *TableScan) {
-	var rowCount float64 = FullRangeCount
-	for _, con := range v.AccessConditions {
-		rowCount *= guesstimateFilterRate(con)
+	rowCount, ok := statsTableScanRowCount(v)
+	if !ok {
+		rowCount = FullRangeCount
+		for _, con := range v.AccessConditions {
+			rowCount *= guesstimateFilterRate(con)
+		}
 	}
 	v.startupCost = 0
 	if v.limit == 0 {
@@ -81,12 +121,20 @@ func tableScan(v *TableScan) {
 		v.rowCount = math.Min(rowCount, v.limit)
 	}
 	v.totalCost = v.rowCount * RowCost
+	if v.statsTbl != nil {
+		for _, con := range v.FilterConditions {
+			v.rowCount *= statsFilterRate(v.statsTbl, v.Table, con)
+		}
+	}
 }
 
 func indexScan(v *IndexScan) {
-	var rowCount float64 = FullRangeCount
-	for _, con := range v.AccessConditions {
-		rowCount *= guesstimateFilterRate(con)
+	rowCount, ok := statsIndexScanRowCount(v)
+	if !ok {
+		rowCount = FullRangeCount
+		for _, con := range v.AccessConditions {
+			rowCount *= guesstimateFilterRate(con)
+		}
 	}
 	v.startupCost = 0
 	if v.limit == 0 {
@@ -96,6 +144,239 @@ func indexScan(v *IndexScan) {
 		v.rowCount = math.Min(rowCount, v.limit)
 	}
 	v.totalCost = v.rowCount * IndexCost
+	if v.statsTbl != nil {
+		for _, con := range v.FilterConditions {
+			v.rowCount *= statsFilterRate(v.statsTbl, v.Table, con)
+		}
+	}
+}
+
+// statsTableScanRowCount estimates the row count of a table scan by the histogram of the handle column.
+// It returns false if the statistics is not available.
+func statsTableScanRowCount(v *TableScan) (float64, bool) {
+	// Access conditions referencing a previous joined table can't be used to build ranges now.
+	if v.statsTbl == nil || v.RefAccess {
+		return 0, false
+	}
+	if len(v.AccessConditions) == 0 {
+		return float64(v.statsTbl.Count), true
+	}
+	var pkCol *statistics.Column
+	for _, colInfo := range v.Table.Columns {
+		if mysql.HasPriKeyFlag(colInfo.Flag) {
+			pkCol = v.statsTbl.ColumnByID(colInfo.ID)
+			break
+		}
+	}
+	if pkCol == nil {
+		return 0, false
+	}
+	// The ranges will be built again when refining the plan.
+	if err := buildTableRange(v); err != nil {
+		return 0, false
+	}
+	return pkRangesRowCount(pkCol, v.Ranges)
+}
+
+// statsNewTableScanRowCount estimates the row count of a new table scan, the ranges must be built already.
+// It returns false if the statistics is not available.
+func statsNewTableScanRowCount(v *NewTableScan) (float64, bool) {
+	if v.statsTbl == nil {
+		return 0, false
+	}
+	if len(v.AccessCondition) == 0 {
+		return float64(v.statsTbl.Count), true
+	}
+	var pkCol *statistics.Column
+	for _, colInfo := range v.Table.Columns {
+		if mysql.HasPriKeyFlag(colInfo.Flag) {
+			pkCol = v.statsTbl.ColumnByID(colInfo.ID)
+			break
+		}
+	}
+	if pkCol == nil {
+		return 0, false
+	}
+	return pkRangesRowCount(pkCol, v.Ranges)
+}
+
+// pkRangesRowCount estimates the row count of the table ranges by the histogram of the handle column.
+func pkRangesRowCount(pkCol *statistics.Column, ranges []TableRange) (float64, bool) {
+	var rowCount int64
+	for _, rg := range ranges {
+		low, high := types.NewIntDatum(rg.LowVal), types.NewIntDatum(rg.HighVal)
+		cnt, err := pkCol.BetweenRowCount(low, high)
+		if err != nil {
+			return 0, false
+		}
+		// Table range is a closed interval.
+		eqCnt, err := pkCol.EqualRowCount(high)
+		if err != nil {
+			return 0, false
+		}
+		rowCount += cnt + eqCnt
+	}
+	return float64(rowCount), true
+}
+
+// statsIndexScanRowCount estimates the row count of an index scan by the histograms of the index columns.
+// It returns false if the statistics is not available.
+func statsIndexScanRowCount(v *IndexScan) (float64, bool) {
+	if v.statsTbl == nil || v.RefAccess {
+		return 0, false
+	}
+	if len(v.AccessConditions) == 0 {
+		return float64(v.statsTbl.Count), true
+	}
+	// The ranges will be built again when refining the plan.
+	if err := buildIndexRange(v); err != nil {
+		return 0, false
+	}
+	var rowCount float64
+	for _, rg := range v.Ranges {
+		cnt, err := indexRangeRowCount(v.statsTbl, v.Table, v.Index, rg)
+		if err != nil {
+			return 0, false
+		}
+		rowCount += cnt
+	}
+	return rowCount, true
+}
+
+// indexRangeRowCount estimates the row count of an index range.
+// If the index has no statistics, the index columns are assumed to be independent,
+// so the selectivity of each column is multiplied.
+func indexRangeRowCount(statsTbl *statistics.Table, tbl *model.TableInfo, idx *model.IndexInfo, rg *IndexRange) (float64, error) {
+	if statsTbl.Count == 0 {
+		return 0, nil
+	}
+	if idxStats := statsTbl.IndexByID(idx.ID); idxStats != nil {
+		return indexStatsRangeRowCount(statsTbl, idxStats, tbl, idx, rg)
+	}
+	rowCount := float64(statsTbl.Count)
+	for i := range rg.LowVal {
+		col := statsTbl.ColumnByID(tbl.Columns[idx.Columns[i].Offset].ID)
+		if col == nil {
+			return 0, errors.Errorf("column %s has no statistics", idx.Columns[i].Name)
+		}
+		low, high := rg.LowVal[i], rg.HighVal[i]
+		cmp, err := low.CompareDatum(high)
+		if err != nil {
+			return 0, errors.Trace(err)
+		}
+		var cnt int64
+		if cmp == 0 {
+			cnt, err = col.EqualRowCount(low)
+			if err != nil {
+				return 0, errors.Trace(err)
+			}
+			rowCount = rowCount * float64(cnt) / float64(statsTbl.Count)
+			continue
+		}
+		cnt, err = col.BetweenRowCount(low, high)
+		if err != nil {
+			return 0, errors.Trace(err)
+		}
+		// The exclude flags only apply to the last column of the range.
+		if i == len(rg.LowVal)-1 {
+			cnt, err = adjustRangeBound(col, cnt, low, rg.LowExclude, high, rg.HighExclude)
+			if err != nil {
+				return 0, errors.Trace(err)
+			}
+		}
+		// The values of the following columns are not constrained once a column is not a point.
+		return rowCount * float64(cnt) / float64(statsTbl.Count), nil
+	}
+	return rowCount, nil
+}
+
+// indexStatsRangeRowCount estimates the row count of an index range by the index statistics.
+// The equal prefix of the range is estimated by the distinct count of the index prefix, and the
+// following range column is estimated by its column histogram.
+func indexStatsRangeRowCount(statsTbl *statistics.Table, idxStats *statistics.Index, tbl *model.TableInfo, idx *model.IndexInfo, rg *IndexRange) (float64, error) {
+	eqPrefix := 0
+	for ; eqPrefix < len(rg.LowVal); eqPrefix++ {
+		cmp, err := rg.LowVal[eqPrefix].CompareDatum(rg.HighVal[eqPrefix])
+		if err != nil {
+			return 0, errors.Trace(err)
+		}
+		if cmp != 0 {
+			break
+		}
+	}
+	if eqPrefix == len(rg.LowVal) {
+		if rg.LowExclude || rg.HighExclude {
+			return 0, nil
+		}
+		if eqPrefix < len(idx.Columns) {
+			return float64(idxStats.PrefixRowCount(eqPrefix)), nil
+		}
+		key, err := codec.EncodeKey(nil, rg.LowVal...)
+		if err != nil {
+			return 0, errors.Trace(err)
+		}
+		cnt, err := idxStats.EqualRowCount(types.NewBytesDatum(key))
+		return float64(cnt), errors.Trace(err)
+	}
+	if eqPrefix == 0 {
+		// The index keys are memory comparable, so a range on the first column can be estimated
+		// by the histogram of the index keys.
+		low, err := codec.EncodeKey(nil, rg.LowVal...)
+		if err != nil {
+			return 0, errors.Trace(err)
+		}
+		high, err := codec.EncodeKey(nil, rg.HighVal...)
+		if err != nil {
+			return 0, errors.Trace(err)
+		}
+		if rg.LowExclude {
+			low = kv.Key(low).PrefixNext()
+		}
+		if !rg.HighExclude {
+			high = kv.Key(high).PrefixNext()
+		}
+		cnt, err := idxStats.BetweenRowCount(types.NewBytesDatum(low), types.NewBytesDatum(high))
+		return float64(cnt), errors.Trace(err)
+	}
+	rowCount := float64(idxStats.PrefixRowCount(eqPrefix))
+	col := statsTbl.ColumnByID(tbl.Columns[idx.Columns[eqPrefix].Offset].ID)
+	if col == nil {
+		return rowCount, nil
+	}
+	low, high := rg.LowVal[eqPrefix], rg.HighVal[eqPrefix]
+	cnt, err := col.BetweenRowCount(low, high)
+	if err != nil {
+		return 0, errors.Trace(err)
+	}
+	if eqPrefix == len(rg.LowVal)-1 {
+		cnt, err = adjustRangeBound(col, cnt, low, rg.LowExclude, high, rg.HighExclude)
+		if err != nil {
+			return 0, errors.Trace(err)
+		}
+	}
+	return rowCount * float64(cnt) / float64(statsTbl.Count), nil
+}
+
+// adjustRangeBound adjusts the row count of [low, high) to the interval specified by the exclude flags.
+func adjustRangeBound(col *statistics.Column, cnt int64, low types.Datum, lowExclude bool, high types.Datum, highExclude bool) (int64, error) {
+	if lowExclude {
+		eqCnt, err := col.EqualRowCount(low)
+		if err != nil {
+			return 0, errors.Trace(err)
+		}
+		cnt -= eqCnt
+	}
+	if !highExclude {
+		eqCnt, err := col.EqualRowCount(high)
+		if err != nil {
+			return 0, errors.Trace(err)
+		}
+		cnt += eqCnt
+	}
+	if cnt < 0 {
+		cnt = 0
+	}
+	return cnt, nil
 }
 
 // EstimateCost estimates the cost of the plan.
@@ -77,6 +77,18 @@ func (er *expressionRewriter) Enter(inNode ast.Node) (retNode ast.Node, skipChil
 			er.ctxStack = append(er.ctxStack, col)
 			return inNode, true
 		}
+	case *ast.WindowFuncExpr:
+		// The window function is evaluated by the window plan, it can only be referred by the plans over it.
+		index := -1
+		if col, ok := er.b.windowMapper[v]; ok {
+			index = er.schema.GetIndex(col)
+		}
+		if index == -1 {
+			er.err = errors.Errorf("You cannot use the window function '%s' in this context.", v.F)
+			return inNode, true
+		}
+		er.ctxStack = append(er.ctxStack, er.schema[index])
+		return inNode, true
 	case *ast.ExistsSubqueryExpr:
 		subq, ok := v.Sel.(*ast.SubqueryExpr)
 		if !ok {
@@ -129,7 +141,7 @@ func (er *expressionRewriter) Leave(inNode ast.Node) (retNode ast.Node, ok bool)
 		return retNode, false
 	}
 	switch v := inNode.(type) {
-	case *ast.AggregateFuncExpr:
+	case *ast.AggregateFuncExpr, *ast.WindowFuncExpr:
 	case *ast.FuncCallExpr:
 		function := &expression.ScalarFunction{FuncName: v.FnName}
 		for i := length - len(v.Args); i < length; i++ {
@@ -210,10 +222,19 @@ func (er *expressionRewriter) Leave(inNode ast.Node) (retNode ast.Node, ok bool)
 		er.ctxStack = er.ctxStack[:length-2]
 		er.ctxStack = append(er.ctxStack, function)
 	case *ast.UnaryOperationExpr:
-		funcName, ok := opcode.Ops[v.Op]
-		if !ok {
-			er.err = errors.Errorf("Unknown opcode %v", v.Op)
-			return retNode, false
+		var funcName string
+		switch v.Op {
+		case opcode.Plus:
+			funcName = ast.UnaryPlus
+		case opcode.Minus:
+			funcName = ast.UnaryMinus
+		default:
+			var ok bool
+			funcName, ok = opcode.Ops[v.Op]
+			if !ok {
+				er.err = errors.Errorf("Unknown opcode %v", v.Op)
+				return retNode, false
+			}
 		}
 		function, err := expression.NewFunction(funcName, []expression.Expression{er.ctxStack[length-1]}, v.Type)
 		if err != nil {
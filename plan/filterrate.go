@@ -15,7 +15,11 @@ package plan
 
 import (
 	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/expression"
+	"github.com/pingcap/tidb/model"
 	"github.com/pingcap/tidb/parser/opcode"
+	"github.com/pingcap/tidb/plan/statistics"
+	"github.com/pingcap/tidb/util/types"
 )
 
 const (
@@ -113,3 +117,198 @@ func guesstimatePatternLike(expr *ast.PatternLikeExpr) float64 {
 	}
 	return rateLike
 }
+
+// statsFilterRate estimates the filter rate for an expression by the column histograms of the table.
+// The parts of the expression that can't be estimated by the histograms are guesstimated.
+func statsFilterRate(statsTbl *statistics.Table, tbl *model.TableInfo, expr ast.ExprNode) float64 {
+	switch x := expr.(type) {
+	case *ast.BetweenExpr:
+		return statsBetween(statsTbl, tbl, x)
+	case *ast.BinaryOperationExpr:
+		return statsBinop(statsTbl, tbl, x)
+	case *ast.ParenthesesExpr:
+		return statsFilterRate(statsTbl, tbl, x.Expr)
+	case *ast.PatternInExpr:
+		return statsPatternIn(statsTbl, tbl, x)
+	}
+	return guesstimateFilterRate(expr)
+}
+
+func statsBinop(statsTbl *statistics.Table, tbl *model.TableInfo, expr *ast.BinaryOperationExpr) float64 {
+	switch expr.Op {
+	case opcode.AndAnd:
+		return statsFilterRate(statsTbl, tbl, expr.L) * statsFilterRate(statsTbl, tbl, expr.R)
+	case opcode.OrOr:
+		rateL := statsFilterRate(statsTbl, tbl, expr.L)
+		rateR := statsFilterRate(statsTbl, tbl, expr.R)
+		return rateL + rateR - rateL*rateR
+	case opcode.EQ, opcode.NE, opcode.LT, opcode.LE, opcode.GT, opcode.GE:
+		op := expr.Op
+		col := statsColumn(statsTbl, tbl, expr.L)
+		val, ok := constantValue(expr.R)
+		if col == nil {
+			// The constant is on the left side, e.g. '1 < a', reverse the operator.
+			col = statsColumn(statsTbl, tbl, expr.R)
+			val, ok = constantValue(expr.L)
+			op = reverseOp(op)
+		}
+		if col == nil || !ok {
+			break
+		}
+		rate, err := col.Selectivity(op, val)
+		if err != nil {
+			break
+		}
+		return rate
+	}
+	return guesstimateBinop(expr)
+}
+
+func statsBetween(statsTbl *statistics.Table, tbl *model.TableInfo, expr *ast.BetweenExpr) float64 {
+	col := statsColumn(statsTbl, tbl, expr.Expr)
+	low, lowOK := constantValue(expr.Left)
+	high, highOK := constantValue(expr.Right)
+	if col == nil || !lowOK || !highOK {
+		return guesstimateFilterRate(expr)
+	}
+	rate, err := col.BetweenSelectivity(low, high)
+	if err != nil {
+		return guesstimateFilterRate(expr)
+	}
+	if expr.Not {
+		return rateFull - rate
+	}
+	return rate
+}
+
+func statsPatternIn(statsTbl *statistics.Table, tbl *model.TableInfo, expr *ast.PatternInExpr) float64 {
+	col := statsColumn(statsTbl, tbl, expr.Expr)
+	if col == nil || expr.Sel != nil || len(expr.List) == 0 {
+		return guesstimatePatternIn(expr)
+	}
+	var rate float64
+	for _, item := range expr.List {
+		val, ok := constantValue(item)
+		if !ok {
+			return guesstimatePatternIn(expr)
+		}
+		r, err := col.Selectivity(opcode.EQ, val)
+		if err != nil {
+			return guesstimatePatternIn(expr)
+		}
+		rate += r
+	}
+	if rate > rateFull {
+		rate = rateFull
+	}
+	if expr.Not {
+		return rateFull - rate
+	}
+	return rate
+}
+
+// statsColumn returns the column statistics if the expression is a column of the table.
+func statsColumn(statsTbl *statistics.Table, tbl *model.TableInfo, expr ast.ExprNode) *statistics.Column {
+	if p, ok := expr.(*ast.ParenthesesExpr); ok {
+		return statsColumn(statsTbl, tbl, p.Expr)
+	}
+	cn, ok := expr.(*ast.ColumnNameExpr)
+	if !ok || cn.Refer == nil || cn.Refer.Table == nil || cn.Refer.Column == nil {
+		return nil
+	}
+	// Column IDs are only unique in a table.
+	if cn.Refer.Table.ID != tbl.ID {
+		return nil
+	}
+	return statsTbl.ColumnByID(cn.Refer.Column.ID)
+}
+
+// constantValue returns the value of the expression if it can be evaluated before execution.
+func constantValue(expr ast.ExprNode) (types.Datum, bool) {
+	if v, ok := expr.(*ast.ValueExpr); ok {
+		return *v.GetDatum(), true
+	}
+	if ast.IsEvaluated(expr) {
+		return *expr.GetDatum(), true
+	}
+	return types.Datum{}, false
+}
+
+func reverseOp(op opcode.Op) opcode.Op {
+	switch op {
+	case opcode.LT:
+		return opcode.GT
+	case opcode.LE:
+		return opcode.GE
+	case opcode.GT:
+		return opcode.LT
+	case opcode.GE:
+		return opcode.LE
+	}
+	return op
+}
+
+var funcNameToOp = map[string]opcode.Op{
+	ast.EQ: opcode.EQ,
+	ast.NE: opcode.NE,
+	ast.LT: opcode.LT,
+	ast.LE: opcode.LE,
+	ast.GT: opcode.GT,
+	ast.GE: opcode.GE,
+}
+
+// exprFilterRate estimates the filter rate for a condition of the new plan on the table scan.
+// The histograms are used if the condition compares a column of the table scan with a constant.
+func exprFilterRate(scan *NewTableScan, expr expression.Expression) float64 {
+	sf, ok := expr.(*expression.ScalarFunction)
+	if !ok {
+		return rateFull
+	}
+	switch sf.FuncName.L {
+	case ast.AndAnd:
+		return exprFilterRate(scan, sf.Args[0]) * exprFilterRate(scan, sf.Args[1])
+	case ast.OrOr:
+		rateL := exprFilterRate(scan, sf.Args[0])
+		rateR := exprFilterRate(scan, sf.Args[1])
+		return rateL + rateR - rateL*rateR
+	case ast.IsNull:
+		return rateIsNull
+	}
+	op, ok := funcNameToOp[sf.FuncName.L]
+	if !ok {
+		return rateFull
+	}
+	col, colOK := sf.Args[0].(*expression.Column)
+	con, conOK := sf.Args[1].(*expression.Constant)
+	if !colOK || !conOK {
+		col, colOK = sf.Args[1].(*expression.Column)
+		con, conOK = sf.Args[0].(*expression.Constant)
+		op = reverseOp(op)
+	}
+	if colOK && conOK && scan != nil && scan.statsTbl != nil && col.FromID == scan.id {
+		if colInfo := findColumnByName(scan.Table, col.ColName); colInfo != nil {
+			if c := scan.statsTbl.ColumnByID(colInfo.ID); c != nil {
+				rate, err := c.Selectivity(op, con.Value)
+				if err == nil {
+					return rate
+				}
+			}
+		}
+	}
+	switch op {
+	case opcode.EQ:
+		return rateEqual
+	case opcode.NE:
+		return rateNotEqual
+	}
+	return rateGreaterOrLess
+}
+
+func findColumnByName(tbl *model.TableInfo, name model.CIStr) *model.ColumnInfo {
+	for _, col := range tbl.Columns {
+		if col.Name.L == name.L {
+			return col
+		}
+	}
+	return nil
+}
@@ -0,0 +1,215 @@
+// Copyright 2016 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plan
+
+import (
+	"github.com/pingcap/tidb/expression"
+	"github.com/pingcap/tidb/model"
+	"github.com/pingcap/tidb/mysql"
+)
+
+// JoinAlgorithm is the algorithm to execute a join.
+type JoinAlgorithm int
+
+const (
+	// HashJoinAlgorithm builds a hash table with the rows of the inner side and probes it with the outer rows.
+	HashJoinAlgorithm JoinAlgorithm = iota
+	// IndexLookUpJoinAlgorithm looks up the inner table by the join keys of a batch of outer rows,
+	// through the handle or an index of the inner table.
+	IndexLookUpJoinAlgorithm
+	// MergeJoinAlgorithm merges the rows of the two children which are both ordered by the first equal condition.
+	MergeJoinAlgorithm
+)
+
+// chooseJoinAlgorithm chooses the algorithm of the joins in the plan by the estimated costs.
+// It must be called after the plan is refined, so the ranges and the indices of the columns are built.
+func chooseJoinAlgorithm(p Plan) {
+	if apply, ok := p.(*Apply); ok {
+		chooseJoinAlgorithm(apply.InnerPlan)
+	}
+	for _, child := range p.GetChildren() {
+		chooseJoinAlgorithm(child)
+	}
+	join, ok := p.(*Join)
+	if !ok || len(join.EqualConditions) == 0 {
+		return
+	}
+	join.Algorithm = HashJoinAlgorithm
+	// The merge join reads the two children once like the hash join, but it doesn't build the hash table.
+	mergeKeyIdx := mergeJoinKey(join)
+	if mergeKeyIdx != -1 {
+		join.Algorithm = MergeJoinAlgorithm
+	}
+	var innerIdxs []int
+	switch join.JoinType {
+	case InnerJoin:
+		innerIdxs = []int{1, 0}
+	case LeftOuterJoin:
+		innerIdxs = []int{1}
+	case RightOuterJoin:
+		innerIdxs = []int{0}
+	}
+	bestCost := 0.0
+	for _, innerIdx := range innerIdxs {
+		lookUp := buildIndexLookUp(join, innerIdx)
+		if lookUp == nil {
+			continue
+		}
+		// The hash join scans the inner table once, the index look up join looks up it for every outer row.
+		scan := findTableScan(join.GetChildByIndex(innerIdx))
+		newTableScan(scan)
+		outerRows := estimateRowCount(join.GetChildByIndex(1 - innerIdx))
+		cost := outerRows * lookUp.rowsPerKey * lookUp.rowCost
+		if cost >= scan.TotalCost() || (join.Algorithm == IndexLookUpJoinAlgorithm && cost >= bestCost) {
+			continue
+		}
+		join.Algorithm = IndexLookUpJoinAlgorithm
+		join.LookUpInnerIdx = innerIdx
+		join.LookUpIndex = lookUp.index
+		join.LookUpOuterKeys = lookUp.outerKeys
+		bestCost = cost
+	}
+	if join.Algorithm == MergeJoinAlgorithm {
+		conds := join.EqualConditions
+		conds[0], conds[mergeKeyIdx] = conds[mergeKeyIdx], conds[0]
+	}
+}
+
+// mergeJoinKey finds the equal condition of the join whose arguments are the handle columns of the children,
+// so the children can be read in the handle order for merge join. It returns -1 if not found.
+func mergeJoinKey(join *Join) int {
+	leftHandle := orderedHandleColumn(join.GetChildByIndex(0))
+	rightHandle := orderedHandleColumn(join.GetChildByIndex(1))
+	if leftHandle == nil || rightHandle == nil {
+		return -1
+	}
+	for i, eqCond := range join.EqualConditions {
+		if leftHandle.Equal(eqCond.Args[0]) && rightHandle.Equal(eqCond.Args[1]) {
+			return i
+		}
+	}
+	return -1
+}
+
+// orderedHandleColumn returns the handle column of the plan if the plan is a table scan or a selection on a table scan,
+// whose rows are in the ascending handle order because the table scan always keeps the order. Otherwise it returns nil.
+func orderedHandleColumn(p Plan) *expression.Column {
+	scan := findTableScan(p)
+	if scan == nil || scan.Desc || !scan.Table.PKIsHandle {
+		return nil
+	}
+	for _, col := range scan.Table.Columns {
+		if !mysql.HasPriKeyFlag(col.Flag) {
+			continue
+		}
+		for _, schemaCol := range p.GetSchema() {
+			if schemaCol.FromID == scan.id && schemaCol.ColName.L == col.Name.L {
+				return schemaCol
+			}
+		}
+	}
+	return nil
+}
+
+// indexLookUp describes how to look up the inner table of a join.
+type indexLookUp struct {
+	// index is nil if the inner table is looked up by the handle.
+	index     *model.IndexInfo
+	outerKeys []*expression.Column
+	// rowsPerKey is the estimated row count of the inner table for a look up key.
+	rowsPerKey float64
+	rowCost    float64
+}
+
+// buildIndexLookUp checks whether the inner child of the join can be looked up by the join keys.
+// The inner child must be a table scan or a selection on a table scan, and the join keys must cover the handle
+// or a prefix of an index. It returns nil if the inner child can't be looked up.
+func buildIndexLookUp(join *Join, innerIdx int) *indexLookUp {
+	inner := join.GetChildByIndex(innerIdx)
+	scan := findTableScan(inner)
+	if scan == nil || inner.IsCorrelated() {
+		return nil
+	}
+	switch scan.DBName.L {
+	case "information_schema", "performance_schema":
+		return nil
+	}
+	// innerKeys maps the inner column names of the equal conditions to the outer columns.
+	innerKeys := make(map[string]*expression.Column, len(join.EqualConditions))
+	for _, eqCond := range join.EqualConditions {
+		innerCol, _ := eqCond.Args[innerIdx].(*expression.Column)
+		outerCol, _ := eqCond.Args[1-innerIdx].(*expression.Column)
+		if innerCol == nil || outerCol == nil || scan.GetSchema().GetIndex(innerCol) == -1 {
+			continue
+		}
+		innerKeys[innerCol.ColName.L] = outerCol
+	}
+	if scan.Table.PKIsHandle {
+		for _, col := range scan.Table.Columns {
+			if !mysql.HasPriKeyFlag(col.Flag) {
+				continue
+			}
+			if outerCol, ok := innerKeys[col.Name.L]; ok {
+				return &indexLookUp{outerKeys: []*expression.Column{outerCol}, rowsPerKey: 1, rowCost: RowCost}
+			}
+		}
+	}
+	var best *indexLookUp
+	for _, idx := range scan.Table.Indices {
+		if idx.State != model.StatePublic {
+			continue
+		}
+		var outerKeys []*expression.Column
+		for _, idxCol := range idx.Columns {
+			outerCol, ok := innerKeys[idxCol.Name.L]
+			if !ok {
+				break
+			}
+			outerKeys = append(outerKeys, outerCol)
+		}
+		if len(outerKeys) == 0 {
+			continue
+		}
+		lookUp := &indexLookUp{
+			index:      idx,
+			outerKeys:  outerKeys,
+			rowsPerKey: indexRowsPerKey(scan, idx, len(outerKeys)),
+			rowCost:    IndexCost + RowCost,
+		}
+		if best == nil || lookUp.rowsPerKey < best.rowsPerKey {
+			best = lookUp
+		}
+	}
+	return best
+}
+
+// indexRowsPerKey estimates the row count of the table where the first prefixLen index columns equal to some values.
+func indexRowsPerKey(scan *NewTableScan, idx *model.IndexInfo, prefixLen int) float64 {
+	if idx.Unique && prefixLen == len(idx.Columns) {
+		return 1
+	}
+	if scan.statsTbl == nil {
+		return MiddleRangeCount
+	}
+	if idxStats := scan.statsTbl.IndexByID(idx.ID); idxStats != nil {
+		return float64(idxStats.PrefixRowCount(prefixLen))
+	}
+	// Without the index statistics, we estimate it by the first column.
+	colInfo := scan.Table.Columns[idx.Columns[0].Offset]
+	col := scan.statsTbl.ColumnByID(colInfo.ID)
+	if col == nil || col.NDV == 0 {
+		return MiddleRangeCount
+	}
+	return float64(scan.statsTbl.Count) / float64(col.NDV)
+}
@@ -0,0 +1,401 @@
+// Copyright 2016 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plan
+
+import (
+	"math"
+
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/expression"
+)
+
+// joinReorderDPThreshold is the max number of tables in a join group that is reordered by dynamic programming,
+// larger join groups are reordered greedily.
+var joinReorderDPThreshold = 8
+
+// joinGroup is a tree of inner joins whose tables can be joined in any order.
+type joinGroup struct {
+	leaves []Plan
+	// joins are the join plans of the group, they are reused to build the reordered tree.
+	joins []*Join
+	conds []expression.Expression
+	// condMasks[i] is the bitmask of the leaves referenced by conds[i].
+	condMasks []uint
+	leafRows  []float64
+}
+
+// joinNode is a node of the reordered join tree, a leaf node has no children.
+type joinNode struct {
+	mask        uint
+	rows        float64
+	cost        float64
+	left, right *joinNode
+}
+
+// reorderJoin reorders the inner joins in the plan by the estimated row counts.
+// It must be called after predicate push down, so the conditions of the joins are the join conditions,
+// and before column pruning, which resolves the column indices of the reordered joins.
+func (b *planBuilder) reorderJoin(p Plan) error {
+	if join, ok := p.(*Join); ok && isReorderableJoin(join) {
+		group := &joinGroup{}
+		group.collect(join)
+		err := group.reorder()
+		if err != nil {
+			return errors.Trace(err)
+		}
+		for _, leaf := range group.leaves {
+			if err = b.reorderJoin(leaf); err != nil {
+				return errors.Trace(err)
+			}
+		}
+		return nil
+	}
+	if apply, ok := p.(*Apply); ok {
+		if err := b.reorderJoin(apply.InnerPlan); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	for _, child := range p.GetChildren() {
+		if err := b.reorderJoin(child); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	return nil
+}
+
+// isReorderableJoin checks whether the join can be reordered with its child joins.
+// Outer joins can't be reordered, and we don't reorder the joins referencing outer columns.
+func isReorderableJoin(p Plan) bool {
+	join, ok := p.(*Join)
+	return ok && join.JoinType == InnerJoin && !join.IsCorrelated()
+}
+
+// collect collects the leaves and conditions of the join group rooted at join.
+// The left and right conditions of an inner join have been pushed down to its children by predicate push down,
+// so only the equal conditions and other conditions are collected.
+func (g *joinGroup) collect(join *Join) {
+	g.joins = append(g.joins, join)
+	for _, child := range join.GetChildren() {
+		if isReorderableJoin(child) {
+			g.collect(child.(*Join))
+		} else {
+			g.leaves = append(g.leaves, child)
+		}
+	}
+	g.conds = append(g.conds, expression.ScalarFuncs2Exprs(join.EqualConditions)...)
+	g.conds = append(g.conds, join.OtherConditions...)
+}
+
+// reorder finds the best join order of the group and rebuilds the join tree in place,
+// the root join plan is still the root of the group after reordering.
+func (g *joinGroup) reorder() error {
+	g.leafRows = make([]float64, len(g.leaves))
+	for i, leaf := range g.leaves {
+		g.leafRows[i] = estimateRowCount(leaf)
+	}
+	g.condMasks = make([]uint, len(g.conds))
+	for i, cond := range g.conds {
+		cols, _ := extractColumn(cond, nil, nil)
+		for _, col := range cols {
+			for j, leaf := range g.leaves {
+				if leaf.GetSchema().GetIndex(col) != -1 {
+					g.condMasks[i] |= 1 << uint(j)
+				}
+			}
+		}
+	}
+	var root *joinNode
+	if len(g.leaves) <= joinReorderDPThreshold {
+		root = g.solveByDP()
+	} else {
+		root = g.solveGreedily()
+	}
+	leafParents := make([]Plan, len(g.leaves))
+	for i, leaf := range g.leaves {
+		leafParents[i] = leaf.GetParentByIndex(0)
+	}
+	_, err := g.buildPlan(root, leafParents, true)
+	return errors.Trace(err)
+}
+
+// solveByDP finds the join tree with the least cost for every subset of the leaves, the subsets are joined
+// by the join conditions if possible, cartesian products are only used for the disconnected subsets.
+func (g *joinGroup) solveByDP() *joinNode {
+	n := uint(len(g.leaves))
+	best := make([]*joinNode, 1<<n)
+	for i := uint(0); i < n; i++ {
+		best[1<<i] = &joinNode{mask: 1 << i, rows: g.leafRows[i]}
+	}
+	for mask := uint(1); mask < 1<<n; mask++ {
+		if best[mask] != nil {
+			continue
+		}
+		rows := g.rowCount(mask)
+		lowest := mask & -mask
+		for _, connectedOnly := range []bool{true, false} {
+			for sub := (mask - 1) & mask; sub > 0; sub = (sub - 1) & mask {
+				if sub&lowest == 0 {
+					continue
+				}
+				if connectedOnly && !g.connected(sub, mask^sub) {
+					continue
+				}
+				node := g.newJoinNode(best[sub], best[mask^sub], rows)
+				if betterJoinNode(node, best[mask]) {
+					best[mask] = node
+				}
+			}
+			if best[mask] != nil {
+				break
+			}
+		}
+	}
+	return best[1<<n-1]
+}
+
+// solveGreedily joins the pair of trees with the least result row count repeatedly until there is only one tree,
+// the connected pairs are preferred.
+func (g *joinGroup) solveGreedily() *joinNode {
+	nodes := make([]*joinNode, len(g.leaves))
+	for i := range g.leaves {
+		nodes[i] = &joinNode{mask: 1 << uint(i), rows: g.leafRows[i]}
+	}
+	for len(nodes) > 1 {
+		var bestNode *joinNode
+		var bestI, bestJ int
+		for _, connectedOnly := range []bool{true, false} {
+			for i := 0; i < len(nodes); i++ {
+				for j := i + 1; j < len(nodes); j++ {
+					if connectedOnly && !g.connected(nodes[i].mask, nodes[j].mask) {
+						continue
+					}
+					node := g.newJoinNode(nodes[i], nodes[j], g.rowCount(nodes[i].mask|nodes[j].mask))
+					if bestNode == nil || node.rows < bestNode.rows {
+						bestNode, bestI, bestJ = node, i, j
+					}
+				}
+			}
+			if bestNode != nil {
+				break
+			}
+		}
+		nodes[bestI] = bestNode
+		nodes = append(nodes[:bestJ], nodes[bestJ+1:]...)
+	}
+	return nodes[0]
+}
+
+// newJoinNode joins two trees, the one with less rows is put on the right side,
+// because the right side is used to build the hash table.
+func (g *joinGroup) newJoinNode(first, second *joinNode, rows float64) *joinNode {
+	node := &joinNode{
+		mask:  first.mask | second.mask,
+		rows:  rows,
+		cost:  first.cost + second.cost + rows,
+		left:  first,
+		right: second,
+	}
+	if first.rows < second.rows {
+		node.left, node.right = second, first
+	}
+	return node
+}
+
+// betterJoinNode checks whether node is better than best. If the costs are the same, the left deep tree in
+// the original join order is preferred, it is the one whose right side is the last leaf.
+func betterJoinNode(node, best *joinNode) bool {
+	if best == nil {
+		return true
+	}
+	const epsilon = 1e-9
+	if math.Abs(node.cost-best.cost) > epsilon*math.Max(1, best.cost) {
+		return node.cost < best.cost
+	}
+	return node.right.mask == highestBit(node.mask) && best.right.mask != highestBit(best.mask)
+}
+
+func highestBit(mask uint) uint {
+	var bit uint
+	for ; mask != 0; mask &= mask - 1 {
+		bit = mask & -mask
+	}
+	return bit
+}
+
+// connected checks whether there is a join condition between the two sets of leaves.
+func (g *joinGroup) connected(left, right uint) bool {
+	for _, m := range g.condMasks {
+		if m&left != 0 && m&right != 0 && m&^(left|right) == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// rowCount estimates the row count of joining the set of leaves, it is the product of the row counts of
+// the leaves and the selectivities of the conditions on them.
+func (g *joinGroup) rowCount(mask uint) float64 {
+	rows := 1.0
+	for i, leafRows := range g.leafRows {
+		if mask&(1<<uint(i)) != 0 {
+			rows *= leafRows
+		}
+	}
+	for i, cond := range g.conds {
+		m := g.condMasks[i]
+		if m&mask == m && m&(m-1) != 0 {
+			rows *= g.joinCondSelectivity(cond)
+		}
+	}
+	return rows
+}
+
+// joinCondSelectivity estimates the selectivity of a join condition. For an equal condition,
+// it is 1 / max(ndv of left column, ndv of right column), if the ndv is unknown, we assume the column is unique.
+func (g *joinGroup) joinCondSelectivity(cond expression.Expression) float64 {
+	sf, ok := cond.(*expression.ScalarFunction)
+	if !ok || sf.FuncName.L != ast.EQ {
+		return FilterRate
+	}
+	ndv := 1.0
+	for _, arg := range sf.Args {
+		col, ok := arg.(*expression.Column)
+		if !ok {
+			return FilterRate
+		}
+		ndv = math.Max(ndv, g.columnNDV(col))
+	}
+	return 1 / ndv
+}
+
+func (g *joinGroup) columnNDV(col *expression.Column) float64 {
+	for i, leaf := range g.leaves {
+		if leaf.GetSchema().GetIndex(col) == -1 {
+			continue
+		}
+		rows := g.leafRows[i]
+		scan := findTableScan(leaf)
+		if scan == nil || scan.statsTbl == nil || col.FromID != scan.id {
+			return rows
+		}
+		colInfo := findColumnByName(scan.Table, col.ColName)
+		if colInfo == nil {
+			return rows
+		}
+		c := scan.statsTbl.ColumnByID(colInfo.ID)
+		if c == nil || c.NDV == 0 {
+			return rows
+		}
+		return math.Min(float64(c.NDV), rows)
+	}
+	return 1
+}
+
+// findTableScan finds the table scan under the selections.
+func findTableScan(p Plan) *NewTableScan {
+	switch v := p.(type) {
+	case *NewTableScan:
+		return v
+	case *Selection:
+		return findTableScan(v.GetChildByIndex(0))
+	}
+	return nil
+}
+
+// buildPlan builds the join plans of the tree by reusing the join plans of the group, the conditions are
+// attached to the lowest join which contains all the leaves they reference.
+func (g *joinGroup) buildPlan(node *joinNode, leafParents []Plan, top bool) (Plan, error) {
+	if node.left == nil {
+		return nil, nil
+	}
+	// The joins are built from top to bottom, so the original root is still the root.
+	join := g.joins[0]
+	g.joins = g.joins[1:]
+	join.children = nil
+	if !top {
+		join.parents = nil
+	}
+	join.EqualConditions = nil
+	join.LeftConditions = nil
+	join.RightConditions = nil
+	join.OtherConditions = nil
+	for _, child := range []*joinNode{node.left, node.right} {
+		p, err := g.buildPlan(child, leafParents, false)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if p != nil {
+			addChild(join, p)
+			continue
+		}
+		for i, leaf := range g.leaves {
+			if child.mask == 1<<uint(i) {
+				err = leaf.ReplaceParent(leafParents[i], join)
+				if err != nil {
+					return nil, errors.Trace(err)
+				}
+				join.AddChild(leaf)
+			}
+		}
+	}
+	left, right := join.GetChildByIndex(0), join.GetChildByIndex(1)
+	join.SetSchema(append(left.GetSchema().DeepCopy(), right.GetSchema().DeepCopy()...))
+
+	var conds []expression.Expression
+	for i, cond := range g.conds {
+		m := g.condMasks[i]
+		if m&node.mask == m && m&node.left.mask != m && m&node.right.mask != m {
+			conds = append(conds, cond)
+		} else if top && m == 0 {
+			// The condition doesn't reference any leaf.
+			conds = append(conds, cond)
+		}
+	}
+	eqCond, leftCond, rightCond, otherCond := extractOnCondition(conds, left, right)
+	join.EqualConditions = eqCond
+	join.OtherConditions = append(append(otherCond, leftCond...), rightCond...)
+	return join, nil
+}
+
+// estimateRowCount estimates the row count of a join leaf before the plan is refined.
+func estimateRowCount(p Plan) float64 {
+	switch v := p.(type) {
+	case *NewTableScan:
+		newTableScan(v)
+		return v.rowCount
+	case *Selection:
+		rows := estimateRowCount(v.GetChildByIndex(0))
+		scan, _ := v.GetChildByIndex(0).(*NewTableScan)
+		for _, cond := range v.Conditions {
+			rows *= exprFilterRate(scan, cond)
+		}
+		return rows
+	case *Projection, *NewSort, *Trim:
+		if len(p.GetChildren()) == 0 {
+			return 1
+		}
+		return estimateRowCount(p.GetChildByIndex(0))
+	case *Limit:
+		return math.Min(float64(v.Count), estimateRowCount(v.GetChildByIndex(0)))
+	case *TopN:
+		return math.Min(float64(v.Count), estimateRowCount(v.GetChildByIndex(0)))
+	case *Join:
+		if v.JoinType == RightOuterJoin {
+			return estimateRowCount(v.GetChildByIndex(1))
+		}
+		return estimateRowCount(v.GetChildByIndex(0))
+	}
+	return FullRangeCount
+}
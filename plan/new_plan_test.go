@@ -23,13 +23,17 @@ import (
 	"github.com/pingcap/tidb/model"
 	"github.com/pingcap/tidb/mysql"
 	"github.com/pingcap/tidb/parser"
+	"github.com/pingcap/tidb/plan/statistics"
 	"github.com/pingcap/tidb/util/testleak"
+	"github.com/pingcap/tidb/util/types"
 )
 
 func newMockResolve(node ast.Node) error {
 	indices := []*model.IndexInfo{
 		{
-			Name: model.NewCIStr("b"),
+			Name:   model.NewCIStr("b"),
+			Unique: true,
+			State:  model.StatePublic,
 			Columns: []*model.IndexColumn{
 				{
 					Name: model.NewCIStr("b"),
@@ -37,7 +41,8 @@ func newMockResolve(node ast.Node) error {
 			},
 		},
 		{
-			Name: model.NewCIStr("c_d_e"),
+			Name:  model.NewCIStr("c_d_e"),
+			State: model.StatePublic,
 			Columns: []*model.IndexColumn{
 				{
 					Name: model.NewCIStr("c"),
@@ -52,23 +57,28 @@ func newMockResolve(node ast.Node) error {
 		},
 	}
 	pkColumn := &model.ColumnInfo{
+		ID:    1,
 		State: model.StatePublic,
 		Name:  model.NewCIStr("a"),
 	}
 	col0 := &model.ColumnInfo{
+		ID:    2,
 		State: model.StatePublic,
 		Name:  model.NewCIStr("b"),
 	}
 	col1 := &model.ColumnInfo{
+		ID:    3,
 		State: model.StatePublic,
 		Name:  model.NewCIStr("c"),
 	}
 	col2 := &model.ColumnInfo{
+		ID:    4,
 		State: model.StatePublic,
 		Name:  model.NewCIStr("d"),
 	}
 	pkColumn.Flag = mysql.PriKeyFlag
 	table := &model.TableInfo{
+		ID:         1,
 		Columns:    []*model.ColumnInfo{pkColumn, col0, col1, col2},
 		Indices:    indices,
 		Name:       model.NewCIStr("t"),
@@ -160,6 +170,317 @@ func (s *testPlanSuite) TestPredicatePushDown(c *C) {
 	UseNewPlanner = false
 }
 
+func (s *testPlanSuite) TestJoinReorder(c *C) {
+	UseNewPlanner = true
+	defer testleak.AfterTest(c)()
+	cases := []struct {
+		sql       string
+		threshold int
+		best      string
+	}{
+		{
+			sql:       "select * from t t1 join t t2 on t1.b = t2.b join t t3 on t2.c = t3.c where t3.d = 1",
+			threshold: 8,
+			best:      "Join{DataScan(t)->Join{DataScan(t)->DataScan(t)->Selection}}->Projection",
+		},
+		{
+			sql:       "select * from t t1 join t t2 join t t3 on t1.b = t3.b and t2.c = t3.c where t1.d = 1 and t2.d = 1",
+			threshold: 8,
+			best:      "Join{Join{DataScan(t)->DataScan(t)->Selection}->DataScan(t)->Selection}->Projection",
+		},
+		{
+			sql:       "select * from t t1 join t t2 on t1.b = t2.b join t t3 on t2.c = t3.c where t3.d = 1",
+			threshold: 1,
+			best:      "Join{DataScan(t)->Join{DataScan(t)->DataScan(t)->Selection}}->Projection",
+		},
+		{
+			sql:       "select * from t t1 left join t t2 on t1.b = t2.b join t t3 on t2.c = t3.c where t3.d = 1",
+			threshold: 8,
+			best:      "Join{Join{DataScan(t)->DataScan(t)}->DataScan(t)->Selection}->Projection",
+		},
+		{
+			sql:       "select * from t t1 join t t2 on t1.b = t2.b where t1.a = 1",
+			threshold: 8,
+			best:      "Join{DataScan(t)->DataScan(t)->Selection}->Projection",
+		},
+	}
+	defer func(threshold int) {
+		joinReorderDPThreshold = threshold
+	}(joinReorderDPThreshold)
+	for _, ca := range cases {
+		comment := Commentf("for %s", ca.sql)
+		stmt, err := parser.ParseOneStmt(ca.sql, "", "")
+		c.Assert(err, IsNil, comment)
+		ast.SetFlag(stmt)
+
+		err = newMockResolve(stmt)
+		c.Assert(err, IsNil)
+
+		builder := &planBuilder{}
+		p := builder.build(stmt)
+		c.Assert(builder.err, IsNil)
+
+		_, err = builder.predicatePushDown(p, []expression.Expression{})
+		c.Assert(err, IsNil)
+		joinReorderDPThreshold = ca.threshold
+		err = builder.reorderJoin(p)
+		c.Assert(err, IsNil)
+		_, err = pruneColumnsAndResolveIndices(p, p.GetSchema())
+		c.Assert(err, IsNil)
+		c.Check(ToString(p), Equals, ca.best, comment)
+	}
+	UseNewPlanner = false
+}
+
+func (s *testPlanSuite) TestTopNPushDown(c *C) {
+	UseNewPlanner = true
+	defer testleak.AfterTest(c)()
+	cases := []struct {
+		sql   string
+		first string
+		best  string
+	}{
+		{
+			sql:   "select a from t where d > 1 order by b limit 2, 3",
+			first: "DataScan(t)->Selection->Projection->TopN(2, 3)->Trim",
+			best:  "DataScan(t)->Selection->TopN(0, 5)->Projection->TopN(2, 3)->Trim",
+		},
+		{
+			sql:   "select a + b as x from t order by x limit 1",
+			first: "DataScan(t)->Projection->TopN(0, 1)",
+			best:  "DataScan(t)->Projection->TopN(0, 1)",
+		},
+		{
+			sql:   "select * from (select a from t order by a desc limit 3) k where k.a > 1",
+			first: "DataScan(t)->Projection->TopN(0, 3)->Selection->Projection",
+			best:  "DataScan(t)->TopN(0, 3)->Projection->TopN(0, 3)->Selection->Projection",
+		},
+		{
+			sql:   "select b, count(*) as c from t group by b order by c limit 1",
+			first: "DataScan(t)->Aggr->Projection->TopN(0, 1)->Trim",
+			best:  "DataScan(t)->Aggr->TopN(0, 1)->Projection->TopN(0, 1)->Trim",
+		},
+	}
+	for _, ca := range cases {
+		comment := Commentf("for %s", ca.sql)
+		stmt, err := parser.ParseOneStmt(ca.sql, "", "")
+		c.Assert(err, IsNil, comment)
+		ast.SetFlag(stmt)
+
+		err = newMockResolve(stmt)
+		c.Assert(err, IsNil)
+
+		builder := &planBuilder{colMapper: make(map[*ast.ColumnNameExpr]expression.Expression)}
+		p := builder.build(stmt)
+		c.Assert(builder.err, IsNil, comment)
+		c.Assert(ToString(p), Equals, ca.first, comment)
+
+		_, err = builder.predicatePushDown(p, []expression.Expression{})
+		c.Assert(err, IsNil)
+		err = builder.pushTopNDown(p)
+		c.Assert(err, IsNil)
+		_, err = pruneColumnsAndResolveIndices(p, p.GetSchema())
+		c.Assert(err, IsNil)
+		c.Assert(ToString(p), Equals, ca.best, comment)
+	}
+	UseNewPlanner = false
+}
+
+func (s *testPlanSuite) TestWindow(c *C) {
+	UseNewPlanner = true
+	defer testleak.AfterTest(c)()
+	cases := []struct {
+		sql   string
+		first string
+		best  string
+	}{
+		{
+			sql:   "select a, rank() over (partition by b order by c) from t where d > 1",
+			first: "DataScan(t)->Selection->Window->Projection",
+			best:  "DataScan(t)->Selection->Window->Projection",
+		},
+		{
+			sql:   "select * from (select a, row_number() over (order by b) as r from t) k where k.r > 1",
+			first: "DataScan(t)->Window->Projection->Selection->Projection",
+			best:  "DataScan(t)->Window->Selection->Projection->Projection",
+		},
+		{
+			sql:   "select b, sum(count(*)) over (order by b) from t group by b",
+			first: "DataScan(t)->Aggr->Window->Projection",
+			best:  "DataScan(t)->Aggr->Window->Projection",
+		},
+		{
+			sql:   "select a, row_number() over (order by b) as r from t order by r limit 2",
+			first: "DataScan(t)->Window->Projection->TopN(0, 2)",
+			best:  "DataScan(t)->Window->TopN(0, 2)->Projection->TopN(0, 2)",
+		},
+	}
+	for _, ca := range cases {
+		comment := Commentf("for %s", ca.sql)
+		stmt, err := parser.ParseOneStmt(ca.sql, "", "")
+		c.Assert(err, IsNil, comment)
+		ast.SetFlag(stmt)
+
+		err = newMockResolve(stmt)
+		c.Assert(err, IsNil)
+
+		builder := &planBuilder{colMapper: make(map[*ast.ColumnNameExpr]expression.Expression)}
+		p := builder.build(stmt)
+		c.Assert(builder.err, IsNil, comment)
+		c.Assert(ToString(p), Equals, ca.first, comment)
+
+		_, err = builder.predicatePushDown(p, []expression.Expression{})
+		c.Assert(err, IsNil)
+		err = builder.pushTopNDown(p)
+		c.Assert(err, IsNil)
+		_, err = pruneColumnsAndResolveIndices(p, p.GetSchema())
+		c.Assert(err, IsNil)
+		c.Assert(ToString(p), Equals, ca.best, comment)
+	}
+
+	// The window functions can't be used in the where clause.
+	stmt, err := parser.ParseOneStmt("select a from t where rank() over (order by b) > 1", "", "")
+	c.Assert(err, IsNil)
+	ast.SetFlag(stmt)
+	err = newMockResolve(stmt)
+	c.Assert(err, IsNil)
+	builder := &planBuilder{colMapper: make(map[*ast.ColumnNameExpr]expression.Expression)}
+	builder.build(stmt)
+	c.Assert(builder.err, NotNil)
+	UseNewPlanner = false
+}
+
+func (s *testPlanSuite) TestCommonTableExpression(c *C) {
+	UseNewPlanner = true
+	defer testleak.AfterTest(c)()
+	cases := []struct {
+		sql   string
+		first string
+		best  string
+	}{
+		{
+			sql:   "with cte as (select a, b from t where c > 1) select a from cte where b > 1",
+			first: "DataScan(t)->Selection->Projection->Selection->Projection",
+			best:  "DataScan(t)->Selection->Projection->Projection",
+		},
+		{
+			sql:   "with c1 as (select a from t), c2 (x) as (select a + 1 from c1) select * from c1 join c2 on c1.a = c2.x",
+			first: "Join{DataScan(t)->Projection->DataScan(t)->Projection->Projection}->Projection",
+			best:  "Join{DataScan(t)->Projection->DataScan(t)->Projection->Projection}->Projection",
+		},
+		{
+			sql:   "with recursive cte (n) as (select 1 union all select n + 1 from cte where n < 10) select n from cte where n > 5",
+			first: "RecursiveCTE{*plan.NewTableDual->Projection->CTETable->Selection->Projection}->Selection->Projection",
+			best:  "RecursiveCTE{*plan.NewTableDual->Projection->CTETable->Selection->Projection}->Selection->Projection",
+		},
+		{
+			sql:   "with recursive cte as (select a, b from t where c = 1 union select t.a, t.b from t join cte on t.c = cte.a) select b from cte",
+			first: "RecursiveCTE{DataScan(t)->Selection->Projection->Join{DataScan(t)->CTETable}->Projection}->Projection",
+			best:  "RecursiveCTE{DataScan(t)->Selection->Projection->Join{DataScan(t)->CTETable}->Projection}->Projection",
+		},
+	}
+	for _, ca := range cases {
+		comment := Commentf("for %s", ca.sql)
+		stmt, err := parser.ParseOneStmt(ca.sql, "", "")
+		c.Assert(err, IsNil, comment)
+		ast.SetFlag(stmt)
+
+		err = newMockResolve(stmt)
+		c.Assert(err, IsNil)
+
+		builder := &planBuilder{colMapper: make(map[*ast.ColumnNameExpr]expression.Expression)}
+		p := builder.build(stmt)
+		c.Assert(builder.err, IsNil, comment)
+		c.Assert(ToString(p), Equals, ca.first, comment)
+
+		_, err = builder.predicatePushDown(p, []expression.Expression{})
+		c.Assert(err, IsNil)
+		_, err = pruneColumnsAndResolveIndices(p, p.GetSchema())
+		c.Assert(err, IsNil)
+		c.Assert(ToString(p), Equals, ca.best, comment)
+	}
+	UseNewPlanner = false
+}
+
+func (s *testPlanSuite) TestJoinAlgorithm(c *C) {
+	UseNewPlanner = true
+	defer testleak.AfterTest(c)()
+	cases := []struct {
+		sql  string
+		best string
+	}{
+		{
+			sql:  "select * from t t1 join t t2 on t1.a = t2.a",
+			best: "MergeJoin{DataScan(t)->DataScan(t)}->Projection",
+		},
+		{
+			sql:  "select * from t t1 left join t t2 on t1.b = t2.b and t1.a = t2.a and t2.c > 1",
+			best: "MergeJoin{DataScan(t)->DataScan(t)->Selection}->Projection",
+		},
+		{
+			sql:  "select * from t t1 right join t t2 on t1.a = t2.a and t2.b > 1",
+			best: "MergeJoin{DataScan(t)->DataScan(t)}->Projection",
+		},
+		{
+			sql:  "select * from t t1 join t t2 on t1.a = t2.a where t1.d = 1",
+			best: "IndexJoin{DataScan(t)->DataScan(t)->Selection}->Projection",
+		},
+		{
+			sql:  "select * from t t1 join t t2 on t1.a = t2.b",
+			best: "Join{DataScan(t)->DataScan(t)}->Projection",
+		},
+		{
+			sql:  "select * from t t1 join t t2 on t1.b = t2.a where t1.d = 1",
+			best: "IndexJoin{DataScan(t)->DataScan(t)->Selection}->Projection",
+		},
+		{
+			sql:  "select * from t t1 left join t t2 on t1.b = t2.a where t1.d = 1",
+			best: "IndexJoin{DataScan(t)->Selection->DataScan(t)}->Projection",
+		},
+		{
+			sql:  "select * from t t1 right join t t2 on t1.b = t2.a where t1.d = 1",
+			best: "Join{DataScan(t)->DataScan(t)}->Selection->Projection",
+		},
+		{
+			sql:  "select * from t t1 join t t2 on t1.b = t2.b where t1.d = 1",
+			best: "IndexJoin{DataScan(t)->DataScan(t)->Selection}->Projection",
+		},
+		{
+			sql:  "select * from t t1 join t t2 on t1.b = t2.c where t1.d = 1",
+			best: "Join{DataScan(t)->DataScan(t)->Selection}->Projection",
+		},
+		{
+			sql:  "select * from t t1 join t t2 on t1.b = t2.c and t1.c = t2.d where t1.d = 1 and t1.c = 1",
+			best: "IndexJoin{DataScan(t)->DataScan(t)->Selection}->Projection",
+		},
+	}
+	for _, ca := range cases {
+		comment := Commentf("for %s", ca.sql)
+		stmt, err := parser.ParseOneStmt(ca.sql, "", "")
+		c.Assert(err, IsNil, comment)
+		ast.SetFlag(stmt)
+
+		err = newMockResolve(stmt)
+		c.Assert(err, IsNil)
+
+		builder := &planBuilder{}
+		p := builder.build(stmt)
+		c.Assert(builder.err, IsNil)
+		_, err = builder.predicatePushDown(p, []expression.Expression{})
+		c.Assert(err, IsNil)
+		err = builder.reorderJoin(p)
+		c.Assert(err, IsNil)
+		_, err = pruneColumnsAndResolveIndices(p, p.GetSchema())
+		c.Assert(err, IsNil)
+		err = Refine(p)
+		c.Assert(err, IsNil)
+		estimate(p)
+		chooseJoinAlgorithm(p)
+		c.Check(ToString(p), Equals, ca.best, comment)
+	}
+	UseNewPlanner = false
+}
+
 func (s *testPlanSuite) TestColumnPruning(c *C) {
 	UseNewPlanner = true
 	defer testleak.AfterTest(c)()
@@ -248,6 +569,18 @@ func (s *testPlanSuite) TestColumnPruning(c *C) {
 				"*plan.NewTableScan_1": {"a", "b", "c"},
 			},
 		},
+		{
+			sql: "select a, rank() over (partition by b order by c) from t",
+			ans: map[string][]string{
+				"*plan.NewTableScan_1": {"a", "b", "c"},
+			},
+		},
+		{
+			sql: "select k.a from (select a, rank() over (partition by b order by c) as r from t) k",
+			ans: map[string][]string{
+				"*plan.NewTableScan_1": {"a"},
+			},
+		},
 	}
 	for _, ca := range cases {
 		comment := Commentf("for %s", ca.sql)
@@ -397,3 +730,89 @@ func check(p Plan, c *C, ans map[string][]string, comment CommentInterface) {
 		check(child, c, ans, comment)
 	}
 }
+
+func mockStatsTable(c *C, tblInfo *model.TableInfo) *statistics.Table {
+	// Column a is the handle with values in [0, 1000), column d has 990 rows of value 0 and 1 row of each value in [1, 10].
+	samples := make([][]types.Datum, len(tblInfo.Columns))
+	for i := 0; i < 1000; i++ {
+		samples[0] = append(samples[0], types.NewIntDatum(int64(i)))
+		samples[1] = append(samples[1], types.NewIntDatum(int64(i)))
+		samples[2] = append(samples[2], types.NewIntDatum(int64(i)))
+		d := int64(0)
+		if i >= 990 {
+			d = int64(i - 989)
+		}
+		samples[3] = append(samples[3], types.NewIntDatum(d))
+	}
+	statsTbl, err := statistics.NewTable(tblInfo, 1, 1000, 64, samples, nil)
+	c.Assert(err, IsNil)
+	return statsTbl
+}
+
+func (s *testPlanSuite) TestStatsFilterRate(c *C) {
+	defer testleak.AfterTest(c)()
+	cases := []struct {
+		expr     string
+		rate     float64
+		rowCount float64
+	}{
+		{expr: "d = 0", rate: 0.99, rowCount: 990},
+		{expr: "d = 5", rate: 0.09, rowCount: 90},
+		{expr: "d > 0", rate: 0.01, rowCount: 10},
+		{expr: "0 < d", rate: 0.01, rowCount: 10},
+		{expr: "d != 0", rate: 0.01, rowCount: 10},
+		{expr: "d <= 0", rate: 0.99, rowCount: 990},
+		{expr: "d > 0 or d = 0", rate: 0.9901, rowCount: 990.1},
+		{expr: "a < 100 and d = 0", rate: 0.10296, rowCount: 96.03},
+	}
+	for _, ca := range cases {
+		comment := Commentf("for %s", ca.expr)
+		sql := "select * from t where " + ca.expr
+		stmt, err := parser.ParseOneStmt(sql, "", "")
+		c.Assert(err, IsNil, comment)
+		ast.SetFlag(stmt)
+		err = newMockResolve(stmt)
+		c.Assert(err, IsNil, comment)
+		tn := stmt.(*ast.SelectStmt).From.TableRefs.Left.(*ast.TableSource).Source.(*ast.TableName)
+		statsTbl := mockStatsTable(c, tn.TableInfo)
+
+		rate := statsFilterRate(statsTbl, tn.TableInfo, stmt.(*ast.SelectStmt).Where)
+		c.Check(rate, Equals, ca.rate, comment)
+
+		UseNewPlanner = true
+		builder := &planBuilder{}
+		p := builder.build(stmt)
+		c.Assert(builder.err, IsNil, comment)
+		_, err = builder.predicatePushDown(p, []expression.Expression{})
+		c.Assert(err, IsNil, comment)
+		_, err = pruneColumnsAndResolveIndices(p, p.GetSchema())
+		c.Assert(err, IsNil, comment)
+		sel := p.GetChildByIndex(0).(*Selection)
+		sel.GetChildByIndex(0).(*NewTableScan).statsTbl = statsTbl
+		err = Refine(p)
+		c.Assert(err, IsNil, comment)
+		estimate(p)
+		c.Check(sel.RowCount(), Equals, ca.rowCount, comment)
+		UseNewPlanner = false
+	}
+
+	// The new planner doesn't support between and in expression now.
+	oldCases := []struct {
+		expr string
+		rate float64
+	}{
+		{expr: "d between 0 and 3", rate: 1},
+		{expr: "d in (5, 6)", rate: 0.18},
+		{expr: "d not in (5, 6)", rate: rateFull - 0.18},
+	}
+	for _, ca := range oldCases {
+		comment := Commentf("for %s", ca.expr)
+		stmt, err := parser.ParseOneStmt("select * from t where "+ca.expr, "", "")
+		c.Assert(err, IsNil, comment)
+		err = newMockResolve(stmt)
+		c.Assert(err, IsNil, comment)
+		tn := stmt.(*ast.SelectStmt).From.TableRefs.Left.(*ast.TableSource).Source.(*ast.TableName)
+		rate := statsFilterRate(mockStatsTable(c, tn.TableInfo), tn.TableInfo, stmt.(*ast.SelectStmt).Where)
+		c.Check(rate, Equals, ca.rate, comment)
+	}
+}
@@ -15,8 +15,10 @@ package plan
 
 import (
 	"github.com/juju/errors"
+	"github.com/pingcap/tidb/ast"
 	"github.com/pingcap/tidb/expression"
 	"github.com/pingcap/tidb/model"
+	"github.com/pingcap/tidb/plan/statistics"
 )
 
 // JoinType contains CrossJoin, InnerJoin, LeftOuterJoin, RightOuterJoin, FullOuterJoin, SemiJoin.
@@ -44,6 +46,15 @@ type Join struct {
 	LeftConditions  []expression.Expression
 	RightConditions []expression.Expression
 	OtherConditions []expression.Expression
+
+	// Algorithm is the algorithm chosen to execute the join.
+	Algorithm JoinAlgorithm
+	// LookUpInnerIdx is the index of the child looked up by the index look up join.
+	LookUpInnerIdx int
+	// LookUpIndex is the index of the inner table to look up, it is nil if the inner table is looked up by the handle.
+	LookUpIndex *model.IndexInfo
+	// LookUpOuterKeys are the outer columns whose values are used to look up the handle or the index prefix.
+	LookUpOuterKeys []*expression.Column
 }
 
 // Projection represents a select fields plan.
@@ -107,6 +118,18 @@ type NewTableScan struct {
 	TableAsName *model.CIStr
 
 	LimitCount *int64
+
+	// statsTbl is the statistics of the table, it is nil if the table has not been analyzed.
+	statsTbl *statistics.Table
+}
+
+// TopN represents a top-n plan, it returns Count rows after skipping Offset rows in the order of ByItems.
+type TopN struct {
+	basePlan
+
+	ByItems []ByItems
+	Offset  uint64
+	Count   uint64
 }
 
 // Trim trims child's rows.
@@ -114,6 +137,42 @@ type Trim struct {
 	basePlan
 }
 
+// WindowFuncDesc describes a window function, or an aggregate function evaluated over a window.
+type WindowFuncDesc struct {
+	// Name is the lower-case function name.
+	Name        string
+	Args        []expression.Expression
+	PartitionBy []expression.Expression
+	OrderBy     []ByItems
+	// Frame is the ROWS frame of the function, it is nil if not specified.
+	Frame *ast.FrameClause
+}
+
+// Window evaluates the window functions for every row of its child.
+// Its schema is the schema of the child followed by a column for each window function.
+type Window struct {
+	basePlan
+
+	WindowFuncs []*WindowFuncDesc
+}
+
+// RecursiveCTE represents a recursive common table expression. Its first child is the seed part, and its second
+// child is the recursive part, which reads the rows of the last iteration by the CTETable plans in it.
+// The iterations stop when the recursive part returns no new rows.
+type RecursiveCTE struct {
+	basePlan
+
+	// Distinct means the duplicated rows are removed, so the rows which are already returned are not new rows.
+	Distinct bool
+}
+
+// CTETable reads the rows of the last iteration of a recursive common table expression.
+type CTETable struct {
+	basePlan
+
+	CTE *RecursiveCTE
+}
+
 // NewUnion represents Union plan.
 type NewUnion struct {
 	basePlan
@@ -15,6 +15,7 @@ package plan
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/juju/errors"
 	"github.com/pingcap/tidb/ast"
@@ -60,6 +61,85 @@ func (b *planBuilder) buildAggregation(p Plan, aggFuncList []*ast.AggregateFuncE
 	return agg
 }
 
+// extractWindowFuncs extracts the window functions in the select fields.
+func extractWindowFuncs(fields []*ast.SelectField) []*ast.WindowFuncExpr {
+	extractor := &ast.WindowFuncExtractor{}
+	for _, f := range fields {
+		if f.Expr != nil {
+			f.Expr.Accept(extractor)
+		}
+	}
+	return extractor.WindowFuncs
+}
+
+func (b *planBuilder) buildWindow(p Plan, windowFuncs []*ast.WindowFuncExpr, aggMapper map[*ast.AggregateFuncExpr]int) Plan {
+	window := &Window{WindowFuncs: make([]*WindowFuncDesc, 0, len(windowFuncs))}
+	window.id = b.allocID(window)
+	var correlated bool
+	for _, wf := range windowFuncs {
+		desc := &WindowFuncDesc{Name: strings.ToLower(wf.F), Frame: wf.Spec.Frame}
+		var cor bool
+		p, cor, desc.Args = b.rewriteWindowExprs(p, wf.Args, aggMapper)
+		if b.err != nil {
+			return nil
+		}
+		correlated = correlated || cor
+		p, cor, desc.PartitionBy = b.rewriteWindowExprs(p, wf.Spec.PartitionBy, aggMapper)
+		if b.err != nil {
+			return nil
+		}
+		correlated = correlated || cor
+		if wf.Spec.OrderBy != nil {
+			items := make([]ast.ExprNode, 0, len(wf.Spec.OrderBy.Items))
+			for _, item := range wf.Spec.OrderBy.Items {
+				items = append(items, item.Expr)
+			}
+			var exprs []expression.Expression
+			p, cor, exprs = b.rewriteWindowExprs(p, items, aggMapper)
+			if b.err != nil {
+				return nil
+			}
+			correlated = correlated || cor
+			for i, expr := range exprs {
+				desc.OrderBy = append(desc.OrderBy, ByItems{Expr: expr, Desc: wf.Spec.OrderBy.Items[i].Desc})
+			}
+		}
+		window.WindowFuncs = append(window.WindowFuncs, desc)
+	}
+	window.correlated = p.IsCorrelated() || correlated
+	addChild(window, p)
+	if b.windowMapper == nil {
+		b.windowMapper = make(map[*ast.WindowFuncExpr]*expression.Column)
+	}
+	schema := p.GetSchema().DeepCopy()
+	for i, wf := range windowFuncs {
+		col := &expression.Column{FromID: window.id,
+			ColName:  model.NewCIStr(fmt.Sprintf("%s_col_%d", window.id, i)),
+			Position: i,
+			RetType:  wf.GetType()}
+		b.windowMapper[wf] = col
+		schema = append(schema, col)
+	}
+	window.SetSchema(schema)
+	return window
+}
+
+func (b *planBuilder) rewriteWindowExprs(p Plan, exprs []ast.ExprNode, aggMapper map[*ast.AggregateFuncExpr]int) (Plan, bool, []expression.Expression) {
+	newExprs := make([]expression.Expression, 0, len(exprs))
+	correlated := false
+	for _, expr := range exprs {
+		newExpr, np, cor, err := b.rewrite(expr, p, aggMapper)
+		if err != nil {
+			b.err = errors.Trace(err)
+			return nil, false, nil
+		}
+		newExprs = append(newExprs, newExpr)
+		correlated = correlated || cor
+		p = np
+	}
+	return p, correlated, newExprs
+}
+
 func (b *planBuilder) buildResultSetNode(node ast.ResultSetNode) Plan {
 	switch x := node.(type) {
 	case *ast.Join:
@@ -72,6 +152,10 @@ func (b *planBuilder) buildResultSetNode(node ast.ResultSetNode) Plan {
 		case *ast.UnionStmt:
 			p = b.buildNewUnion(v)
 		case *ast.TableName:
+			if v.CTE != nil {
+				p = b.buildCTE(v.CTE)
+				break
+			}
 			// TODO: select physical algorithm during cbo phase.
 			p = b.buildNewTableScanPlan(v)
 		default:
@@ -102,6 +186,120 @@ func (b *planBuilder) buildResultSetNode(node ast.ResultSetNode) Plan {
 	}
 }
 
+// buildCTE builds the plan of a reference to a common table expression. In the recursive part of a recursive
+// common table expression, the reference reads the rows of the last iteration. Otherwise the query of the
+// common table expression is built for every reference.
+func (b *planBuilder) buildCTE(cte *ast.CommonTableExpression) Plan {
+	if recursive, ok := b.cteTables[cte]; ok {
+		t := &CTETable{CTE: recursive}
+		t.id = b.allocID(t)
+		schema := recursive.GetSchema().DeepCopy()
+		for _, col := range schema {
+			col.FromID = t.id
+		}
+		t.SetSchema(schema)
+		return t
+	}
+	var p Plan
+	if cte.IsRecursive {
+		p = b.buildRecursiveCTE(cte)
+	} else {
+		p = b.buildResultSetNode(cte.Query)
+	}
+	if b.err != nil {
+		return nil
+	}
+	setCTEColumnNames(p.GetSchema(), cte)
+	return p
+}
+
+func setCTEColumnNames(schema expression.Schema, cte *ast.CommonTableExpression) {
+	for i, col := range schema {
+		col.ColName = cte.TableInfo.Columns[i].Name
+		col.TblName = cte.Name
+		col.DBName = model.NewCIStr("")
+	}
+}
+
+// buildRecursiveCTE builds the select statements of the union before the first one which refers to
+// the common table expression as the seed part, and the others as the recursive part.
+func (b *planBuilder) buildRecursiveCTE(cte *ast.CommonTableExpression) Plan {
+	union := cte.Query.(*ast.UnionStmt)
+	if union.OrderBy != nil || union.Limit != nil {
+		b.err = ErrUnsupportedType.Gen("ORDER BY and LIMIT in recursive Common Table Expression are not supported")
+		return nil
+	}
+	sels := union.SelectList.Selects
+	seedLen := 0
+	for seedLen < len(sels) && !refersCTE(sels[seedLen], cte) {
+		seedLen++
+	}
+	seed := b.buildUnionOfSelects(sels[:seedLen])
+	if b.err != nil {
+		return nil
+	}
+	p := &RecursiveCTE{Distinct: union.Distinct}
+	p.id = b.allocID(p)
+	schema := seed.GetSchema().DeepCopy()
+	for i, col := range schema {
+		col.FromID = p.id
+		col.Position = i
+	}
+	// The names are used by the CTETable plans in the recursive part.
+	setCTEColumnNames(schema, cte)
+	p.SetSchema(schema)
+	if b.cteTables == nil {
+		b.cteTables = make(map[*ast.CommonTableExpression]*RecursiveCTE)
+	}
+	b.cteTables[cte] = p
+	recursive := b.buildUnionOfSelects(sels[seedLen:])
+	delete(b.cteTables, cte)
+	if b.err != nil {
+		return nil
+	}
+	if len(recursive.GetSchema()) != len(schema) {
+		b.err = errors.New("The used SELECT statements have a different number of columns")
+		return nil
+	}
+	addChild(p, seed)
+	addChild(p, recursive)
+	p.correlated = seed.IsCorrelated() || recursive.IsCorrelated()
+	return p
+}
+
+// buildUnionOfSelects builds the union all of the select statements.
+func (b *planBuilder) buildUnionOfSelects(sels []*ast.SelectStmt) Plan {
+	if len(sels) == 1 {
+		return b.buildNewSelect(sels[0])
+	}
+	return b.buildNewUnion(&ast.UnionStmt{SelectList: &ast.UnionSelectList{Selects: sels}})
+}
+
+// cteRefFinder finds the table names which refer to a common table expression.
+type cteRefFinder struct {
+	cte   *ast.CommonTableExpression
+	found bool
+}
+
+// Enter implements ast.Visitor interface.
+func (f *cteRefFinder) Enter(in ast.Node) (ast.Node, bool) {
+	if tn, ok := in.(*ast.TableName); ok && tn.CTE == f.cte {
+		f.found = true
+	}
+	return in, f.found
+}
+
+// Leave implements ast.Visitor interface.
+func (f *cteRefFinder) Leave(in ast.Node) (ast.Node, bool) {
+	return in, true
+}
+
+func refersCTE(sel *ast.SelectStmt, cte *ast.CommonTableExpression) bool {
+	finder := &cteRefFinder{cte: cte}
+	sel.Accept(finder)
+	return finder.found
+}
+
 func extractColumn(expr expression.Expression, cols []*expression.Column, outerCols []*expression.Column) (result []*expression.Column, outer []*expression.Column) {
 	switch v := expr.(type) {
 	case *expression.Column:
@@ -384,6 +582,26 @@ func (b *planBuilder) buildNewLimit(src Plan, limit *ast.Limit) Plan {
 	return li
 }
 
+// buildTopN replaces the sort plan followed by a limit with a top-n plan.
+func (b *planBuilder) buildTopN(sort *NewSort, limit *ast.Limit) Plan {
+	topN := &TopN{
+		ByItems: sort.ByItems,
+		Offset:  limit.Offset,
+		Count:   limit.Count,
+	}
+	child := sort.GetChildByIndex(0)
+	err := child.ReplaceParent(sort, topN)
+	if err != nil {
+		b.err = errors.Trace(err)
+		return nil
+	}
+	topN.AddChild(child)
+	topN.correlated = sort.correlated
+	topN.id = b.allocID(topN)
+	topN.SetSchema(sort.GetSchema())
+	return topN
+}
+
 func (b *planBuilder) extractAggFunc(sel *ast.SelectStmt) (
 	[]*ast.AggregateFuncExpr, map[*ast.AggregateFuncExpr]int,
 	map[*ast.AggregateFuncExpr]int, map[*ast.AggregateFuncExpr]int) {
@@ -692,6 +910,17 @@ func (b *planBuilder) buildNewSelect(sel *ast.SelectStmt) Plan {
 			return nil
 		}
 	}
+	if windowFuncs := extractWindowFuncs(sel.Fields.Fields); len(windowFuncs) > 0 {
+		if sel.Having != nil {
+			// The window functions should be evaluated after the having clause, which is built after the projection.
+			b.err = ErrUnsupportedType.Gen("Window functions with HAVING clause are not supported")
+			return nil
+		}
+		p = b.buildWindow(p, windowFuncs, totalMap)
+		if b.err != nil {
+			return nil
+		}
+	}
 	var oldLen int
 	p, oldLen = b.buildProjection(p, sel.Fields.Fields, totalMap)
 	if b.err != nil {
@@ -736,7 +965,11 @@ func (b *planBuilder) buildNewSelect(sel *ast.SelectStmt) Plan {
 		}
 	}
 	if sel.Limit != nil {
-		p = b.buildLimit(p, sel.Limit)
+		if sort, ok := p.(*NewSort); ok {
+			p = b.buildTopN(sort, sel.Limit)
+		} else {
+			p = b.buildLimit(p, sel.Limit)
+		}
 		if b.err != nil {
 			return nil
 		}
@@ -767,6 +1000,7 @@ func (b *planBuilder) buildNewTableDual() Plan {
 func (b *planBuilder) buildNewTableScanPlan(tn *ast.TableName) Plan {
 	p := &NewTableScan{Table: tn.TableInfo}
 	p.id = b.allocID(p)
+	p.statsTbl = b.statisticsTable(tn.TableInfo)
 	// Equal condition contains a column from previous joined table.
 	rfs := tn.GetResultFields()
 	schema := make([]*expression.Column, 0, len(rfs))
@@ -38,12 +38,25 @@ func Optimize(ctx context.Context, node ast.Node, sb SubQueryBuilder, is infosch
 	if builder.err != nil {
 		return nil, errors.Trace(builder.err)
 	}
+	// The explained statement is optimized as if it is executed.
+	stmtPlan := p
+	if explain, ok := p.(*Explain); ok {
+		stmtPlan = explain.StmtPlan
+	}
 	if UseNewPlanner {
-		_, err := builder.predicatePushDown(p, []expression.Expression{})
+		_, err := builder.predicatePushDown(stmtPlan, []expression.Expression{})
 		if err != nil {
 			return nil, errors.Trace(err)
 		}
-		_, err = pruneColumnsAndResolveIndices(p, p.GetSchema())
+		err = builder.reorderJoin(stmtPlan)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		err = builder.pushTopNDown(stmtPlan)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		_, err = pruneColumnsAndResolveIndices(stmtPlan, stmtPlan.GetSchema())
 		if err != nil {
 			return nil, errors.Trace(err)
 		}
@@ -52,6 +65,10 @@ func Optimize(ctx context.Context, node ast.Node, sb SubQueryBuilder, is infosch
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
+	if UseNewPlanner {
+		estimate(stmtPlan)
+		chooseJoinAlgorithm(stmtPlan)
+	}
 	return p, nil
 }
 
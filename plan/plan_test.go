@@ -330,6 +330,14 @@ func (s *testPlanSuite) TestBestPlan(c *C) {
 			sql:  "admin show ddl",
 			best: "ShowDDL",
 		},
+		{
+			sql:  "admin show ddl jobs",
+			best: "ShowDDLJobs",
+		},
+		{
+			sql:  "admin cancel ddl jobs 1, 2",
+			best: "CancelDDLJobs",
+		},
 		{
 			sql:  "admin check table t",
 			best: "CheckTable",
@@ -26,6 +26,8 @@ import (
 	"github.com/pingcap/tidb/model"
 	"github.com/pingcap/tidb/mysql"
 	"github.com/pingcap/tidb/parser/opcode"
+	"github.com/pingcap/tidb/plan/statistics"
+	"github.com/pingcap/tidb/sessionctx"
 	"github.com/pingcap/tidb/terror"
 	"github.com/pingcap/tidb/util/charset"
 	"github.com/pingcap/tidb/util/types"
@@ -64,6 +66,10 @@ type planBuilder struct {
 	outerSchemas []expression.Schema
 	// colMapper stores the column that must be pre-resolved.
 	colMapper map[*ast.ColumnNameExpr]expression.Expression
+	// windowMapper stores the columns of the window functions in the schemas of the window plans.
+	windowMapper map[*ast.WindowFuncExpr]*expression.Column
+	// cteTables stores the recursive common table expressions whose recursive parts are being built.
+	cteTables map[*ast.CommonTableExpression]*RecursiveCTE
 }
 
 func (b *planBuilder) build(node ast.Node) Plan {
@@ -98,12 +104,18 @@ func (b *planBuilder) build(node ast.Node) Plan {
 		return b.buildInsert(x)
 	case *ast.PrepareStmt:
 		return b.buildPrepare(x)
+	case *ast.RenameTableStmt:
+		return b.buildDDL(x)
 	case *ast.SelectStmt:
 		if UseNewPlanner {
 			return b.buildNewSelect(x)
 		}
 		return b.buildSelect(x)
 	case *ast.UnionStmt:
+		// The common table expressions are only built by the new planner.
+		if UseNewPlanner && x.With != nil {
+			return b.buildNewUnion(x)
+		}
 		return b.buildUnion(x)
 	case *ast.UpdateStmt:
 		return b.buildUpdate(x)
@@ -222,6 +234,14 @@ func (b *planBuilder) buildSubquery(n ast.Node) {
 }
 
 func (b *planBuilder) buildSelect(sel *ast.SelectStmt) Plan {
+	if len(extractWindowFuncs(sel.Fields.Fields)) > 0 {
+		b.err = ErrUnsupportedType.Gen("Window functions are only supported by the new planner")
+		return nil
+	}
+	if sel.With != nil {
+		b.err = ErrUnsupportedType.Gen("WITH clause is only supported by the new planner")
+		return nil
+	}
 	var aggFuncs []*ast.AggregateFuncExpr
 	hasAgg := b.detectSelectAgg(sel)
 	canPushLimit := !hasAgg
@@ -453,6 +473,7 @@ func (b *planBuilder) buildTableScanPlan(path *joinPath) Plan {
 	p := &TableScan{
 		Table:     tn.TableInfo,
 		TableName: tn,
+		statsTbl:  b.statisticsTable(tn.TableInfo),
 	}
 	// Equal condition contains a column from previous joined table.
 	p.RefAccess = len(path.eqConds) > 0
@@ -483,7 +504,7 @@ func (b *planBuilder) buildTableScanPlan(path *joinPath) Plan {
 
 func (b *planBuilder) buildIndexScanPlan(index *model.IndexInfo, path *joinPath) Plan {
 	tn := path.table
-	ip := &IndexScan{Table: tn.TableInfo, Index: index, TableName: tn}
+	ip := &IndexScan{Table: tn.TableInfo, Index: index, TableName: tn, statsTbl: b.statisticsTable(tn.TableInfo)}
 	ip.RefAccess = len(path.eqConds) > 0
 	ip.SetFields(tn.GetResultFields())
 	ip.TableAsName = getTableAsName(ip.Fields())
@@ -536,6 +557,50 @@ out:
 	return ip
 }
 
+// isCoveringIndex checks whether all the referenced columns are in the index.
+// The handle column is covered too as the handle is stored in the index, but a column with a prefix index is not.
+func isCoveringIndex(fields []*ast.ResultField, index *model.IndexInfo, pkIsHandle bool) bool {
+	for _, rf := range fields {
+		if !rf.Referenced {
+			continue
+		}
+		if pkIsHandle && mysql.HasPriKeyFlag(rf.Column.Flag) {
+			continue
+		}
+		covered := false
+		for _, ic := range index.Columns {
+			if ic.Name.L == rf.Column.Name.L && !isPrefixIndexColumn(ic, rf.Column) {
+				covered = true
+				break
+			}
+		}
+		if !covered {
+			return false
+		}
+	}
+	return true
+}
+
+// isPrefixIndexColumn checks whether only a prefix of the column value is indexed.
+func isPrefixIndexColumn(ic *model.IndexColumn, col *model.ColumnInfo) bool {
+	if ic.Length == types.UnspecifiedLength {
+		return false
+	}
+	return col.Flen == types.UnspecifiedLength || ic.Length < col.Flen
+}
+
+// statisticsTable gets the statistics of the table from the domain, it returns nil if it's not available.
+func (b *planBuilder) statisticsTable(tblInfo *model.TableInfo) *statistics.Table {
+	if b.ctx == nil {
+		return nil
+	}
+	do := sessionctx.GetDomain(b.ctx)
+	if do == nil {
+		return nil
+	}
+	return do.StatsHandle().GetTableStats(tblInfo)
+}
+
 // buildPseudoSelectPlan pre-builds more complete plans that may affect total cost.
 // Also set OutOfOrder and NoLimit property.
 func (b *planBuilder) buildPseudoSelectPlan(p Plan, sel *ast.SelectStmt) Plan {
@@ -654,6 +719,12 @@ func (b *planBuilder) buildAdmin(as *ast.AdminStmt) Plan {
 	case ast.AdminShowDDL:
 		p = &ShowDDL{}
 		p.SetFields(buildShowDDLFields())
+	case ast.AdminShowDDLJobs:
+		p = &ShowDDLJobs{}
+		p.SetFields(buildShowDDLJobsFields())
+	case ast.AdminCancelDDLJobs:
+		p = &CancelDDLJobs{JobIDs: as.JobIDs}
+		p.SetFields(buildCancelDDLJobsFields())
 	default:
 		b.err = ErrUnsupportedType.Gen("Unsupported type %T", as)
 	}
@@ -673,6 +744,22 @@ func buildShowDDLFields() []*ast.ResultField {
 	return rfs
 }
 
+func buildShowDDLJobsFields() []*ast.ResultField {
+	rfs := make([]*ast.ResultField, 0, 2)
+	rfs = append(rfs, buildResultField("", "JOBS", mysql.TypeVarchar, 128))
+	rfs = append(rfs, buildResultField("", "STATE", mysql.TypeVarchar, 64))
+
+	return rfs
+}
+
+func buildCancelDDLJobsFields() []*ast.ResultField {
+	rfs := make([]*ast.ResultField, 0, 2)
+	rfs = append(rfs, buildResultField("", "JOB_ID", mysql.TypeVarchar, 64))
+	rfs = append(rfs, buildResultField("", "RESULT", mysql.TypeVarchar, 128))
+
+	return rfs
+}
+
 func buildResultField(tableName, name string, tp byte, size int) *ast.ResultField {
 	cs := charset.CharsetBin
 	cl := charset.CharsetBin
@@ -850,6 +937,10 @@ func (se *subqueryVisitor) Leave(in ast.Node) (out ast.Node, ok bool) {
 }
 
 func (b *planBuilder) buildUnion(union *ast.UnionStmt) Plan {
+	if union.With != nil {
+		b.err = ErrUnsupportedType.Gen("WITH clause is only supported by the new planner")
+		return nil
+	}
 	sels := make([]Plan, len(union.SelectList.Selects))
 	for i, sel := range union.SelectList.Selects {
 		sels[i] = b.buildSelect(sel)
@@ -1092,12 +1183,24 @@ func (b *planBuilder) buildExplain(explain *ast.ExplainStmt) Plan {
 	if b.err != nil {
 		return nil
 	}
-	p := &Explain{StmtPlan: targetPlan}
+	p := &Explain{StmtPlan: targetPlan, Analyze: explain.Analyze}
 	addChild(p, targetPlan)
-	p.SetFields(buildExplainFields())
+	if explain.Analyze {
+		p.SetFields(buildExplainAnalyzeFields())
+	} else {
+		p.SetFields(buildExplainFields())
+	}
 	return p
 }
 
+func buildExplainAnalyzeFields() []*ast.ResultField {
+	rfs := make([]*ast.ResultField, 0, 3)
+	rfs = append(rfs, buildResultField("", "id", mysql.TypeLonglong, 4))
+	rfs = append(rfs, buildResultField("", "operator", mysql.TypeVarchar, 128))
+	rfs = append(rfs, buildResultField("", "spill_count", mysql.TypeLonglong, 4))
+	return rfs
+}
+
 // See: https://dev.mysql.com/doc/refman/5.7/en/explain-output.html
 func buildExplainFields() []*ast.ResultField {
 	rfs := make([]*ast.ResultField, 0, 10)
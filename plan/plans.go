@@ -15,8 +15,10 @@ package plan
 
 import (
 	"fmt"
+
 	"github.com/pingcap/tidb/ast"
 	"github.com/pingcap/tidb/model"
+	"github.com/pingcap/tidb/plan/statistics"
 	"github.com/pingcap/tidb/util/types"
 )
 
@@ -59,6 +61,9 @@ type TableScan struct {
 	TableAsName *model.CIStr
 
 	LimitCount *int64
+
+	// statsTbl is the statistics of the table, it is nil if the table has not been analyzed.
+	statsTbl *statistics.Table
 }
 
 // ShowDDL is for showing DDL information.
@@ -66,6 +71,18 @@ type ShowDDL struct {
 	basePlan
 }
 
+// ShowDDLJobs is for showing DDL job list.
+type ShowDDLJobs struct {
+	basePlan
+}
+
+// CancelDDLJobs represents a cancel DDL jobs plan.
+type CancelDDLJobs struct {
+	basePlan
+
+	JobIDs []int64
+}
+
 // CheckTable is for checking table data.
 type CheckTable struct {
 	basePlan
@@ -140,6 +157,10 @@ type IndexScan struct {
 	// NoLimit indicates that this plan need fetch all the rows.
 	NoLimit bool
 
+	// IndexOnly indicates that all the referenced columns are covered by the index,
+	// so the values are read from the index without looking up the table rows.
+	IndexOnly bool
+
 	// TableName is used to distinguish the same table selected multiple times in different place,
 	// like 'select * from t where exists(select 1 from t as x where t.c < x.c)'
 	TableName *ast.TableName
@@ -147,6 +168,9 @@ type IndexScan struct {
 	TableAsName *model.CIStr
 
 	LimitCount *int64
+
+	// statsTbl is the statistics of the table, it is nil if the table has not been analyzed.
+	statsTbl *statistics.Table
 }
 
 // JoinOuter represents outer join plan.
@@ -388,4 +412,5 @@ type Explain struct {
 	basePlan
 
 	StmtPlan Plan
+	Analyze  bool
 }
@@ -105,6 +105,9 @@ func (b *planBuilder) predicatePushDown(p Plan, predicates []expression.Expressi
 			}
 		}
 		if v.JoinType == InnerJoin {
+			// The left and right conditions have been pushed down to the children.
+			v.LeftConditions = nil
+			v.RightConditions = nil
 			v.EqualConditions = append(v.EqualConditions, equalCond...)
 			v.OtherConditions = append(v.OtherConditions, otherCond...)
 		}
@@ -153,6 +156,48 @@ func (b *planBuilder) predicatePushDown(p Plan, predicates []expression.Expressi
 			}
 		}
 		return
+	case *TopN:
+		// The predicates can't be pushed through the top-n plan, but the ones below it can be pushed down.
+		rest, err1 := b.predicatePushDown(p.GetChildByIndex(0), nil)
+		if err1 != nil {
+			return nil, errors.Trace(err1)
+		}
+		if len(rest) > 0 {
+			err1 = b.addSelection(p, p.GetChildByIndex(0), rest)
+			if err1 != nil {
+				return nil, errors.Trace(err1)
+			}
+		}
+		return predicates, nil
+	case *Window:
+		// The predicates can't be pushed through the window plan, because they change the rows in the windows.
+		rest, err1 := b.predicatePushDown(p.GetChildByIndex(0), nil)
+		if err1 != nil {
+			return nil, errors.Trace(err1)
+		}
+		if len(rest) > 0 {
+			err1 = b.addSelection(p, p.GetChildByIndex(0), rest)
+			if err1 != nil {
+				return nil, errors.Trace(err1)
+			}
+		}
+		return predicates, nil
+	case *RecursiveCTE:
+		// The predicates can't be pushed into the recursive common table expression, because they change the rows
+		// read by the next iteration.
+		for _, child := range p.GetChildren() {
+			rest, err1 := b.predicatePushDown(child, nil)
+			if err1 != nil {
+				return nil, errors.Trace(err1)
+			}
+			if len(rest) > 0 {
+				err1 = b.addSelection(p, child, rest)
+				if err1 != nil {
+					return nil, errors.Trace(err1)
+				}
+			}
+		}
+		return predicates, nil
 	case *NewUnion:
 		for _, proj := range v.Selects {
 			newExprs := make([]expression.Expression, 0, len(predicates))
@@ -170,7 +215,7 @@ func (b *planBuilder) predicatePushDown(p Plan, predicates []expression.Expressi
 		}
 		return
 	//TODO: support aggregation, apply.
-	case *Aggregation, *Simple, *Apply:
+	case *Aggregation, *Simple, *Apply, *CTETable:
 		return predicates, nil
 	default:
 		log.Warnf("Unknown Type %T in Predicate Pushdown", v)
@@ -25,7 +25,7 @@ import (
 	"github.com/pingcap/tidb/util/types"
 )
 
-// Refine tries to build index or table range.
+// Refine tries to build index or table range, and checks whether the index scan is covered by the index.
 func Refine(p Plan) error {
 	return refine(p)
 }
@@ -42,6 +42,8 @@ func refine(in Plan) error {
 	switch x := in.(type) {
 	case *IndexScan:
 		err = buildIndexRange(x)
+		// The referenced columns are known after the whole plan is built.
+		x.IndexOnly = isCoveringIndex(x.Fields(), x.Index, x.Table.PKIsHandle)
 	case *Limit:
 		x.SetLimit(0)
 	case *TableScan:
@@ -107,6 +107,11 @@ type resolverContext struct {
 	inCreateOrDropTable bool
 	// When visiting show statement.
 	inShow bool
+
+	// ctes are the common table expressions defined in the with clause of the select statement.
+	ctes []*ast.CommonTableExpression
+	// When visiting the with clause, a common table expression can be referred in its own query if it is recursive.
+	inRecursiveWith bool
 }
 
 // currentContext gets the current resolverContext.
@@ -155,6 +160,11 @@ func (nr *nameResolver) Enter(inNode ast.Node) (outNode ast.Node, skipChildren b
 		}
 	case *ast.AlterTableStmt:
 		nr.pushContext()
+	case *ast.AlterTableSpec:
+		if v.Tp == ast.AlterTableRenameTable {
+			// The new table name does not exist yet.
+			nr.currentContext().inCreateOrDropTable = true
+		}
 	case *ast.AnalyzeTableStmt:
 		nr.pushContext()
 	case *ast.ByItem:
@@ -170,6 +180,11 @@ func (nr *nameResolver) Enter(inNode ast.Node) (outNode ast.Node, skipChildren b
 				return inNode, true
 			}
 		}
+	case *ast.CommonTableExpression:
+		ctx := nr.currentContext()
+		if ctx.inRecursiveWith {
+			ctx.ctes = append(ctx.ctes, v)
+		}
 	case *ast.CreateIndexStmt:
 		nr.pushContext()
 	case *ast.CreateTableStmt:
@@ -200,6 +215,11 @@ func (nr *nameResolver) Enter(inNode ast.Node) (outNode ast.Node, skipChildren b
 		nr.currentContext().inOnCondition = true
 	case *ast.OrderByClause:
 		nr.currentContext().inOrderBy = true
+	case *ast.RenameTableStmt:
+		nr.pushContext()
+		// The old table may be renamed by a previous pair in the same statement,
+		// so the tables are resolved when the statement is executed.
+		nr.currentContext().inCreateOrDropTable = true
 	case *ast.SelectStmt:
 		nr.pushContext()
 	case *ast.SetStmt:
@@ -222,6 +242,8 @@ func (nr *nameResolver) Enter(inNode ast.Node) (outNode ast.Node, skipChildren b
 		nr.pushContext()
 	case *ast.UpdateStmt:
 		nr.pushContext()
+	case *ast.WithClause:
+		nr.currentContext().inRecursiveWith = v.IsRecursive
 	}
 	return inNode, false
 }
@@ -238,12 +260,16 @@ func (nr *nameResolver) Leave(inNode ast.Node) (node ast.Node, ok bool) {
 		}
 	case *ast.AlterTableStmt:
 		nr.popContext()
+	case *ast.AlterTableSpec:
+		nr.currentContext().inCreateOrDropTable = false
 	case *ast.AnalyzeTableStmt:
 		nr.popContext()
 	case *ast.TableName:
 		nr.handleTableName(v)
 	case *ast.ColumnNameExpr:
 		nr.handleColumnName(v)
+	case *ast.CommonTableExpression:
+		nr.handleCommonTableExpression(v)
 	case *ast.CreateIndexStmt:
 		nr.popContext()
 	case *ast.CreateTableStmt:
@@ -285,6 +311,8 @@ func (nr *nameResolver) Leave(inNode ast.Node) (node ast.Node, ok bool) {
 		nr.currentContext().inByItemExpression = false
 	case *ast.PositionExpr:
 		nr.handlePosition(v)
+	case *ast.RenameTableStmt:
+		nr.popContext()
 	case *ast.SelectStmt:
 		ctx := nr.currentContext()
 		v.SetResultFields(ctx.fieldList)
@@ -320,12 +348,16 @@ func (nr *nameResolver) Leave(inNode ast.Node) (node ast.Node, ok bool) {
 		nr.popContext()
 	case *ast.UpdateStmt:
 		nr.popContext()
+	case *ast.WithClause:
+		nr.currentContext().inRecursiveWith = false
 	}
 	return inNode, nr.Err == nil
 }
 
 // handleTableName looks up and sets the schema information and result fields for table name.
 func (nr *nameResolver) handleTableName(tn *ast.TableName) {
+	// Only a table name without schema can refer to a common table expression.
+	maybeCTE := tn.Schema.L == ""
 	if tn.Schema.L == "" {
 		tn.Schema = nr.DefaultSchema
 	}
@@ -348,6 +380,12 @@ func (nr *nameResolver) handleTableName(tn *ast.TableName) {
 		tn.SetResultFields(tableName.GetResultFields())
 		return
 	}
+	if maybeCTE {
+		if cte := nr.findCTE(tn.Name); cte != nil {
+			nr.handleCTEName(tn, cte)
+			return
+		}
+	}
 	table, err := nr.Info.TableByName(tn.Schema, tn.Name)
 	if err != nil {
 		nr.Err = errors.Trace(err)
@@ -377,6 +415,99 @@ func (nr *nameResolver) handleTableName(tn *ast.TableName) {
 	return
 }
 
+// findCTE looks up the common table expression of the name from the inner most select statement to the outer most.
+func (nr *nameResolver) findCTE(name model.CIStr) *ast.CommonTableExpression {
+	for i := len(nr.contextStack) - 1; i >= 0; i-- {
+		ctes := nr.contextStack[i].ctes
+		for j := len(ctes) - 1; j >= 0; j-- {
+			if ctes[j].Name.L == name.L {
+				return ctes[j]
+			}
+		}
+	}
+	return nil
+}
+
+// handleCTEName sets the result fields of the table name which refers to a common table expression.
+// If the table name is in the query of the common table expression, the common table expression is recursive,
+// its query must be a union whose first select statement doesn't refer to itself, and the columns
+// are defined by the first select statement.
+func (nr *nameResolver) handleCTEName(tn *ast.TableName, cte *ast.CommonTableExpression) {
+	if cte.TableInfo == nil {
+		union, ok := cte.Query.(*ast.UnionStmt)
+		if !ok {
+			nr.Err = errors.Errorf("Recursive Common Table Expression '%s' should contain a UNION", cte.Name.O)
+			return
+		}
+		rfs := union.SelectList.Selects[0].GetResultFields()
+		if len(rfs) == 0 {
+			nr.Err = errors.Errorf("Recursive Common Table Expression '%s' should have one or more non-recursive query blocks followed by one or more recursive ones", cte.Name.O)
+			return
+		}
+		nr.setCTETableInfo(cte, rfs)
+		if nr.Err != nil {
+			return
+		}
+		cte.IsRecursive = true
+	}
+	tn.CTE = cte
+	tn.TableInfo = cte.TableInfo
+	rfs := make([]*ast.ResultField, 0, len(cte.TableInfo.Columns))
+	for _, col := range cte.TableInfo.Columns {
+		expr := &ast.ValueExpr{}
+		expr.SetType(&col.FieldType)
+		rfs = append(rfs, &ast.ResultField{
+			Column:    col,
+			Table:     cte.TableInfo,
+			Expr:      expr,
+			TableName: tn,
+		})
+	}
+	tn.SetResultFields(rfs)
+}
+
+// handleCommonTableExpression sets the columns of the common table expression,
+// and makes it available for the following common table expressions and the select statement.
+func (nr *nameResolver) handleCommonTableExpression(cte *ast.CommonTableExpression) {
+	if cte.TableInfo == nil {
+		nr.setCTETableInfo(cte, cte.Query.GetResultFields())
+	}
+	ctx := nr.currentContext()
+	if !ctx.inRecursiveWith {
+		ctx.ctes = append(ctx.ctes, cte)
+	}
+}
+
+// setCTETableInfo sets the table info of the common table expression by the result fields of its query.
+// The types of the columns are set by the type inferer.
+func (nr *nameResolver) setCTETableInfo(cte *ast.CommonTableExpression, rfs []*ast.ResultField) {
+	if len(cte.ColNames) > 0 && len(cte.ColNames) != len(rfs) {
+		nr.Err = errors.New("In definition of view, derived table or common table expression, SELECT list and column names list have different column counts")
+		return
+	}
+	info := &model.TableInfo{Name: cte.Name, State: model.StatePublic}
+	dupNames := make(map[string]struct{}, len(rfs))
+	for i, rf := range rfs {
+		name := rf.ColumnAsName
+		if len(cte.ColNames) > 0 {
+			name = cte.ColNames[i]
+		} else if name.L == "" {
+			name = rf.Column.Name
+		}
+		if _, ok := dupNames[name.L]; ok {
+			nr.Err = errors.Errorf("Duplicate column name '%s'", name.O)
+			return
+		}
+		dupNames[name.L] = struct{}{}
+		info.Columns = append(info.Columns, &model.ColumnInfo{
+			Name:   name,
+			Offset: i,
+			State:  model.StatePublic,
+		})
+	}
+	cte.TableInfo = info
+}
+
 // handleTableSources checks name duplication
 // and puts the table source in current resolverContext.
 // Note:
@@ -909,6 +1040,14 @@ func (nr *nameResolver) fillShowFields(s *ast.ShowStmt) {
 		ftypes = []byte{mysql.TypeVarchar, mysql.TypeLonglong, mysql.TypeVarchar, mysql.TypeLonglong,
 			mysql.TypeVarchar, mysql.TypeVarchar, mysql.TypeLonglong, mysql.TypeLonglong,
 			mysql.TypeVarchar, mysql.TypeVarchar, mysql.TypeVarchar, mysql.TypeVarchar, mysql.TypeVarchar}
+	case ast.ShowStatsMeta:
+		names = []string{"Db_name", "Table_name", "Update_time", "Row_count"}
+		ftypes = []byte{mysql.TypeVarchar, mysql.TypeVarchar, mysql.TypeDatetime, mysql.TypeLonglong}
+	case ast.ShowStatsHistograms:
+		names = []string{"Db_name", "Table_name", "Column_name", "Is_index", "Distinct_count",
+			"Bucket_id", "Count", "Repeats", "Upper_bound"}
+		ftypes = []byte{mysql.TypeVarchar, mysql.TypeVarchar, mysql.TypeVarchar, mysql.TypeLonglong, mysql.TypeLonglong,
+			mysql.TypeLonglong, mysql.TypeLonglong, mysql.TypeLonglong, mysql.TypeVarchar}
 	}
 	for i, name := range names {
 		f := &ast.ResultField{
@@ -72,6 +72,23 @@ var resolverTestCases = []resolverTestCase{
 	{"select c1 from t1 group by c1 having c1 = 3", true},
 	{"select c1 from t1 group by c1 having c2 = 3", false},
 	{"select c1 from t1 where exists (select c2)", true},
+	{"with cte as (select c1 from t1) select c1 from cte", true},
+	{"with cte as (select c1 from t1) select c2 from cte", false},
+	{"with cte (a, b) as (select * from t1) select cte.a, b from cte", true},
+	{"with cte (a, b) as (select c1 from t1) select a from cte", false},
+	{"with cte (a, a) as (select * from t1) select a from cte", false},
+	{"with c1 as (select c1 from t1), c2 as (select c1 from c1) select c1.c1 from c1 join c2 on c1.c1 = c2.c1", true},
+	{"with c1 as (select c1 from c2), c2 as (select c1 from t1) select c1 from c1", false},
+	{"with cte as (select c1 from t1) select c1 from t1 where exists (select * from cte where cte.c1 = t1.c2)", true},
+	{"with t1 as (select 1 as a) select a from t1", true},
+	{"with cte as (select 1 as a) select a from test.cte", false},
+	{"with recursive cte (n) as (select 1 union all select n + 1 from cte where n < 10) select n from cte", true},
+	{"with recursive cte (n) as (select n from cte union all select 1) select n from cte", false},
+	{"with recursive cte (n) as (select n + 1 from cte) select n from cte", false},
+	{"with cte (n) as (select 1 union all select n + 1 from cte where n < 10) select n from cte", false},
+	{"with cte as (select c1 from t1) select c1 from cte union select c2 from t1", true},
+	{"select c1 from (with cte as (select c1 from t1) select c1 from cte) t", true},
+	{"select c1 from (with cte as (select c1 from t1) select c1 from cte) t where exists (select * from cte)", false},
 }
 
 func (ts *testNameResolverSuite) TestNameResolver(c *C) {
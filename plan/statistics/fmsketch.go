@@ -0,0 +1,116 @@
+// Copyright 2016 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package statistics
+
+import (
+	"hash/fnv"
+
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/util/codec"
+	"github.com/pingcap/tidb/util/types"
+)
+
+// FMSketch is used to count the number of distinct values of a column.
+// It only keeps the hash values whose lowest bits masked by mask are all zero, when the
+// number of kept hash values exceeds maxSize, the mask is extended and half of the values are dropped.
+// Sketches built on different regions can be merged, so we don't need to collect all the rows.
+// See https://en.wikipedia.org/wiki/Flajolet%E2%80%93Martin_algorithm
+type FMSketch struct {
+	hashset map[uint64]bool
+	mask    uint64
+	maxSize int
+}
+
+// NewFMSketch returns a new FM sketch.
+func NewFMSketch(maxSize int) *FMSketch {
+	return &FMSketch{
+		hashset: make(map[uint64]bool),
+		maxSize: maxSize,
+	}
+}
+
+// NDV returns the estimated number of distinct values.
+func (s *FMSketch) NDV() int64 {
+	return int64(s.mask+1) * int64(len(s.hashset))
+}
+
+func (s *FMSketch) insertHashValue(hashVal uint64) {
+	if hashVal&s.mask != 0 {
+		return
+	}
+	s.hashset[hashVal] = true
+	if len(s.hashset) > s.maxSize {
+		s.mask = s.mask*2 + 1
+		for key := range s.hashset {
+			if key&s.mask != 0 {
+				delete(s.hashset, key)
+			}
+		}
+	}
+}
+
+// InsertValue inserts a value into the FM sketch.
+func (s *FMSketch) InsertValue(value types.Datum) error {
+	bytes, err := codec.EncodeValue(nil, value)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	h := fnv.New64a()
+	h.Write(bytes)
+	s.insertHashValue(h.Sum64())
+	return nil
+}
+
+// MergeFMSketch merges another FM sketch into s.
+func (s *FMSketch) MergeFMSketch(rs *FMSketch) {
+	if s.mask < rs.mask {
+		s.mask = rs.mask
+		for key := range s.hashset {
+			if key&s.mask != 0 {
+				delete(s.hashset, key)
+			}
+		}
+	}
+	for key := range rs.hashset {
+		s.insertHashValue(key)
+	}
+}
+
+// Encode encodes the FM sketch to bytes.
+func (s *FMSketch) Encode() ([]byte, error) {
+	datums := make([]types.Datum, 0, len(s.hashset)+2)
+	datums = append(datums, types.NewIntDatum(int64(s.maxSize)), types.NewUintDatum(s.mask))
+	for key := range s.hashset {
+		datums = append(datums, types.NewUintDatum(key))
+	}
+	data, err := codec.EncodeValue(nil, datums...)
+	return data, errors.Trace(err)
+}
+
+// DecodeFMSketch decodes a FM sketch from bytes.
+func DecodeFMSketch(data []byte) (*FMSketch, error) {
+	datums, err := codec.Decode(data)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if len(datums) < 2 {
+		return nil, errors.Errorf("invalid FM sketch data")
+	}
+	s := NewFMSketch(int(datums[0].GetInt64()))
+	s.mask = datums[1].GetUint64()
+	for _, d := range datums[2:] {
+		s.hashset[d.GetUint64()] = true
+	}
+	return s, nil
+}
@@ -0,0 +1,125 @@
+// Copyright 2016 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package statistics
+
+import (
+	"sync"
+
+	"github.com/pingcap/tidb/model"
+)
+
+// Handle caches the statistics of tables, it is shared by all the sessions in a domain.
+type Handle struct {
+	mu     sync.RWMutex
+	tables map[int64]*Table
+	// modifyCounts saves the count of rows modified since the statistics of a table were built.
+	modifyCounts map[int64]int64
+	// version is the statistics version in storage when the statistics were loaded last time.
+	version int64
+}
+
+// NewHandle creates a Handle.
+func NewHandle() *Handle {
+	return &Handle{
+		tables:       make(map[int64]*Table),
+		modifyCounts: make(map[int64]int64),
+	}
+}
+
+// GetTableStats returns the statistics of the table.
+// It returns nil if the table has not been analyzed, or its columns have changed since then.
+func (h *Handle) GetTableStats(tblInfo *model.TableInfo) *Table {
+	h.mu.RLock()
+	t := h.tables[tblInfo.ID]
+	h.mu.RUnlock()
+	if t == nil || len(t.Columns) != len(tblInfo.Columns) {
+		return nil
+	}
+	for i, col := range tblInfo.Columns {
+		if t.Columns[i].ID != col.ID {
+			return nil
+		}
+	}
+	return t
+}
+
+// SetTableStats sets the statistics of a table.
+func (h *Handle) SetTableStats(t *Table) {
+	h.mu.Lock()
+	h.tables[t.info.ID] = t
+	delete(h.modifyCounts, t.info.ID)
+	h.mu.Unlock()
+}
+
+// Update caches the statistics loaded from storage at the statistics version.
+func (h *Handle) Update(tables []*Table, version int64) {
+	h.mu.Lock()
+	for _, t := range tables {
+		h.tables[t.info.ID] = t
+	}
+	h.version = version
+	h.mu.Unlock()
+}
+
+// Version returns the statistics version in storage when the statistics were loaded last time.
+func (h *Handle) Version() int64 {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.version
+}
+
+// UpdateModifyCount adds the counts of rows modified by a committed transaction.
+func (h *Handle) UpdateModifyCount(deltas map[int64]int64) {
+	h.mu.Lock()
+	for id, delta := range deltas {
+		h.modifyCounts[id] += delta
+	}
+	h.mu.Unlock()
+}
+
+// ModifyCount returns the count of rows modified since the statistics of the table were built.
+func (h *Handle) ModifyCount(tableID int64) int64 {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.modifyCounts[tableID]
+}
+
+// PopOutdatedTables returns the IDs of the analyzed tables whose modified row count exceeds
+// ratio of their row count, and the IDs of the tables without statistics whose modified row
+// count reaches minCount. The modify counts of the returned tables are reset so a table
+// is returned only once before it is analyzed again.
+func (h *Handle) PopOutdatedTables(ratio float64, minCount int64) []int64 {
+	if ratio <= 0 {
+		return nil
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	var ids []int64
+	for id, count := range h.modifyCounts {
+		t, ok := h.tables[id]
+		if !ok {
+			// The table has never been analyzed, so the modify count is the only hint of its size.
+			if count >= minCount {
+				ids = append(ids, id)
+				delete(h.modifyCounts, id)
+			}
+			continue
+		}
+		if float64(count) > ratio*float64(t.Count) {
+			ids = append(ids, id)
+			delete(h.modifyCounts, id)
+		}
+	}
+	return ids
+}
@@ -0,0 +1,170 @@
+// Copyright 2016 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package statistics
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"time"
+
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/util/codec"
+	"github.com/pingcap/tidb/util/types"
+	"github.com/pingcap/tipb/go-tipb"
+)
+
+// MaxSketchSize is the max size of the FM sketch of a column.
+const MaxSketchSize = 10000
+
+// SampleCollector collects a sample of the rows and the FM sketches of the columns.
+// It is built on each region by the coprocessor, and merged in tidb.
+type SampleCollector struct {
+	// Count is the number of rows the samples are taken from.
+	Count int64
+	// Samples are the sampled rows, the data is encoded by codec.EncodeValue.
+	Samples  []*tipb.Row
+	Sketches []*FMSketch
+
+	maxSampleSize int64
+	rand          *rand.Rand
+}
+
+// NewSampleCollector creates a SampleCollector.
+func NewSampleCollector(maxSampleSize int64, columnCount int) *SampleCollector {
+	c := &SampleCollector{
+		Sketches:      make([]*FMSketch, columnCount),
+		maxSampleSize: maxSampleSize,
+		rand:          rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+	for i := range c.Sketches {
+		c.Sketches[i] = NewFMSketch(MaxSketchSize)
+	}
+	return c
+}
+
+// Collect adds a row into the collector, using Reservoir Sampling algorithm.
+// See https://en.wikipedia.org/wiki/Reservoir_sampling
+func (c *SampleCollector) Collect(row *tipb.Row) error {
+	values, err := codec.Decode(row.Data)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if len(values) != len(c.Sketches) {
+		return errors.Errorf("column count not match, expected %d, got %d", len(c.Sketches), len(values))
+	}
+	for i, val := range values {
+		err = c.Sketches[i].InsertValue(val)
+		if err != nil {
+			return errors.Trace(err)
+		}
+	}
+	c.Count++
+	if int64(len(c.Samples)) < c.maxSampleSize {
+		c.Samples = append(c.Samples, row)
+	} else if c.rand.Int63n(c.Count) < c.maxSampleSize {
+		c.Samples[c.rand.Int63n(c.maxSampleSize)] = row
+	}
+	return nil
+}
+
+// ToRows converts the collector to rows which can be returned in a coprocessor response.
+// Data layout:
+//
+//	The first row:	count, sketch1, sketch2, ...
+//	Other rows:	the sampled rows.
+func (c *SampleCollector) ToRows() ([]*tipb.Row, error) {
+	header := make([]types.Datum, 0, len(c.Sketches)+1)
+	header = append(header, types.NewIntDatum(c.Count))
+	for _, s := range c.Sketches {
+		data, err := s.Encode()
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		header = append(header, types.NewBytesDatum(data))
+	}
+	data, err := codec.EncodeValue(nil, header...)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	rows := make([]*tipb.Row, 0, len(c.Samples)+1)
+	rows = append(rows, &tipb.Row{Data: data})
+	return append(rows, c.Samples...), nil
+}
+
+// SampleCollectorFromRows creates a SampleCollector from the rows of a coprocessor response.
+func SampleCollectorFromRows(rows []*tipb.Row) (*SampleCollector, error) {
+	if len(rows) == 0 {
+		return nil, errors.Errorf("invalid sample collector rows")
+	}
+	header, err := codec.Decode(rows[0].Data)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	c := &SampleCollector{
+		Count:    header[0].GetInt64(),
+		Samples:  rows[1:],
+		Sketches: make([]*FMSketch, len(header)-1),
+	}
+	for i, d := range header[1:] {
+		c.Sketches[i], err = DecodeFMSketch(d.GetBytes())
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+	return c, nil
+}
+
+type weightedSample struct {
+	row *tipb.Row
+	key float64
+}
+
+type weightedSamples []weightedSample
+
+func (s weightedSamples) Len() int           { return len(s) }
+func (s weightedSamples) Less(i, j int) bool { return s[i].key > s[j].key }
+func (s weightedSamples) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+
+// MergeSampleCollectors merges the collectors built on different regions.
+// A sample of a region represents count/len(samples) rows, so it is weighted by that, and the merged
+// samples are chosen by the weighted random sampling algorithm A-Res.
+// See https://en.wikipedia.org/wiki/Reservoir_sampling#Weighted_random_sampling
+func MergeSampleCollectors(collectors []*SampleCollector, maxSampleSize int64, columnCount int) *SampleCollector {
+	merged := NewSampleCollector(maxSampleSize, columnCount)
+	var samples weightedSamples
+	for _, c := range collectors {
+		merged.Count += c.Count
+		for i, s := range c.Sketches {
+			merged.Sketches[i].MergeFMSketch(s)
+		}
+		if len(c.Samples) == 0 {
+			continue
+		}
+		weight := float64(c.Count) / float64(len(c.Samples))
+		for _, row := range c.Samples {
+			key := math.Pow(merged.rand.Float64(), 1/weight)
+			samples = append(samples, weightedSample{row: row, key: key})
+		}
+	}
+	sort.Sort(samples)
+	if int64(len(samples)) > maxSampleSize {
+		samples = samples[:maxSampleSize]
+	}
+	merged.Samples = make([]*tipb.Row, 0, len(samples))
+	for _, s := range samples {
+		merged.Samples = append(merged.Samples, s.row)
+	}
+	return merged
+}
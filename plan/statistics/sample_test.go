@@ -0,0 +1,87 @@
+// Copyright 2016 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package statistics
+
+import (
+	. "github.com/pingcap/check"
+	"github.com/pingcap/tidb/util/codec"
+	"github.com/pingcap/tidb/util/types"
+	"github.com/pingcap/tipb/go-tipb"
+)
+
+var _ = Suite(&testSampleSuite{})
+
+type testSampleSuite struct {
+}
+
+func (s *testSampleSuite) TestFMSketch(c *C) {
+	sketch := NewFMSketch(1000)
+	for i := 0; i < 100000; i++ {
+		err := sketch.InsertValue(types.NewIntDatum(int64(i % 50000)))
+		c.Assert(err, IsNil)
+	}
+	ndv := sketch.NDV()
+	c.Check(ndv > 40000 && ndv < 60000, IsTrue, Commentf("ndv %d", ndv))
+
+	// Merging sketches of the same values doesn't change the NDV.
+	other := NewFMSketch(1000)
+	for i := 0; i < 50000; i++ {
+		err := other.InsertValue(types.NewIntDatum(int64(i)))
+		c.Assert(err, IsNil)
+	}
+	sketch.MergeFMSketch(other)
+	c.Check(sketch.NDV(), Equals, ndv)
+
+	data, err := sketch.Encode()
+	c.Assert(err, IsNil)
+	decoded, err := DecodeFMSketch(data)
+	c.Assert(err, IsNil)
+	c.Check(decoded.NDV(), Equals, ndv)
+	c.Check(decoded.maxSize, Equals, sketch.maxSize)
+}
+
+func (s *testSampleSuite) TestSampleCollector(c *C) {
+	var collectors []*SampleCollector
+	for region := 0; region < 3; region++ {
+		collector := NewSampleCollector(100, 2)
+		for i := 0; i < 1000; i++ {
+			data, err := codec.EncodeValue(nil, types.NewIntDatum(int64(region*1000+i)), types.NewIntDatum(int64(i%10)))
+			c.Assert(err, IsNil)
+			err = collector.Collect(&tipb.Row{Data: data})
+			c.Assert(err, IsNil)
+		}
+		c.Check(collector.Count, Equals, int64(1000))
+		c.Check(collector.Samples, HasLen, 100)
+
+		rows, err := collector.ToRows()
+		c.Assert(err, IsNil)
+		c.Check(rows, HasLen, 101)
+		collector, err = SampleCollectorFromRows(rows)
+		c.Assert(err, IsNil)
+		c.Check(collector.Count, Equals, int64(1000))
+		c.Check(collector.Samples, HasLen, 100)
+		collectors = append(collectors, collector)
+	}
+	merged := MergeSampleCollectors(collectors, 150, 2)
+	c.Check(merged.Count, Equals, int64(3000))
+	c.Check(merged.Samples, HasLen, 150)
+	c.Check(merged.Sketches[0].NDV(), Equals, int64(3000))
+	c.Check(merged.Sketches[1].NDV(), Equals, int64(10))
+
+	// A row with wrong column count is rejected.
+	data, err := codec.EncodeValue(nil, types.NewIntDatum(1))
+	c.Assert(err, IsNil)
+	err = merged.Collect(&tipb.Row{Data: data})
+	c.Check(err, NotNil)
+}
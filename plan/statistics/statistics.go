@@ -15,11 +15,14 @@ package statistics
 
 import (
 	"fmt"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/golang/protobuf/proto"
 	"github.com/juju/errors"
 	"github.com/pingcap/tidb/model"
+	"github.com/pingcap/tidb/parser/opcode"
 	"github.com/pingcap/tidb/tablecodec"
 	"github.com/pingcap/tidb/util/codec"
 	"github.com/pingcap/tidb/util/types"
@@ -57,25 +60,299 @@ func (c *Column) String() string {
 	return strings.Join(strs, "\n")
 }
 
+// ValueToString returns the string of the ith bucket value.
+func (c *Column) ValueToString(i int) (string, error) {
+	str, err := c.Values[i].ToString()
+	return str, errors.Trace(err)
+}
+
+// EqualRowCount estimates the row count where the column equals to value.
+func (c *Column) EqualRowCount(value types.Datum) (int64, error) {
+	if len(c.Numbers) == 0 {
+		return 0, nil
+	}
+	index, match, err := c.search(value)
+	if err != nil {
+		return 0, errors.Trace(err)
+	}
+	if index == len(c.Numbers) {
+		return 0, nil
+	}
+	if match {
+		return c.Repeats[index], nil
+	}
+	if c.NDV == 0 {
+		return 0, nil
+	}
+	return c.totalRowCount() / c.NDV, nil
+}
+
+// GreaterRowCount estimates the row count where the column greater than value.
+func (c *Column) GreaterRowCount(value types.Datum) (int64, error) {
+	lessCount, err := c.LessRowCount(value)
+	if err != nil {
+		return 0, errors.Trace(err)
+	}
+	eqCount, err := c.EqualRowCount(value)
+	if err != nil {
+		return 0, errors.Trace(err)
+	}
+	gtCount := c.totalRowCount() - lessCount - eqCount
+	if gtCount < 0 {
+		gtCount = 0
+	}
+	return gtCount, nil
+}
+
+// LessRowCount estimates the row count where the column less than value.
+func (c *Column) LessRowCount(value types.Datum) (int64, error) {
+	if len(c.Numbers) == 0 {
+		return 0, nil
+	}
+	index, match, err := c.search(value)
+	if err != nil {
+		return 0, errors.Trace(err)
+	}
+	if index == len(c.Numbers) {
+		return c.totalRowCount(), nil
+	}
+	curCount := c.Numbers[index]
+	prevCount := int64(0)
+	if index > 0 {
+		prevCount = c.Numbers[index-1]
+	}
+	if match {
+		return curCount - c.Repeats[index], nil
+	}
+	// We don't know where the value is located in the bucket, assume it is in the middle.
+	return (prevCount + curCount) / 2, nil
+}
+
+// BetweenRowCount estimates the row count where column greater or equal to a and less than b.
+func (c *Column) BetweenRowCount(a, b types.Datum) (int64, error) {
+	lessCountA, err := c.LessRowCount(a)
+	if err != nil {
+		return 0, errors.Trace(err)
+	}
+	lessCountB, err := c.LessRowCount(b)
+	if err != nil {
+		return 0, errors.Trace(err)
+	}
+	if lessCountA >= lessCountB {
+		return 0, nil
+	}
+	return lessCountB - lessCountA, nil
+}
+
+// Selectivity estimates the fraction of rows that satisfy "column op value".
+// The op must be one of opcode.EQ, opcode.NE, opcode.LT, opcode.LE, opcode.GT and opcode.GE.
+func (c *Column) Selectivity(op opcode.Op, value types.Datum) (float64, error) {
+	total := c.totalRowCount()
+	if total == 0 {
+		return 0, nil
+	}
+	if value.IsNull() {
+		// Comparing with null never returns true.
+		return 0, nil
+	}
+	eqCount, err := c.EqualRowCount(value)
+	if err != nil {
+		return 0, errors.Trace(err)
+	}
+	var rowCount int64
+	switch op {
+	case opcode.EQ:
+		rowCount = eqCount
+	case opcode.NE, opcode.LT, opcode.LE:
+		nullCount, err1 := c.nullRowCount()
+		if err1 != nil {
+			return 0, errors.Trace(err1)
+		}
+		if op == opcode.NE {
+			rowCount = total - eqCount - nullCount
+			break
+		}
+		lessCount, err1 := c.LessRowCount(value)
+		if err1 != nil {
+			return 0, errors.Trace(err1)
+		}
+		rowCount = lessCount - nullCount
+		if op == opcode.LE {
+			rowCount += eqCount
+		}
+	case opcode.GT, opcode.GE:
+		rowCount, err = c.GreaterRowCount(value)
+		if err != nil {
+			return 0, errors.Trace(err)
+		}
+		if op == opcode.GE {
+			rowCount += eqCount
+		}
+	default:
+		return 0, errors.Errorf("unsupported operator %s", op)
+	}
+	return clampRate(float64(rowCount) / float64(total)), nil
+}
+
+// BetweenSelectivity estimates the fraction of rows where the column is between low and high inclusively.
+func (c *Column) BetweenSelectivity(low, high types.Datum) (float64, error) {
+	total := c.totalRowCount()
+	if total == 0 || low.IsNull() || high.IsNull() {
+		return 0, nil
+	}
+	rowCount, err := c.BetweenRowCount(low, high)
+	if err != nil {
+		return 0, errors.Trace(err)
+	}
+	cmp, err := low.CompareDatum(high)
+	if err != nil {
+		return 0, errors.Trace(err)
+	}
+	if cmp <= 0 {
+		eqCount, err := c.EqualRowCount(high)
+		if err != nil {
+			return 0, errors.Trace(err)
+		}
+		rowCount += eqCount
+	}
+	return clampRate(float64(rowCount) / float64(total)), nil
+}
+
+// nullRowCount returns the row count of null values, which are always in the first bucket if exist.
+func (c *Column) nullRowCount() (int64, error) {
+	_, match, err := c.search(types.Datum{})
+	if err != nil || !match {
+		return 0, errors.Trace(err)
+	}
+	return c.EqualRowCount(types.Datum{})
+}
+
+func clampRate(rate float64) float64 {
+	if rate < 0 {
+		return 0
+	}
+	if rate > 1 {
+		return 1
+	}
+	return rate
+}
+
+func (c *Column) totalRowCount() int64 {
+	if len(c.Numbers) == 0 {
+		return 0
+	}
+	return c.Numbers[len(c.Numbers)-1]
+}
+
+// search returns the index of the first bucket whose value is greater or equal to the target value,
+// and whether the bucket value equals to the target value.
+func (c *Column) search(target types.Datum) (index int, match bool, err error) {
+	index = sort.Search(len(c.Values), func(i int) bool {
+		cmp, err1 := c.Values[i].CompareDatum(target)
+		if err1 != nil {
+			err = errors.Trace(err1)
+			return false
+		}
+		if cmp == 0 {
+			match = true
+		}
+		return cmp >= 0
+	})
+	return
+}
+
+// Index represents statistics for an index.
+// The histogram is built on the encoded index key of all the index columns, its ID is the index ID.
+type Index struct {
+	Column
+
+	// NDVs[i] is the number of distinct values of the first i+1 index columns.
+	NDVs []int64
+}
+
+// String implements Stringer interface.
+func (idx *Index) String() string {
+	return fmt.Sprintf("index:%d ndvs:%v\n%s", idx.ID, idx.NDVs, idx.Column.String())
+}
+
+// ValueToString returns the string of the ith bucket value, the encoded index key is decoded
+// to the index column values.
+func (idx *Index) ValueToString(i int) (string, error) {
+	vals, err := codec.Decode(idx.Values[i].GetBytes())
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	strs := make([]string, len(vals))
+	for j, val := range vals {
+		strs[j], err = val.ToString()
+		if err != nil {
+			return "", errors.Trace(err)
+		}
+	}
+	return "(" + strings.Join(strs, ", ") + ")", nil
+}
+
+// PrefixRowCount estimates the row count where the first prefixLen index columns equal to some values.
+func (idx *Index) PrefixRowCount(prefixLen int) int64 {
+	if prefixLen <= 0 || prefixLen > len(idx.NDVs) || idx.NDVs[prefixLen-1] == 0 {
+		return 0
+	}
+	return idx.totalRowCount() / idx.NDVs[prefixLen-1]
+}
+
 // Table represents statistics for a table.
 type Table struct {
 	info        *model.TableInfo
 	TS          int64 // build timestamp.
 	Columns     []*Column
+	Indices     []*Index
 	Count       int64 // Total row count in a table.
 	BucketCount int64 // Number of histogram bucket.
 }
 
 // String implements Stringer interface.
 func (t *Table) String() string {
-	strs := make([]string, 0, len(t.Columns)+1)
+	strs := make([]string, 0, len(t.Columns)+len(t.Indices)+1)
 	strs = append(strs, fmt.Sprintf("Table:%d ts:%d count:%d", t.info.ID, t.TS, t.Count))
 	for _, col := range t.Columns {
 		strs = append(strs, col.String())
 	}
+	for _, idx := range t.Indices {
+		strs = append(strs, idx.String())
+	}
 	return strings.Join(strs, "\n")
 }
 
+// tsPhysicalShiftBits is the count of the logical bits in a timestamp allocated by the store,
+// the rest bits are the physical time in milliseconds.
+const tsPhysicalShiftBits = 18
+
+// BuildTime returns the time when the statistics were built.
+func (t *Table) BuildTime() time.Time {
+	ms := t.TS >> tsPhysicalShiftBits
+	return time.Unix(ms/1e3, (ms%1e3)*1e6)
+}
+
+// ColumnByID returns the statistics of the column with the specified ID.
+func (t *Table) ColumnByID(id int64) *Column {
+	for _, col := range t.Columns {
+		if col.ID == id {
+			return col
+		}
+	}
+	return nil
+}
+
+// IndexByID returns the statistics of the index with the specified ID.
+func (t *Table) IndexByID(id int64) *Index {
+	for _, idx := range t.Indices {
+		if idx.ID == id {
+			return idx
+		}
+	}
+	return nil
+}
+
 // ToPB converts Table to TablePB.
 func (t *Table) ToPB() (*TablePB, error) {
 	tblPB := &TablePB{
@@ -83,6 +360,7 @@ func (t *Table) ToPB() (*TablePB, error) {
 		Ts:      proto.Int64(t.TS),
 		Count:   proto.Int64(t.Count),
 		Columns: make([]*ColumnPB, len(t.Columns)),
+		Indices: make([]*IndexPB, len(t.Indices)),
 	}
 	for i, col := range t.Columns {
 		data, err := codec.EncodeValue(nil, col.Values...)
@@ -97,22 +375,96 @@ func (t *Table) ToPB() (*TablePB, error) {
 			Repeats: col.Repeats,
 		}
 	}
+	for i, idx := range t.Indices {
+		data, err := codec.EncodeValue(nil, idx.Values...)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		tblPB.Indices[i] = &IndexPB{
+			Id:      proto.Int64(idx.ID),
+			Ndvs:    idx.NDVs,
+			Numbers: idx.Numbers,
+			Value:   data,
+			Repeats: idx.Repeats,
+		}
+	}
 	return tblPB, nil
 }
 
 // buildColumn builds column statistics from samples.
 func (t *Table) buildColumn(offset int, samples []types.Datum) error {
-	err := types.SortDatums(samples)
+	col, err := t.buildHistogram(t.info.Columns[offset].ID, samples)
 	if err != nil {
 		return errors.Trace(err)
 	}
-	estimatedNDV, err := estimateNDV(t.Count, samples)
+	t.Columns[offset] = col
+	return nil
+}
+
+// buildIndex builds index statistics from samples, the samples are the encoded index keys.
+func (t *Table) buildIndex(offset int, samples []types.Datum) error {
+	idxInfo := t.info.Indices[offset]
+	ndvs, err := t.estimatePrefixNDVs(len(idxInfo.Columns), samples)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	col, err := t.buildHistogram(idxInfo.ID, samples)
 	if err != nil {
 		return errors.Trace(err)
 	}
-	ci := t.info.Columns[offset]
+	t.Indices[offset] = &Index{Column: *col, NDVs: ndvs}
+	return nil
+}
+
+// estimatePrefixNDVs estimates the number of distinct values of each index prefix.
+// The encoded key of an index prefix is the prefix of the encoded index key, so we can cut it from the samples.
+func (t *Table) estimatePrefixNDVs(columnCount int, samples []types.Datum) ([]int64, error) {
+	ndvs := make([]int64, columnCount)
+	if len(samples) == 0 {
+		return ndvs, nil
+	}
+	remains := make([][]byte, len(samples))
+	for i := range samples {
+		remains[i] = samples[i].GetBytes()
+	}
+	prefixes := make([]types.Datum, len(samples))
+	for i := 0; i < columnCount; i++ {
+		for j, key := range samples {
+			remain, _, err := codec.DecodeOne(remains[j])
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+			remains[j] = remain
+			b := key.GetBytes()
+			prefixes[j].SetBytes(b[:len(b)-len(remain)])
+		}
+		err := types.SortDatums(prefixes)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		ndvs[i], err = estimateNDV(t.Count, prefixes)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+	return ndvs, nil
+}
+
+// buildHistogram builds a histogram from samples.
+func (t *Table) buildHistogram(id int64, samples []types.Datum) (*Column, error) {
+	if len(samples) == 0 {
+		return &Column{ID: id}, nil
+	}
+	err := types.SortDatums(samples)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	estimatedNDV, err := estimateNDV(t.Count, samples)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
 	col := &Column{
-		ID:      ci.ID,
+		ID:      id,
 		NDV:     estimatedNDV,
 		Numbers: make([]int64, 1, t.BucketCount),
 		Values:  make([]types.Datum, 1, t.BucketCount),
@@ -127,30 +479,30 @@ func (t *Table) buildColumn(offset int, samples []types.Datum) error {
 	for i := int64(0); i < int64(len(samples)); i++ {
 		cmp, err := col.Values[bucketIdx].CompareDatum(samples[i])
 		if err != nil {
-			return errors.Trace(err)
+			return nil, errors.Trace(err)
 		}
+		totalCount := (i + 1) * sampleFactor
 		if cmp == 0 {
 			// The new item has the same value as current bucket value, to ensure that
 			// a same value only stored in a single bucket, we do not increase bucketIdx even if it exceeds
 			// valuesPerBucket.
-			col.Numbers[bucketIdx] = i * sampleFactor
+			col.Numbers[bucketIdx] = totalCount
 			col.Repeats[bucketIdx] += sampleFactor
-		} else if i*sampleFactor-lastNumber <= valuesPerBucket {
+		} else if totalCount-lastNumber <= valuesPerBucket {
 			// The bucket still have room to store a new item, update the bucket.
-			col.Numbers[bucketIdx] = i * sampleFactor
+			col.Numbers[bucketIdx] = totalCount
 			col.Values[bucketIdx] = samples[i]
-			col.Repeats[bucketIdx] = 0
+			col.Repeats[bucketIdx] = sampleFactor
 		} else {
 			// The bucket is full, store the item in the next bucket.
 			lastNumber = col.Numbers[bucketIdx]
 			bucketIdx++
-			col.Numbers = append(col.Numbers, i*sampleFactor)
+			col.Numbers = append(col.Numbers, totalCount)
 			col.Values = append(col.Values, samples[i])
-			col.Repeats = append(col.Repeats, 0)
+			col.Repeats = append(col.Repeats, sampleFactor)
 		}
 	}
-	t.Columns[offset] = col
-	return nil
+	return col, nil
 }
 
 // estimateNDV estimates the number of distinct value given a count and samples.
@@ -184,13 +536,15 @@ func estimateNDV(count int64, samples []types.Datum) (int64, error) {
 }
 
 // NewTable creates a table statistics.
-func NewTable(ti *model.TableInfo, ts, count, numBuckets int64, columnSamples [][]types.Datum) (*Table, error) {
+// The indexSamples are the encoded index keys of the sampled rows for each index of the table.
+func NewTable(ti *model.TableInfo, ts, count, numBuckets int64, columnSamples, indexSamples [][]types.Datum) (*Table, error) {
 	t := &Table{
 		info:        ti,
 		TS:          ts,
 		Count:       count,
 		BucketCount: numBuckets,
 		Columns:     make([]*Column, len(columnSamples)),
+		Indices:     make([]*Index, len(indexSamples)),
 	}
 	for i, sample := range columnSamples {
 		err := t.buildColumn(i, sample)
@@ -198,6 +552,12 @@ func NewTable(ti *model.TableInfo, ts, count, numBuckets int64, columnSamples []
 			return nil, errors.Trace(err)
 		}
 	}
+	for i, sample := range indexSamples {
+		err := t.buildIndex(i, sample)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
 	return t, nil
 }
 
@@ -219,25 +579,74 @@ func TableFromPB(ti *model.TableInfo, tpb *TablePB) (*Table, error) {
 	t.Count = tpb.GetCount()
 	t.Columns = make([]*Column, len(tpb.GetColumns()))
 	for i, cInfo := range t.info.Columns {
-		cpb := tpb.Columns[i]
-		values, err := codec.Decode(cpb.GetValue())
+		c, err := ColumnFromPB(tpb.Columns[i], &cInfo.FieldType)
 		if err != nil {
 			return nil, errors.Trace(err)
 		}
-		c := &Column{
-			ID:      cpb.GetId(),
-			NDV:     cpb.GetNdv(),
-			Numbers: cpb.GetNumbers(),
-			Values:  make([]types.Datum, len(values)),
-			Repeats: cpb.GetRepeats(),
+		t.Columns[i] = c
+	}
+	for _, ipb := range tpb.GetIndices() {
+		// The index may have been dropped after the statistics was built.
+		if findIndexByID(ti, ipb.GetId()) == nil {
+			continue
 		}
-		for i, val := range values {
-			c.Values[i], err = tablecodec.Unflatten(val, &cInfo.FieldType)
-			if err != nil {
-				return nil, errors.Trace(err)
-			}
+		idx, err := IndexFromPB(ipb)
+		if err != nil {
+			return nil, errors.Trace(err)
 		}
-		t.Columns[i] = c
+		t.Indices = append(t.Indices, idx)
 	}
 	return t, nil
 }
+
+// ColumnFromPB decodes the statistics of a column, the values are converted to the field type ft.
+func ColumnFromPB(cpb *ColumnPB, ft *types.FieldType) (*Column, error) {
+	values, err := codec.Decode(cpb.GetValue())
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	c := &Column{
+		ID:      cpb.GetId(),
+		NDV:     cpb.GetNdv(),
+		Numbers: cpb.GetNumbers(),
+		Values:  make([]types.Datum, len(values)),
+		Repeats: cpb.GetRepeats(),
+	}
+	for i, val := range values {
+		c.Values[i], err = tablecodec.Unflatten(val, ft)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+	return c, nil
+}
+
+// IndexFromPB decodes the statistics of an index.
+func IndexFromPB(ipb *IndexPB) (*Index, error) {
+	values, err := codec.Decode(ipb.GetValue())
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	idx := &Index{
+		Column: Column{
+			ID:      ipb.GetId(),
+			Numbers: ipb.GetNumbers(),
+			Values:  values,
+			Repeats: ipb.GetRepeats(),
+		},
+		NDVs: ipb.GetNdvs(),
+	}
+	if len(idx.NDVs) > 0 {
+		idx.NDV = idx.NDVs[len(idx.NDVs)-1]
+	}
+	return idx, nil
+}
+
+func findIndexByID(ti *model.TableInfo, id int64) *model.IndexInfo {
+	for _, idxInfo := range ti.Indices {
+		if idxInfo.ID == id {
+			return idxInfo
+		}
+	}
+	return nil
+}
@@ -10,6 +10,7 @@ It is generated from these files:
 
 It has these top-level messages:
 	ColumnPB
+	IndexPB
 	TablePB
 */
 package statistics
@@ -76,18 +77,68 @@ func (m *ColumnPB) GetRepeats() []int64 {
 	return nil
 }
 
+type IndexPB struct {
+	Id               *int64  `protobuf:"varint,1,opt,name=id" json:"id,omitempty"`
+	Ndvs             []int64 `protobuf:"varint,2,rep,name=ndvs" json:"ndvs,omitempty"`
+	Numbers          []int64 `protobuf:"varint,3,rep,name=numbers" json:"numbers,omitempty"`
+	Value            []byte  `protobuf:"bytes,4,opt,name=value" json:"value,omitempty"`
+	Repeats          []int64 `protobuf:"varint,5,rep,name=repeats" json:"repeats,omitempty"`
+	XXX_unrecognized []byte  `json:"-"`
+}
+
+func (m *IndexPB) Reset()                    { *m = IndexPB{} }
+func (m *IndexPB) String() string            { return proto.CompactTextString(m) }
+func (*IndexPB) ProtoMessage()               {}
+func (*IndexPB) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{1} }
+
+func (m *IndexPB) GetId() int64 {
+	if m != nil && m.Id != nil {
+		return *m.Id
+	}
+	return 0
+}
+
+func (m *IndexPB) GetNdvs() []int64 {
+	if m != nil {
+		return m.Ndvs
+	}
+	return nil
+}
+
+func (m *IndexPB) GetNumbers() []int64 {
+	if m != nil {
+		return m.Numbers
+	}
+	return nil
+}
+
+func (m *IndexPB) GetValue() []byte {
+	if m != nil {
+		return m.Value
+	}
+	return nil
+}
+
+func (m *IndexPB) GetRepeats() []int64 {
+	if m != nil {
+		return m.Repeats
+	}
+	return nil
+}
+
 type TablePB struct {
 	Id               *int64      `protobuf:"varint,1,opt,name=id" json:"id,omitempty"`
 	Ts               *int64      `protobuf:"varint,2,opt,name=ts" json:"ts,omitempty"`
 	Count            *int64      `protobuf:"varint,3,opt,name=count" json:"count,omitempty"`
 	Columns          []*ColumnPB `protobuf:"bytes,4,rep,name=columns" json:"columns,omitempty"`
+	Indices          []*IndexPB  `protobuf:"bytes,5,rep,name=indices" json:"indices,omitempty"`
 	XXX_unrecognized []byte      `json:"-"`
 }
 
 func (m *TablePB) Reset()                    { *m = TablePB{} }
 func (m *TablePB) String() string            { return proto.CompactTextString(m) }
 func (*TablePB) ProtoMessage()               {}
-func (*TablePB) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{1} }
+func (*TablePB) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{2} }
 
 func (m *TablePB) GetId() int64 {
 	if m != nil && m.Id != nil {
@@ -117,22 +168,33 @@ func (m *TablePB) GetColumns() []*ColumnPB {
 	return nil
 }
 
+func (m *TablePB) GetIndices() []*IndexPB {
+	if m != nil {
+		return m.Indices
+	}
+	return nil
+}
+
 func init() {
 	proto.RegisterType((*ColumnPB)(nil), "statistics.ColumnPB")
+	proto.RegisterType((*IndexPB)(nil), "statistics.IndexPB")
 	proto.RegisterType((*TablePB)(nil), "statistics.TablePB")
 }
 
 var fileDescriptor0 = []byte{
-	// 170 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x09, 0x6e, 0x88, 0x02, 0xff, 0xe2, 0x12, 0x28, 0x2e, 0x49, 0x2c,
-	0xc9, 0x2c, 0x2e, 0xc9, 0x4c, 0x2e, 0xd6, 0x2b, 0x28, 0xca, 0x2f, 0xc9, 0x17, 0xe2, 0x42, 0x88,
-	0x28, 0x85, 0x70, 0x71, 0x38, 0xe7, 0xe7, 0x94, 0xe6, 0xe6, 0x05, 0x38, 0x09, 0x71, 0x71, 0x31,
-	0x65, 0xa6, 0x48, 0x30, 0x2a, 0x30, 0x6a, 0x30, 0x0b, 0x71, 0x73, 0x31, 0xe7, 0xa5, 0x94, 0x49,
-	0x30, 0x81, 0x39, 0xfc, 0x5c, 0xec, 0x79, 0xa5, 0xb9, 0x49, 0xa9, 0x45, 0xc5, 0x12, 0xcc, 0x0a,
-	0xcc, 0x40, 0x01, 0x5e, 0x2e, 0xd6, 0xb2, 0xc4, 0x9c, 0xd2, 0x54, 0x09, 0x16, 0xa0, 0x3c, 0x0f,
-	0x48, 0xbe, 0x28, 0xb5, 0x20, 0x35, 0xb1, 0xa4, 0x58, 0x82, 0x15, 0x24, 0xaf, 0x14, 0xce, 0xc5,
-	0x1e, 0x92, 0x98, 0x94, 0x93, 0x8a, 0x66, 0x28, 0x90, 0x0d, 0x54, 0x02, 0x31, 0x13, 0x68, 0x44,
-	0x72, 0x7e, 0x69, 0x5e, 0x09, 0xd0, 0x44, 0x10, 0x57, 0x95, 0x8b, 0x3d, 0x19, 0xec, 0x8e, 0x62,
-	0xa0, 0x99, 0xcc, 0x1a, 0xdc, 0x46, 0x22, 0x7a, 0x48, 0xee, 0x86, 0x39, 0x11, 0x10, 0x00, 0x00,
-	0xff, 0xff, 0xe6, 0x97, 0x42, 0x05, 0xcd, 0x00, 0x00, 0x00,
+	// 209 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x9c, 0x90, 0xb1, 0x4e, 0xc3, 0x30,
+	0x10, 0x86, 0x95, 0x5c, 0x22, 0xa3, 0x3f, 0xad, 0x40, 0x81, 0xe1, 0x46, 0xab, 0x02, 0x29, 0x53,
+	0x87, 0x3e, 0x02, 0x4c, 0x6c, 0x0c, 0x79, 0x81, 0xd4, 0xf6, 0x60, 0x29, 0xb5, 0xab, 0x9c, 0x1d,
+	0xb1, 0xf0, 0xee, 0xa8, 0xa1, 0x82, 0x2a, 0x23, 0xe3, 0xef, 0xdf, 0xfa, 0xee, 0xbe, 0xc3, 0x83,
+	0xa4, 0x21, 0x79, 0x49, 0xde, 0xc8, 0xfe, 0x3c, 0xc5, 0x14, 0x5b, 0xfc, 0xbd, 0xec, 0x7a, 0xdc,
+	0xbd, 0xc5, 0x31, 0x9f, 0xc2, 0xc7, 0x6b, 0x0b, 0x94, 0xde, 0x72, 0xa1, 0x8b, 0x8e, 0xda, 0x06,
+	0x14, 0xec, 0xcc, 0xe5, 0x12, 0xee, 0xa1, 0x42, 0x3e, 0x1d, 0xdd, 0x24, 0x4c, 0x9a, 0x3a, 0x6a,
+	0xb7, 0xa8, 0xe7, 0x61, 0xcc, 0x8e, 0x2b, 0x5d, 0x74, 0x9b, 0x4b, 0x3f, 0xb9, 0xb3, 0x1b, 0x92,
+	0x70, 0x7d, 0xe9, 0x77, 0x3d, 0xd4, 0x7b, 0xb0, 0xee, 0x73, 0x05, 0xdd, 0xa0, 0x0a, 0x76, 0x16,
+	0x2e, 0x35, 0xfd, 0x87, 0xfa, 0x05, 0xd5, 0x0f, 0xc7, 0xd1, 0xad, 0xa8, 0x40, 0x99, 0xe4, 0xba,
+	0xe9, 0x16, 0xb5, 0x89, 0x39, 0x24, 0xa6, 0x25, 0xbe, 0x40, 0x99, 0xc5, 0x4e, 0xb8, 0xd2, 0xd4,
+	0x35, 0x87, 0xa7, 0xfd, 0xcd, 0x35, 0x7e, 0xc5, 0x9f, 0xa1, 0x7c, 0xb0, 0xde, 0xb8, 0x9f, 0x49,
+	0xcd, 0xe1, 0xf1, 0xf6, 0xdb, 0xd5, 0xe4, 0x7b, 0x00, 0x48, 0x94, 0xee, 0x29, 0x49, 0x01, 0x00,
+	0x00,
 }
@@ -21,6 +21,8 @@ import (
 	. "github.com/pingcap/check"
 	"github.com/pingcap/tidb/model"
 	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/parser/opcode"
+	"github.com/pingcap/tidb/util/codec"
 	"github.com/pingcap/tidb/util/types"
 )
 
@@ -77,7 +79,7 @@ func (s *testStatisticsSuite) TestTable(c *C) {
 	tblInfo.Columns = columns
 	timestamp := int64(10)
 	bucketCount := int64(256)
-	t, err := NewTable(tblInfo, timestamp, s.count, bucketCount, [][]types.Datum{s.samples})
+	t, err := NewTable(tblInfo, timestamp, s.count, bucketCount, [][]types.Datum{s.samples}, nil)
 	c.Check(err, IsNil)
 	str := t.String()
 	log.Debug(str)
@@ -94,3 +96,180 @@ func (s *testStatisticsSuite) TestTable(c *C) {
 	c.Check(err, IsNil)
 	c.Check(nt.String(), Equals, str)
 }
+
+func (s *testStatisticsSuite) TestIndex(c *C) {
+	tblInfo := &model.TableInfo{
+		ID: 1,
+	}
+	tblInfo.Columns = []*model.ColumnInfo{
+		{
+			ID:        1,
+			Offset:    0,
+			FieldType: *types.NewFieldType(mysql.TypeLonglong),
+		},
+		{
+			ID:        2,
+			Offset:    1,
+			FieldType: *types.NewFieldType(mysql.TypeLonglong),
+		},
+	}
+	tblInfo.Indices = []*model.IndexInfo{
+		{
+			ID: 3,
+			Columns: []*model.IndexColumn{
+				{Offset: 0},
+				{Offset: 1},
+			},
+		},
+	}
+	// Column a has 100 distinct values, every value of a has 100 distinct values of b.
+	columnSamples := make([][]types.Datum, 2)
+	indexSamples := make([][]types.Datum, 1)
+	for i := 0; i < 10000; i++ {
+		a, b := types.NewIntDatum(int64(i%100)), types.NewIntDatum(int64(i))
+		columnSamples[0] = append(columnSamples[0], a)
+		columnSamples[1] = append(columnSamples[1], b)
+		key, err := codec.EncodeKey(nil, a, b)
+		c.Assert(err, IsNil)
+		indexSamples[0] = append(indexSamples[0], types.NewBytesDatum(key))
+	}
+	t, err := NewTable(tblInfo, 10, 10000, 256, columnSamples, indexSamples)
+	c.Assert(err, IsNil)
+	idx := t.IndexByID(3)
+	c.Assert(idx, NotNil)
+	c.Check(idx.NDVs, DeepEquals, []int64{100, 10000})
+	c.Check(idx.PrefixRowCount(1), Equals, int64(100))
+	c.Check(idx.PrefixRowCount(2), Equals, int64(1))
+	c.Check(idx.PrefixRowCount(3), Equals, int64(0))
+	low, err := codec.EncodeKey(nil, types.NewIntDatum(10))
+	c.Assert(err, IsNil)
+	high, err := codec.EncodeKey(nil, types.NewIntDatum(20))
+	c.Assert(err, IsNil)
+	count, err := idx.BetweenRowCount(types.NewBytesDatum(low), types.NewBytesDatum(high))
+	c.Check(err, IsNil)
+	c.Check(count, Equals, int64(1000))
+
+	tpb, err := t.ToPB()
+	c.Check(err, IsNil)
+	data, err := proto.Marshal(tpb)
+	c.Check(err, IsNil)
+	ntpb := &TablePB{}
+	err = proto.Unmarshal(data, ntpb)
+	c.Check(err, IsNil)
+	nt, err := TableFromPB(tblInfo, ntpb)
+	c.Check(err, IsNil)
+	c.Check(nt.String(), Equals, t.String())
+
+	// The statistics of a dropped index is ignored.
+	tblInfo.Indices = nil
+	nt, err = TableFromPB(tblInfo, ntpb)
+	c.Check(err, IsNil)
+	c.Check(nt.Indices, HasLen, 0)
+}
+
+func (s *testStatisticsSuite) TestColumnRowCount(c *C) {
+	tblInfo := &model.TableInfo{
+		ID: 1,
+	}
+	tblInfo.Columns = []*model.ColumnInfo{
+		{
+			ID:        2,
+			FieldType: *types.NewFieldType(mysql.TypeLonglong),
+		},
+	}
+	t, err := NewTable(tblInfo, 10, s.count, 256, [][]types.Datum{s.samples}, nil)
+	c.Check(err, IsNil)
+	col := t.Columns[0]
+	count, err := col.EqualRowCount(types.NewIntDatum(1000))
+	c.Check(err, IsNil)
+	c.Check(count, Equals, int64(2))
+	count, err = col.EqualRowCount(types.Datum{})
+	c.Check(err, IsNil)
+	c.Check(count, Equals, int64(10000))
+	count, err = col.LessRowCount(types.NewIntDatum(2000))
+	c.Check(err, IsNil)
+	c.Check(count, Equals, int64(19965))
+	count, err = col.GreaterRowCount(types.NewIntDatum(2000))
+	c.Check(err, IsNil)
+	c.Check(count, Equals, int64(80033))
+	count, err = col.BetweenRowCount(types.NewIntDatum(3000), types.NewIntDatum(3500))
+	c.Check(err, IsNil)
+	c.Check(count, Equals, int64(5070))
+	count, err = col.GreaterRowCount(types.NewIntDatum(100000))
+	c.Check(err, IsNil)
+	c.Check(count, Equals, int64(0))
+}
+
+func (s *testStatisticsSuite) TestColumnSelectivity(c *C) {
+	tblInfo := &model.TableInfo{
+		ID: 1,
+	}
+	tblInfo.Columns = []*model.ColumnInfo{
+		{
+			ID:        2,
+			FieldType: *types.NewFieldType(mysql.TypeLonglong),
+		},
+	}
+	t, err := NewTable(tblInfo, 10, s.count, 256, [][]types.Datum{s.samples}, nil)
+	c.Check(err, IsNil)
+	col := t.Columns[0]
+	tests := []struct {
+		op    opcode.Op
+		value types.Datum
+		rate  float64
+	}{
+		{opcode.EQ, types.NewIntDatum(1000), 2e-05},
+		{opcode.NE, types.NewIntDatum(1000), 0.89998},
+		{opcode.LT, types.NewIntDatum(2000), 0.09965},
+		{opcode.LE, types.NewIntDatum(2000), 0.09967},
+		{opcode.GT, types.NewIntDatum(2000), 0.80033},
+		{opcode.GE, types.NewIntDatum(2000), 0.80035},
+		{opcode.GT, types.NewIntDatum(100000), 0},
+		{opcode.EQ, types.Datum{}, 0},
+	}
+	for _, tt := range tests {
+		rate, err := col.Selectivity(tt.op, tt.value)
+		c.Check(err, IsNil)
+		c.Check(rate, Equals, tt.rate, Commentf("%s %v", tt.op, tt.value.GetValue()))
+	}
+	rate, err := col.BetweenSelectivity(types.NewIntDatum(3000), types.NewIntDatum(3500))
+	c.Check(err, IsNil)
+	c.Check(rate, Equals, 0.05072)
+	rate, err = col.BetweenSelectivity(types.NewIntDatum(3500), types.NewIntDatum(3000))
+	c.Check(err, IsNil)
+	c.Check(rate, Equals, 0.0)
+}
+
+func (s *testStatisticsSuite) TestHandle(c *C) {
+	tblInfo := &model.TableInfo{
+		ID: 1,
+	}
+	tblInfo.Columns = []*model.ColumnInfo{
+		{
+			ID:        2,
+			FieldType: *types.NewFieldType(mysql.TypeLonglong),
+		},
+	}
+	t, err := NewTable(tblInfo, 10, s.count, 256, [][]types.Datum{s.samples}, nil)
+	c.Check(err, IsNil)
+	h := NewHandle()
+	c.Check(h.GetTableStats(tblInfo), IsNil)
+	h.SetTableStats(t)
+	c.Check(h.GetTableStats(tblInfo), Equals, t)
+
+	// The statistics is outdated after a column is added.
+	newTblInfo := &model.TableInfo{
+		ID:      1,
+		Columns: append(tblInfo.Columns, &model.ColumnInfo{ID: 3}),
+	}
+	c.Check(h.GetTableStats(newTblInfo), IsNil)
+
+	// The statistics loaded from storage are merged into the cached ones.
+	c.Check(h.Version(), Equals, int64(0))
+	t2, err := NewTable(newTblInfo, 20, s.count, 256, [][]types.Datum{s.samples, s.samples}, nil)
+	c.Check(err, IsNil)
+	h.Update([]*Table{t2}, 5)
+	c.Check(h.Version(), Equals, int64(5))
+	c.Check(h.GetTableStats(tblInfo), IsNil)
+	c.Check(h.GetTableStats(newTblInfo), Equals, t2)
+}
@@ -27,7 +27,7 @@ func ToString(p Plan) string {
 
 func toString(in Plan, strs []string, idxs []int) ([]string, []int) {
 	switch in.(type) {
-	case *JoinOuter, *JoinInner, *Join, *Union, *NewUnion:
+	case *JoinOuter, *JoinInner, *Join, *Union, *NewUnion, *RecursiveCTE:
 		idxs = append(idxs, len(strs))
 	}
 
@@ -58,6 +58,10 @@ func toString(in Plan, strs []string, idxs []int) ([]string, []int) {
 		str = "Lock"
 	case *ShowDDL:
 		str = "ShowDDL"
+	case *ShowDDLJobs:
+		str = "ShowDDLJobs"
+	case *CancelDDLJobs:
+		str = "CancelDDLJobs"
 	case *Filter:
 		str = "Filter"
 	case *Sort:
@@ -98,7 +102,14 @@ func toString(in Plan, strs []string, idxs []int) ([]string, []int) {
 		idx := idxs[last]
 		children := strs[idx:]
 		strs = strs[:idx]
-		str = "Join{" + strings.Join(children, "->") + "}"
+		switch x.Algorithm {
+		case IndexLookUpJoinAlgorithm:
+			str = "IndexJoin{" + strings.Join(children, "->") + "}"
+		case MergeJoinAlgorithm:
+			str = "MergeJoin{" + strings.Join(children, "->") + "}"
+		default:
+			str = "Join{" + strings.Join(children, "->") + "}"
+		}
 		idxs = idxs[:last]
 	case *Union, *NewUnion:
 		last := len(idxs) - 1
@@ -107,6 +118,15 @@ func toString(in Plan, strs []string, idxs []int) ([]string, []int) {
 		strs = strs[:idx]
 		str = "UnionAll{" + strings.Join(children, "->") + "}"
 		idxs = idxs[:last]
+	case *RecursiveCTE:
+		last := len(idxs) - 1
+		idx := idxs[last]
+		children := strs[idx:]
+		strs = strs[:idx]
+		str = "RecursiveCTE{" + strings.Join(children, "->") + "}"
+		idxs = idxs[:last]
+	case *CTETable:
+		str = "CTETable"
 	case *NewTableScan:
 		str = fmt.Sprintf("DataScan(%v)", x.Table.Name.L)
 	case *Selection:
@@ -119,6 +139,10 @@ func toString(in Plan, strs []string, idxs []int) ([]string, []int) {
 		str = "Aggregate"
 	case *Distinct:
 		str = "Distinct"
+	case *TopN:
+		str = fmt.Sprintf("TopN(%v, %v)", x.Offset, x.Count)
+	case *Window:
+		str = "Window"
 	case *Trim:
 		str = "Trim"
 	default:
@@ -0,0 +1,76 @@
+// Copyright 2016 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plan
+
+import (
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/expression"
+)
+
+// pushTopNDown pushes the top-n plans through the projections, so the top-n can be pushed to the coprocessor
+// together with the table scan below. A copy of the top-n plan keeping offset + count rows is inserted below
+// the projection, and the original one still skips the offset rows.
+// e.g. select a + 1 from t order by b limit 2, 3 => DataScan(t)->TopN(0, 5)->Projection->TopN(2, 3).
+func (b *planBuilder) pushTopNDown(p Plan) error {
+	if topN, ok := p.(*TopN); ok {
+		if proj, ok := p.GetChildByIndex(0).(*Projection); ok && canPushTopN(topN, proj) {
+			byItems := make([]ByItems, 0, len(topN.ByItems))
+			for _, item := range topN.ByItems {
+				expr := columnSubstitute(item.Expr.DeepCopy(), proj.GetSchema(), proj.Exprs)
+				byItems = append(byItems, ByItems{Expr: expr, Desc: item.Desc})
+			}
+			child := proj.GetChildByIndex(0)
+			newTopN := &TopN{
+				ByItems: byItems,
+				Count:   topN.Offset + topN.Count,
+			}
+			newTopN.correlated = topN.correlated
+			newTopN.id = b.allocID(newTopN)
+			newTopN.SetSchema(child.GetSchema().DeepCopy())
+			if err := InsertPlan(proj, child, newTopN); err != nil {
+				return errors.Trace(err)
+			}
+		}
+	}
+	for _, child := range p.GetChildren() {
+		if err := b.pushTopNDown(child); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	return nil
+}
+
+// canPushTopN checks whether the order by items of the top-n plan can be substituted by the projection.
+// Like the predicates, only the columns projected from the child columns or constants are substituted.
+func canPushTopN(topN *TopN, proj *Projection) bool {
+	if len(proj.GetChildren()) == 0 {
+		return false
+	}
+	for _, item := range topN.ByItems {
+		cols, outerCols := extractColumn(item.Expr, nil, nil)
+		if len(outerCols) > 0 {
+			return false
+		}
+		for _, col := range cols {
+			id := proj.GetSchema().GetIndex(col)
+			if id == -1 {
+				return false
+			}
+			if _, ok := proj.Exprs[id].(*expression.ScalarFunction); ok {
+				return false
+			}
+		}
+	}
+	return true
+}
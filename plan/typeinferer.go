@@ -51,6 +51,10 @@ func (v *typeInferrer) Leave(in ast.Node) (out ast.Node, ok bool) {
 		x.Type.Collate = charset.CollationBin
 	case *ast.BinaryOperationExpr:
 		v.binaryOperation(x)
+	case *ast.TableName:
+		if x.CTE != nil {
+			v.cteColumns(x.CTE)
+		}
 	case *ast.CaseExpr:
 		v.handleCaseExpr(x)
 	case *ast.ColumnNameExpr:
@@ -112,6 +116,8 @@ func (v *typeInferrer) Leave(in ast.Node) (out ast.Node, ok bool) {
 			v.err = err
 		}
 		x.Type.Collate = cln
+	case *ast.WindowFuncExpr:
+		v.windowFunc(x)
 		// TODO: handle all expression types.
 	}
 	return in, true
@@ -129,21 +135,27 @@ func (v *typeInferrer) selectStmt(x *ast.SelectStmt) {
 }
 
 func (v *typeInferrer) aggregateFunc(x *ast.AggregateFuncExpr) {
-	name := strings.ToLower(x.F)
-	switch name {
+	if ft := v.aggregateFuncType(x.F, x.Args); ft != nil {
+		x.SetType(ft)
+	}
+}
+
+// aggregateFuncType returns the result type of the aggregate function, it returns nil if the type is not inferred.
+func (v *typeInferrer) aggregateFuncType(name string, args []ast.ExprNode) *types.FieldType {
+	switch strings.ToLower(name) {
 	case ast.AggFuncCount:
 		ft := types.NewFieldType(mysql.TypeLonglong)
 		ft.Flen = 21
 		ft.Charset = charset.CharsetBin
 		ft.Collate = charset.CollationBin
-		x.SetType(ft)
+		return ft
 	case ast.AggFuncMax, ast.AggFuncMin:
-		x.SetType(x.Args[0].GetType())
+		return args[0].GetType()
 	case ast.AggFuncSum, ast.AggFuncAvg:
 		ft := types.NewFieldType(mysql.TypeNewDecimal)
 		ft.Charset = charset.CharsetBin
 		ft.Collate = charset.CollationBin
-		x.SetType(ft)
+		return ft
 	case ast.AggFuncGroupConcat:
 		ft := types.NewFieldType(mysql.TypeVarString)
 		ft.Charset = v.defaultCharset
@@ -152,7 +164,37 @@ func (v *typeInferrer) aggregateFunc(x *ast.AggregateFuncExpr) {
 			v.err = err
 		}
 		ft.Collate = cln
+		return ft
+	}
+	return nil
+}
+
+func (v *typeInferrer) windowFunc(x *ast.WindowFuncExpr) {
+	switch strings.ToLower(x.F) {
+	case ast.WindowFuncRowNumber, ast.WindowFuncRank, ast.WindowFuncDenseRank:
+		ft := types.NewFieldType(mysql.TypeLonglong)
+		ft.Flen = 21
+		ft.Charset = charset.CharsetBin
+		ft.Collate = charset.CollationBin
 		x.SetType(ft)
+	case ast.WindowFuncLead, ast.WindowFuncLag, ast.WindowFuncFirstValue:
+		x.SetType(x.Args[0].GetType())
+	default:
+		x.SetType(v.aggregateFuncType(x.F, x.Args))
+	}
+}
+
+// cteColumns sets the types of the columns of the common table expression by the result fields of its query,
+// the types of a recursive common table expression are decided by the first select statement.
+func (v *typeInferrer) cteColumns(cte *ast.CommonTableExpression) {
+	rfs := cte.Query.GetResultFields()
+	if cte.IsRecursive {
+		rfs = cte.Query.(*ast.UnionStmt).SelectList.Selects[0].GetResultFields()
+	}
+	for i, col := range cte.TableInfo.Columns {
+		if tp := rfs[i].Expr.GetType(); tp != nil {
+			col.FieldType = *tp
+		}
 	}
 }
 
@@ -135,6 +135,14 @@ func (ts *testTypeInferrerSuite) TestInferType(c *C) {
 		{"if(1>2, 2, 3)", mysql.TypeLonglong, charset.CharsetBin},
 		{"case c1 when null then 2 when 2 then 1.1 else 1 END", mysql.TypeNewDecimal, charset.CharsetBin},
 		{"case c1 when null then 2 when 2 then 'tidb' else 1.1 END", mysql.TypeVarchar, "utf8"},
+
+		// Window functions
+		{"row_number() over (order by c1)", mysql.TypeLonglong, charset.CharsetBin},
+		{"rank() over (partition by c3 order by c1)", mysql.TypeLonglong, charset.CharsetBin},
+		{"lag(c2) over (order by c1)", mysql.TypeDouble, charset.CharsetBin},
+		{"first_value(c1) over (order by c2)", mysql.TypeLong, charset.CharsetBin},
+		{"sum(c1) over (rows 1 preceding)", mysql.TypeNewDecimal, charset.CharsetBin},
+		{"count(c3) over ()", mysql.TypeLonglong, charset.CharsetBin},
 	}
 	for _, ca := range cases {
 		ctx := testKit.Se.(context.Context)
@@ -38,10 +38,11 @@ type validator struct {
 	wildCardCount int
 	inPrepare     bool
 	inAggregate   bool
+	inWindow      bool
 }
 
 func (v *validator) Enter(in ast.Node) (out ast.Node, skipChildren bool) {
-	switch in.(type) {
+	switch x := in.(type) {
 	case *ast.AggregateFuncExpr:
 		if v.inAggregate {
 			// Aggregate function can not contain aggregate function.
@@ -49,6 +50,13 @@ func (v *validator) Enter(in ast.Node) (out ast.Node, skipChildren bool) {
 			return in, true
 		}
 		v.inAggregate = true
+	case *ast.WindowFuncExpr:
+		if v.inWindow {
+			// Window function can not contain window function.
+			v.err = errors.Errorf("You cannot use the window function '%s' in this context.", x.F)
+			return in, true
+		}
+		v.inWindow = true
 	}
 	return in, false
 }
@@ -85,6 +93,9 @@ func (v *validator) Leave(in ast.Node) (out ast.Node, ok bool) {
 		if x.Count > math.MaxUint64-x.Offset {
 			x.Count = math.MaxUint64 - x.Offset
 		}
+	case *ast.WindowFuncExpr:
+		v.inWindow = false
+		v.checkWindowFunc(x)
 	}
 
 	return in, v.err == nil
@@ -107,6 +118,31 @@ func (v *validator) checkAllOneColumn(exprs ...ast.ExprNode) {
 	return
 }
 
+// checkWindowFunc checks the arguments of lead and lag, the offset must be a non-negative integer.
+func (v *validator) checkWindowFunc(x *ast.WindowFuncExpr) {
+	switch strings.ToLower(x.F) {
+	case ast.WindowFuncLead, ast.WindowFuncLag:
+		if len(x.Args) > 3 {
+			v.err = errors.Errorf("Incorrect parameter count in the call to native function '%s'", x.F)
+			return
+		}
+		if len(x.Args) > 1 {
+			valid := false
+			if val, ok := x.Args[1].(*ast.ValueExpr); ok {
+				switch n := val.GetValue().(type) {
+				case int64:
+					valid = n >= 0
+				case uint64:
+					valid = true
+				}
+			}
+			if !valid {
+				v.err = errors.Errorf("Incorrect arguments to %s", x.F)
+			}
+		}
+	}
+}
+
 func checkAutoIncrementOp(colDef *ast.ColumnDef, num int) (bool, error) {
 	var hasAutoIncrement bool
 
@@ -52,6 +52,12 @@ func (s *testValidatorSuite) TestValidator(c *C) {
 			errors.New("Incorrect column specifier for column 'id'")},
 		{"create table t(id float auto_increment, key (id))", true, nil},
 		{"create table t(id int auto_increment) ENGINE=MYISAM", true, nil},
+		{"select lag(1, 2, 3) over ()", false, nil},
+		{"select lead(1, -1) over ()", false, errors.New("Incorrect arguments to lead")},
+		{"select lead(1, 1, 1, 1) over ()", false,
+			errors.New("Incorrect parameter count in the call to native function 'lead'")},
+		{"select sum(rank() over ()) over ()", false,
+			errors.New("You cannot use the window function 'rank' in this context.")},
 	}
 	store, err := tidb.NewStore(tidb.EngineGoLevelDBMemory)
 	c.Assert(err, IsNil)
@@ -173,6 +173,7 @@ func (s *session) finishTxn(rollback bool) error {
 	if rollback {
 		s.resetHistory()
 		s.cleanRetryInfo()
+		variable.GetSessionVars(s).ResetTableDelta()
 		return s.txn.Rollback()
 	}
 
@@ -189,9 +190,29 @@ func (s *session) finishTxn(rollback bool) error {
 
 	s.resetHistory()
 	s.cleanRetryInfo()
+	s.flushTableDelta()
 	return nil
 }
 
+// flushTableDelta reports the rows modified by the committed transaction to the statistics handle,
+// and adds background jobs to analyze the tables whose statistics are outdated.
+func (s *session) flushTableDelta() {
+	vars := variable.GetSessionVars(s)
+	if len(vars.TableDeltaMap) == 0 {
+		return
+	}
+	do := sessionctx.GetDomain(s)
+	h := do.StatsHandle()
+	h.UpdateModifyCount(vars.TableDeltaMap)
+	vars.ResetTableDelta()
+	for _, id := range h.PopOutdatedTables(autoAnalyzeRatio, autoAnalyzeMinCount) {
+		err := do.DDL().AnalyzeTable(id)
+		if err != nil {
+			log.Warnf("[stats] add analyze job for table %d err %v", id, errors.ErrorStack(err))
+		}
+	}
+}
+
 func (s *session) CommitTxn() error {
 	return s.finishTxn(false)
 }
@@ -25,6 +25,7 @@ import (
 	"github.com/pingcap/tidb/context"
 	"github.com/pingcap/tidb/executor"
 	"github.com/pingcap/tidb/kv"
+	"github.com/pingcap/tidb/model"
 	"github.com/pingcap/tidb/mysql"
 	"github.com/pingcap/tidb/plan"
 	"github.com/pingcap/tidb/sessionctx"
@@ -2332,3 +2333,58 @@ func (s *testSessionSuite) TestSelectHaving(c *C) {
 	mustExecMultiSQL(c, se, "select * from select_having_test group by id having null is not null;")
 	mustExecMultiSQL(c, se, "drop table select_having_test")
 }
+
+func (s *testSessionSuite) TestAutoAnalyze(c *C) {
+	defer testleak.AfterTest(c)()
+	store := newStore(c, s.dbName)
+	se := newSession(c, store, s.dbName)
+	defer func(minCount int64) {
+		autoAnalyzeMinCount = minCount
+	}(autoAnalyzeMinCount)
+	autoAnalyzeMinCount = 4
+	mustExecMultiSQL(c, se, `
+		drop table if exists t;
+		create table t (a int);
+		insert into t values (1), (2), (3);`)
+
+	do := sessionctx.GetDomain(se.(context.Context))
+	tbl, err := do.InfoSchema().TableByName(model.NewCIStr(s.dbName), model.NewCIStr("t"))
+	c.Assert(err, IsNil)
+	tblInfo := tbl.Meta()
+	h := do.StatsHandle()
+	// The table has no statistics, 3 modified rows don't reach the minimum count.
+	c.Assert(h.ModifyCount(tblInfo.ID), Equals, int64(3))
+	c.Assert(h.GetTableStats(tblInfo), IsNil)
+
+	// The fourth modified row reaches the minimum count, the table is analyzed for the first time.
+	mustExecSQL(c, se, "insert into t values (4)")
+	c.Assert(h.ModifyCount(tblInfo.ID), Equals, int64(0))
+	for i := 0; i < 100; i++ {
+		if h.GetTableStats(tblInfo) != nil {
+			break
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	c.Assert(h.GetTableStats(tblInfo), NotNil)
+	c.Assert(h.GetTableStats(tblInfo).Count, Equals, int64(4))
+
+	// Rolled back modifications are not counted.
+	mustExecMultiSQL(c, se, "begin; insert into t values (5), (6), (7); rollback;")
+	c.Assert(h.ModifyCount(tblInfo.ID), Equals, int64(0))
+
+	// 2 modified rows don't exceed the ratio.
+	mustExecMultiSQL(c, se, "insert into t values (5); update t set a = 6 where a = 5;")
+	c.Assert(h.ModifyCount(tblInfo.ID), Equals, int64(2))
+
+	// The third modified row exceeds the ratio, the table is analyzed in the background.
+	mustExecSQL(c, se, "insert into t values (7)")
+	c.Assert(h.ModifyCount(tblInfo.ID), Equals, int64(0))
+	for i := 0; i < 100; i++ {
+		if h.GetTableStats(tblInfo).Count == 6 {
+			break
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	c.Assert(h.GetTableStats(tblInfo).Count, Equals, int64(6))
+	mustExecSQL(c, se, "drop table t")
+}
@@ -14,11 +14,13 @@
 package variable
 
 import (
+	"strconv"
+	"strings"
+
 	"github.com/juju/errors"
 	"github.com/pingcap/tidb/context"
 	"github.com/pingcap/tidb/terror"
 	"github.com/pingcap/tidb/util/types"
-	"strings"
 )
 
 const (
@@ -104,6 +106,25 @@ type SessionVars struct {
 
 	// Strict SQL mode
 	StrictSQLMode bool
+
+	// TableDeltaMap saves the count of modified rows of each table in the current transaction,
+	// it is used to decide whether the statistics of a table are outdated.
+	TableDeltaMap map[int64]int64
+
+	// MemQuotaQuery is the memory quota in bytes of the executors of a query, they spill data to disk
+	// when the quota is exceeded. A non-positive value means no limit.
+	MemQuotaQuery int64
+
+	// AggConcurrency is the number of the workers which aggregate the rows of the groups in parallel.
+	// The rows are aggregated in the session goroutine if it is not more than 1.
+	AggConcurrency int
+
+	// CTEMaxRecursionDepth is the max number of the iterations a recursive common table expression runs.
+	CTEMaxRecursionDepth int
+
+	// ForeignKeyChecks indicates whether the foreign key constraints are checked and their actions are
+	// applied when the rows are written.
+	ForeignKeyChecks bool
 }
 
 // sessionVarsKeyType is a dummy type to avoid naming collision in context.
@@ -125,6 +146,9 @@ func BindSessionVars(ctx context.Context) {
 		PreparedStmtNameToID: make(map[string]uint32),
 		RetryInfo:            &RetryInfo{},
 		StrictSQLMode:        true,
+		TableDeltaMap:        make(map[int64]int64),
+		CTEMaxRecursionDepth: 1000,
+		ForeignKeyChecks:     true,
 	}
 	ctx.SetValue(sessionVarsKey, v)
 }
@@ -175,6 +199,18 @@ func (s *SessionVars) AddAffectedRows(rows uint64) {
 	s.AffectedRows += rows
 }
 
+// UpdateTableDelta adds the count of modified rows of a table.
+func (s *SessionVars) UpdateTableDelta(tableID int64, count int64) {
+	s.TableDeltaMap[tableID] += count
+}
+
+// ResetTableDelta clears the modified rows counted in the current transaction.
+func (s *SessionVars) ResetTableDelta() {
+	if len(s.TableDeltaMap) > 0 {
+		s.TableDeltaMap = make(map[int64]int64)
+	}
+}
+
 // AddFoundRows adds found rows with the argument rows.
 func (s *SessionVars) AddFoundRows(rows uint64) {
 	s.FoundRows += rows
@@ -224,6 +260,30 @@ func (s *SessionVars) SetSystemVar(key string, value types.Datum) error {
 			s.StrictSQLMode = false
 		}
 	}
+	if key == TiDBMemQuotaQuery {
+		quota, err := strconv.ParseInt(sVal, 10, 64)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		s.MemQuotaQuery = quota
+	}
+	if key == TiDBAggConcurrency {
+		concurrency, err := strconv.Atoi(sVal)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		s.AggConcurrency = concurrency
+	}
+	if key == CTEMaxRecursionDepth {
+		depth, err := strconv.Atoi(sVal)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		s.CTEMaxRecursionDepth = depth
+	}
+	if key == ForeignKeyChecks {
+		s.ForeignKeyChecks = strings.EqualFold(sVal, "ON") || sVal == "1"
+	}
 	s.systems[key] = sVal
 	return nil
 }
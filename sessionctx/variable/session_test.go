@@ -61,6 +61,28 @@ func (*testSessionSuite) TestSession(c *C) {
 	v.SetSystemVar("sql_mode", types.NewStringDatum(""))
 	c.Assert(v.StrictSQLMode, IsFalse)
 
+	c.Assert(v.SetSystemVar(variable.TiDBMemQuotaQuery, types.NewStringDatum("1024")), IsNil)
+	c.Assert(v.MemQuotaQuery, Equals, int64(1024))
+	c.Assert(v.SetSystemVar(variable.TiDBMemQuotaQuery, types.NewStringDatum("abc")), NotNil)
+	c.Assert(v.MemQuotaQuery, Equals, int64(1024))
+
+	c.Assert(v.SetSystemVar(variable.TiDBAggConcurrency, types.NewStringDatum("4")), IsNil)
+	c.Assert(v.AggConcurrency, Equals, 4)
+	c.Assert(v.SetSystemVar(variable.TiDBAggConcurrency, types.NewStringDatum("x")), NotNil)
+	c.Assert(v.AggConcurrency, Equals, 4)
+
+	c.Assert(v.CTEMaxRecursionDepth, Equals, 1000)
+	c.Assert(v.SetSystemVar(variable.CTEMaxRecursionDepth, types.NewStringDatum("10")), IsNil)
+	c.Assert(v.CTEMaxRecursionDepth, Equals, 10)
+	c.Assert(v.SetSystemVar(variable.CTEMaxRecursionDepth, types.NewStringDatum("x")), NotNil)
+	c.Assert(v.CTEMaxRecursionDepth, Equals, 10)
+
+	c.Assert(v.ForeignKeyChecks, IsTrue)
+	c.Assert(v.SetSystemVar(variable.ForeignKeyChecks, types.NewIntDatum(0)), IsNil)
+	c.Assert(v.ForeignKeyChecks, IsFalse)
+	c.Assert(v.SetSystemVar(variable.ForeignKeyChecks, types.NewStringDatum("on")), IsNil)
+	c.Assert(v.ForeignKeyChecks, IsTrue)
+
 	v.SetSystemVar("character_set_connection", types.NewStringDatum("utf8"))
 	v.SetSystemVar("collation_connection", types.NewStringDatum("utf8_general_ci"))
 	charset, collation := variable.GetCharsetInfo(ctx)
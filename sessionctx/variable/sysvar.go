@@ -97,6 +97,9 @@ var defaultSysVars = []*SysVar{
 	{ScopeGlobal, "slave_pending_jobs_size_max", "16777216"},
 	{ScopeNone, "innodb_sync_array_size", "1"},
 	{ScopeSession, "rand_seed2", ""},
+	{ScopeSession, TiDBMemQuotaQuery, "0"},
+	{ScopeSession, TiDBAggConcurrency, "1"},
+	{ScopeSession, CTEMaxRecursionDepth, "1000"},
 	{ScopeGlobal, "validate_password_number_count", ""},
 	{ScopeSession, "gtid_next", ""},
 	{ScopeGlobal | ScopeSession, "sql_select_limit", "18446744073709551615"},
@@ -186,7 +189,7 @@ var defaultSysVars = []*SysVar{
 	{ScopeNone, "innodb_autoinc_lock_mode", "1"},
 	{ScopeGlobal, "slave_net_timeout", "3600"},
 	{ScopeGlobal, "key_buffer_size", "8388608"},
-	{ScopeGlobal | ScopeSession, "foreign_key_checks", "ON"},
+	{ScopeGlobal | ScopeSession, ForeignKeyChecks, "ON"},
 	{ScopeGlobal, "host_cache_size", "279"},
 	{ScopeGlobal, "delay_key_write", "ON"},
 	{ScopeNone, "metadata_locks_cache_size", "1024"},
@@ -596,6 +599,14 @@ const (
 	CharsetDatabase = "character_set_database"
 	// CollationDatabase is the name for collation_database system variable.
 	CollationDatabase = "collation_database"
+	// TiDBMemQuotaQuery is the name for tidb_mem_quota_query system variable.
+	TiDBMemQuotaQuery = "tidb_mem_quota_query"
+	// TiDBAggConcurrency is the name for tidb_agg_concurrency system variable.
+	TiDBAggConcurrency = "tidb_agg_concurrency"
+	// CTEMaxRecursionDepth is the name for cte_max_recursion_depth system variable.
+	CTEMaxRecursionDepth = "cte_max_recursion_depth"
+	// ForeignKeyChecks is the name for foreign_key_checks system variable.
+	ForeignKeyChecks = "foreign_key_checks"
 )
 
 // GlobalVarAccessor is the interface for accessing global scope system and status variables.
@@ -3,6 +3,7 @@ package localstore
 import (
 	"io"
 
+	"github.com/juju/errors"
 	"github.com/pingcap/tidb/kv"
 	"github.com/pingcap/tipb/go-tipb"
 )
@@ -31,6 +32,7 @@ func (c *dbClient) Send(req *kv.Request) kv.Response {
 	it.taskChan = make(chan *task, it.concurrency)
 	it.errChan = make(chan error, it.concurrency)
 	it.respChan = make(chan *regionResponse, it.concurrency)
+	it.done = make(chan struct{})
 	it.run()
 	return it
 }
@@ -39,7 +41,7 @@ func (c *dbClient) SupportRequestType(reqType, subType int64) bool {
 	switch reqType {
 	case kv.ReqTypeSelect:
 		switch subType {
-		case kv.ReqSubTypeGroupBy:
+		case kv.ReqSubTypeGroupBy, kv.ReqSubTypeTopN:
 			return true
 		default:
 			return supportExpr(tipb.ExprType(subType))
@@ -49,6 +51,8 @@ func (c *dbClient) SupportRequestType(reqType, subType int64) bool {
 		case kv.ReqSubTypeDesc, kv.ReqSubTypeBasic:
 			return true
 		}
+	case kv.ReqTypeAnalyze:
+		return subType == kv.ReqSubTypeBasic
 	}
 	return false
 }
@@ -68,7 +72,27 @@ func supportExpr(exprType tipb.ExprType) bool {
 		return true
 	case tipb.ExprType_Plus, tipb.ExprType_Div:
 		return true
-	case tipb.ExprType_Count, tipb.ExprType_First:
+	case tipb.ExprType_Minus, tipb.ExprType_Mul, tipb.ExprType_IntDiv, tipb.ExprType_Mod,
+		tipb.ExprType_BitAnd, tipb.ExprType_BitOr, tipb.ExprType_BitXor, tipb.ExprType_LeftShift,
+		tipb.ExprType_RighShift, tipb.ExprType_BitNeg, tipb.ExprType_Neg, tipb.ExprType_Xor,
+		tipb.ExprType_Abs, tipb.ExprType_Pow:
+		return true
+	case tipb.ExprType_Case, tipb.ExprType_If, tipb.ExprType_NullIf, tipb.ExprType_IfNull,
+		tipb.ExprType_Coalesce, tipb.ExprType_IsNull:
+		return true
+	case tipb.ExprType_Concat, tipb.ExprType_ConcatWS, tipb.ExprType_Left, tipb.ExprType_Length,
+		tipb.ExprType_Lower, tipb.ExprType_Repeat, tipb.ExprType_Replace, tipb.ExprType_Upper,
+		tipb.ExprType_Strcmp, tipb.ExprType_Substring, tipb.ExprType_SubstringIndex, tipb.ExprType_Locate,
+		tipb.ExprType_Trim:
+		return true
+	case tipb.ExprType_Date, tipb.ExprType_Year, tipb.ExprType_YearWeek, tipb.ExprType_Month,
+		tipb.ExprType_Week, tipb.ExprType_Weekday, tipb.ExprType_WeekOfYear, tipb.ExprType_Day,
+		tipb.ExprType_DayName, tipb.ExprType_DayOfYear, tipb.ExprType_DayOfMonth, tipb.ExprType_DayOfWeek,
+		tipb.ExprType_Hour, tipb.ExprType_Minute, tipb.ExprType_Second, tipb.ExprType_Microsecond,
+		tipb.ExprType_Extract:
+		return true
+	case tipb.ExprType_Count, tipb.ExprType_First, tipb.ExprType_Sum, tipb.ExprType_Avg,
+		tipb.ExprType_Max, tipb.ExprType_Min, tipb.ExprType_GroupConcat:
 		return true
 	case kv.ReqSubTypeDesc:
 		return true
@@ -113,7 +137,9 @@ type response struct {
 	taskChan    chan *task
 	respChan    chan *regionResponse
 	errChan     chan error
-	finished    bool
+	// done is closed when the response is closed, so the workers quit instead of blocking on sending.
+	done     chan struct{}
+	finished bool
 }
 
 type task struct {
@@ -134,6 +160,10 @@ func (it *response) Next() (resp io.ReadCloser, err error) {
 		it.Close()
 		return nil, err
 	}
+	if regionResp.resumeKey != nil {
+		// More chunks of the region are coming, the next task is sent after the region is finished.
+		return &localResponseReader{s: regionResp.data}, nil
+	}
 	if len(regionResp.newStartKey) != 0 {
 		it.client.updateRegionInfo()
 		retryTasks := it.createRetryTasks(regionResp)
@@ -201,6 +231,7 @@ func (it *response) Close() error {
 	if it.finished {
 		return nil
 	}
+	close(it.done)
 	close(it.taskChan)
 	it.finished = true
 	return nil
@@ -210,15 +241,46 @@ func (it *response) run() {
 	for i := 0; i < it.concurrency; i++ {
 		go func() {
 			for task := range it.taskChan {
-				resp, err := task.region.Handle(task.request)
+				err := it.handleTask(task)
 				if err != nil {
-					it.errChan <- err
+					select {
+					case it.errChan <- err:
+					case <-it.done:
+					}
 					break
 				}
-				it.respChan <- resp
 			}
 		}()
 		it.taskChan <- it.tasks[i]
 		it.reqSent++
 	}
 }
+
+// handleTask sends the response chunks of the region one by one,
+// the next chunk is requested with the resume key of the previous one.
+// It returns without handling the rest chunks if the response is closed.
+func (it *response) handleTask(task *task) error {
+	req := task.request
+	for {
+		select {
+		case <-it.done:
+			return nil
+		default:
+		}
+		resp, err := task.region.Handle(req)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		select {
+		case it.respChan <- resp:
+		case <-it.done:
+			return nil
+		}
+		if resp.resumeKey == nil {
+			return nil
+		}
+		next := *req
+		next.resumeKey = resp.resumeKey
+		req = &next
+	}
+}
@@ -8,11 +8,13 @@ import (
 	"github.com/juju/errors"
 	"github.com/pingcap/tidb/kv"
 	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/plan/statistics"
 	"github.com/pingcap/tidb/tablecodec"
 	"github.com/pingcap/tidb/terror"
 	"github.com/pingcap/tidb/util/codec"
 	"github.com/pingcap/tidb/util/types"
 	"github.com/pingcap/tidb/xapi"
+	"github.com/pingcap/tidb/xapi/xcop"
 	"github.com/pingcap/tidb/xapi/xeval"
 	"github.com/pingcap/tipb/go-tipb"
 )
@@ -25,11 +27,17 @@ type localRegion struct {
 	endKey   []byte
 }
 
+// regionChunkSize is the max number of rows in a response if the rows of the region are returned in chunks.
+var regionChunkSize int64 = 1024
+
 type regionRequest struct {
 	Tp       int64
 	data     []byte
 	startKey []byte
 	endKey   []byte
+	// resumeKey is set if the request is for the following chunk of the region,
+	// the keys scanned by the previous chunks are skipped.
+	resumeKey []byte
 }
 
 type regionResponse struct {
@@ -39,17 +47,26 @@ type regionResponse struct {
 	// If region missed some request key range, newStartKey and newEndKey is returned.
 	newStartKey []byte
 	newEndKey   []byte
+	// If the region has more rows to return, resumeKey is returned for the next chunk.
+	resumeKey []byte
 }
 
 type selectContext struct {
-	sel          *tipb.SelectRequest
-	txn          kv.Transaction
-	eval         *xeval.Evaluator
+	sel  *tipb.SelectRequest
+	txn  kv.Transaction
+	eval *xeval.Evaluator
+	// whereColumns are the columns used by the where condition and the order by items,
+	// they are decoded into the evaluator before the row is evaluated.
 	whereColumns map[int64]*tipb.ColumnInfo
-	groups       map[string]bool
-	groupKeys    [][]byte
-	aggregates   []*aggregateFuncExpr
 	aggregate    bool
+	// aggregator computes the partial results of the aggregate request.
+	aggregator *xcop.Aggregator
+	// topn is not nil if the request is a top-n request, which has the order by items and the limit.
+	topn *xcop.TopN
+	// chunkSize is not zero if the rows are returned in chunks.
+	chunkSize int64
+	// resumeKey is the key where the scan resumes, it is advanced after each row is returned.
+	resumeKey kv.Key
 }
 
 func (rs *localRegion) Handle(req *regionRequest) (*regionResponse, error) {
@@ -64,33 +81,65 @@ func (rs *localRegion) Handle(req *regionRequest) (*regionResponse, error) {
 		}
 		txn := newTxn(rs.store, kv.Version{Ver: uint64(*sel.StartTs)})
 		ctx := &selectContext{
-			sel: sel,
-			txn: txn,
+			sel:       sel,
+			txn:       txn,
+			resumeKey: req.resumeKey,
 		}
 		ctx.eval = &xeval.Evaluator{Row: make(map[int64]types.Datum)}
-		if sel.Where != nil {
+		if xcop.IsTopNRequest(sel) {
+			ctx.topn = xcop.NewTopN(sel, ctx.eval)
+		}
+		if sel.Where != nil || ctx.topn != nil {
 			ctx.whereColumns = make(map[int64]*tipb.ColumnInfo)
 			collectColumnsInWhere(sel.Where, ctx)
+			if ctx.topn != nil {
+				for _, item := range sel.OrderBy {
+					collectColumnsInWhere(item.Expr, ctx)
+				}
+			}
 		}
 		ctx.aggregate = len(sel.Aggregates) > 0 || len(sel.GetGroupBy()) > 0
 		if ctx.aggregate {
-			// compose aggregateFuncExpr
-			ctx.aggregates = make([]*aggregateFuncExpr, 0, len(sel.Aggregates))
-			for _, agg := range sel.Aggregates {
-				aggExpr := &aggregateFuncExpr{expr: agg}
-				ctx.aggregates = append(ctx.aggregates, aggExpr)
-			}
-			ctx.groups = make(map[string]bool)
-			ctx.groupKeys = make([][]byte, 0)
+			ctx.aggregator = xcop.NewAggregator(sel, ctx.eval)
+		}
+		// The rows are returned in chunks if the request doesn't limit the rows, so the memory is bounded
+		// and the client can consume the rows while the region is still scanning.
+		// The aggregate and top-n requests return the result after all the rows are scanned.
+		if sel.Limit == nil && !ctx.aggregate {
+			ctx.chunkSize = regionChunkSize
 		}
 
 		var rows []*tipb.Row
 		if req.Tp == kv.ReqTypeSelect {
 			rows, err = rs.getRowsFromSelectReq(ctx)
 		} else {
-			rows, err = rs.getRowsFromIndexReq(txn, sel)
+			rows, err = rs.getRowsFromIndexReq(ctx)
+		}
+		if err == nil && ctx.chunkSize > 0 && int64(len(rows)) == ctx.chunkSize {
+			resp.resumeKey = ctx.resumeKey
 		}
 
+		selResp := new(tipb.SelectResponse)
+		selResp.Error = toPBError(err)
+		selResp.Rows = rows
+		resp.err = err
+		data, err := proto.Marshal(selResp)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		resp.data = data
+	} else if req.Tp == kv.ReqTypeAnalyze {
+		sel := new(tipb.SelectRequest)
+		err := proto.Unmarshal(req.data, sel)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		txn := newTxn(rs.store, kv.Version{Ver: uint64(*sel.StartTs)})
+		ctx := &selectContext{
+			sel: sel,
+			txn: txn,
+		}
+		rows, err := rs.getSampleRows(ctx)
 		selResp := new(tipb.SelectResponse)
 		selResp.Error = toPBError(err)
 		selResp.Rows = rows
@@ -142,10 +191,14 @@ func collectColumnsInWhere(expr *tipb.Expr, ctx *selectContext) error {
 
 func (rs *localRegion) getRowsFromSelectReq(ctx *selectContext) ([]*tipb.Row, error) {
 	kvRanges, desc := rs.extractKVRanges(ctx.sel)
+	kvRanges = resumeKVRanges(kvRanges, ctx.resumeKey, desc)
 	var rows []*tipb.Row
 	limit := int64(-1)
-	if ctx.sel.Limit != nil {
+	// The limit of the top-n request is applied to the sorted rows.
+	if ctx.sel.Limit != nil && ctx.topn == nil {
 		limit = ctx.sel.GetLimit()
+	} else if ctx.chunkSize > 0 {
+		limit = ctx.chunkSize
 	}
 	for _, ran := range kvRanges {
 		if limit == 0 {
@@ -159,39 +212,32 @@ func (rs *localRegion) getRowsFromSelectReq(ctx *selectContext) ([]*tipb.Row, er
 		limit -= int64(len(ranRows))
 	}
 	if ctx.aggregate {
-		return rs.getRowsFromAgg(ctx)
+		return ctx.aggregator.Rows()
+	}
+	if ctx.topn != nil {
+		return ctx.topn.SortedRows(), nil
 	}
 	return rows, nil
 }
 
-/*
- * Convert aggregate partial result to rows.
- * Data layout example:
- *	SQL:	select count(c1), sum(c2), avg(c3) from t;
- *	Aggs:	count(c1), sum(c2), avg(c3)
- *	Rows:	groupKey1, count1, value2, count3, value3
- *		groupKey2, count1, value2, count3, value3
- */
-func (rs *localRegion) getRowsFromAgg(ctx *selectContext) ([]*tipb.Row, error) {
-	rows := make([]*tipb.Row, 0, len(ctx.groupKeys))
-	for _, gk := range ctx.groupKeys {
-		row := new(tipb.Row)
-		// Each aggregate partial result will be converted to one or two datums.
-		rowData := make([]types.Datum, 0, 1+2*len(ctx.aggregates))
-		// The first column is group key.
-		rowData = append(rowData, types.NewBytesDatum(gk))
-		for _, agg := range ctx.aggregates {
-			agg.currentGroup = gk
-			rowData = append(rowData, agg.toDatums()...)
-		}
-		var err error
-		row.Data, err = codec.EncodeValue(nil, rowData...)
+// getSampleRows samples the rows in the region, the limit of the request is the max sample size.
+// The rows returned are converted from a statistics.SampleCollector.
+func (rs *localRegion) getSampleRows(ctx *selectContext) ([]*tipb.Row, error) {
+	collector := statistics.NewSampleCollector(ctx.sel.GetLimit(), len(ctx.sel.TableInfo.Columns))
+	kvRanges, _ := rs.extractKVRanges(ctx.sel)
+	for _, ran := range kvRanges {
+		ranRows, err := rs.getRowsFromRange(ctx, ran, -1, false)
 		if err != nil {
 			return nil, errors.Trace(err)
 		}
-		rows = append(rows, row)
+		for _, row := range ranRows {
+			err = collector.Collect(row)
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+		}
 	}
-	return rows, nil
+	return collector.ToRows()
 }
 
 // extractKVRanges extracts kv.KeyRanges slice from a SelectRequest, and also returns if it is in descending order.
@@ -237,8 +283,10 @@ func (rs *localRegion) extractKVRanges(sel *tipb.SelectRequest) (kvRanges []kv.K
 		}
 		kvRanges = append(kvRanges, kvr)
 	}
-	if sel.OrderBy != nil {
-		desc = *sel.OrderBy[0].Desc
+	// The order by item without expression means the rows are scanned in the handle order,
+	// otherwise the rows are sorted by the top-n.
+	if len(sel.OrderBy) > 0 && sel.OrderBy[0].Expr == nil {
+		desc = sel.OrderBy[0].GetDesc()
 	}
 	if desc {
 		reverseKVRanges(kvRanges)
@@ -246,6 +294,34 @@ func (rs *localRegion) extractKVRanges(sel *tipb.SelectRequest) (kvRanges []kv.K
 	return
 }
 
+// resumeKVRanges cuts off the keys scanned by the previous chunks of the region.
+// The resume key is the start key of the rest of the ranges, or the end key if in descending order.
+func resumeKVRanges(kvRanges []kv.KeyRange, resumeKey kv.Key, desc bool) []kv.KeyRange {
+	if resumeKey == nil {
+		return kvRanges
+	}
+	ranges := make([]kv.KeyRange, 0, len(kvRanges))
+	for _, ran := range kvRanges {
+		if desc {
+			if ran.StartKey.Cmp(resumeKey) >= 0 {
+				continue
+			}
+			if ran.EndKey.Cmp(resumeKey) > 0 {
+				ran.EndKey = resumeKey
+			}
+		} else {
+			if ran.EndKey.Cmp(resumeKey) <= 0 {
+				continue
+			}
+			if ran.StartKey.Cmp(resumeKey) < 0 {
+				ran.StartKey = resumeKey
+			}
+		}
+		ranges = append(ranges, ran)
+	}
+	return ranges
+}
+
 func (rs *localRegion) getRowsFromRange(ctx *selectContext, ran kv.KeyRange, limit int64, desc bool) ([]*tipb.Row, error) {
 	if limit == 0 {
 		return nil, nil
@@ -275,6 +351,11 @@ func (rs *localRegion) getRowsFromRange(ctx *selectContext, ran kv.KeyRange, lim
 		}
 		if row != nil {
 			rows = append(rows, row)
+			if desc {
+				ctx.resumeKey = ran.StartKey
+			} else {
+				ctx.resumeKey = ran.EndKey
+			}
 		}
 		return rows, nil
 	}
@@ -332,6 +413,7 @@ func (rs *localRegion) getRowsFromRange(ctx *selectContext, ran kv.KeyRange, lim
 		if row != nil {
 			rows = append(rows, row)
 			limit--
+			ctx.resumeKey = seekKey
 		}
 	}
 	return rows, nil
@@ -399,12 +481,17 @@ func (rs *localRegion) getRowByHandle(ctx *selectContext, handle int64) (*tipb.R
 		for _, d := range rowData {
 			row.Data = append(row.Data, d...)
 		}
+		if ctx.topn != nil {
+			// The row is kept by the top-n heap, and returned after all the rows are scanned.
+			err = ctx.topn.Add(row)
+			return nil, errors.Trace(err)
+		}
 	}
 	return row, nil
 }
 
 func (rs *localRegion) evalWhereForRow(ctx *selectContext, h int64) (bool, error) {
-	if ctx.sel.Where == nil {
+	if len(ctx.whereColumns) == 0 && ctx.sel.Where == nil {
 		return true, nil
 	}
 	tid := ctx.sel.TableInfo.GetTableId()
@@ -428,6 +515,9 @@ func (rs *localRegion) evalWhereForRow(ctx *selectContext, h int64) (bool, error
 			ctx.eval.Row[colID] = datum
 		}
 	}
+	if ctx.sel.Where == nil {
+		return true, nil
+	}
 	result, err := ctx.eval.Eval(ctx.sel.Where)
 	if err != nil {
 		return false, errors.Trace(err)
@@ -454,18 +544,21 @@ func toPBError(err error) *tipb.Error {
 	return perr
 }
 
-func (rs *localRegion) getRowsFromIndexReq(txn kv.Transaction, sel *tipb.SelectRequest) ([]*tipb.Row, error) {
-	kvRanges, desc := rs.extractKVRanges(sel)
+func (rs *localRegion) getRowsFromIndexReq(ctx *selectContext) ([]*tipb.Row, error) {
+	kvRanges, desc := rs.extractKVRanges(ctx.sel)
+	kvRanges = resumeKVRanges(kvRanges, ctx.resumeKey, desc)
 	var rows []*tipb.Row
 	limit := int64(-1)
-	if sel.Limit != nil {
-		limit = sel.GetLimit()
+	if ctx.sel.Limit != nil {
+		limit = ctx.sel.GetLimit()
+	} else if ctx.chunkSize > 0 {
+		limit = ctx.chunkSize
 	}
 	for _, ran := range kvRanges {
 		if limit == 0 {
 			break
 		}
-		ranRows, err := getIndexRowFromRange(sel.IndexInfo, txn, ran, desc, limit)
+		ranRows, err := getIndexRowFromRange(ctx, ran, desc, limit)
 		if err != nil {
 			return nil, errors.Trace(err)
 		}
@@ -482,7 +575,8 @@ func reverseKVRanges(kvRanges []kv.KeyRange) {
 	}
 }
 
-func getIndexRowFromRange(idxInfo *tipb.IndexInfo, txn kv.Transaction, ran kv.KeyRange, desc bool, limit int64) ([]*tipb.Row, error) {
+func getIndexRowFromRange(ctx *selectContext, ran kv.KeyRange, desc bool, limit int64) ([]*tipb.Row, error) {
+	idxInfo, txn := ctx.sel.IndexInfo, ctx.txn
 	var rows []*tipb.Row
 	var seekKey kv.Key
 	if desc {
@@ -549,6 +643,7 @@ func getIndexRowFromRange(idxInfo *tipb.IndexInfo, txn kv.Transaction, ran kv.Ke
 		row := &tipb.Row{Handle: handleData, Data: data}
 		rows = append(rows, row)
 		limit--
+		ctx.resumeKey = seekKey
 	}
 
 	return rows, nil
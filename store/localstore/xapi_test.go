@@ -26,6 +26,7 @@ import (
 	. "github.com/pingcap/check"
 	"github.com/pingcap/tidb/kv"
 	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/plan/statistics"
 	"github.com/pingcap/tidb/tablecodec"
 	"github.com/pingcap/tidb/util/codec"
 	"github.com/pingcap/tidb/util/testleak"
@@ -113,6 +114,126 @@ func (s *testXAPISuite) TestSelect(c *C) {
 	store.Close()
 }
 
+func (s *testXAPISuite) TestSelectChunks(c *C) {
+	defer testleak.AfterTest(c)()
+	store := createMemStore(time.Now().Nanosecond())
+	count := int64(10)
+	err := prepareTableData(store, tbInfo, count, genValues)
+	c.Check(err, IsNil)
+	defer func(size int64) {
+		regionChunkSize = size
+	}(regionChunkSize)
+	regionChunkSize = 3
+
+	txn, err := store.Begin()
+	c.Check(err, IsNil)
+	client := txn.GetClient()
+	req, err := prepareSelectRequest(tbInfo, txn.StartTS())
+	c.Check(err, IsNil)
+	handles := fetchChunks(c, client.Send(req), []int{3, 3, 3, 1})
+	for i, h := range handles {
+		c.Assert(h, Equals, int64(i+1))
+	}
+
+	req, err = prepareIndexRequest(tbInfo, txn.StartTS())
+	c.Check(err, IsNil)
+	handles = fetchChunks(c, client.Send(req), []int{3, 3, 3, 1})
+	c.Assert(handles, HasLen, int(count))
+
+	// The chunk is full at the end of the region, the last chunk is empty.
+	regionChunkSize = 5
+	req, err = prepareSelectRequest(tbInfo, txn.StartTS())
+	c.Check(err, IsNil)
+	fetchChunks(c, client.Send(req), []int{5, 5, 0})
+
+	// The rows are not returned in chunks if the request has a limit.
+	selReq := new(tipb.SelectRequest)
+	err = proto.Unmarshal(req.Data, selReq)
+	c.Check(err, IsNil)
+	selReq.Limit = proto.Int64(8)
+	req.Data, err = proto.Marshal(selReq)
+	c.Check(err, IsNil)
+	fetchChunks(c, client.Send(req), []int{8})
+
+	// The workers quit if the response is closed before all the chunks are read.
+	regionChunkSize = 1
+	req, err = prepareSelectRequest(tbInfo, txn.StartTS())
+	c.Check(err, IsNil)
+	resp := client.Send(req)
+	subResp, err := resp.Next()
+	c.Assert(err, IsNil)
+	c.Assert(subResp, NotNil)
+	c.Assert(resp.Close(), IsNil)
+	txn.Commit()
+
+	store.Close()
+}
+
+// fetchChunks reads all the responses, checks the number of rows in each one, and returns the handles.
+func fetchChunks(c *C, resp kv.Response, rowCounts []int) []int64 {
+	var handles []int64
+	for _, rowCount := range rowCounts {
+		subResp, err := resp.Next()
+		c.Assert(err, IsNil)
+		c.Assert(subResp, NotNil)
+		data, err := ioutil.ReadAll(subResp)
+		c.Assert(err, IsNil)
+		selResp := new(tipb.SelectResponse)
+		err = proto.Unmarshal(data, selResp)
+		c.Assert(err, IsNil)
+		c.Assert(selResp.Rows, HasLen, rowCount)
+		for _, row := range selResp.Rows {
+			datums, err := codec.Decode(row.Handle)
+			c.Assert(err, IsNil)
+			handles = append(handles, datums[0].GetInt64())
+		}
+	}
+	subResp, err := resp.Next()
+	c.Assert(err, IsNil)
+	c.Assert(subResp, IsNil)
+	return handles
+}
+
+func (s *testXAPISuite) TestAnalyze(c *C) {
+	defer testleak.AfterTest(c)()
+	store := createMemStore(time.Now().Nanosecond())
+	count := int64(10)
+	err := prepareTableData(store, tbInfo, count, genValues)
+	c.Check(err, IsNil)
+
+	txn, err := store.Begin()
+	c.Check(err, IsNil)
+	client := txn.GetClient()
+	c.Assert(client.SupportRequestType(kv.ReqTypeAnalyze, kv.ReqSubTypeBasic), IsTrue)
+	req, err := prepareSelectRequest(tbInfo, txn.StartTS())
+	c.Check(err, IsNil)
+	selReq := new(tipb.SelectRequest)
+	err = proto.Unmarshal(req.Data, selReq)
+	c.Check(err, IsNil)
+	// The limit of an analyze request is the max sample size.
+	selReq.Limit = proto.Int64(5)
+	req.Data, err = proto.Marshal(selReq)
+	c.Check(err, IsNil)
+	req.Tp = kv.ReqTypeAnalyze
+	resp := client.Send(req)
+	subResp, err := resp.Next()
+	c.Check(err, IsNil)
+	data, err := ioutil.ReadAll(subResp)
+	c.Check(err, IsNil)
+	selResp := new(tipb.SelectResponse)
+	proto.Unmarshal(data, selResp)
+	c.Check(selResp.Error, IsNil)
+	collector, err := statistics.SampleCollectorFromRows(selResp.Rows)
+	c.Check(err, IsNil)
+	c.Check(collector.Count, Equals, count)
+	c.Check(collector.Samples, HasLen, 5)
+	c.Check(collector.Sketches, HasLen, len(tbInfo.cIDs)+1)
+	c.Check(collector.Sketches[0].NDV(), Equals, count)
+	txn.Commit()
+
+	store.Close()
+}
+
 // simpleTableInfo just have the minimum information enough to describe the table.
 // The first column is pk handle column.
 type simpleTableInfo struct {
@@ -25,6 +25,7 @@ import (
 	"github.com/pingcap/kvproto/pkg/coprocessor"
 	"github.com/pingcap/tidb/kv"
 	"github.com/pingcap/tidb/terror"
+	"github.com/pingcap/tidb/xapi"
 	"github.com/pingcap/tipb/go-tipb"
 )
 
@@ -38,7 +39,7 @@ func (c *CopClient) SupportRequestType(reqType, subType int64) bool {
 	switch reqType {
 	case kv.ReqTypeSelect:
 		switch subType {
-		case kv.ReqSubTypeGroupBy:
+		case kv.ReqSubTypeGroupBy, kv.ReqSubTypeTopN:
 			return true
 		default:
 			return supportExpr(tipb.ExprType(subType))
@@ -48,13 +49,16 @@ func (c *CopClient) SupportRequestType(reqType, subType int64) bool {
 		case kv.ReqSubTypeDesc, kv.ReqSubTypeBasic:
 			return true
 		}
+	case kv.ReqTypeAnalyze:
+		return subType == kv.ReqSubTypeBasic
 	}
 	return false
 }
 
 func supportExpr(exprType tipb.ExprType) bool {
 	switch exprType {
-	case tipb.ExprType_Null, tipb.ExprType_Int64, tipb.ExprType_Uint64, tipb.ExprType_String, tipb.ExprType_Bytes,
+	case tipb.ExprType_Null, tipb.ExprType_Int64, tipb.ExprType_Uint64, tipb.ExprType_Float32,
+		tipb.ExprType_Float64, tipb.ExprType_String, tipb.ExprType_Bytes,
 		tipb.ExprType_MysqlDuration, tipb.ExprType_MysqlDecimal,
 		tipb.ExprType_ColumnRef,
 		tipb.ExprType_And, tipb.ExprType_Or,
@@ -63,7 +67,29 @@ func supportExpr(exprType tipb.ExprType) bool {
 		tipb.ExprType_In, tipb.ExprType_ValueList,
 		tipb.ExprType_Like, tipb.ExprType_Not:
 		return true
-	case tipb.ExprType_Count, tipb.ExprType_First:
+	case tipb.ExprType_Plus, tipb.ExprType_Div:
+		return true
+	case tipb.ExprType_Minus, tipb.ExprType_Mul, tipb.ExprType_IntDiv, tipb.ExprType_Mod,
+		tipb.ExprType_BitAnd, tipb.ExprType_BitOr, tipb.ExprType_BitXor, tipb.ExprType_LeftShift,
+		tipb.ExprType_RighShift, tipb.ExprType_BitNeg, tipb.ExprType_Neg, tipb.ExprType_Xor,
+		tipb.ExprType_Abs, tipb.ExprType_Pow:
+		return true
+	case tipb.ExprType_Case, tipb.ExprType_If, tipb.ExprType_NullIf, tipb.ExprType_IfNull,
+		tipb.ExprType_Coalesce, tipb.ExprType_IsNull:
+		return true
+	case tipb.ExprType_Concat, tipb.ExprType_ConcatWS, tipb.ExprType_Left, tipb.ExprType_Length,
+		tipb.ExprType_Lower, tipb.ExprType_Repeat, tipb.ExprType_Replace, tipb.ExprType_Upper,
+		tipb.ExprType_Strcmp, tipb.ExprType_Substring, tipb.ExprType_SubstringIndex, tipb.ExprType_Locate,
+		tipb.ExprType_Trim:
+		return true
+	case tipb.ExprType_Date, tipb.ExprType_Year, tipb.ExprType_YearWeek, tipb.ExprType_Month,
+		tipb.ExprType_Week, tipb.ExprType_Weekday, tipb.ExprType_WeekOfYear, tipb.ExprType_Day,
+		tipb.ExprType_DayName, tipb.ExprType_DayOfYear, tipb.ExprType_DayOfMonth, tipb.ExprType_DayOfWeek,
+		tipb.ExprType_Hour, tipb.ExprType_Minute, tipb.ExprType_Second, tipb.ExprType_Microsecond,
+		tipb.ExprType_Extract:
+		return true
+	case tipb.ExprType_Count, tipb.ExprType_First, tipb.ExprType_Sum, tipb.ExprType_Avg,
+		tipb.ExprType_Max, tipb.ExprType_Min, tipb.ExprType_GroupConcat:
 		return true
 	case kv.ReqSubTypeDesc:
 		return true
@@ -92,9 +118,10 @@ func (c *CopClient) Send(req *kv.Request) kv.Response {
 		it.concurrency = 1
 	}
 	if !it.req.KeepOrder {
-		it.respChan = make(chan *coprocessor.Response, it.concurrency)
+		it.respChan = make(chan *copResponse, it.concurrency)
 	}
 	it.errChan = make(chan error, 1)
+	it.done = make(chan struct{})
 	if len(it.tasks) == 0 {
 		it.Close()
 	}
@@ -115,7 +142,14 @@ type copTask struct {
 
 	status   int
 	idx      int // Index of task in the tasks slice.
-	respChan chan *coprocessor.Response
+	respChan chan *copResponse
+}
+
+// copResponse is a response of a copTask, scanned is the key range scanned by the response
+// if the region returns its result in chunks and more chunks are coming.
+type copResponse struct {
+	*coprocessor.Response
+	scanned *tipb.KeyRange
 }
 
 func (t *copTask) pbRanges() []*coprocessor.KeyRange {
@@ -167,7 +201,7 @@ func appendTask(tasks []*copTask, cache *RegionCache, r kv.KeyRange) ([]*copTask
 			region: region,
 			status: taskNew,
 		}
-		last.respChan = make(chan *coprocessor.Response, 1)
+		last.respChan = make(chan *copResponse, 1)
 		tasks = append(tasks, last)
 	}
 	if last.region.Contains(r.EndKey) || bytes.Equal(last.region.EndKey(), r.EndKey) {
@@ -196,9 +230,12 @@ type copIterator struct {
 	mu          sync.RWMutex
 	respGot     int
 	concurrency int
-	respChan    chan *coprocessor.Response
+	respChan    chan *copResponse
 	errChan     chan error
-	finished    bool
+	// done is closed when the iterator is closed, so the workers quit instead of blocking on sending.
+	// It is closed only once, and finished is set at the same time, both are protected by mu.
+	done     chan struct{}
+	finished bool
 }
 
 // Pick the next new copTask and send request to tikv-server.
@@ -206,7 +243,7 @@ func (it *copIterator) work() {
 	for {
 		it.mu.Lock()
 		if it.finished {
-			it.mu.Lock()
+			it.mu.Unlock()
 			break
 		}
 		// Find the next task to send.
@@ -223,17 +260,68 @@ func (it *copIterator) work() {
 		}
 		task.status = taskRunning
 		it.mu.Unlock()
-		resp, err := it.handleTask(task)
+		err := it.handleTaskChunks(task)
 		if err != nil {
-			it.errChan <- err
+			select {
+			case it.errChan <- err:
+			case <-it.done:
+			}
 			break
 		}
-		if !it.req.KeepOrder {
-			it.respChan <- resp
+	}
+}
+
+// handleTaskChunks sends the responses of the task one by one. If the region returns its result in chunks,
+// the ranges scanned by a chunk are cut off from the task, and the rest are requested for the next chunk.
+// It returns without requesting the rest chunks if the iterator is closed.
+func (it *copIterator) handleTaskChunks(task *copTask) error {
+	respChan := task.respChan
+	if !it.req.KeepOrder {
+		respChan = it.respChan
+	}
+	for {
+		resp, err := it.handleTask(task)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		scanned, err := xapi.GetScannedRange(resp.Data)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		select {
+		case respChan <- &copResponse{Response: resp, scanned: scanned}:
+		case <-it.done:
+			return nil
+		}
+		if scanned == nil {
+			return nil
+		}
+		task.ranges = resumeRanges(task.ranges, scanned, it.req.Desc)
+	}
+}
+
+// resumeRanges cuts off the key range scanned by the previous chunk from the ranges.
+func resumeRanges(ranges []kv.KeyRange, scanned *tipb.KeyRange, desc bool) []kv.KeyRange {
+	rest := make([]kv.KeyRange, 0, len(ranges))
+	for _, r := range ranges {
+		if desc {
+			if bytes.Compare(r.StartKey, scanned.GetLow()) >= 0 {
+				continue
+			}
+			if bytes.Compare(r.EndKey, scanned.GetLow()) > 0 {
+				r.EndKey = scanned.GetLow()
+			}
 		} else {
-			task.respChan <- resp
+			if bytes.Compare(r.EndKey, scanned.GetHigh()) <= 0 {
+				continue
+			}
+			if bytes.Compare(r.StartKey, scanned.GetHigh()) < 0 {
+				r.StartKey = scanned.GetHigh()
+			}
 		}
+		rest = append(rest, r)
 	}
+	return rest
 }
 
 func (it *copIterator) run() {
@@ -245,11 +333,14 @@ func (it *copIterator) run() {
 
 // Return next coprocessor result.
 func (it *copIterator) Next() (io.ReadCloser, error) {
-	if it.finished {
+	it.mu.RLock()
+	finished := it.finished
+	it.mu.RUnlock()
+	if finished {
 		return nil, nil
 	}
 	var (
-		resp *coprocessor.Response
+		resp *copResponse
 		err  error
 	)
 	// If data order matters, response should be returned in the same order as copTask slice.
@@ -278,19 +369,25 @@ func (it *copIterator) Next() (io.ReadCloser, error) {
 		case resp = <-task.respChan:
 		case err = <-it.errChan:
 		}
-		it.mu.Lock()
-		task.status = taskDone
-		it.mu.Unlock()
+		if err == nil && resp.scanned == nil {
+			it.mu.Lock()
+			task.status = taskDone
+			it.mu.Unlock()
+		}
 	}
 	if err != nil {
 		it.Close()
 		return nil, err
 	}
+	if resp.scanned != nil {
+		// More chunks of the task are coming.
+		return ioutil.NopCloser(bytes.NewBuffer(resp.Data)), nil
+	}
 	it.mu.Lock()
 	defer it.mu.Unlock()
 	it.respGot++
 	if it.respGot == len(it.tasks) {
-		it.Close()
+		it.close()
 	}
 	return ioutil.NopCloser(bytes.NewBuffer(resp.Data)), nil
 }
@@ -375,10 +472,20 @@ func (it *copIterator) rebuildCurrentTask(task *copTask) error {
 }
 
 func (it *copIterator) Close() error {
-	it.finished = true
+	it.mu.Lock()
+	it.close()
+	it.mu.Unlock()
 	return nil
 }
 
+// close closes the iterator, it.mu must be held by the caller.
+func (it *copIterator) close() {
+	if !it.finished {
+		close(it.done)
+		it.finished = true
+	}
+}
+
 // copErrorResponse returns error when calling Next()
 type copErrorResponse struct{ error }
 
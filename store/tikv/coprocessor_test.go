@@ -14,9 +14,12 @@
 package tikv
 
 import (
+	"sync"
+
 	. "github.com/pingcap/check"
 	"github.com/pingcap/tidb/kv"
 	"github.com/pingcap/tidb/store/tikv/mock-tikv"
+	"github.com/pingcap/tipb/go-tipb"
 )
 
 type testCoprocessorSuite struct{}
@@ -138,6 +141,46 @@ func (s *testCoprocessorSuite) TestRebuild(c *C) {
 	s.taskEqual(c, iter.tasks[0], regionIDs[2], "q", "z")
 }
 
+func (s *testCoprocessorSuite) TestResumeRanges(c *C) {
+	ranges := s.buildKeyRanges("a", "c", "e", "g", "i", "k")
+
+	rest := resumeRanges(ranges, &tipb.KeyRange{Low: []byte("a"), High: []byte("b")}, false)
+	s.rangesEqual(c, rest, "b", "c", "e", "g", "i", "k")
+	rest = resumeRanges(ranges, &tipb.KeyRange{Low: []byte("a"), High: []byte("c")}, false)
+	s.rangesEqual(c, rest, "e", "g", "i", "k")
+	rest = resumeRanges(ranges, &tipb.KeyRange{Low: []byte("a"), High: []byte("h")}, false)
+	s.rangesEqual(c, rest, "i", "k")
+	rest = resumeRanges(ranges, &tipb.KeyRange{Low: []byte("a"), High: []byte("k")}, false)
+	s.rangesEqual(c, rest)
+
+	rest = resumeRanges(ranges, &tipb.KeyRange{Low: []byte("j"), High: []byte("k")}, true)
+	s.rangesEqual(c, rest, "a", "c", "e", "g", "i", "j")
+	rest = resumeRanges(ranges, &tipb.KeyRange{Low: []byte("i"), High: []byte("k")}, true)
+	s.rangesEqual(c, rest, "a", "c", "e", "g")
+	rest = resumeRanges(ranges, &tipb.KeyRange{Low: []byte("d"), High: []byte("k")}, true)
+	s.rangesEqual(c, rest, "a", "c")
+	rest = resumeRanges(ranges, &tipb.KeyRange{Low: []byte("a"), High: []byte("k")}, true)
+	s.rangesEqual(c, rest)
+}
+
+func (s *testCoprocessorSuite) TestCloseIterator(c *C) {
+	it := &copIterator{done: make(chan struct{})}
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			it.Close()
+		}()
+	}
+	wg.Wait()
+	_, ok := <-it.done
+	c.Assert(ok, IsFalse)
+	resp, err := it.Next()
+	c.Assert(err, IsNil)
+	c.Assert(resp, IsNil)
+}
+
 func (s *testCoprocessorSuite) buildKeyRanges(keys ...string) []kv.KeyRange {
 	var ranges []kv.KeyRange
 	for i := 0; i < len(keys); i += 2 {
@@ -149,6 +192,14 @@ func (s *testCoprocessorSuite) buildKeyRanges(keys ...string) []kv.KeyRange {
 	return ranges
 }
 
+func (s *testCoprocessorSuite) rangesEqual(c *C, ranges []kv.KeyRange, keys ...string) {
+	c.Assert(ranges, HasLen, len(keys)/2)
+	for i, r := range ranges {
+		c.Assert(string(r.StartKey), Equals, keys[2*i])
+		c.Assert(string(r.EndKey), Equals, keys[2*i+1])
+	}
+}
+
 func (s *testCoprocessorSuite) taskEqual(c *C, task *copTask, regionID uint64, keys ...string) {
 	c.Assert(task.region.GetID(), Equals, regionID)
 	for i, r := range task.ranges {
@@ -0,0 +1,45 @@
+// Copyright 2016 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mocktikv
+
+import (
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/tablecodec"
+	"github.com/pingcap/tidb/util/codec"
+	"github.com/pingcap/tidb/xapi"
+	"github.com/pingcap/tipb/go-tipb"
+)
+
+// Update aggregate functions with rows.
+func (h *rpcHandler) aggregate(ctx *selectContext, row *tipb.Row) error {
+	// Put row data into evaluate context for later evaluation.
+	values, err := codec.Decode(row.Data)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	cols := ctx.sel.TableInfo.Columns
+	for i, col := range cols {
+		colID := col.GetColumnId()
+		if ctx.whereColumns[colID] != nil {
+			// The column is saved in evaluator already.
+			continue
+		}
+		datum, err := tablecodec.Unflatten(values[i], xapi.FieldTypeFromPBColumn(col))
+		if err != nil {
+			return errors.Trace(err)
+		}
+		ctx.eval.Row[colID] = datum
+	}
+	return errors.Trace(ctx.aggregator.Update())
+}
@@ -22,19 +22,35 @@ import (
 	"github.com/pingcap/kvproto/pkg/coprocessor"
 	"github.com/pingcap/tidb/kv"
 	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/plan/statistics"
 	"github.com/pingcap/tidb/tablecodec"
 	"github.com/pingcap/tidb/terror"
 	"github.com/pingcap/tidb/util/codec"
 	"github.com/pingcap/tidb/util/types"
 	"github.com/pingcap/tidb/xapi"
+	"github.com/pingcap/tidb/xapi/xcop"
 	"github.com/pingcap/tidb/xapi/xeval"
 	"github.com/pingcap/tipb/go-tipb"
 )
 
+// chunkSize is the max number of rows in a response if the rows of the region are returned in chunks.
+var chunkSize int64 = 1024
+
 type selectContext struct {
-	sel          *tipb.SelectRequest
-	eval         *xeval.Evaluator
+	sel  *tipb.SelectRequest
+	eval *xeval.Evaluator
+	// whereColumns are the columns used by the where condition and the order by items,
+	// they are decoded into the evaluator before the row is evaluated.
 	whereColumns map[int64]*tipb.ColumnInfo
+	aggregate    bool
+	// aggregator computes the partial results of the aggregate request.
+	aggregator *xcop.Aggregator
+	// topn is not nil if the request is a top-n request, which has the order by items and the limit.
+	topn *xcop.TopN
+	// chunkSize is not zero if the rows are returned in chunks.
+	chunkSize int64
+	// resumeKey is the key where the next chunk resumes scanning, it is advanced after each row is returned.
+	resumeKey []byte
 }
 
 func (h *rpcHandler) handleCopRequest(req *coprocessor.Request) (*coprocessor.Response, error) {
@@ -46,6 +62,10 @@ func (h *rpcHandler) handleCopRequest(req *coprocessor.Request) (*coprocessor.Re
 	if len(req.Ranges) == 0 {
 		return resp, nil
 	}
+	// Only the keys in the ranges of the request are scanned,
+	// the ranges are narrowed by the client for the following chunks of the region.
+	h.startKey = maxStartKey(req.Ranges[0].GetStart(), h.startKey)
+	h.endKey = minEndKey(req.Ranges[len(req.Ranges)-1].GetEnd(), h.endKey)
 	if req.GetTp() == kv.ReqTypeSelect || req.GetTp() == kv.ReqTypeIndex {
 		sel := new(tipb.SelectRequest)
 		err := proto.Unmarshal(req.Data, sel)
@@ -55,10 +75,28 @@ func (h *rpcHandler) handleCopRequest(req *coprocessor.Request) (*coprocessor.Re
 		ctx := &selectContext{
 			sel: sel,
 		}
-		if sel.Where != nil {
-			ctx.eval = &xeval.Evaluator{Row: make(map[int64]types.Datum)}
+		ctx.eval = &xeval.Evaluator{Row: make(map[int64]types.Datum)}
+		if xcop.IsTopNRequest(sel) {
+			ctx.topn = xcop.NewTopN(sel, ctx.eval)
+		}
+		if sel.Where != nil || ctx.topn != nil {
 			ctx.whereColumns = make(map[int64]*tipb.ColumnInfo)
 			collectColumnsInWhere(sel.Where, ctx)
+			if ctx.topn != nil {
+				for _, item := range sel.OrderBy {
+					collectColumnsInWhere(item.Expr, ctx)
+				}
+			}
+		}
+		ctx.aggregate = len(sel.Aggregates) > 0 || len(sel.GetGroupBy()) > 0
+		if ctx.aggregate {
+			ctx.aggregator = xcop.NewAggregator(sel, ctx.eval)
+		}
+		// The rows are returned in chunks if the request doesn't limit the rows, so the memory is bounded
+		// and the client can consume the rows while the region is still scanning.
+		// The aggregate and top-n requests return the result after all the rows are scanned.
+		if sel.Limit == nil && !ctx.aggregate {
+			ctx.chunkSize = chunkSize
 		}
 		var rows []*tipb.Row
 		if req.GetTp() == kv.ReqTypeSelect {
@@ -66,6 +104,37 @@ func (h *rpcHandler) handleCopRequest(req *coprocessor.Request) (*coprocessor.Re
 		} else {
 			rows, err = h.getRowsFromIndexReq(ctx)
 		}
+		var scanned *tipb.KeyRange
+		if err == nil && ctx.chunkSize > 0 && int64(len(rows)) == ctx.chunkSize {
+			scanned = h.scannedRange(ctx)
+		}
+		selResp := new(tipb.SelectResponse)
+		selResp.Error = toPBError(err)
+		selResp.Rows = rows
+		if err != nil {
+			resp.OtherError = proto.String(err.Error())
+		}
+		data, err := proto.Marshal(selResp)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if scanned != nil {
+			data, err = xapi.AppendScannedRange(data, scanned)
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+		}
+		resp.Data = data
+	} else if req.GetTp() == kv.ReqTypeAnalyze {
+		sel := new(tipb.SelectRequest)
+		err := proto.Unmarshal(req.Data, sel)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		ctx := &selectContext{
+			sel: sel,
+		}
+		rows, err := h.getSampleRows(ctx)
 		selResp := new(tipb.SelectResponse)
 		selResp.Error = toPBError(err)
 		selResp.Rows = rows
@@ -129,8 +198,11 @@ func (h *rpcHandler) getRowsFromSelectReq(ctx *selectContext) ([]*tipb.Row, erro
 	kvRanges, desc := h.extractKVRanges(ctx.sel)
 	var rows []*tipb.Row
 	limit := int64(-1)
-	if ctx.sel.Limit != nil {
+	// The limit of the top-n request is applied to the sorted rows.
+	if ctx.sel.Limit != nil && ctx.topn == nil {
 		limit = ctx.sel.GetLimit()
+	} else if ctx.chunkSize > 0 {
+		limit = ctx.chunkSize
 	}
 	for _, ran := range kvRanges {
 		if limit == 0 {
@@ -143,9 +215,35 @@ func (h *rpcHandler) getRowsFromSelectReq(ctx *selectContext) ([]*tipb.Row, erro
 		rows = append(rows, ranRows...)
 		limit -= int64(len(ranRows))
 	}
+	if ctx.aggregate {
+		return ctx.aggregator.Rows()
+	}
+	if ctx.topn != nil {
+		return ctx.topn.SortedRows(), nil
+	}
 	return rows, nil
 }
 
+// getSampleRows samples the rows in the region, the limit of the request is the max sample size.
+// The rows returned are converted from a statistics.SampleCollector.
+func (h *rpcHandler) getSampleRows(ctx *selectContext) ([]*tipb.Row, error) {
+	collector := statistics.NewSampleCollector(ctx.sel.GetLimit(), len(ctx.sel.TableInfo.Columns))
+	kvRanges, _ := h.extractKVRanges(ctx.sel)
+	for _, ran := range kvRanges {
+		ranRows, err := h.getRowsFromRange(ctx, ran, -1, false)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		for _, row := range ranRows {
+			err = collector.Collect(row)
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+		}
+	}
+	return collector.ToRows()
+}
+
 // extractKVRanges extracts kv.KeyRanges slice from a SelectRequest, and also returns if it is in descending order.
 func (h *rpcHandler) extractKVRanges(sel *tipb.SelectRequest) (kvRanges []kv.KeyRange, desc bool) {
 	var (
@@ -181,8 +279,10 @@ func (h *rpcHandler) extractKVRanges(sel *tipb.SelectRequest) (kvRanges []kv.Key
 		kvr.EndKey = kv.Key(minEndKey(upperKey, h.endKey))
 		kvRanges = append(kvRanges, kvr)
 	}
-	if sel.OrderBy != nil {
-		desc = *sel.OrderBy[0].Desc
+	// The order by item without expression means the rows are scanned in the handle order,
+	// otherwise the rows are sorted by the top-n.
+	if len(sel.OrderBy) > 0 && sel.OrderBy[0].Expr == nil {
+		desc = sel.OrderBy[0].GetDesc()
 	}
 	if desc {
 		reverseKVRanges(kvRanges)
@@ -190,6 +290,16 @@ func (h *rpcHandler) extractKVRanges(sel *tipb.SelectRequest) (kvRanges []kv.Key
 	return
 }
 
+// scannedRange returns the key range scanned by the chunk, the client requests the next chunk
+// from the end of the range, or the start if in descending order.
+func (h *rpcHandler) scannedRange(ctx *selectContext) *tipb.KeyRange {
+	orderBy := ctx.sel.OrderBy
+	if len(orderBy) > 0 && orderBy[0].Expr == nil && orderBy[0].GetDesc() {
+		return &tipb.KeyRange{Low: ctx.resumeKey, High: h.endKey}
+	}
+	return &tipb.KeyRange{Low: h.startKey, High: ctx.resumeKey}
+}
+
 func reverseKVRanges(kvRanges []kv.KeyRange) {
 	for i := 0; i < len(kvRanges)/2; i++ {
 		j := len(kvRanges) - i - 1
@@ -228,6 +338,11 @@ func (h *rpcHandler) getRowsFromRange(ctx *selectContext, ran kv.KeyRange, limit
 		}
 		if row != nil {
 			rows = append(rows, row)
+			if desc {
+				ctx.resumeKey = startKey
+			} else {
+				ctx.resumeKey = []byte(kv.Key(startKey).PrefixNext())
+			}
 		}
 		return rows, nil
 	}
@@ -290,6 +405,7 @@ func (h *rpcHandler) getRowsFromRange(ctx *selectContext, ran kv.KeyRange, limit
 		if row != nil {
 			rows = append(rows, row)
 			limit--
+			ctx.resumeKey = seekKey
 		}
 	}
 	return rows, nil
@@ -342,11 +458,23 @@ func (h *rpcHandler) getRowByHandle(ctx *selectContext, handle int64) (*tipb.Row
 			}
 		}
 	}
+	if ctx.aggregate {
+		// Update aggregate functions, the partial results are returned instead of the row data.
+		err = h.aggregate(ctx, row)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		row.Data = nil
+	} else if ctx.topn != nil {
+		// The row is kept by the top-n heap, and returned after all the rows are scanned.
+		err = ctx.topn.Add(row)
+		return nil, errors.Trace(err)
+	}
 	return row, nil
 }
 
 func (h *rpcHandler) evalWhereForRow(ctx *selectContext, handle int64) (bool, error) {
-	if ctx.sel.Where == nil {
+	if len(ctx.whereColumns) == 0 && ctx.sel.Where == nil {
 		return true, nil
 	}
 	tid := ctx.sel.TableInfo.GetTableId()
@@ -379,6 +507,9 @@ func (h *rpcHandler) evalWhereForRow(ctx *selectContext, handle int64) (bool, er
 			}
 		}
 	}
+	if ctx.sel.Where == nil {
+		return true, nil
+	}
 	result, err := ctx.eval.Eval(ctx.sel.Where)
 	if err != nil {
 		return false, errors.Trace(err)
@@ -399,12 +530,14 @@ func (h *rpcHandler) getRowsFromIndexReq(ctx *selectContext) ([]*tipb.Row, error
 	limit := int64(-1)
 	if ctx.sel.Limit != nil {
 		limit = ctx.sel.GetLimit()
+	} else if ctx.chunkSize > 0 {
+		limit = ctx.chunkSize
 	}
 	for _, ran := range kvRanges {
 		if limit == 0 {
 			break
 		}
-		ranRows, err := h.getIndexRowFromRange(ctx.sel, ran, desc, limit)
+		ranRows, err := h.getIndexRowFromRange(ctx, ran, desc, limit)
 		if err != nil {
 			return nil, errors.Trace(err)
 		}
@@ -414,7 +547,8 @@ func (h *rpcHandler) getRowsFromIndexReq(ctx *selectContext) ([]*tipb.Row, error
 	return rows, nil
 }
 
-func (h *rpcHandler) getIndexRowFromRange(sel *tipb.SelectRequest, ran kv.KeyRange, desc bool, limit int64) ([]*tipb.Row, error) {
+func (h *rpcHandler) getIndexRowFromRange(ctx *selectContext, ran kv.KeyRange, desc bool, limit int64) ([]*tipb.Row, error) {
+	sel := ctx.sel
 	startKey := maxStartKey(ran.StartKey, h.startKey)
 	endKey := minEndKey(ran.EndKey, h.endKey)
 	if limit == 0 || bytes.Compare(startKey, endKey) >= 0 {
@@ -491,6 +625,7 @@ func (h *rpcHandler) getIndexRowFromRange(sel *tipb.SelectRequest, ran kv.KeyRan
 		row := &tipb.Row{Handle: handleData, Data: data}
 		rows = append(rows, row)
 		limit--
+		ctx.resumeKey = seekKey
 	}
 	return rows, nil
 }
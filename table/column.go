@@ -113,6 +113,25 @@ func CastValue(ctx context.Context, val types.Datum, col *Column) (casted types.
 	return casted, nil
 }
 
+// ConvertChangingValue converts a value of the column being modified to col, the column whose values are
+// converted from it. Unlike CastValue, the value is never truncated, an error is returned if it isn't valid for col.
+func ConvertChangingValue(val types.Datum, col *model.ColumnInfo) (types.Datum, error) {
+	if val.IsNull() {
+		if mysql.HasNotNullFlag(col.Flag) {
+			return val, errColumnCantNull.Gen("Column %s can't be null.", col.Name)
+		}
+		return val, nil
+	}
+	casted, err := val.ConvertTo(&col.FieldType)
+	if err != nil {
+		return casted, errors.Trace(err)
+	}
+	if (types.IsTypeChar(col.Tp) || types.IsTypeBlob(col.Tp)) && casted.GetString() != val.GetString() {
+		return casted, ErrDataTooLong.Gen("Data too long for column %s", col.Name)
+	}
+	return casted, nil
+}
+
 // ColDesc describes column information like MySQL desc and show columns do.
 type ColDesc struct {
 	Field        string
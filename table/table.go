@@ -53,6 +53,8 @@ var (
 	ErrIndexStateCantNone = terror.ClassTable.New(codeIndexStateCantNone, "index can not be in none state")
 	// ErrInvalidRecordKey returns for invalid record key.
 	ErrInvalidRecordKey = terror.ClassTable.New(codeInvalidRecordKey, "invalid record key")
+	// ErrDataTooLong returns for the string value which is too long for the column.
+	ErrDataTooLong = terror.ClassTable.New(codeDataTooLong, "data too long")
 )
 
 // RecordIterFunc is used for low-level record iteration.
@@ -140,6 +142,7 @@ const (
 	codeUnknownColumn   = 1054
 	codeDuplicateColumn = 1110
 	codeNoDefaultValue  = 1364
+	codeDataTooLong     = 1406
 )
 
 func init() {
@@ -148,6 +151,7 @@ func init() {
 		codeUnknownColumn:   mysql.ErrBadField,
 		codeDuplicateColumn: mysql.ErrFieldSpecifiedTwice,
 		codeNoDefaultValue:  mysql.ErrNoDefaultForField,
+		codeDataTooLong:     mysql.ErrDataTooLong,
 	}
 	terror.ErrClassToMySQLCodes[terror.ClassTable] = tableMySQLErrCodes
 }
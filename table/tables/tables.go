@@ -217,6 +217,7 @@ func (t *Table) UpdateRecord(ctx context.Context, h int64, oldData []types.Datum
 		return errors.Trace(err)
 	}
 
+	updateTableDelta(ctx, t.ID)
 	return nil
 }
 
@@ -247,6 +248,34 @@ func (t *Table) setNewData(rm kv.RetrieverMutator, h int64, touched map[int]bool
 		}
 	}
 
+	// The columns being modified are written with the converted values.
+	for _, col := range t.writableCols() {
+		if col.ChangeFrom == 0 {
+			continue
+		}
+		from := t.colByID(col.ChangeFrom)
+		if from == nil || !touched[from.Offset] {
+			continue
+		}
+		value, err := table.ConvertChangingValue(data[from.Offset], &col.ColumnInfo)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if err = SetColValue(rm, t.RecordKey(h, col), value); err != nil {
+			return errors.Trace(err)
+		}
+	}
+
+	return nil
+}
+
+// colByID returns the column of the ID in all the columns, including the non-public columns.
+func (t *Table) colByID(id int64) *table.Column {
+	for _, col := range t.Columns {
+		if col.ID == id {
+			return col
+		}
+	}
 	return nil
 }
 
@@ -320,6 +349,14 @@ func (t *Table) AddRecord(ctx context.Context, r []types.Datum) (recordID int64,
 		if col.IsPKHandleColumn(t.meta) {
 			continue
 		}
+		if col.ChangeFrom != 0 {
+			// The column being modified is written with the converted value.
+			err = t.addChangingColValue(txn, recordID, col, r)
+			if err != nil {
+				return 0, errors.Trace(err)
+			}
+			continue
+		}
 		if col.DefaultValue == nil && r[col.Offset].IsNull() {
 			// Save storage space by not storing null value.
 			continue
@@ -350,9 +387,25 @@ func (t *Table) AddRecord(ctx context.Context, r []types.Datum) (recordID int64,
 	}
 
 	variable.GetSessionVars(ctx).AddAffectedRows(1)
+	updateTableDelta(ctx, t.ID)
 	return recordID, nil
 }
 
+func (t *Table) addChangingColValue(txn kv.Transaction, h int64, col *table.Column, r []types.Datum) error {
+	from := t.colByID(col.ChangeFrom)
+	if from == nil {
+		return nil
+	}
+	value, err := table.ConvertChangingValue(r[from.Offset], &col.ColumnInfo)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if col.DefaultValue == nil && value.IsNull() {
+		return nil
+	}
+	return errors.Trace(SetColValue(txn, t.RecordKey(h, col), value))
+}
+
 // Generate index content string representation.
 func (t *Table) genIndexKeyStr(colVals []types.Datum) (string, error) {
 	// Pass pre-composed error to txn.
@@ -507,9 +560,18 @@ func (t *Table) RemoveRecord(ctx context.Context, h int64, r []types.Datum) erro
 		return errors.Trace(err)
 	}
 
+	updateTableDelta(ctx, t.ID)
 	return nil
 }
 
+// updateTableDelta counts a modified row of the table in the session, the count is used to
+// decide when the statistics of the table should be rebuilt.
+func updateTableDelta(ctx context.Context, tableID int64) {
+	if vars := variable.GetSessionVars(ctx); vars != nil {
+		vars.UpdateTableDelta(tableID, 1)
+	}
+}
+
 func (t *Table) removeRowData(ctx context.Context, h int64) error {
 	if err := t.LockRow(ctx, h, false); err != nil {
 		return errors.Trace(err)
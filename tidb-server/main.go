@@ -40,6 +40,8 @@ var (
 	statusPort = flag.String("status", "10080", "tidb server status port")
 	lease      = flag.Int("lease", 1, "schema lease seconds, very dangerous to change only if you know what you do")
 	socket     = flag.String("socket", "", "The socket file to use for connection.")
+	analyze    = flag.Float64("auto-analyze-ratio", 0.5, "analyze a table in the background when the ratio of its modified rows exceeds the value, 0 to disable")
+	spillDir   = flag.String("spill-dir", os.TempDir(), "directory of the temporary files written by the queries which exceed tidb_mem_quota_query")
 )
 
 func main() {
@@ -57,6 +59,8 @@ func main() {
 	}
 
 	tidb.SetSchemaLease(time.Duration(*lease) * time.Second)
+	tidb.SetAutoAnalyzeRatio(*analyze)
+	tidb.SetSpillDir(*spillDir)
 
 	cfg := &server.Config{
 		Addr:       fmt.Sprintf(":%s", *port),
@@ -18,6 +18,7 @@
 package tidb
 
 import (
+	"fmt"
 	"net/http"
 	"time"
 	// For pprof
@@ -31,11 +32,14 @@ import (
 	"github.com/ngaut/log"
 	"github.com/pingcap/tidb/ast"
 	"github.com/pingcap/tidb/context"
+	"github.com/pingcap/tidb/ddl"
 	"github.com/pingcap/tidb/domain"
 	"github.com/pingcap/tidb/executor"
+	"github.com/pingcap/tidb/infoschema"
 	"github.com/pingcap/tidb/kv"
 	"github.com/pingcap/tidb/metric"
 	"github.com/pingcap/tidb/parser"
+	"github.com/pingcap/tidb/sessionctx"
 	"github.com/pingcap/tidb/sessionctx/autocommit"
 	"github.com/pingcap/tidb/sessionctx/variable"
 	"github.com/pingcap/tidb/store/localstore"
@@ -96,6 +100,15 @@ var (
 	// but you must know that too little may cause badly performance degradation.
 	// For production, you should set a big schema lease, like 300s+.
 	schemaLease = 1 * time.Second
+
+	// autoAnalyzeRatio is the ratio of modified rows to the row count of an analyzed table,
+	// once it is exceeded, the table is analyzed again in the background.
+	// If it is not greater than 0, the statistics are only built by ANALYZE TABLE.
+	autoAnalyzeRatio = 0.5
+
+	// autoAnalyzeMinCount is the count of modified rows of a table without statistics,
+	// once it is reached, the table is analyzed for the first time in the background.
+	autoAnalyzeMinCount int64 = 1000
 )
 
 // SetSchemaLease changes the default schema lease time for DDL.
@@ -105,6 +118,43 @@ func SetSchemaLease(lease time.Duration) {
 	schemaLease = lease
 }
 
+// SetAutoAnalyzeRatio changes the ratio of modified rows to the row count of a table
+// that triggers a background analyze job, a non-positive ratio disables it.
+func SetAutoAnalyzeRatio(ratio float64) {
+	autoAnalyzeRatio = ratio
+}
+
+// SetSpillDir changes the directory of the temporary files written by the queries which exceed the memory quota.
+func SetSpillDir(dir string) {
+	executor.SpillDir = dir
+}
+
+// analyzeTable runs ANALYZE TABLE in a new session for the background analyze job.
+func analyzeTable(store kv.Storage, schemaID, tableID int64) error {
+	se, err := CreateSession(store)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer se.Close()
+
+	is := sessionctx.GetDomain(se.(context.Context)).InfoSchema()
+	di, ok := is.SchemaByID(schemaID)
+	if !ok {
+		return errors.Trace(infoschema.ErrDatabaseNotExists)
+	}
+	tbl, ok := is.TableByID(tableID)
+	if !ok {
+		return errors.Trace(infoschema.ErrTableNotExists)
+	}
+	_, err = se.Execute(fmt.Sprintf("analyze table `%s`.`%s`", escapeName(di.Name.O), escapeName(tbl.Meta().Name.O)))
+	return errors.Trace(err)
+}
+
+// escapeName escapes the backquotes in a name which is quoted by backquotes in a SQL statement.
+func escapeName(name string) string {
+	return strings.Replace(name, "`", "``", -1)
+}
+
 // What character set should the server translate a statement to after receiving it?
 // For this, the server uses the character_set_connection and collation_connection system variables.
 // It converts statements sent by the client from character_set_client to character_set_connection
@@ -295,4 +345,6 @@ func init() {
 
 	// Init metrics
 	tpsMetrics = metric.NewTPSMetrics()
+
+	ddl.RegisterAnalyzeTableFunc(analyzeTable)
 }
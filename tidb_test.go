@@ -27,7 +27,10 @@ import (
 	"github.com/ngaut/log"
 	. "github.com/pingcap/check"
 	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/context"
 	"github.com/pingcap/tidb/kv"
+	"github.com/pingcap/tidb/model"
+	"github.com/pingcap/tidb/sessionctx"
 	"github.com/pingcap/tidb/util/testleak"
 	"github.com/pingcap/tidb/util/types"
 )
@@ -192,6 +195,23 @@ func (s *testMainSuite) TestCaseInsensitive(c *C) {
 	c.Assert(err, IsNil)
 }
 
+func (s *testMainSuite) TestAnalyzeTableWithQuotedName(c *C) {
+	store := newStore(c, s.dbName)
+	se := newSession(c, store, s.dbName)
+	defer store.Close()
+	mustExecSQL(c, se, "create table `a``b` (a int, index (a))")
+	mustExecSQL(c, se, "insert `a``b` values (1), (2)")
+
+	is := sessionctx.GetDomain(se.(context.Context)).InfoSchema()
+	di, ok := is.SchemaByName(model.NewCIStr(s.dbName))
+	c.Assert(ok, IsTrue)
+	tbl, err := is.TableByName(model.NewCIStr(s.dbName), model.NewCIStr("a`b"))
+	c.Assert(err, IsNil)
+	err = analyzeTable(store, di.ID, tbl.Meta().ID)
+	c.Assert(err, IsNil)
+	mustExecSQL(c, se, s.dropDBSQL)
+}
+
 // Testcase for delete panic
 func (s *testMainSuite) TestDeletePanic(c *C) {
 	store := newStore(c, s.dbName)
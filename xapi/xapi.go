@@ -132,9 +132,10 @@ func (r *SubResult) Close() error {
 }
 
 // Select do a select request, returns SelectResult.
-func Select(client kv.Client, req *tipb.SelectRequest, concurrency int) (*SelectResult, error) {
+// If keepOrder is false, the subsets of the result may be returned out of the order of the ranges.
+func Select(client kv.Client, req *tipb.SelectRequest, concurrency int, keepOrder bool) (*SelectResult, error) {
 	// Convert tipb.*Request to kv.Request
-	kvReq, err := composeRequest(req, concurrency)
+	kvReq, err := composeRequest(req, concurrency, keepOrder)
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
@@ -158,10 +159,10 @@ func Select(client kv.Client, req *tipb.SelectRequest, concurrency int) (*Select
 }
 
 // Convert tipb.Request to kv.Request.
-func composeRequest(req *tipb.SelectRequest, concurrency int) (*kv.Request, error) {
+func composeRequest(req *tipb.SelectRequest, concurrency int, keepOrder bool) (*kv.Request, error) {
 	kvReq := &kv.Request{
 		Concurrency: concurrency,
-		KeepOrder:   true,
+		KeepOrder:   keepOrder,
 	}
 	if req.IndexInfo != nil {
 		kvReq.Tp = kv.ReqTypeIndex
@@ -173,8 +174,9 @@ func composeRequest(req *tipb.SelectRequest, concurrency int) (*kv.Request, erro
 		tid := req.GetTableInfo().GetTableId()
 		kvReq.KeyRanges = EncodeTableRanges(tid, req.Ranges)
 	}
-	if req.OrderBy != nil {
-		kvReq.Desc = *req.OrderBy[0].Desc
+	// The order by item without expression means the rows are scanned in the handle order.
+	if len(req.OrderBy) > 0 && req.OrderBy[0].Expr == nil {
+		kvReq.Desc = req.OrderBy[0].GetDesc()
 	}
 	var err error
 	kvReq.Data, err = proto.Marshal(req)
@@ -304,3 +306,62 @@ func EncodeIndexRanges(tid, idxID int64, rans []*tipb.KeyRange) []kv.KeyRange {
 	}
 	return keyRanges
 }
+
+// scannedRangeField is the field number of the key range scanned by a chunk of a SelectResponse.
+// tipb.SelectResponse doesn't define the field, it is appended to the marshaled response,
+// so the decoders which don't know it keep it in XXX_unrecognized.
+const scannedRangeField = 100
+
+// AppendScannedRange appends the key range scanned by a chunk to the marshaled SelectResponse data,
+// the client requests the next chunk of the region with the rest of the ranges.
+func AppendScannedRange(data []byte, r *tipb.KeyRange) ([]byte, error) {
+	rangeData, err := proto.Marshal(r)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	data = append(data, proto.EncodeVarint(uint64(scannedRangeField<<3|proto.WireBytes))...)
+	data = append(data, proto.EncodeVarint(uint64(len(rangeData)))...)
+	return append(data, rangeData...), nil
+}
+
+// GetScannedRange gets the key range scanned by a chunk from the marshaled SelectResponse data.
+// It returns nil if the response is the last chunk of the region or the region is not returned in chunks.
+func GetScannedRange(data []byte) (*tipb.KeyRange, error) {
+	// Only the top level fields are decoded, the rows are skipped.
+	for len(data) > 0 {
+		tag, n := proto.DecodeVarint(data)
+		if n == 0 {
+			return nil, errors.Trace(errInvalidResp)
+		}
+		data = data[n:]
+		switch tag & 7 {
+		case proto.WireVarint:
+			_, n = proto.DecodeVarint(data)
+			if n == 0 {
+				return nil, errors.Trace(errInvalidResp)
+			}
+		case proto.WireFixed64:
+			n = 8
+		case proto.WireFixed32:
+			n = 4
+		case proto.WireBytes:
+			l, m := proto.DecodeVarint(data)
+			if m == 0 || m+int(l) > len(data) {
+				return nil, errors.Trace(errInvalidResp)
+			}
+			n = m + int(l)
+			if tag>>3 == scannedRangeField {
+				r := new(tipb.KeyRange)
+				err := proto.Unmarshal(data[m:n], r)
+				return r, errors.Trace(err)
+			}
+		default:
+			return nil, errors.Trace(errInvalidResp)
+		}
+		if n > len(data) {
+			return nil, errors.Trace(errInvalidResp)
+		}
+		data = data[n:]
+	}
+	return nil, nil
+}
@@ -16,11 +16,13 @@ package xapi
 import (
 	"testing"
 
+	"github.com/golang/protobuf/proto"
 	. "github.com/pingcap/check"
 	"github.com/pingcap/tidb/model"
 	"github.com/pingcap/tidb/mysql"
 	"github.com/pingcap/tidb/util/testleak"
 	"github.com/pingcap/tidb/util/types"
+	"github.com/pingcap/tipb/go-tipb"
 )
 
 func TestT(t *testing.T) {
@@ -43,3 +45,31 @@ func (s *testTableCodecSuite) TestColumnToProto(c *C) {
 	pc := columnToProto(col)
 	c.Assert(pc.GetFlag(), Equals, int32(10))
 }
+
+func (s *testTableCodecSuite) TestScannedRange(c *C) {
+	defer testleak.AfterTest(c)()
+	selResp := &tipb.SelectResponse{
+		Rows: []*tipb.Row{{Handle: []byte("h1"), Data: []byte("d1")}, {Handle: []byte("h2")}},
+	}
+	data, err := proto.Marshal(selResp)
+	c.Assert(err, IsNil)
+	r, err := GetScannedRange(data)
+	c.Assert(err, IsNil)
+	c.Assert(r, IsNil)
+
+	data, err = AppendScannedRange(data, &tipb.KeyRange{Low: []byte("a"), High: []byte("b")})
+	c.Assert(err, IsNil)
+	r, err = GetScannedRange(data)
+	c.Assert(err, IsNil)
+	c.Assert(r.GetLow(), BytesEquals, []byte("a"))
+	c.Assert(r.GetHigh(), BytesEquals, []byte("b"))
+
+	// The scanned range doesn't change the rows of the response.
+	decoded := new(tipb.SelectResponse)
+	c.Assert(proto.Unmarshal(data, decoded), IsNil)
+	c.Assert(decoded.Rows, HasLen, 2)
+	c.Assert(decoded.Rows[0].Data, BytesEquals, []byte("d1"))
+
+	_, err = GetScannedRange(data[:len(data)-1])
+	c.Assert(err, NotNil)
+}
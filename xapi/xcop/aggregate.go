@@ -0,0 +1,311 @@
+// Copyright 2016 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package xcop implements the operators executed by the coprocessor, which are shared by the
+// local store and the mock tikv.
+package xcop
+
+import (
+	"bytes"
+
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/tablecodec"
+	"github.com/pingcap/tidb/util/codec"
+	"github.com/pingcap/tidb/util/types"
+	"github.com/pingcap/tidb/xapi/xeval"
+	"github.com/pingcap/tipb/go-tipb"
+)
+
+var singleGroup = []byte("SingleGroup")
+
+// Aggregator computes the partial results of the aggregate functions in a SelectRequest for every group.
+type Aggregator struct {
+	eval       *xeval.Evaluator
+	groupBy    []*tipb.ByItem
+	aggregates []*aggregateFuncExpr
+	groups     map[string]bool
+	groupKeys  [][]byte
+}
+
+// NewAggregator creates an Aggregator for the aggregate request, the rows are evaluated by eval.
+func NewAggregator(sel *tipb.SelectRequest, eval *xeval.Evaluator) *Aggregator {
+	a := &Aggregator{
+		eval:       eval,
+		groupBy:    sel.GetGroupBy(),
+		aggregates: make([]*aggregateFuncExpr, 0, len(sel.Aggregates)),
+		groups:     make(map[string]bool),
+	}
+	for _, agg := range sel.Aggregates {
+		a.aggregates = append(a.aggregates, &aggregateFuncExpr{expr: agg})
+	}
+	return a
+}
+
+func (a *Aggregator) getGroupKey() ([]byte, error) {
+	if len(a.groupBy) == 0 {
+		return singleGroup, nil
+	}
+	vals := make([]types.Datum, 0, len(a.groupBy))
+	for _, item := range a.groupBy {
+		v, err := a.eval.Eval(item.Expr)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		vals = append(vals, v)
+	}
+	bs, err := codec.EncodeValue(nil, vals...)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return bs, nil
+}
+
+// Update updates the aggregate functions with the row, whose column values are put into the evaluator already.
+func (a *Aggregator) Update() error {
+	// Get group key.
+	gk, err := a.getGroupKey()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if _, ok := a.groups[string(gk)]; !ok {
+		a.groups[string(gk)] = true
+		a.groupKeys = append(a.groupKeys, gk)
+	}
+	// Update aggregate funcs.
+	for _, agg := range a.aggregates {
+		agg.currentGroup = gk
+		args := make([]types.Datum, 0, len(agg.expr.Children))
+		// Evaluate arguments.
+		for _, x := range agg.expr.Children {
+			cv, err := a.eval.Eval(x)
+			if err != nil {
+				return errors.Trace(err)
+			}
+			args = append(args, cv)
+		}
+		err = agg.update(args)
+		if err != nil {
+			return errors.Trace(err)
+		}
+	}
+	return nil
+}
+
+/*
+ * Rows converts the aggregate partial results to rows.
+ * Data layout example:
+ *	SQL:	select count(c1), sum(c2), avg(c3) from t;
+ *	Aggs:	count(c1), sum(c2), avg(c3)
+ *	Rows:	groupKey1, count1, value2, count3, value3
+ *		groupKey2, count1, value2, count3, value3
+ */
+func (a *Aggregator) Rows() ([]*tipb.Row, error) {
+	rows := make([]*tipb.Row, 0, len(a.groupKeys))
+	for _, gk := range a.groupKeys {
+		row := new(tipb.Row)
+		// Each aggregate partial result will be converted to one or two datums.
+		rowData := make([]types.Datum, 0, 1+2*len(a.aggregates))
+		// The first column is group key.
+		rowData = append(rowData, types.NewBytesDatum(gk))
+		for _, agg := range a.aggregates {
+			agg.currentGroup = gk
+			rowData = append(rowData, agg.toDatums()...)
+		}
+		// The partial results are flattened like the column values, so they can be decoded by the field types.
+		for _, d := range rowData {
+			data, err := tablecodec.EncodeValue(d)
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+			row.Data = append(row.Data, data...)
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// Partial result for a single aggregate group.
+type aggItem struct {
+	// Number of rows, this could be used in cout/avg
+	count uint64
+	// This could be used to store sum/max/min
+	value  types.Datum
+	buffer *bytes.Buffer // Buffer is used for group_concat.
+	// Used by FirstRow, Max and Min aggregate functions.
+	evaluated bool
+}
+
+// This is similar to ast.AggregateFuncExpr but use tipb.Expr.
+type aggregateFuncExpr struct {
+	expr         *tipb.Expr
+	currentGroup []byte
+	// contextPerGroupMap is used to store aggregate evaluation context.
+	// Each entry for a group.
+	contextPerGroupMap map[string](*aggItem)
+}
+
+// Update is used for update aggregate context.
+func (n *aggregateFuncExpr) update(args []types.Datum) error {
+	switch n.expr.GetTp() {
+	case tipb.ExprType_Count:
+		return n.updateCount(args)
+	case tipb.ExprType_First:
+		return n.updateFirst(args)
+	case tipb.ExprType_Sum, tipb.ExprType_Avg:
+		return n.updateSum(args)
+	case tipb.ExprType_Max:
+		return n.updateMaxMin(args, true)
+	case tipb.ExprType_Min:
+		return n.updateMaxMin(args, false)
+	case tipb.ExprType_GroupConcat:
+		return n.updateGroupConcat(args)
+	}
+	return errors.Errorf("Unknown AggExpr: %v", n.expr.GetTp())
+}
+
+func (n *aggregateFuncExpr) toDatums() []types.Datum {
+	switch n.expr.GetTp() {
+	case tipb.ExprType_Count:
+		return n.getCountDatum()
+	case tipb.ExprType_First, tipb.ExprType_Sum, tipb.ExprType_Max, tipb.ExprType_Min:
+		return n.getValueDatum()
+	case tipb.ExprType_Avg:
+		return append(n.getCountDatum(), n.getValueDatum()...)
+	case tipb.ExprType_GroupConcat:
+		return n.getGroupConcatDatum()
+	}
+	return nil
+}
+
+// Convert count to datum list.
+func (n *aggregateFuncExpr) getCountDatum() []types.Datum {
+	item := n.getAggItem()
+	return []types.Datum{types.NewUintDatum(item.count)}
+}
+
+// Convert value to datum list.
+func (n *aggregateFuncExpr) getValueDatum() []types.Datum {
+	item := n.getAggItem()
+	return []types.Datum{item.value}
+}
+
+// Convert group_concat buffer to datum list.
+func (n *aggregateFuncExpr) getGroupConcatDatum() []types.Datum {
+	item := n.getAggItem()
+	if item.buffer == nil {
+		return []types.Datum{{}}
+	}
+	return []types.Datum{types.NewStringDatum(item.buffer.String())}
+}
+
+// getAggItem gets aggregate evaluation context for the current group.
+// If it is nil, add a new context into contextPerGroupMap.
+func (n *aggregateFuncExpr) getAggItem() *aggItem {
+	if n.currentGroup == nil {
+		n.currentGroup = singleGroup
+	}
+	if n.contextPerGroupMap == nil {
+		n.contextPerGroupMap = make(map[string](*aggItem))
+	}
+	if _, ok := n.contextPerGroupMap[string(n.currentGroup)]; !ok {
+		n.contextPerGroupMap[string(n.currentGroup)] = &aggItem{}
+	}
+	return n.contextPerGroupMap[string(n.currentGroup)]
+}
+
+func (n *aggregateFuncExpr) updateCount(args []types.Datum) error {
+	for _, a := range args {
+		if a.IsNull() {
+			return nil
+		}
+	}
+	aggItem := n.getAggItem()
+	aggItem.count++
+	return nil
+}
+
+func (n *aggregateFuncExpr) updateFirst(args []types.Datum) error {
+	aggItem := n.getAggItem()
+	if aggItem.evaluated {
+		return nil
+	}
+	if len(args) != 1 {
+		return errors.New("Wrong number of args for AggFuncFirstRow")
+	}
+	aggItem.value = args[0]
+	aggItem.evaluated = true
+	return nil
+}
+
+func (n *aggregateFuncExpr) updateSum(args []types.Datum) error {
+	if len(args) != 1 {
+		return errors.New("Wrong number of args for AggFuncSum")
+	}
+	if args[0].IsNull() {
+		return nil
+	}
+	aggItem := n.getAggItem()
+	sum, err := types.CalculateSum(aggItem.value.GetValue(), args[0].GetValue())
+	if err != nil {
+		return errors.Trace(err)
+	}
+	aggItem.value = types.NewDatum(sum)
+	aggItem.count++
+	return nil
+}
+
+func (n *aggregateFuncExpr) updateMaxMin(args []types.Datum, max bool) error {
+	if len(args) != 1 {
+		return errors.New("Wrong number of args for AggFuncMaxMin")
+	}
+	if args[0].IsNull() {
+		return nil
+	}
+	aggItem := n.getAggItem()
+	if !aggItem.evaluated {
+		aggItem.value = args[0]
+		aggItem.evaluated = true
+		return nil
+	}
+	c, err := aggItem.value.CompareDatum(args[0])
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if (max && c < 0) || (!max && c > 0) {
+		aggItem.value = args[0]
+	}
+	return nil
+}
+
+func (n *aggregateFuncExpr) updateGroupConcat(args []types.Datum) error {
+	for _, a := range args {
+		if a.IsNull() {
+			return nil
+		}
+	}
+	aggItem := n.getAggItem()
+	if aggItem.buffer == nil {
+		aggItem.buffer = &bytes.Buffer{}
+	} else {
+		// now use comma separator
+		aggItem.buffer.WriteString(",")
+	}
+	for _, a := range args {
+		s, err := a.ToString()
+		if err != nil {
+			return errors.Trace(err)
+		}
+		aggItem.buffer.WriteString(s)
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+// Copyright 2016 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xcop
+
+import (
+	"testing"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/tidb/util/codec"
+	"github.com/pingcap/tidb/util/types"
+	"github.com/pingcap/tidb/xapi/xeval"
+	"github.com/pingcap/tipb/go-tipb"
+)
+
+func TestT(t *testing.T) {
+	TestingT(t)
+}
+
+var _ = Suite(&testXCopSuite{})
+
+type testXCopSuite struct{}
+
+func columnExpr(columnID int64) *tipb.Expr {
+	return &tipb.Expr{Tp: tipb.ExprType_ColumnRef.Enum(), Val: codec.EncodeInt(nil, columnID)}
+}
+
+func (s *testXCopSuite) TestAggregator(c *C) {
+	// select c1, count(c2), max(c2) from t group by c1
+	sel := &tipb.SelectRequest{
+		GroupBy: []*tipb.ByItem{{Expr: columnExpr(1)}},
+		Aggregates: []*tipb.Expr{
+			{Tp: tipb.ExprType_Count.Enum(), Children: []*tipb.Expr{columnExpr(2)}},
+			{Tp: tipb.ExprType_Max.Enum(), Children: []*tipb.Expr{columnExpr(2)}},
+		},
+	}
+	eval := &xeval.Evaluator{Row: make(map[int64]types.Datum)}
+	a := NewAggregator(sel, eval)
+	for _, row := range [][]int64{{1, 10}, {2, 20}, {1, 30}} {
+		eval.Row[1] = types.NewIntDatum(row[0])
+		eval.Row[2] = types.NewIntDatum(row[1])
+		c.Assert(a.Update(), IsNil)
+	}
+	rows, err := a.Rows()
+	c.Assert(err, IsNil)
+	c.Assert(rows, HasLen, 2)
+	expected := [][]int64{{2, 30}, {1, 20}}
+	for i, row := range rows {
+		datums, err := codec.Decode(row.Data)
+		c.Assert(err, IsNil)
+		c.Assert(datums, HasLen, 3)
+		c.Assert(datums[1].GetUint64(), Equals, uint64(expected[i][0]))
+		c.Assert(datums[2].GetInt64(), Equals, expected[i][1])
+	}
+}
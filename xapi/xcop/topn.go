@@ -0,0 +1,152 @@
+// Copyright 2016 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xcop
+
+import (
+	"container/heap"
+
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/util/types"
+	"github.com/pingcap/tidb/xapi/xeval"
+	"github.com/pingcap/tipb/go-tipb"
+)
+
+// IsTopNRequest checks whether the rows are sorted by the order by items and limited in the region.
+func IsTopNRequest(sel *tipb.SelectRequest) bool {
+	return len(sel.OrderBy) > 0 && sel.OrderBy[0].Expr != nil && sel.Limit != nil
+}
+
+// TopN keeps the top n rows of a region in the order of the order by items of a top-n request.
+type TopN struct {
+	eval *xeval.Evaluator
+	heap *topnHeap
+}
+
+// NewTopN creates a TopN for the top-n request, the order by items are evaluated by eval.
+func NewTopN(sel *tipb.SelectRequest, eval *xeval.Evaluator) *TopN {
+	return &TopN{
+		eval: eval,
+		heap: &topnHeap{
+			orderBy:    sel.OrderBy,
+			totalCount: int(sel.GetLimit()),
+			rows:       make([]*sortRow, 0, sel.GetLimit()),
+		},
+	}
+}
+
+// Add evaluates the order by items of the row, whose column values are put into the evaluator already,
+// and keeps the row if it is in the top n rows.
+func (t *TopN) Add(row *tipb.Row) error {
+	sr := &sortRow{
+		key:  make([]types.Datum, 0, len(t.heap.orderBy)),
+		data: row,
+	}
+	for _, item := range t.heap.orderBy {
+		d, err := t.eval.Eval(item.Expr)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		sr.key = append(sr.key, d)
+	}
+	return errors.Trace(t.heap.tryToAddRow(sr))
+}
+
+// SortedRows returns the kept rows in the order, the TopN is empty after it is called.
+func (t *TopN) SortedRows() []*tipb.Row {
+	return t.heap.sortedRows()
+}
+
+type sortRow struct {
+	key  []types.Datum
+	data *tipb.Row
+}
+
+// topnHeap holds the top n rows of the region in a max heap,
+// the row on the top of the heap is the last one in the order.
+type topnHeap struct {
+	orderBy    []*tipb.ByItem
+	totalCount int
+	rows       []*sortRow
+	err        error
+}
+
+func (h *topnHeap) lessRow(row1, row2 *sortRow) bool {
+	for i, item := range h.orderBy {
+		cmp, err := row1.key[i].CompareDatum(row2.key[i])
+		if err != nil {
+			h.err = errors.Trace(err)
+			return true
+		}
+		if item.GetDesc() {
+			cmp = -cmp
+		}
+		if cmp < 0 {
+			return true
+		} else if cmp > 0 {
+			return false
+		}
+	}
+	return false
+}
+
+// Len implements heap.Interface Len interface.
+func (h *topnHeap) Len() int {
+	return len(h.rows)
+}
+
+// Less implements heap.Interface Less interface.
+func (h *topnHeap) Less(i, j int) bool {
+	return h.lessRow(h.rows[j], h.rows[i])
+}
+
+// Swap implements heap.Interface Swap interface.
+func (h *topnHeap) Swap(i, j int) {
+	h.rows[i], h.rows[j] = h.rows[j], h.rows[i]
+}
+
+// Push implements heap.Interface Push interface.
+func (h *topnHeap) Push(x interface{}) {
+	h.rows = append(h.rows, x.(*sortRow))
+}
+
+// Pop implements heap.Interface Pop interface.
+func (h *topnHeap) Pop() interface{} {
+	n := len(h.rows)
+	row := h.rows[n-1]
+	h.rows = h.rows[:n-1]
+	return row
+}
+
+// tryToAddRow adds the row into the heap if it is in the top n rows.
+func (h *topnHeap) tryToAddRow(row *sortRow) error {
+	if h.totalCount <= 0 {
+		return nil
+	}
+	if h.Len() < h.totalCount {
+		heap.Push(h, row)
+	} else if h.lessRow(row, h.rows[0]) {
+		h.rows[0] = row
+		heap.Fix(h, 0)
+	}
+	return errors.Trace(h.err)
+}
+
+// sortedRows pops all the rows in the heap, and returns them in the order.
+func (h *topnHeap) sortedRows() []*tipb.Row {
+	rows := make([]*tipb.Row, h.Len())
+	for i := len(rows) - 1; i >= 0; i-- {
+		rows[i] = heap.Pop(h).(*sortRow).data
+	}
+	return rows
+}
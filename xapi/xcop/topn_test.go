@@ -0,0 +1,42 @@
+// Copyright 2016 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xcop
+
+import (
+	"github.com/golang/protobuf/proto"
+	. "github.com/pingcap/check"
+	"github.com/pingcap/tidb/util/types"
+	"github.com/pingcap/tidb/xapi/xeval"
+	"github.com/pingcap/tipb/go-tipb"
+)
+
+func (s *testXCopSuite) TestTopN(c *C) {
+	// select * from t order by c1 desc limit 2
+	sel := &tipb.SelectRequest{
+		OrderBy: []*tipb.ByItem{{Expr: columnExpr(1), Desc: proto.Bool(true)}},
+		Limit:   proto.Int64(2),
+	}
+	c.Assert(IsTopNRequest(sel), IsTrue)
+	c.Assert(IsTopNRequest(&tipb.SelectRequest{Limit: proto.Int64(2)}), IsFalse)
+	eval := &xeval.Evaluator{Row: make(map[int64]types.Datum)}
+	topn := NewTopN(sel, eval)
+	for _, v := range []int64{3, 1, 5, 4, 2} {
+		eval.Row[1] = types.NewIntDatum(v)
+		c.Assert(topn.Add(&tipb.Row{Handle: []byte{byte(v)}}), IsNil)
+	}
+	rows := topn.SortedRows()
+	c.Assert(rows, HasLen, 2)
+	c.Assert(rows[0].Handle, DeepEquals, []byte{5})
+	c.Assert(rows[1].Handle, DeepEquals, []byte{4})
+}
@@ -98,8 +98,14 @@ func (e *Evaluator) Eval(expr *tipb.Expr) (types.Datum, error) {
 		return e.evalNot(expr)
 	case tipb.ExprType_In:
 		return e.evalIn(expr)
-	case tipb.ExprType_Plus, tipb.ExprType_Div:
+	case tipb.ExprType_Plus, tipb.ExprType_Minus, tipb.ExprType_Mul, tipb.ExprType_Div,
+		tipb.ExprType_IntDiv, tipb.ExprType_Mod:
 		return e.evalArithmetic(expr)
+	case tipb.ExprType_Case:
+		return e.evalCase(expr)
+	}
+	if IsBuiltinFunc(expr.GetTp()) {
+		return e.evalBuiltinFunc(expr)
 	}
 	return types.Datum{}, nil
 }
@@ -570,8 +576,16 @@ func (e *Evaluator) evalArithmetic(expr *tipb.Expr) (types.Datum, error) {
 	switch expr.GetTp() {
 	case tipb.ExprType_Plus:
 		return types.ComputePlus(a, b)
+	case tipb.ExprType_Minus:
+		return types.ComputeMinus(a, b)
+	case tipb.ExprType_Mul:
+		return types.ComputeMul(a, b)
 	case tipb.ExprType_Div:
 		return types.ComputeDiv(a, b)
+	case tipb.ExprType_IntDiv:
+		return types.ComputeIntDiv(a, b)
+	case tipb.ExprType_Mod:
+		return types.ComputeMod(a, b)
 	default:
 		return result, errors.Errorf("Unknown binop type: %v", expr.GetTp())
 	}
@@ -0,0 +1,136 @@
+// Copyright 2016 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xeval
+
+import (
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/evaluator"
+	"github.com/pingcap/tidb/util/types"
+	"github.com/pingcap/tipb/go-tipb"
+)
+
+// builtinFuncs maps the expression types to the names of the builtin functions in the SQL layer,
+// so an expression is evaluated the same whether it is pushed down or not.
+// The functions must not use the context, which is nil in the region.
+var builtinFuncs = map[tipb.ExprType]string{
+	// Bit operations.
+	tipb.ExprType_BitAnd:    ast.And,
+	tipb.ExprType_BitOr:     ast.Or,
+	tipb.ExprType_BitXor:    ast.Xor,
+	tipb.ExprType_LeftShift: ast.LeftShift,
+	tipb.ExprType_RighShift: ast.RightShift,
+	tipb.ExprType_BitNeg:    ast.BitNeg,
+	tipb.ExprType_Neg:       ast.UnaryMinus,
+	tipb.ExprType_Xor:       ast.LogicXor,
+
+	// Math functions.
+	tipb.ExprType_Abs: "abs",
+	tipb.ExprType_Pow: "pow",
+
+	// Control functions.
+	tipb.ExprType_If:       "if",
+	tipb.ExprType_NullIf:   "nullif",
+	tipb.ExprType_IfNull:   "ifnull",
+	tipb.ExprType_Coalesce: "coalesce",
+	tipb.ExprType_IsNull:   "isnull",
+
+	// String functions.
+	tipb.ExprType_Concat:         "concat",
+	tipb.ExprType_ConcatWS:       "concat_ws",
+	tipb.ExprType_Left:           "left",
+	tipb.ExprType_Length:         "length",
+	tipb.ExprType_Lower:          "lower",
+	tipb.ExprType_Repeat:         "repeat",
+	tipb.ExprType_Replace:        "replace",
+	tipb.ExprType_Upper:          "upper",
+	tipb.ExprType_Strcmp:         "strcmp",
+	tipb.ExprType_Substring:      "substring",
+	tipb.ExprType_SubstringIndex: "substring_index",
+	tipb.ExprType_Locate:         "locate",
+	tipb.ExprType_Trim:           "trim",
+
+	// Time functions.
+	tipb.ExprType_Date:        "date",
+	tipb.ExprType_Year:        "year",
+	tipb.ExprType_YearWeek:    "yearweek",
+	tipb.ExprType_Month:       "month",
+	tipb.ExprType_Week:        "week",
+	tipb.ExprType_Weekday:     "weekday",
+	tipb.ExprType_WeekOfYear:  "weekofyear",
+	tipb.ExprType_Day:         "day",
+	tipb.ExprType_DayName:     "dayname",
+	tipb.ExprType_DayOfYear:   "dayofyear",
+	tipb.ExprType_DayOfMonth:  "dayofmonth",
+	tipb.ExprType_DayOfWeek:   "dayofweek",
+	tipb.ExprType_Hour:        "hour",
+	tipb.ExprType_Minute:      "minute",
+	tipb.ExprType_Second:      "second",
+	tipb.ExprType_Microsecond: "microsecond",
+	tipb.ExprType_Extract:     "extract",
+}
+
+// IsBuiltinFunc checks whether the expression type is evaluated by a builtin function.
+func IsBuiltinFunc(tp tipb.ExprType) bool {
+	_, ok := builtinFuncs[tp]
+	return ok
+}
+
+func (e *Evaluator) evalBuiltinFunc(expr *tipb.Expr) (types.Datum, error) {
+	name := builtinFuncs[expr.GetTp()]
+	f := evaluator.Funcs[name]
+	if len(expr.Children) < f.MinArgs || (f.MaxArgs != -1 && len(expr.Children) > f.MaxArgs) {
+		return types.Datum{}, ErrInvalid.Gen("%s need [%d, %d] operands, got %d",
+			name, f.MinArgs, f.MaxArgs, len(expr.Children))
+	}
+	args := make([]types.Datum, 0, len(expr.Children))
+	for _, child := range expr.Children {
+		arg, err := e.Eval(child)
+		if err != nil {
+			return types.Datum{}, errors.Trace(err)
+		}
+		args = append(args, arg)
+	}
+	d, err := f.F(args, nil)
+	return d, errors.Trace(err)
+}
+
+// evalCase evaluates the searched case expression, the children are the pairs of the when condition and the result,
+// followed by the optional else result. The condition is matched if it equals to 1, the same as the SQL layer.
+func (e *Evaluator) evalCase(expr *tipb.Expr) (types.Datum, error) {
+	trueDatum := types.NewIntDatum(1)
+	children := expr.Children
+	for ; len(children) >= 2; children = children[2:] {
+		cond, err := e.Eval(children[0])
+		if err != nil {
+			return types.Datum{}, errors.Trace(err)
+		}
+		if cond.IsNull() {
+			continue
+		}
+		cmp, err := trueDatum.CompareDatum(cond)
+		if err != nil {
+			return types.Datum{}, errors.Trace(err)
+		}
+		if cmp == 0 {
+			d, err := e.Eval(children[1])
+			return d, errors.Trace(err)
+		}
+	}
+	if len(children) == 1 {
+		d, err := e.Eval(children[0])
+		return d, errors.Trace(err)
+	}
+	return types.Datum{}, nil
+}
@@ -429,3 +429,94 @@ func inExpr(target interface{}, list ...interface{}) *tipb.Expr {
 	listExpr := &tipb.Expr{Tp: tipb.ExprType_ValueList.Enum(), Val: val}
 	return &tipb.Expr{Tp: tipb.ExprType_In.Enum(), Children: []*tipb.Expr{targetExpr, listExpr}}
 }
+
+func (s *testEvalSuite) TestEvalBuiltin(c *C) {
+	colID := int64(1)
+	row := make(map[int64]types.Datum)
+	row[colID] = types.NewBytesDatum([]byte("2016-10-16 10:20:30"))
+	xevaluator := &Evaluator{Row: row}
+	cases := []struct {
+		expr   *tipb.Expr
+		result interface{}
+	}{
+		// Arithmetic operations.
+		{funcExpr(tipb.ExprType_Minus, 3, 5), -2},
+		{funcExpr(tipb.ExprType_Mul, 3, 5), 15},
+		{funcExpr(tipb.ExprType_IntDiv, 7, 2), 3},
+		{funcExpr(tipb.ExprType_Mod, 7, 2), 1},
+		{funcExpr(tipb.ExprType_Mod, 7, nil), nil},
+		{funcExpr(tipb.ExprType_Neg, 7), -7},
+		{funcExpr(tipb.ExprType_Abs, -7), 7},
+		{funcExpr(tipb.ExprType_Pow, 2, 3), 8},
+		// Bit operations.
+		{funcExpr(tipb.ExprType_BitAnd, 6, 3), uint64(2)},
+		{funcExpr(tipb.ExprType_BitOr, 6, 3), uint64(7)},
+		{funcExpr(tipb.ExprType_BitXor, 6, 3), uint64(5)},
+		{funcExpr(tipb.ExprType_LeftShift, 1, 3), uint64(8)},
+		{funcExpr(tipb.ExprType_RighShift, 8, 3), uint64(1)},
+		{funcExpr(tipb.ExprType_BitNeg, 0), uint64(18446744073709551615)},
+		{funcExpr(tipb.ExprType_Xor, 1, 0), 1},
+		// Control functions.
+		{funcExpr(tipb.ExprType_If, 1, "a", "b"), "a"},
+		{funcExpr(tipb.ExprType_If, nil, "a", "b"), "b"},
+		{funcExpr(tipb.ExprType_IfNull, nil, 2), 2},
+		{funcExpr(tipb.ExprType_NullIf, 2, 2), nil},
+		{funcExpr(tipb.ExprType_Coalesce, nil, nil, 3), 3},
+		{funcExpr(tipb.ExprType_IsNull, nil), 1},
+		{funcExpr(tipb.ExprType_Case, 0, "a", nil, "b", 1, "c", "d"), "c"},
+		{funcExpr(tipb.ExprType_Case, 0, "a", "d"), "d"},
+		{funcExpr(tipb.ExprType_Case, 0, "a"), nil},
+		// String functions.
+		{funcExpr(tipb.ExprType_Concat, "a", 1, "b"), "a1b"},
+		{funcExpr(tipb.ExprType_ConcatWS, ",", "a", "b"), "a,b"},
+		{funcExpr(tipb.ExprType_Left, "abc", 2), "ab"},
+		{funcExpr(tipb.ExprType_Length, "abc"), 3},
+		{funcExpr(tipb.ExprType_Lower, "ABC"), "abc"},
+		{funcExpr(tipb.ExprType_Upper, "abc"), "ABC"},
+		{funcExpr(tipb.ExprType_Repeat, "ab", 2), "abab"},
+		{funcExpr(tipb.ExprType_Replace, "abc", "b", "x"), "axc"},
+		{funcExpr(tipb.ExprType_Strcmp, "a", "b"), -1},
+		{funcExpr(tipb.ExprType_Substring, "abcde", 2, 3), "bcd"},
+		{funcExpr(tipb.ExprType_SubstringIndex, "a.b.c", ".", 2), "a.b"},
+		{funcExpr(tipb.ExprType_Locate, "b", "abc"), 2},
+		{funcExpr(tipb.ExprType_Trim, "  abc  "), "abc"},
+		// Time functions.
+		{funcExpr(tipb.ExprType_Year, columnExpr(colID)), 2016},
+		{funcExpr(tipb.ExprType_Month, columnExpr(colID)), 10},
+		{funcExpr(tipb.ExprType_Day, columnExpr(colID)), 16},
+		{funcExpr(tipb.ExprType_Hour, columnExpr(colID)), 10},
+		{funcExpr(tipb.ExprType_Minute, columnExpr(colID)), 20},
+		{funcExpr(tipb.ExprType_Second, columnExpr(colID)), 30},
+		{funcExpr(tipb.ExprType_DayName, columnExpr(colID)), "Sunday"},
+		{funcExpr(tipb.ExprType_DayOfYear, columnExpr(colID)), 290},
+		{funcExpr(tipb.ExprType_Year, nil), nil},
+	}
+	for _, ca := range cases {
+		result, err := xevaluator.Eval(ca.expr)
+		c.Assert(err, IsNil)
+		expected := types.NewDatum(ca.result)
+		if expected.IsNull() {
+			c.Assert(result.IsNull(), IsTrue, Commentf("%v", ca.expr))
+			continue
+		}
+		cmp, err := result.CompareDatum(expected)
+		c.Assert(err, IsNil)
+		c.Assert(cmp, Equals, 0, Commentf("%v got %v", ca.expr, result.GetValue()))
+	}
+
+	// The number of the arguments is checked.
+	_, err := xevaluator.Eval(funcExpr(tipb.ExprType_Left, "abc"))
+	c.Assert(err, NotNil)
+}
+
+func funcExpr(tp tipb.ExprType, args ...interface{}) *tipb.Expr {
+	expr := &tipb.Expr{Tp: tp.Enum()}
+	for _, arg := range args {
+		if child, ok := arg.(*tipb.Expr); ok {
+			expr.Children = append(expr.Children, child)
+		} else {
+			expr.Children = append(expr.Children, datumExpr(types.NewDatum(arg)))
+		}
+	}
+	return expr
+}